@@ -98,6 +98,10 @@ import (
 type listUserRequestParamsWrapper struct {
 	// in:query
 	metav1.ListOptions
+
+	// Cursor returned in the X-Next-Cursor header of the previous page, enables keyset pagination.
+	// in:query
+	After string `json:"after"`
 }
 
 // List users response.
@@ -0,0 +1,49 @@
+-- Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+-- Use of this source code is governed by a MIT style
+-- license that can be found in the LICENSE file.
+
+-- 为已部署的 iam 数据库添加租户字段，iam-apiserver 升级前执行，可以重复执行：
+--   mysql -h127.0.0.1 -P3306 -uiam -p'iam59!z$' < configs/migrations/001_tenant_id.sql
+-- 已有的用户、策略和密钥的 tenant_id 为空，即不属于任何租户。
+
+USE `iam`;
+
+DROP PROCEDURE IF EXISTS `iam_add_tenant_id`;
+DELIMITER ;;
+CREATE PROCEDURE `iam_add_tenant_id`(IN table_name_in varchar(64))
+BEGIN
+  IF NOT EXISTS (SELECT 1 FROM information_schema.COLUMNS
+      WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = table_name_in AND COLUMN_NAME = 'tenant_id') THEN
+    SET @ddl = CONCAT('ALTER TABLE `', table_name_in,
+      '` ADD COLUMN `tenant_id` varchar(63) NOT NULL DEFAULT '''' COMMENT ''empty: not tenant scoped''');
+    PREPARE stmt FROM @ddl;
+    EXECUTE stmt;
+    DEALLOCATE PREPARE stmt;
+  END IF;
+
+  IF NOT EXISTS (SELECT 1 FROM information_schema.STATISTICS
+      WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = table_name_in AND INDEX_NAME = 'idx_tenant_id') THEN
+    SET @ddl = CONCAT('ALTER TABLE `', table_name_in, '` ADD KEY `idx_tenant_id` (`tenant_id`)');
+    PREPARE stmt FROM @ddl;
+    EXECUTE stmt;
+    DEALLOCATE PREPARE stmt;
+  END IF;
+END ;;
+DELIMITER ;
+
+-- tenant_id 是 policy_audit 的最后一列，和 policy_BEFORE_DELETE 触发器插入的列顺序一致
+CALL `iam_add_tenant_id`('user');
+CALL `iam_add_tenant_id`('policy');
+CALL `iam_add_tenant_id`('policy_audit');
+CALL `iam_add_tenant_id`('secret');
+
+DROP PROCEDURE IF EXISTS `iam_add_tenant_id`;
+
+-- 删除策略时把 tenant_id 一起写入 policy_audit
+DROP TRIGGER IF EXISTS `policy_BEFORE_DELETE`;
+DELIMITER ;;
+CREATE TRIGGER `policy_BEFORE_DELETE` BEFORE DELETE ON `policy` FOR EACH ROW
+BEGIN
+	insert into policy_audit values(old.id, old.instanceID, old.name, old.username, old.policyShadow, old.extendShadow, old.createdAt, old.updatedAt, curtime(), old.tenant_id);
+END ;;
+DELIMITER ;
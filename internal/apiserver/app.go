@@ -27,7 +27,9 @@ func NewApp(basename string) *app.App {
 		app.WithOptions(opts),
 		app.WithDescription(commandDesc),
 		app.WithDefaultValidArgs(),
+		app.WithCompletion(),
 		app.WithRunFunc(run(opts)),
+		app.WithConfigReload(func() app.CliOptions { return options.NewOptions() }, reload()),
 	)
 
 	return application
@@ -46,3 +48,12 @@ func run(opts *options.Options) app.RunFunc {
 		return Run(cfg)
 	}
 }
+
+// reload re-applies the settings which can be changed without a restart.
+func reload() app.ReloadFunc {
+	return func(opts app.CliOptions) {
+		if o, ok := opts.(*options.Options); ok {
+			log.Init(o.Log)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package audit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/marmotedu/component-base/pkg/json"
+
+	"github.com/marmotedu/iam/internal/apiserver/store"
+	"github.com/marmotedu/iam/internal/pkg/middleware"
+	"github.com/marmotedu/iam/pkg/log"
+)
+
+// Defined audit actions.
+const (
+	ActionCreate  = "create"
+	ActionUpdate  = "update"
+	ActionDelete  = "delete"
+	ActionRestore = "restore"
+)
+
+// Defined audited resources.
+const (
+	ResourceUser   = "user"
+	ResourcePolicy = "policy"
+	ResourceSecret = "secret"
+)
+
+// sensitiveFields are removed from the summaries of resources.
+var sensitiveFields = map[string]bool{
+	"password":                        true,
+	"secretKey":                       true,
+	store.SecretPreviousKeyKey:        true,
+	store.SecretPreviousKeyExpiresKey: true,
+}
+
+// Entry is an audit entry of an administrative action.
+type Entry struct {
+	Timestamp time.Time `json:"timestamp"`
+	Operator  string    `json:"operator"`
+	Action    string    `json:"action"`
+	Resource  string    `json:"resource"`
+	Name      string    `json:"name"`
+	// Old and New are the summaries of the resource before and after the action.
+	Old map[string]interface{} `json:"old,omitempty"`
+	New map[string]interface{} `json:"new,omitempty"`
+}
+
+// Recorder records audit entries.
+type Recorder interface {
+	Record(ctx context.Context, entry *Entry)
+}
+
+type logRecorder struct{}
+
+// Record writes the audit entry to the log.
+func (logRecorder) Record(ctx context.Context, entry *Entry) {
+	log.L(ctx).Infow("admin audit",
+		"operator", entry.Operator,
+		"action", entry.Action,
+		"resource", entry.Resource,
+		"name", entry.Name,
+		"old", entry.Old,
+		"new", entry.New,
+	)
+}
+
+var (
+	mu       sync.RWMutex
+	recorder Recorder = logRecorder{}
+)
+
+// SetRecorder replaces the recorder of audit entries and returns the previous one,
+// audit entries are written to the log by default.
+func SetRecorder(r Recorder) Recorder {
+	mu.Lock()
+	defer mu.Unlock()
+
+	previous := recorder
+	recorder = r
+
+	return previous
+}
+
+// Record records the action of the authenticated user on a resource, old and new
+// are the resource before and after the action, nil if it doesn't exist.
+func Record(c *gin.Context, action, resource, name string, old, new interface{}) {
+	entry := &Entry{
+		Timestamp: time.Now(),
+		Operator:  c.GetString(middleware.UsernameKey),
+		Action:    action,
+		Resource:  resource,
+		Name:      name,
+		Old:       Summary(old),
+		New:       Summary(new),
+	}
+
+	mu.RLock()
+	defer mu.RUnlock()
+
+	recorder.Record(c, entry)
+}
+
+// Summary returns the fields of a resource without the sensitive ones, e.g. password.
+// It returns nil if obj is nil or can't be encoded.
+func Summary(obj interface{}) map[string]interface{} {
+	if obj == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil
+	}
+
+	removeSensitive(fields)
+
+	return fields
+}
+
+func removeSensitive(fields map[string]interface{}) {
+	for k, v := range fields {
+		if sensitiveFields[k] {
+			delete(fields, k)
+
+			continue
+		}
+
+		if nested, ok := v.(map[string]interface{}); ok {
+			removeSensitive(nested)
+		}
+	}
+}
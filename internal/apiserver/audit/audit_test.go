@@ -0,0 +1,37 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package audit
+
+import (
+	"testing"
+
+	v1 "github.com/marmotedu/api/apiserver/v1"
+	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/marmotedu/iam/internal/apiserver/store"
+)
+
+func TestSummary(t *testing.T) {
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "secret",
+			Extend: metav1.Extend{store.SecretPreviousKeyKey: "old", "owner": "colin"},
+		},
+		SecretID:  "id",
+		SecretKey: "key",
+	}
+
+	summary := Summary(secret)
+	assert.Equal(t, "id", summary["secretID"])
+	assert.NotContains(t, summary, "secretKey")
+
+	metadata, _ := summary["metadata"].(map[string]interface{})
+	extend, _ := metadata["extend"].(map[string]interface{})
+	assert.Equal(t, "colin", extend["owner"])
+	assert.NotContains(t, extend, store.SecretPreviousKeyKey)
+
+	assert.Nil(t, Summary(nil))
+}
@@ -0,0 +1,7 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+// Package audit records the administrative actions on the resources of iam-apiserver,
+// e.g. who deleted which policy, for change tracking.
+package audit // import "github.com/marmotedu/iam/internal/apiserver/audit"
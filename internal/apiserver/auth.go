@@ -5,7 +5,6 @@
 package apiserver
 
 import (
-	"context"
 	"encoding/base64"
 	"net/http"
 	"strings"
@@ -14,10 +13,13 @@ import (
 	jwt "github.com/appleboy/gin-jwt/v2"
 	"github.com/gin-gonic/gin"
 	v1 "github.com/marmotedu/api/apiserver/v1"
+	"github.com/marmotedu/component-base/pkg/core"
 	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
+	"github.com/marmotedu/errors"
 	"github.com/spf13/viper"
 
 	"github.com/marmotedu/iam/internal/apiserver/store"
+	"github.com/marmotedu/iam/internal/pkg/code"
 	"github.com/marmotedu/iam/internal/pkg/middleware"
 	"github.com/marmotedu/iam/internal/pkg/middleware/auth"
 	"github.com/marmotedu/iam/pkg/log"
@@ -29,6 +31,9 @@ const (
 
 	// APIServerIssuer defines the value of jwt issuer field.
 	APIServerIssuer = "iam-apiserver"
+
+	// authErrorKey defines the key in gin context which holds the authentication error.
+	authErrorKey = "authError"
 )
 
 type loginInfo struct {
@@ -37,30 +42,42 @@ type loginInfo struct {
 }
 
 func newBasicAuth() middleware.AuthStrategy {
-	return auth.NewBasicStrategy(func(username string, password string) bool {
-		// fetch user from database
-		user, err := store.Client().Users().Get(context.TODO(), username, metav1.GetOptions{})
+	return auth.NewBasicStrategy(func(c *gin.Context, username string, password string) error {
+		errInvalid := errors.WithCode(code.ErrSignatureInvalid, "Authorization header format is wrong.")
+
+		// fetch user from database, the tenant middleware reuses it
+		u, err := loadAuthUser(c, username)
 		if err != nil {
-			return false
+			if errors.IsCode(err, code.ErrUserNotFound) {
+				return errInvalid
+			}
+
+			return err
 		}
+		user := u.user
 
 		// Compare the login password with the user password.
 		if err := user.Compare(password); err != nil {
-			return false
+			return errInvalid
+		}
+
+		if err := checkUserEnabled(user); err != nil {
+			return err
 		}
 
-		user.LoginedAt = time.Now()
-		_ = store.Client().Users().Update(context.TODO(), user, metav1.UpdateOptions{})
+		recordLogin(c, user.Name)
 
-		return true
+		return nil
 	})
 }
 
 func newJWTAuth() middleware.AuthStrategy {
 	ginjwt, _ := jwt.New(&jwt.GinJWTMiddleware{
 		Realm:            viper.GetString("jwt.Realm"),
-		SigningAlgorithm: "HS256",
+		SigningAlgorithm: signingAlgorithm(),
 		Key:              []byte(viper.GetString("jwt.key")),
+		PrivKeyFile:      viper.GetString("jwt.private-key-file"),
+		PubKeyFile:       viper.GetString("jwt.public-key-file"),
 		Timeout:          viper.GetDuration("jwt.timeout"),
 		MaxRefresh:       viper.GetDuration("jwt.max-refresh"),
 		Authenticator:    authenticator(),
@@ -75,23 +92,37 @@ func newJWTAuth() middleware.AuthStrategy {
 
 			return claims[jwt.IdentityKey]
 		},
-		IdentityKey:  middleware.UsernameKey,
-		Authorizator: authorizator(),
-		Unauthorized: func(c *gin.Context, code int, message string) {
-			c.JSON(code, gin.H{
-				"message": message,
-			})
-		},
-		TokenLookup:   "header: Authorization, query: token, cookie: jwt",
-		TokenHeadName: "Bearer",
-		SendCookie:    true,
-		TimeFunc:      time.Now,
-		// TODO: HTTPStatusMessageFunc:
+		IdentityKey:           middleware.UsernameKey,
+		Authorizator:          authorizator(),
+		HTTPStatusMessageFunc: httpStatusMessage,
+		Unauthorized:          unauthorized,
+		TokenLookup:           "header: Authorization, query: token, cookie: jwt",
+		TokenHeadName:         "Bearer",
+		SendCookie:            true,
+		TimeFunc:              time.Now,
 	})
 
 	return auth.NewJWTStrategy(*ginjwt)
 }
 
+// signingAlgorithm returns the algorithm used to sign jwt token, which defaults to HS256.
+func signingAlgorithm() string {
+	if alg := viper.GetString("jwt.signing-algorithm"); alg != "" {
+		return alg
+	}
+
+	return "HS256"
+}
+
+// issuer returns the value of jwt issuer field, which defaults to APIServerIssuer.
+func issuer() string {
+	if iss := viper.GetString("jwt.issuer"); iss != "" {
+		return iss
+	}
+
+	return APIServerIssuer
+}
+
 func newAutoAuth() middleware.AuthStrategy {
 	return auth.NewAutoStrategy(newBasicAuth().(auth.BasicStrategy), newJWTAuth().(auth.JWTStrategy))
 }
@@ -124,13 +155,112 @@ func authenticator() func(c *gin.Context) (interface{}, error) {
 			return "", jwt.ErrFailedAuthentication
 		}
 
-		user.LoginedAt = time.Now()
-		_ = store.Client().Users().Update(c, user, metav1.UpdateOptions{})
+		if err := checkUserEnabled(user); err != nil {
+			return "", err
+		}
+
+		recordLogin(c, user.Name)
 
 		return user, nil
 	}
 }
 
+// recordLogin records the successful login of the user. The login columns are updated in place, the user
+// loaded before is never saved back, so a concurrent change of the user isn't overwritten.
+func recordLogin(c *gin.Context, username string) {
+	if err := store.Client().Users().RecordLogin(c, username, metav1.UpdateOptions{}); err != nil {
+		log.L(c).Warnf("record the login of user %s failed: %s", username, err.Error())
+	}
+}
+
+// checkUserEnabled returns an ErrUserDisabled error if the user is not allowed to log in.
+func checkUserEnabled(user *v1.User) error {
+	if user.Status != store.UserStatusActive {
+		return errors.WithCode(code.ErrUserDisabled, "user %s is disabled", user.Name)
+	}
+
+	return nil
+}
+
+// activeUser returns the authenticated user and its tenant, it returns an error if the user is deleted or
+// not allowed to log in anymore, so the jwt tokens issued before are rejected. The failures of the lookup
+// are returned as they are, they aren't authentication failures.
+func activeUser(c *gin.Context, username string) (*authUser, error) {
+	u, err := loadAuthUser(c, username)
+	if err != nil {
+		if errors.IsCode(err, code.ErrUserNotFound) {
+			return nil, errors.WithCode(code.ErrTokenInvalid, "user %s doesn't exist", username)
+		}
+
+		return nil, err
+	}
+
+	if err := checkUserEnabled(u.user); err != nil {
+		return nil, err
+	}
+
+	return u, nil
+}
+
+// requireActiveUser is a middleware rejecting the requests of the users who are not active, for the
+// routes authenticated without the tenant middleware, which checks it otherwise.
+func requireActiveUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, err := activeUser(c, c.GetString(middleware.UsernameKey)); err != nil {
+			core.WriteResponse(c, err, nil)
+			c.Abort()
+
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// refreshHandler refreshes the jwt token only if its user is still allowed to log in.
+func refreshHandler(strategy auth.JWTStrategy, s *signer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, err := strategy.CheckIfTokenExpire(c)
+		if err == nil {
+			username, _ := claims[jwt.IdentityKey].(string)
+			if _, err := activeUser(c, username); err != nil {
+				if errors.IsCode(err, code.ErrUserDisabled) || errors.IsCode(err, code.ErrTokenInvalid) {
+					strategy.Unauthorized(c, http.StatusUnauthorized, httpStatusMessage(err, c))
+				} else {
+					core.WriteResponse(c, err, nil)
+				}
+
+				return
+			}
+		}
+
+		// the invalid tokens are rejected by refreshToken
+		refreshToken(c, strategy, s)
+	}
+}
+
+// httpStatusMessage returns the message of the authentication error, errors
+// with a distinct error code are kept for unauthorized to write them.
+func httpStatusMessage(e error, c *gin.Context) string {
+	if errors.IsCode(e, code.ErrUserDisabled) {
+		c.Set(authErrorKey, e)
+	}
+
+	return e.Error()
+}
+
+func unauthorized(c *gin.Context, code int, message string) {
+	if err, ok := c.Get(authErrorKey); ok {
+		core.WriteResponse(c, err.(error), nil)
+
+		return
+	}
+
+	c.JSON(code, gin.H{
+		"message": message,
+	})
+}
+
 func parseWithHeader(c *gin.Context) (loginInfo, error) {
 	auth := strings.SplitN(c.Request.Header.Get("Authorization"), " ", 2)
 	if len(auth) != 2 || auth[0] != "Basic" {
@@ -191,7 +321,7 @@ func loginResponse() func(c *gin.Context, code int, token string, expire time.Ti
 func payloadFunc() func(data interface{}) jwt.MapClaims {
 	return func(data interface{}) jwt.MapClaims {
 		claims := jwt.MapClaims{
-			"iss": APIServerIssuer,
+			"iss": issuer(),
 			"aud": APIServerAudience,
 		}
 		if u, ok := data.(*v1.User); ok {
@@ -205,12 +335,15 @@ func payloadFunc() func(data interface{}) jwt.MapClaims {
 
 func authorizator() func(data interface{}, c *gin.Context) bool {
 	return func(data interface{}, c *gin.Context) bool {
-		if v, ok := data.(string); ok {
-			log.L(c).Infof("user `%s` is authenticated.", v)
-
-			return true
+		v, ok := data.(string)
+		if !ok {
+			return false
 		}
 
-		return false
+		// the tokens of the users disabled or deleted since they were issued are rejected by the
+		// tenant middleware, which looks up the user anyway
+		log.L(c).Infof("user `%s` is authenticated.", v)
+
+		return true
 	}
 }
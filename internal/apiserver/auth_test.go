@@ -0,0 +1,209 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package apiserver
+
+import (
+	"bytes"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	v1 "github.com/marmotedu/api/apiserver/v1"
+	"github.com/marmotedu/component-base/pkg/auth"
+	"github.com/marmotedu/component-base/pkg/json"
+	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
+	"github.com/marmotedu/errors"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/marmotedu/iam/internal/apiserver/store"
+	"github.com/marmotedu/iam/internal/pkg/code"
+	"github.com/marmotedu/iam/internal/pkg/middleware"
+	authstrategy "github.com/marmotedu/iam/internal/pkg/middleware/auth"
+)
+
+// setUserStore sets a store holding the user whose password is Colin@2021.
+func setUserStore(t *testing.T, status int) {
+	t.Helper()
+
+	ctrl := gomock.NewController(t)
+	t.Cleanup(ctrl.Finish)
+
+	password, _ := auth.Encrypt("Colin@2021")
+	user := &v1.User{ObjectMeta: metav1.ObjectMeta{Name: "colin"}, Password: password, Status: status}
+
+	mockFactory := store.NewMockFactory(ctrl)
+	mockUserStore := store.NewMockUserStore(ctrl)
+	mockFactory.EXPECT().Users().Return(mockUserStore).AnyTimes()
+	mockUserStore.EXPECT().Get(gomock.Any(), gomock.Eq("colin"), gomock.Any()).Return(user, nil).AnyTimes()
+	mockUserStore.EXPECT().GetWithTenant(gomock.Any(), gomock.Eq("colin"), gomock.Any()).Return(user, "", nil).AnyTimes()
+	// the login is recorded in place, the user is never saved back by the authentication
+	mockUserStore.EXPECT().RecordLogin(gomock.Any(), gomock.Eq("colin"), gomock.Any()).Return(nil).AnyTimes()
+
+	previous := store.Client()
+	store.SetClient(mockFactory)
+	t.Cleanup(func() { store.SetClient(previous) })
+}
+
+func errorCode(t *testing.T, w *httptest.ResponseRecorder) int {
+	t.Helper()
+
+	var resp struct {
+		Code int `json:"code"`
+	}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &resp))
+
+	return resp.Code
+}
+
+func TestBasicAuth_DisabledUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.GET("/", newBasicAuth().AuthFunc(), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	request := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte("colin:Colin@2021")))
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		return w
+	}
+
+	setUserStore(t, store.UserStatusActive)
+	assert.Equal(t, http.StatusOK, request().Code)
+
+	setUserStore(t, store.UserStatusDisabled)
+	w := request()
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, code.ErrUserDisabled, errorCode(t, w))
+}
+
+func TestJWTLogin_DisabledUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	viper.Set("jwt.key", "dfVpOK8LZeJLZHYmHdb1VdyRrACKpqoo")
+	viper.Set("jwt.timeout", "1h")
+	defer viper.Reset()
+
+	jwtStrategy, _ := newJWTAuth().(authstrategy.JWTStrategy)
+	r := gin.New()
+	r.POST("/login", jwtStrategy.LoginHandler)
+
+	login := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/login",
+			bytes.NewBufferString(`{"username":"colin","password":"Colin@2021"}`))
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		return w
+	}
+
+	setUserStore(t, store.UserStatusActive)
+	assert.Equal(t, http.StatusOK, login().Code)
+
+	setUserStore(t, store.UserStatusDisabled)
+	w := login()
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, code.ErrUserDisabled, errorCode(t, w))
+}
+
+func TestJWTAuth_DisabledUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	viper.Set("jwt.key", "dfVpOK8LZeJLZHYmHdb1VdyRrACKpqoo")
+	viper.Set("jwt.timeout", "1h")
+	viper.Set("jwt.max-refresh", "1h")
+	defer viper.Reset()
+
+	jwtStrategy, _ := newJWTAuth().(authstrategy.JWTStrategy)
+	r := gin.New()
+	r.POST("/refresh", refreshHandler(jwtStrategy, nil))
+	// the users who are not active anymore are rejected by the tenant middleware
+	r.GET("/", jwtStrategy.AuthFunc(), middleware.Tenant(identifyTenant), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	token, _, err := jwtStrategy.TokenGenerator(&v1.User{ObjectMeta: metav1.ObjectMeta{Name: "colin"}})
+	assert.Nil(t, err)
+
+	request := func(method, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		return w
+	}
+
+	setUserStore(t, store.UserStatusActive)
+	assert.Equal(t, http.StatusOK, request(http.MethodGet, "/").Code)
+	assert.Equal(t, http.StatusOK, request(http.MethodPost, "/refresh").Code)
+
+	// the token issued before the user is disabled is rejected and can't be refreshed
+	setUserStore(t, store.UserStatusDisabled)
+	w := request(http.MethodGet, "/")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, code.ErrUserDisabled, errorCode(t, w))
+
+	w = request(http.MethodPost, "/refresh")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, code.ErrUserDisabled, errorCode(t, w))
+}
+
+func TestJWTAuth_DatabaseError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	viper.Set("jwt.key", "dfVpOK8LZeJLZHYmHdb1VdyRrACKpqoo")
+	viper.Set("jwt.timeout", "1h")
+	viper.Set("jwt.max-refresh", "1h")
+	defer viper.Reset()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFactory := store.NewMockFactory(ctrl)
+	mockUserStore := store.NewMockUserStore(ctrl)
+	mockFactory.EXPECT().Users().Return(mockUserStore).AnyTimes()
+	mockUserStore.EXPECT().GetWithTenant(gomock.Any(), gomock.Eq("colin"), gomock.Any()).
+		Return(nil, "", errors.WithCode(code.ErrDatabase, "connection refused")).AnyTimes()
+
+	previous := store.Client()
+	store.SetClient(mockFactory)
+	defer store.SetClient(previous)
+
+	jwtStrategy, _ := newJWTAuth().(authstrategy.JWTStrategy)
+	r := gin.New()
+	r.POST("/refresh", refreshHandler(jwtStrategy, nil))
+	r.GET("/", jwtStrategy.AuthFunc(), middleware.Tenant(identifyTenant), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	token, _, err := jwtStrategy.TokenGenerator(&v1.User{ObjectMeta: metav1.ObjectMeta{Name: "colin"}})
+	assert.Nil(t, err)
+
+	// the failures of the user lookup are not authentication failures
+	for _, method := range []string{http.MethodGet, http.MethodPost} {
+		path := "/"
+		if method == http.MethodPost {
+			path = "/refresh"
+		}
+
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Equal(t, code.ErrDatabase, errorCode(t, w))
+	}
+}
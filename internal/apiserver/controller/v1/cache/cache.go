@@ -10,12 +10,15 @@ import (
 	"fmt"
 	"sync"
 
+	"github.com/AlekSi/pointer"
+	v1 "github.com/marmotedu/api/apiserver/v1"
 	pb "github.com/marmotedu/api/proto/apiserver/v1"
 	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
 	"github.com/marmotedu/errors"
 
 	"github.com/marmotedu/iam/internal/apiserver/store"
 	"github.com/marmotedu/iam/internal/pkg/code"
+	"github.com/marmotedu/iam/internal/pkg/middleware/auth"
 	"github.com/marmotedu/iam/pkg/log"
 )
 
@@ -57,8 +60,42 @@ func (c *Cache) ListSecrets(ctx context.Context, r *pb.ListSecretsRequest) (*pb.
 		return nil, errors.WithCode(code.ErrDatabase, err.Error())
 	}
 
+	disabled, err := c.disabledUsers(ctx)
+	if err != nil {
+		return nil, errors.WithCode(code.ErrDatabase, err.Error())
+	}
+
+	// the secrets of disabled users are left out, so they are rejected by iam-authz-server.
+	// So are the scoped secrets if iam-authz-server doesn't enforce the scopes, e.g. during
+	// a rolling upgrade, rather than granting them the full access of their owner.
+	scopeSupported := auth.ScopeSupported(ctx)
+	scopes := make(map[string]*auth.Scope)
+	totalCount := secrets.TotalCount
 	items := make([]*pb.SecretInfo, 0)
 	for _, secret := range secrets.Items {
+		if disabled[secret.Username] {
+			totalCount--
+
+			continue
+		}
+
+		// SecretInfo has no scope field, the scopes are sent in the response header,
+		// and only to the clients which enforce them, see auth.ScopeSupported.
+		scope, err := auth.ParseScope(secret.Extend[store.SecretScopeKey])
+		if err != nil {
+			log.L(ctx).Warnf("secret %s has an invalid scope, it's left out: %s", secret.SecretID, err.Error())
+			totalCount--
+
+			continue
+		}
+
+		if scope != nil && !scopeSupported {
+			log.L(ctx).Warnf("secret %s is scoped but the client doesn't enforce scopes, it's left out", secret.SecretID)
+			totalCount--
+
+			continue
+		}
+
 		items = append(items, &pb.SecretInfo{
 			SecretId:    secret.SecretID,
 			Username:    secret.Username,
@@ -68,14 +105,78 @@ func (c *Cache) ListSecrets(ctx context.Context, r *pb.ListSecretsRequest) (*pb.
 			CreatedAt:   secret.CreatedAt.Format("2006-01-02 15:04:05"),
 			UpdatedAt:   secret.UpdatedAt.Format("2006-01-02 15:04:05"),
 		})
+
+		if scope != nil {
+			scopes[secret.SecretID] = scope
+		}
+
+		if previous := previousSecret(secret); previous != nil {
+			items = append(items, previous)
+			if scope != nil {
+				scopes[previous.SecretId] = scope
+			}
+		}
+	}
+
+	// the scoped secrets can't be sent without their scopes, they would be granted the
+	// full access of their owner.
+	if len(scopes) > 0 {
+		if err := auth.SendScopes(ctx, scopes); err != nil {
+			return nil, errors.WithCode(code.ErrUnknown, err.Error())
+		}
 	}
 
 	return &pb.ListSecretsResponse{
-		TotalCount: secrets.TotalCount,
+		TotalCount: totalCount,
 		Items:      items,
 	}, nil
 }
 
+// previousSecret returns the secret key replaced by the last rotation of the secret,
+// it's cached by iam-authz-server under auth.PreviousSecretID until its grace period
+// ends. nil is returned if there is no such key or the grace period is over.
+func previousSecret(secret *v1.Secret) *pb.SecretInfo {
+	key, expires := store.SecretPreviousKey(secret)
+	if key == "" || auth.KeyExpired(expires) {
+		return nil
+	}
+
+	// the previous key never outlives the secret
+	if secret.Expires > 0 && secret.Expires < expires {
+		expires = secret.Expires
+	}
+
+	return &pb.SecretInfo{
+		SecretId:    auth.PreviousSecretID(secret.SecretID),
+		Username:    secret.Username,
+		SecretKey:   key,
+		Expires:     expires,
+		Description: secret.Description,
+		CreatedAt:   secret.CreatedAt.Format("2006-01-02 15:04:05"),
+		UpdatedAt:   secret.UpdatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
+
+// disabledUsers returns the names of the disabled users.
+func (c *Cache) disabledUsers(ctx context.Context) (map[string]bool, error) {
+	users, err := c.store.Users().List(ctx, metav1.ListOptions{
+		FieldSelector: fmt.Sprintf("status=%d", store.UserStatusDisabled),
+		Limit:         pointer.ToInt64(-1),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	disabled := make(map[string]bool)
+	for _, user := range users.Items {
+		if user.Status == store.UserStatusDisabled {
+			disabled[user.Name] = true
+		}
+	}
+
+	return disabled, nil
+}
+
 // ListPolicies returns all policies.
 func (c *Cache) ListPolicies(ctx context.Context, r *pb.ListPoliciesRequest) (*pb.ListPoliciesResponse, error) {
 	log.L(ctx).Info("list policies function called.")
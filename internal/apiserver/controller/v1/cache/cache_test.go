@@ -7,16 +7,22 @@ package cache
 
 import (
 	"context"
+	"fmt"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	v1 "github.com/marmotedu/api/apiserver/v1"
 	pb "github.com/marmotedu/api/proto/apiserver/v1"
 	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 
 	"github.com/marmotedu/iam/internal/apiserver/store"
 	"github.com/marmotedu/iam/internal/apiserver/store/fake"
+	"github.com/marmotedu/iam/internal/pkg/middleware/auth"
 )
 
 func TestGetCacheInsOr(t *testing.T) {
@@ -66,6 +72,9 @@ func TestCache_ListSecrets(t *testing.T) {
 	mockFactory := store.NewMockFactory(ctrl)
 	mockSecretStore := store.NewMockSecretStore(ctrl)
 	mockFactory.EXPECT().Secrets().Return(mockSecretStore)
+	mockUserStore := store.NewMockUserStore(ctrl)
+	mockFactory.EXPECT().Users().Return(mockUserStore)
+	mockUserStore.EXPECT().List(gomock.Any(), gomock.Any()).Return(&v1.UserList{}, nil)
 	secrets := &v1.SecretList{
 		ListMeta: metav1.ListMeta{
 			TotalCount: 10,
@@ -136,6 +145,142 @@ func TestCache_ListSecrets(t *testing.T) {
 	}
 }
 
+func TestCache_ListSecretsOfDisabledUsers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	secrets := fake.FakeSecrets(3)
+	disabled := &v1.User{ObjectMeta: metav1.ObjectMeta{Name: secrets[1].Username}, Status: store.UserStatusDisabled}
+
+	mockFactory := store.NewMockFactory(ctrl)
+	mockSecretStore := store.NewMockSecretStore(ctrl)
+	mockFactory.EXPECT().Secrets().Return(mockSecretStore)
+	mockSecretStore.EXPECT().List(gomock.Any(), gomock.Eq(""), gomock.Any()).
+		Return(&v1.SecretList{ListMeta: metav1.ListMeta{TotalCount: 3}, Items: secrets}, nil)
+	mockUserStore := store.NewMockUserStore(ctrl)
+	mockFactory.EXPECT().Users().Return(mockUserStore)
+	mockUserStore.EXPECT().List(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, opts metav1.ListOptions) (*v1.UserList, error) {
+			assert.Equal(t, fmt.Sprintf("status=%d", store.UserStatusDisabled), opts.FieldSelector)
+
+			return &v1.UserList{Items: []*v1.User{disabled}}, nil
+		})
+
+	c := &Cache{store: mockFactory}
+	got, err := c.ListSecrets(context.TODO(), &pb.ListSecretsRequest{})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), got.TotalCount)
+	if assert.Len(t, got.Items, 2) {
+		assert.Equal(t, secrets[0].SecretID, got.Items[0].SecretId)
+		assert.Equal(t, secrets[2].SecretID, got.Items[1].SecretId)
+	}
+}
+
+func TestCache_ListSecretsWithPreviousKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	secrets := fake.FakeSecrets(2)
+	graceExpires := time.Now().Add(time.Minute).Unix()
+	// a rotated secret in its grace period, and one whose grace period is over
+	secrets[0].Extend = metav1.Extend{
+		store.SecretPreviousKeyKey:        "old-key",
+		store.SecretPreviousKeyExpiresKey: float64(graceExpires),
+	}
+	secrets[1].Extend = metav1.Extend{
+		store.SecretPreviousKeyKey:        "older-key",
+		store.SecretPreviousKeyExpiresKey: float64(time.Now().Add(-time.Minute).Unix()),
+	}
+
+	mockFactory := store.NewMockFactory(ctrl)
+	mockSecretStore := store.NewMockSecretStore(ctrl)
+	mockFactory.EXPECT().Secrets().Return(mockSecretStore)
+	mockSecretStore.EXPECT().List(gomock.Any(), gomock.Eq(""), gomock.Any()).
+		Return(&v1.SecretList{ListMeta: metav1.ListMeta{TotalCount: 2}, Items: secrets}, nil)
+	mockUserStore := store.NewMockUserStore(ctrl)
+	mockFactory.EXPECT().Users().Return(mockUserStore)
+	mockUserStore.EXPECT().List(gomock.Any(), gomock.Any()).Return(&v1.UserList{}, nil)
+
+	c := &Cache{store: mockFactory}
+	got, err := c.ListSecrets(context.TODO(), &pb.ListSecretsRequest{})
+	assert.Nil(t, err)
+	if assert.Len(t, got.Items, 3) {
+		assert.Equal(t, secrets[0].SecretID, got.Items[0].SecretId)
+		assert.Equal(t, auth.PreviousSecretID(secrets[0].SecretID), got.Items[1].SecretId)
+		assert.Equal(t, "old-key", got.Items[1].SecretKey)
+		assert.Equal(t, graceExpires, got.Items[1].Expires)
+		assert.Equal(t, secrets[1].SecretID, got.Items[2].SecretId)
+	}
+}
+
+func TestCache_ListScopedSecrets(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	secrets := fake.FakeSecrets(2)
+	secrets[0].Extend = metav1.Extend{
+		store.SecretScopeKey: map[string]interface{}{"actions": []interface{}{"get"}},
+	}
+
+	mockFactory := store.NewMockFactory(ctrl)
+	mockSecretStore := store.NewMockSecretStore(ctrl)
+	mockFactory.EXPECT().Secrets().Return(mockSecretStore).Times(2)
+	mockSecretStore.EXPECT().List(gomock.Any(), gomock.Eq(""), gomock.Any()).
+		Return(&v1.SecretList{ListMeta: metav1.ListMeta{TotalCount: 2}, Items: secrets}, nil).Times(2)
+	mockUserStore := store.NewMockUserStore(ctrl)
+	mockFactory.EXPECT().Users().Return(mockUserStore).Times(2)
+	mockUserStore.EXPECT().List(gomock.Any(), gomock.Any()).Return(&v1.UserList{}, nil).Times(2)
+
+	c := &Cache{store: mockFactory}
+
+	// a client enforcing the scopes gets the scoped secret, and its scope in the header
+	stream := &headerStream{}
+	ctx := metadata.NewIncomingContext(context.TODO(), mdFromOutgoing(auth.WithScopeSupport(context.TODO())))
+	got, err := c.ListSecrets(grpc.NewContextWithServerTransportStream(ctx, stream), &pb.ListSecretsRequest{})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), got.TotalCount)
+	if assert.Len(t, got.Items, 2) {
+		assert.Empty(t, got.Items[0].Name)
+	}
+
+	scopes, err := auth.ScopesFromHeader(stream.header)
+	assert.Nil(t, err)
+	if assert.Len(t, scopes, 1) {
+		assert.Equal(t, []string{"get"}, scopes[secrets[0].SecretID].Actions)
+	}
+
+	// a client which predates the scopes doesn't get it
+	got, err = c.ListSecrets(context.TODO(), &pb.ListSecretsRequest{})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), got.TotalCount)
+	if assert.Len(t, got.Items, 1) {
+		assert.Equal(t, secrets[1].SecretID, got.Items[0].SecretId)
+	}
+}
+
+// headerStream records the header sent by the grpc handler.
+type headerStream struct {
+	header metadata.MD
+}
+
+func (s *headerStream) Method() string { return "" }
+
+func (s *headerStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+
+	return nil
+}
+
+func (s *headerStream) SendHeader(md metadata.MD) error { return s.SetHeader(md) }
+
+func (s *headerStream) SetTrailer(md metadata.MD) error { return nil }
+
+func mdFromOutgoing(ctx context.Context) metadata.MD {
+	md, _ := metadata.FromOutgoingContext(ctx)
+
+	return md
+}
+
 func TestCache_ListPolicies(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
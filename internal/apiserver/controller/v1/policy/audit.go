@@ -0,0 +1,43 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package policy
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/marmotedu/component-base/pkg/core"
+	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
+	"github.com/marmotedu/errors"
+
+	"github.com/marmotedu/iam/internal/apiserver/store"
+	"github.com/marmotedu/iam/internal/pkg/code"
+	"github.com/marmotedu/iam/internal/pkg/util/listutil"
+	"github.com/marmotedu/iam/pkg/log"
+)
+
+// ListAudits returns the audit trail of the policies with the given name of all users.
+// Only administrator can call this function.
+func (p *PolicyController) ListAudits(c *gin.Context) {
+	log.L(c).Info("list policy audits function called.")
+
+	var r metav1.ListOptions
+	if err := c.ShouldBindQuery(&r); err != nil {
+		core.WriteResponse(c, errors.WithCode(code.ErrBind, err.Error()), nil)
+
+		return
+	}
+
+	audits, err := p.srv.Policies().ListAudits(c, c.Param("name"), r)
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+
+		return
+	}
+
+	if audits == nil {
+		audits = &store.PolicyAuditList{}
+	}
+
+	listutil.WriteListResponse(c, r, audits.TotalCount, audits.Items)
+}
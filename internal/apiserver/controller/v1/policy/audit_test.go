@@ -0,0 +1,95 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package policy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	v1 "github.com/marmotedu/api/apiserver/v1"
+	"github.com/marmotedu/component-base/pkg/json"
+	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/marmotedu/iam/internal/apiserver/store"
+	"github.com/marmotedu/iam/internal/pkg/util/gormutil"
+)
+
+// fakePolicyAudits keeps the audits in memory, the most recently deleted last.
+type fakePolicyAudits struct {
+	audits []*store.PolicyAudit
+}
+
+func (f *fakePolicyAudits) insert(name, username string, deletedAt time.Time) {
+	f.audits = append(f.audits, &store.PolicyAudit{
+		Policy: v1.Policy{
+			ObjectMeta: metav1.ObjectMeta{ID: uint64(len(f.audits) + 1), Name: name},
+			Username:   username,
+		},
+		DeletedAt: deletedAt,
+	})
+}
+
+func (f *fakePolicyAudits) List(_ context.Context, name string, opts metav1.ListOptions) (*store.PolicyAuditList, error) {
+	var matched []*store.PolicyAudit
+	for i := len(f.audits) - 1; i >= 0; i-- {
+		if f.audits[i].Name == name {
+			matched = append(matched, f.audits[i])
+		}
+	}
+
+	ol := gormutil.Unpointer(opts.Offset, opts.Limit)
+	items := matched[ol.Offset:]
+	if ol.Limit >= 0 && ol.Limit < len(items) {
+		items = items[:ol.Limit]
+	}
+
+	return &store.PolicyAuditList{ListMeta: metav1.ListMeta{TotalCount: int64(len(matched))}, Items: items}, nil
+}
+
+func (f *fakePolicyAudits) ClearOutdated(context.Context, int) (int64, error) {
+	return 0, nil
+}
+
+func TestPolicyController_ListAudits(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	audits := &fakePolicyAudits{}
+	now := time.Now().UTC().Truncate(time.Second)
+	audits.insert("policy1", "colin", now.Add(-2*time.Hour))
+	audits.insert("policy2", "colin", now.Add(-time.Hour))
+	audits.insert("policy1", "kong", now)
+
+	mockFactory := store.NewMockFactory(ctrl)
+	mockFactory.EXPECT().PolicyAudits().Return(audits).AnyTimes()
+
+	r := gin.New()
+	r.GET("/v1/policies/:name/audits", NewPolicyController(mockFactory).ListAudits)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/policies/policy1/audits?limit=1", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		TotalCount int64               `json:"totalCount"`
+		Items      []store.PolicyAudit `json:"items"`
+	}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, int64(2), resp.TotalCount)
+
+	if assert.Len(t, resp.Items, 1) {
+		assert.Equal(t, "policy1", resp.Items[0].Name)
+		assert.Equal(t, "kong", resp.Items[0].Username)
+		assert.True(t, now.Equal(resp.Items[0].DeletedAt))
+	}
+}
@@ -11,7 +11,9 @@ import (
 	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
 	"github.com/marmotedu/errors"
 
+	"github.com/marmotedu/iam/internal/apiserver/audit"
 	"github.com/marmotedu/iam/internal/pkg/code"
+	"github.com/marmotedu/iam/internal/pkg/condition"
 	"github.com/marmotedu/iam/internal/pkg/middleware"
 	"github.com/marmotedu/iam/pkg/log"
 )
@@ -34,6 +36,18 @@ func (p *PolicyController) Create(c *gin.Context) {
 		return
 	}
 
+	if err := condition.Validate(r.Policy.Conditions); err != nil {
+		core.WriteResponse(c, errors.WithCode(code.ErrValidation, err.Error()), nil)
+
+		return
+	}
+
+	if err := setValidity(&r); err != nil {
+		core.WriteResponse(c, err, nil)
+
+		return
+	}
+
 	r.Username = c.GetString(middleware.UsernameKey)
 
 	if err := p.srv.Policies().Create(c, &r, metav1.CreateOptions{}); err != nil {
@@ -42,5 +56,7 @@ func (p *PolicyController) Create(c *gin.Context) {
 		return
 	}
 
+	audit.Record(c, audit.ActionCreate, audit.ResourcePolicy, r.Name, nil, r)
+
 	core.WriteResponse(c, nil, r)
 }
@@ -8,7 +8,10 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/marmotedu/component-base/pkg/core"
 	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
+	"github.com/marmotedu/errors"
 
+	"github.com/marmotedu/iam/internal/apiserver/audit"
+	"github.com/marmotedu/iam/internal/pkg/code"
 	"github.com/marmotedu/iam/internal/pkg/middleware"
 	"github.com/marmotedu/iam/pkg/log"
 )
@@ -17,12 +20,23 @@ import (
 func (p *PolicyController) Delete(c *gin.Context) {
 	log.L(c).Info("delete policy function called.")
 
-	if err := p.srv.Policies().Delete(c, c.GetString(middleware.UsernameKey), c.Param("name"),
-		metav1.DeleteOptions{}); err != nil {
+	username := c.GetString(middleware.UsernameKey)
+
+	// the policy is audited as it was before the deletion, deleting a missing policy succeeds
+	old, err := p.srv.Policies().Get(c, username, c.Param("name"), metav1.GetOptions{})
+	if err != nil && !errors.IsCode(err, code.ErrPolicyNotFound) {
 		core.WriteResponse(c, err, nil)
 
 		return
 	}
 
+	if err := p.srv.Policies().Delete(c, username, c.Param("name"), metav1.DeleteOptions{}); err != nil {
+		core.WriteResponse(c, err, nil)
+
+		return
+	}
+
+	audit.Record(c, audit.ActionDelete, audit.ResourcePolicy, c.Param("name"), old, nil)
+
 	core.WriteResponse(c, nil, nil)
 }
@@ -6,9 +6,13 @@ package policy
 
 import (
 	"github.com/gin-gonic/gin"
+	v1 "github.com/marmotedu/api/apiserver/v1"
 	"github.com/marmotedu/component-base/pkg/core"
 	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
+	"github.com/marmotedu/errors"
 
+	"github.com/marmotedu/iam/internal/apiserver/audit"
+	"github.com/marmotedu/iam/internal/pkg/code"
 	"github.com/marmotedu/iam/internal/pkg/middleware"
 	"github.com/marmotedu/iam/pkg/log"
 )
@@ -17,12 +21,30 @@ import (
 func (p *PolicyController) DeleteCollection(c *gin.Context) {
 	log.L(c).Info("batch delete policy function called.")
 
-	if err := p.srv.Policies().DeleteCollection(c, c.GetString(middleware.UsernameKey),
-		c.QueryArray("name"), metav1.DeleteOptions{}); err != nil {
+	username := c.GetString(middleware.UsernameKey)
+	names := c.QueryArray("name")
+
+	// the policies are audited as they were before the deletion
+	olds := make([]*v1.Policy, len(names))
+	for i, name := range names {
+		pol, err := p.srv.Policies().Get(c, username, name, metav1.GetOptions{})
+		if err != nil && !errors.IsCode(err, code.ErrPolicyNotFound) {
+			core.WriteResponse(c, err, nil)
+
+			return
+		}
+		olds[i] = pol
+	}
+
+	if err := p.srv.Policies().DeleteCollection(c, username, names, metav1.DeleteOptions{}); err != nil {
 		core.WriteResponse(c, err, nil)
 
 		return
 	}
 
+	for i, name := range names {
+		audit.Record(c, audit.ActionDelete, audit.ResourcePolicy, name, olds[i], nil)
+	}
+
 	core.WriteResponse(c, nil, nil)
 }
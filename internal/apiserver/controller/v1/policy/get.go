@@ -10,6 +10,7 @@ import (
 	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
 
 	"github.com/marmotedu/iam/internal/pkg/middleware"
+	"github.com/marmotedu/iam/internal/pkg/util/etagutil"
 	"github.com/marmotedu/iam/pkg/log"
 )
 
@@ -24,5 +25,5 @@ func (p *PolicyController) Get(c *gin.Context) {
 		return
 	}
 
-	core.WriteResponse(c, nil, pol)
+	etagutil.WriteResourceResponse(c, pol)
 }
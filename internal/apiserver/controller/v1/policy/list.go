@@ -6,12 +6,15 @@ package policy
 
 import (
 	"github.com/gin-gonic/gin"
+	v1 "github.com/marmotedu/api/apiserver/v1"
 	"github.com/marmotedu/component-base/pkg/core"
 	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
 	"github.com/marmotedu/errors"
 
 	"github.com/marmotedu/iam/internal/pkg/code"
 	"github.com/marmotedu/iam/internal/pkg/middleware"
+	"github.com/marmotedu/iam/internal/pkg/util/gormutil"
+	"github.com/marmotedu/iam/internal/pkg/util/listutil"
 	"github.com/marmotedu/iam/pkg/log"
 )
 
@@ -25,6 +28,7 @@ func (p *PolicyController) List(c *gin.Context) {
 
 		return
 	}
+	r.FieldSelector = gormutil.WithCursor(r.FieldSelector, c.Query(gormutil.CursorField))
 
 	policies, err := p.srv.Policies().List(c, c.GetString(middleware.UsernameKey), r)
 	if err != nil {
@@ -33,5 +37,9 @@ func (p *PolicyController) List(c *gin.Context) {
 		return
 	}
 
-	core.WriteResponse(c, nil, policies)
+	if policies == nil {
+		policies = &v1.PolicyList{}
+	}
+
+	listutil.WriteListResponse(c, r, policies.TotalCount, policies.Items)
 }
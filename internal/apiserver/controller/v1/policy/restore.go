@@ -0,0 +1,41 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package policy
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/marmotedu/component-base/pkg/core"
+	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
+
+	"github.com/marmotedu/iam/internal/apiserver/audit"
+	"github.com/marmotedu/iam/internal/pkg/middleware"
+	"github.com/marmotedu/iam/pkg/log"
+)
+
+// Restore restores a deleted policy of the user given by the `username` query parameter,
+// which defaults to the requester. Restoring a policy which is not deleted does nothing.
+// Only administrator can call this function.
+func (p *PolicyController) Restore(c *gin.Context) {
+	log.L(c).Info("restore policy function called.")
+
+	username := c.DefaultQuery("username", c.GetString(middleware.UsernameKey))
+
+	if err := p.srv.Policies().Restore(c, username, c.Param("name")); err != nil {
+		core.WriteResponse(c, err, nil)
+
+		return
+	}
+
+	pol, err := p.srv.Policies().Get(c, username, c.Param("name"), metav1.GetOptions{})
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+
+		return
+	}
+
+	audit.Record(c, audit.ActionRestore, audit.ResourcePolicy, pol.Name, nil, pol)
+
+	core.WriteResponse(c, nil, pol)
+}
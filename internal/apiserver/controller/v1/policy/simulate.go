@@ -0,0 +1,95 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package policy
+
+import (
+	"github.com/gin-gonic/gin"
+	authzv1 "github.com/marmotedu/api/authz/v1"
+	"github.com/marmotedu/component-base/pkg/core"
+	"github.com/marmotedu/component-base/pkg/validation/field"
+	"github.com/marmotedu/errors"
+	"github.com/ory/ladon"
+
+	"github.com/marmotedu/iam/internal/pkg/authorization"
+	"github.com/marmotedu/iam/internal/pkg/code"
+	"github.com/marmotedu/iam/pkg/log"
+)
+
+// maxSimulateRequests is the maximum number of sample requests in a simulation.
+const maxSimulateRequests = 100
+
+// SimulateRequest is a policy document with the sample requests to authorize against it.
+type SimulateRequest struct {
+	Policy   ladon.DefaultPolicy `json:"policy"`
+	Requests []*ladon.Request    `json:"requests"`
+}
+
+// Validate validates the simulation request.
+func (r *SimulateRequest) Validate() field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	requestsPath := field.NewPath("requests")
+	switch {
+	case len(r.Requests) == 0:
+		allErrs = append(allErrs, field.Required(requestsPath, "at least one sample request must be specified"))
+	case len(r.Requests) > maxSimulateRequests:
+		allErrs = append(allErrs, field.TooMany(requestsPath, len(r.Requests), maxSimulateRequests))
+	}
+
+	for i, req := range r.Requests {
+		if req == nil {
+			allErrs = append(allErrs, field.Required(requestsPath.Index(i), ""))
+		}
+	}
+
+	if r.Policy.Effect != ladon.AllowAccess && r.Policy.Effect != ladon.DenyAccess {
+		allErrs = append(allErrs, field.NotSupported(field.NewPath("policy", "effect"), r.Policy.Effect,
+			[]string{ladon.AllowAccess, ladon.DenyAccess}))
+	}
+
+	return allErrs
+}
+
+// SimulateResult is the outcome of a sample request.
+type SimulateResult struct {
+	Request *ladon.Request `json:"request"`
+	*authzv1.Response
+}
+
+// SimulateResponse contains the outcomes of the sample requests, in the order of the requests.
+type SimulateResponse struct {
+	Results []SimulateResult `json:"results"`
+}
+
+// Simulate authorizes the sample requests against a policy without saving the
+// policy, so admins are able to check what a policy allows before creating it.
+func (p *PolicyController) Simulate(c *gin.Context) {
+	log.L(c).Info("simulate policy function called.")
+
+	var r SimulateRequest
+	if err := c.ShouldBindJSON(&r); err != nil {
+		core.WriteResponse(c, errors.WithCode(code.ErrBind, err.Error()), nil)
+
+		return
+	}
+
+	if errs := r.Validate(); len(errs) != 0 {
+		core.WriteResponse(c, errors.WithCode(code.ErrValidation, errs.ToAggregate().Error()), nil)
+
+		return
+	}
+
+	if r.Policy.ID == "" {
+		r.Policy.ID = "simulated"
+	}
+
+	auth := authorization.NewAuthorizer(authorization.NewStaticAuthorization(&r.Policy))
+	resp := SimulateResponse{Results: make([]SimulateResult, 0, len(r.Requests))}
+	for _, req := range r.Requests {
+		resp.Results = append(resp.Results, SimulateResult{Request: req, Response: auth.Authorize(req)})
+	}
+
+	core.WriteResponse(c, nil, resp)
+}
@@ -0,0 +1,135 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package policy
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/marmotedu/component-base/pkg/json"
+	"github.com/ory/ladon"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/marmotedu/iam/internal/pkg/code"
+)
+
+func simulate(t *testing.T, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+
+	data, err := json.Marshal(body)
+	assert.Nil(t, err)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest(http.MethodPost, "/v1/policies/simulate", bytes.NewReader(data))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	(&PolicyController{}).Simulate(c)
+
+	return w
+}
+
+func TestPolicyController_Simulate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	body := map[string]interface{}{
+		"policy": map[string]interface{}{
+			"subjects":  []string{"users:<peter|ken>"},
+			"resources": []string{"resources:articles:<.*>"},
+			"actions":   []string{"<delete|get>"},
+			"effect":    ladon.AllowAccess,
+			"conditions": map[string]interface{}{
+				"remoteIP": map[string]interface{}{
+					"type":    "CIDRCondition",
+					"options": map[string]interface{}{"cidr": "192.168.0.1/16"},
+				},
+			},
+		},
+		"requests": []*ladon.Request{
+			{
+				Subject:  "users:peter",
+				Resource: "resources:articles:ladon-introduction",
+				Action:   "delete",
+				Context:  ladon.Context{"remoteIP": "192.168.0.5"},
+			},
+			{
+				Subject:  "users:alice",
+				Resource: "resources:articles:ladon-introduction",
+				Action:   "delete",
+				Context:  ladon.Context{"remoteIP": "192.168.0.5"},
+			},
+			{
+				Subject:  "users:ken",
+				Resource: "resources:articles:ladon-introduction",
+				Action:   "update",
+				Context:  ladon.Context{"remoteIP": "192.168.0.5"},
+			},
+			{
+				Subject:  "users:ken",
+				Resource: "resources:articles:ladon-introduction",
+				Action:   "get",
+				Context:  ladon.Context{"remoteIP": "10.0.0.5"},
+			},
+		},
+	}
+
+	w := simulate(t, body)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Results []struct {
+			Request *ladon.Request `json:"request"`
+			Allowed bool           `json:"allowed"`
+		} `json:"results"`
+	}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &resp))
+
+	expected := []bool{true, false, false, false}
+	if assert.Len(t, resp.Results, len(expected)) {
+		for i, allowed := range expected {
+			assert.Equal(t, allowed, resp.Results[i].Allowed, "request %d", i)
+			assert.Equal(t, body["requests"].([]*ladon.Request)[i].Subject, resp.Results[i].Request.Subject)
+		}
+	}
+}
+
+func TestPolicyController_SimulateValidation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name string
+		body map[string]interface{}
+	}{
+		{
+			name: "no requests",
+			body: map[string]interface{}{
+				"policy": map[string]interface{}{"effect": ladon.AllowAccess},
+			},
+		},
+		{
+			name: "invalid effect",
+			body: map[string]interface{}{
+				"policy":   map[string]interface{}{"effect": "maybe"},
+				"requests": []*ladon.Request{{Subject: "users:peter"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := simulate(t, tt.body)
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+
+			var resp struct {
+				Code int `json:"code"`
+			}
+			assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, code.ErrValidation, resp.Code)
+		})
+	}
+}
@@ -11,7 +11,9 @@ import (
 	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
 	"github.com/marmotedu/errors"
 
+	"github.com/marmotedu/iam/internal/apiserver/audit"
 	"github.com/marmotedu/iam/internal/pkg/code"
+	"github.com/marmotedu/iam/internal/pkg/condition"
 	"github.com/marmotedu/iam/internal/pkg/middleware"
 	"github.com/marmotedu/iam/pkg/log"
 )
@@ -34,6 +36,8 @@ func (p *PolicyController) Update(c *gin.Context) {
 		return
 	}
 
+	old := audit.Summary(pol)
+
 	// only update policy string
 	pol.Policy = r.Policy
 	pol.Extend = r.Extend
@@ -44,11 +48,25 @@ func (p *PolicyController) Update(c *gin.Context) {
 		return
 	}
 
+	if err := condition.Validate(pol.Policy.Conditions); err != nil {
+		core.WriteResponse(c, errors.WithCode(code.ErrValidation, err.Error()), nil)
+
+		return
+	}
+
+	if err := setValidity(pol); err != nil {
+		core.WriteResponse(c, err, nil)
+
+		return
+	}
+
 	if err := p.srv.Policies().Update(c, pol, metav1.UpdateOptions{}); err != nil {
 		core.WriteResponse(c, err, nil)
 
 		return
 	}
 
+	audit.Record(c, audit.ActionUpdate, audit.ResourcePolicy, pol.Name, old, pol)
+
 	core.WriteResponse(c, nil, pol)
 }
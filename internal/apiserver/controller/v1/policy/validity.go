@@ -0,0 +1,62 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package policy
+
+import (
+	"time"
+
+	v1 "github.com/marmotedu/api/apiserver/v1"
+	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
+	"github.com/marmotedu/errors"
+
+	"github.com/marmotedu/iam/internal/pkg/authorization"
+	"github.com/marmotedu/iam/internal/pkg/code"
+)
+
+// setValidity copies the validity window given in the extend fields of the policy
+// into the meta of the ladon policy, which is what iam-authz-server authorizes with.
+func setValidity(pol *v1.Policy) error {
+	var v authorization.Validity
+	var err error
+
+	if v.EffectiveFrom, err = extendTime(pol.Extend, authorization.EffectiveFromKey); err != nil {
+		return err
+	}
+
+	if v.ExpiresAt, err = extendTime(pol.Extend, authorization.ExpiresAtKey); err != nil {
+		return err
+	}
+
+	if err := v.Validate(); err != nil {
+		return errors.WithCode(code.ErrValidation, err.Error())
+	}
+
+	if err := authorization.SetPolicyValidity(&pol.Policy.DefaultPolicy, v); err != nil {
+		return errors.WithCode(code.ErrValidation, err.Error())
+	}
+
+	return nil
+}
+
+// extendTime returns the RFC3339 time of the extend field, nil is returned if
+// the field is not set.
+func extendTime(extend metav1.Extend, key string) (*time.Time, error) {
+	value, ok := extend[key]
+	if !ok || value == nil {
+		return nil, nil
+	}
+
+	s, ok := value.(string)
+	if !ok {
+		return nil, errors.WithCode(code.ErrValidation, "%s must be a RFC3339 time", key)
+	}
+
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return nil, errors.WithCode(code.ErrValidation, "%s must be a RFC3339 time: %s", key, err.Error())
+	}
+
+	return &t, nil
+}
@@ -0,0 +1,45 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package policy
+
+import (
+	"testing"
+
+	v1 "github.com/marmotedu/api/apiserver/v1"
+	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
+	"github.com/marmotedu/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/marmotedu/iam/internal/pkg/authorization"
+	"github.com/marmotedu/iam/internal/pkg/code"
+)
+
+func TestSetValidity(t *testing.T) {
+	pol := &v1.Policy{ObjectMeta: metav1.ObjectMeta{Extend: metav1.Extend{
+		"effectiveFrom": "2021-01-01T00:00:00Z",
+		"expiresAt":     "2021-02-01T00:00:00+08:00",
+	}}}
+	assert.Nil(t, setValidity(pol))
+
+	v, err := authorization.PolicyValidity(&pol.Policy.DefaultPolicy)
+	assert.Nil(t, err)
+	assert.Equal(t, "2021-01-01T00:00:00Z", v.EffectiveFrom.Format("2006-01-02T15:04:05Z07:00"))
+	assert.Equal(t, "2021-02-01T00:00:00+08:00", v.ExpiresAt.Format("2006-01-02T15:04:05Z07:00"))
+
+	// removing the window from the extend fields removes it from the policy too
+	pol.Extend = nil
+	assert.Nil(t, setValidity(pol))
+	assert.Nil(t, pol.Policy.Meta)
+
+	invalid := []metav1.Extend{
+		{"effectiveFrom": "tomorrow"},
+		{"expiresAt": 1612108800},
+		{"effectiveFrom": "2021-02-01T00:00:00Z", "expiresAt": "2021-01-01T00:00:00Z"},
+	}
+	for _, extend := range invalid {
+		err := setValidity(&v1.Policy{ObjectMeta: metav1.ObjectMeta{Extend: extend}})
+		assert.True(t, errors.IsCode(err, code.ErrValidation), "%v", extend)
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/marmotedu/component-base/pkg/util/idutil"
 	"github.com/marmotedu/errors"
 
+	"github.com/marmotedu/iam/internal/apiserver/audit"
 	"github.com/marmotedu/iam/internal/pkg/code"
 	"github.com/marmotedu/iam/internal/pkg/middleware"
 	"github.com/marmotedu/iam/pkg/log"
@@ -38,6 +39,12 @@ func (s *SecretController) Create(c *gin.Context) {
 		return
 	}
 
+	if err := validateScope(r.Extend); err != nil {
+		core.WriteResponse(c, err, nil)
+
+		return
+	}
+
 	username := c.GetString(middleware.UsernameKey)
 
 	secrets, err := s.srv.Secrets().List(c, username, metav1.ListOptions{
@@ -69,5 +76,7 @@ func (s *SecretController) Create(c *gin.Context) {
 		return
 	}
 
+	audit.Record(c, audit.ActionCreate, audit.ResourceSecret, r.Name, nil, r)
+
 	core.WriteResponse(c, nil, r)
 }
@@ -8,7 +8,10 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/marmotedu/component-base/pkg/core"
 	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
+	"github.com/marmotedu/errors"
 
+	"github.com/marmotedu/iam/internal/apiserver/audit"
+	"github.com/marmotedu/iam/internal/pkg/code"
 	"github.com/marmotedu/iam/internal/pkg/middleware"
 	"github.com/marmotedu/iam/pkg/log"
 )
@@ -16,12 +19,24 @@ import (
 // Delete delete a secret by the secret identifier.
 func (s *SecretController) Delete(c *gin.Context) {
 	log.L(c).Info("delete secret function called.")
+	username := c.GetString(middleware.UsernameKey)
+
+	// the secret is audited as it was before the deletion, deleting a missing secret succeeds
+	old, err := s.srv.Secrets().Get(c, username, c.Param("name"), metav1.GetOptions{})
+	if err != nil && !errors.IsCode(err, code.ErrSecretNotFound) {
+		core.WriteResponse(c, err, nil)
+
+		return
+	}
+
 	opts := metav1.DeleteOptions{Unscoped: true}
-	if err := s.srv.Secrets().Delete(c, c.GetString(middleware.UsernameKey), c.Param("name"), opts); err != nil {
+	if err := s.srv.Secrets().Delete(c, username, c.Param("name"), opts); err != nil {
 		core.WriteResponse(c, err, nil)
 
 		return
 	}
 
+	audit.Record(c, audit.ActionDelete, audit.ResourceSecret, c.Param("name"), old, nil)
+
 	core.WriteResponse(c, nil, nil)
 }
@@ -6,9 +6,13 @@ package secret
 
 import (
 	"github.com/gin-gonic/gin"
+	v1 "github.com/marmotedu/api/apiserver/v1"
 	"github.com/marmotedu/component-base/pkg/core"
 	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
+	"github.com/marmotedu/errors"
 
+	"github.com/marmotedu/iam/internal/apiserver/audit"
+	"github.com/marmotedu/iam/internal/pkg/code"
 	"github.com/marmotedu/iam/internal/pkg/middleware"
 	"github.com/marmotedu/iam/pkg/log"
 )
@@ -17,10 +21,25 @@ import (
 func (s *SecretController) DeleteCollection(c *gin.Context) {
 	log.L(c).Info("batch delete policy function called.")
 
+	username := c.GetString(middleware.UsernameKey)
+	names := c.QueryArray("name")
+
+	// the secrets are audited as they were before the deletion
+	olds := make([]*v1.Secret, len(names))
+	for i, name := range names {
+		secret, err := s.srv.Secrets().Get(c, username, name, metav1.GetOptions{})
+		if err != nil && !errors.IsCode(err, code.ErrSecretNotFound) {
+			core.WriteResponse(c, err, nil)
+
+			return
+		}
+		olds[i] = secret
+	}
+
 	if err := s.srv.Secrets().DeleteCollection(
 		c,
-		c.GetString(middleware.UsernameKey),
-		c.QueryArray("name"),
+		username,
+		names,
 		metav1.DeleteOptions{},
 	); err != nil {
 		core.WriteResponse(c, err, nil)
@@ -28,5 +47,9 @@ func (s *SecretController) DeleteCollection(c *gin.Context) {
 		return
 	}
 
+	for i, name := range names {
+		audit.Record(c, audit.ActionDelete, audit.ResourceSecret, name, olds[i], nil)
+	}
+
 	core.WriteResponse(c, nil, nil)
 }
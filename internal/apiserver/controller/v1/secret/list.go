@@ -6,12 +6,15 @@ package secret
 
 import (
 	"github.com/gin-gonic/gin"
+	v1 "github.com/marmotedu/api/apiserver/v1"
 	"github.com/marmotedu/component-base/pkg/core"
 	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
 	"github.com/marmotedu/errors"
 
 	"github.com/marmotedu/iam/internal/pkg/code"
 	"github.com/marmotedu/iam/internal/pkg/middleware"
+	"github.com/marmotedu/iam/internal/pkg/util/gormutil"
+	"github.com/marmotedu/iam/internal/pkg/util/listutil"
 	"github.com/marmotedu/iam/pkg/log"
 )
 
@@ -24,6 +27,7 @@ func (s *SecretController) List(c *gin.Context) {
 
 		return
 	}
+	r.FieldSelector = gormutil.WithCursor(r.FieldSelector, c.Query(gormutil.CursorField))
 
 	secrets, err := s.srv.Secrets().List(c, c.GetString(middleware.UsernameKey), r)
 	if err != nil {
@@ -32,5 +36,9 @@ func (s *SecretController) List(c *gin.Context) {
 		return
 	}
 
-	core.WriteResponse(c, nil, secrets)
+	if secrets == nil {
+		secrets = &v1.SecretList{}
+	}
+
+	listutil.WriteListResponse(c, r, secrets.TotalCount, secrets.Items)
 }
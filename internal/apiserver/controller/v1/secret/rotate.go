@@ -0,0 +1,107 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package secret
+
+import (
+	"io"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/marmotedu/component-base/pkg/core"
+	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
+	"github.com/marmotedu/component-base/pkg/util/idutil"
+	"github.com/marmotedu/errors"
+
+	"github.com/marmotedu/iam/internal/apiserver/audit"
+	"github.com/marmotedu/iam/internal/apiserver/store"
+	"github.com/marmotedu/iam/internal/pkg/code"
+	"github.com/marmotedu/iam/internal/pkg/middleware"
+	"github.com/marmotedu/iam/pkg/log"
+)
+
+const (
+	// DefaultRotationGracePeriod is how long the replaced secret key is still accepted
+	// after a rotation if the grace period is not specified.
+	DefaultRotationGracePeriod = 10 * time.Minute
+
+	// MaxRotationGracePeriod is the longest grace period of a rotation.
+	MaxRotationGracePeriod = 24 * time.Hour
+)
+
+// RotateRequest defines the options of a secret key rotation.
+type RotateRequest struct {
+	// GracePeriod is the number of seconds the replaced secret key is still accepted.
+	GracePeriod int64 `json:"gracePeriod"`
+}
+
+// Rotate replaces the secret key with a new one. The replaced key is still accepted
+// during the grace period, so the tokens signed with it keep working while clients
+// switch to the new key.
+func (s *SecretController) Rotate(c *gin.Context) {
+	log.L(c).Info("rotate secret function called.")
+
+	var r RotateRequest
+	if err := c.ShouldBindJSON(&r); err != nil && !errors.Is(err, io.EOF) {
+		core.WriteResponse(c, errors.WithCode(code.ErrBind, err.Error()), nil)
+
+		return
+	}
+
+	gracePeriod := DefaultRotationGracePeriod
+	if r.GracePeriod != 0 {
+		gracePeriod = time.Duration(r.GracePeriod) * time.Second
+	}
+
+	if gracePeriod < 0 || gracePeriod > MaxRotationGracePeriod {
+		core.WriteResponse(c, errors.WithCode(code.ErrValidation,
+			"gracePeriod must be between 0 and %d seconds", int64(MaxRotationGracePeriod/time.Second)), nil)
+
+		return
+	}
+
+	secret, err := s.srv.Secrets().Get(c, c.GetString(middleware.UsernameKey), c.Param("name"), metav1.GetOptions{})
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+
+		return
+	}
+
+	old := audit.Summary(secret)
+
+	if secret.Extend == nil {
+		secret.Extend = metav1.Extend{}
+	}
+	secret.Extend[store.SecretPreviousKeyKey] = secret.SecretKey
+	secret.Extend[store.SecretPreviousKeyExpiresKey] = time.Now().Add(gracePeriod).Unix()
+	secret.SecretKey = idutil.NewSecretKey()
+
+	if err := s.srv.Secrets().Update(c, secret, metav1.UpdateOptions{}); err != nil {
+		core.WriteResponse(c, err, nil)
+
+		return
+	}
+
+	audit.Record(c, audit.ActionUpdate, audit.ResourceSecret, secret.Name, old, secret)
+
+	core.WriteResponse(c, nil, secret)
+}
+
+// keepPreviousKey copies the secret key replaced by the last rotation from the
+// extend fields of the stored secret, because it can't be changed by clients.
+func keepPreviousKey(stored, extend metav1.Extend) metav1.Extend {
+	delete(extend, store.SecretPreviousKeyKey)
+	delete(extend, store.SecretPreviousKeyExpiresKey)
+
+	for _, key := range []string{store.SecretPreviousKeyKey, store.SecretPreviousKeyExpiresKey} {
+		if value, ok := stored[key]; ok {
+			if extend == nil {
+				extend = metav1.Extend{}
+			}
+			extend[key] = value
+		}
+	}
+
+	return extend
+}
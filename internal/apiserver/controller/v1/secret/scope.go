@@ -0,0 +1,23 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package secret
+
+import (
+	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
+	"github.com/marmotedu/errors"
+
+	"github.com/marmotedu/iam/internal/apiserver/store"
+	"github.com/marmotedu/iam/internal/pkg/code"
+	"github.com/marmotedu/iam/internal/pkg/middleware/auth"
+)
+
+// validateScope validates the scope given in the extend fields of the secret.
+func validateScope(extend metav1.Extend) error {
+	if _, err := auth.ParseScope(extend[store.SecretScopeKey]); err != nil {
+		return errors.WithCode(code.ErrValidation, err.Error())
+	}
+
+	return nil
+}
@@ -11,6 +11,7 @@ import (
 	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
 	"github.com/marmotedu/errors"
 
+	"github.com/marmotedu/iam/internal/apiserver/audit"
 	"github.com/marmotedu/iam/internal/pkg/code"
 	"github.com/marmotedu/iam/internal/pkg/middleware"
 	"github.com/marmotedu/iam/pkg/log"
@@ -37,10 +38,12 @@ func (s *SecretController) Update(c *gin.Context) {
 		return
 	}
 
+	old := audit.Summary(secret)
+
 	// only update expires and description
 	secret.Expires = r.Expires
 	secret.Description = r.Description
-	secret.Extend = r.Extend
+	secret.Extend = keepPreviousKey(secret.Extend, r.Extend)
 
 	if errs := secret.Validate(); len(errs) != 0 {
 		core.WriteResponse(c, errors.WithCode(code.ErrValidation, errs.ToAggregate().Error()), nil)
@@ -48,11 +51,19 @@ func (s *SecretController) Update(c *gin.Context) {
 		return
 	}
 
+	if err := validateScope(secret.Extend); err != nil {
+		core.WriteResponse(c, err, nil)
+
+		return
+	}
+
 	if err := s.srv.Secrets().Update(c, secret, metav1.UpdateOptions{}); err != nil {
 		core.WriteResponse(c, err, nil)
 
 		return
 	}
 
+	audit.Record(c, audit.ActionUpdate, audit.ResourceSecret, secret.Name, old, secret)
+
 	core.WriteResponse(c, nil, secret)
 }
@@ -0,0 +1,103 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package user
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	v1 "github.com/marmotedu/api/apiserver/v1"
+	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
+	"github.com/marmotedu/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/marmotedu/iam/internal/apiserver/audit"
+	srvv1 "github.com/marmotedu/iam/internal/apiserver/service/v1"
+	"github.com/marmotedu/iam/internal/pkg/code"
+	"github.com/marmotedu/iam/internal/pkg/middleware"
+)
+
+type fakeRecorder struct {
+	entries []*audit.Entry
+}
+
+func (f *fakeRecorder) Record(_ context.Context, entry *audit.Entry) {
+	f.entries = append(f.entries, entry)
+}
+
+func TestUserController_Audit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	recorder := &fakeRecorder{}
+	previous := audit.SetRecorder(recorder)
+	defer audit.SetRecorder(previous)
+
+	mockService := srvv1.NewMockService(ctrl)
+	mockUserSrv := srvv1.NewMockUserSrv(ctrl)
+	mockUserSrv.EXPECT().Create(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+	// the deleted users are audited as they were before the deletion
+	mockUserSrv.EXPECT().Get(gomock.Any(), gomock.Eq("colin"), gomock.Any()).Return(&v1.User{
+		ObjectMeta: metav1.ObjectMeta{Name: "colin"},
+		Email:      "colin@foxmail.com",
+		Password:   "$2a$10$secret",
+	}, nil).Times(2)
+	mockUserSrv.EXPECT().Get(gomock.Any(), gomock.Eq("kong"), gomock.Any()).
+		Return(nil, errors.WithCode(code.ErrUserNotFound, "record not found"))
+	mockUserSrv.EXPECT().Delete(gomock.Any(), gomock.Eq("colin"), gomock.Any()).Return(nil)
+	mockUserSrv.EXPECT().DeleteCollection(gomock.Any(), gomock.Eq([]string{"colin", "kong"}), gomock.Any()).Return(nil)
+	mockService.EXPECT().Users().Return(mockUserSrv).AnyTimes()
+
+	u := &UserController{srv: mockService}
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	body := bytes.NewBufferString(
+		`{"metadata":{"name":"colin"},"nickname":"colin","email":"colin@foxmail.com","password":"Colin@2021"}`,
+	)
+	c.Request, _ = http.NewRequest("POST", "/v1/users", body)
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Set(middleware.UsernameKey, "admin")
+	u.Create(c)
+
+	c, _ = gin.CreateTestContext(httptest.NewRecorder())
+	c.Request, _ = http.NewRequest("DELETE", "/v1/users/colin", nil)
+	c.Params = []gin.Param{{Key: "name", Value: "colin"}}
+	c.Set(middleware.UsernameKey, "admin")
+	u.Delete(c)
+
+	c, _ = gin.CreateTestContext(httptest.NewRecorder())
+	c.Request, _ = http.NewRequest("DELETE", "/v1/users?name=colin&name=kong", nil)
+	c.Set(middleware.UsernameKey, "admin")
+	u.DeleteCollection(c)
+
+	if assert.Len(t, recorder.entries, 4) {
+		created := recorder.entries[0]
+		assert.Equal(t, "admin", created.Operator)
+		assert.Equal(t, audit.ActionCreate, created.Action)
+		assert.Equal(t, audit.ResourceUser, created.Resource)
+		assert.Equal(t, "colin", created.Name)
+		assert.Equal(t, "colin@foxmail.com", created.New["email"])
+		assert.NotContains(t, created.New, "password")
+
+		deleted := recorder.entries[1]
+		assert.Equal(t, "admin", deleted.Operator)
+		assert.Equal(t, audit.ActionDelete, deleted.Action)
+		assert.Equal(t, "colin", deleted.Name)
+		assert.Equal(t, "colin@foxmail.com", deleted.Old["email"])
+		assert.NotContains(t, deleted.Old, "password")
+		assert.Nil(t, deleted.New)
+
+		assert.Equal(t, "colin", recorder.entries[2].Name)
+		assert.Equal(t, "colin@foxmail.com", recorder.entries[2].Old["email"])
+		// a missing user has no old state
+		assert.Equal(t, "kong", recorder.entries[3].Name)
+		assert.Nil(t, recorder.entries[3].Old)
+	}
+}
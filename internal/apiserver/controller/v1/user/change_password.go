@@ -11,6 +11,7 @@ import (
 	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
 	"github.com/marmotedu/errors"
 
+	"github.com/marmotedu/iam/internal/apiserver/audit"
 	"github.com/marmotedu/iam/internal/pkg/code"
 	"github.com/marmotedu/iam/pkg/log"
 )
@@ -58,5 +59,8 @@ func (u *UserController) ChangePassword(c *gin.Context) {
 		return
 	}
 
+	// the password is not included in the summaries.
+	audit.Record(c, audit.ActionUpdate, audit.ResourceUser, user.Name, nil, nil)
+
 	core.WriteResponse(c, nil, nil)
 }
@@ -14,6 +14,7 @@ import (
 	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
 	"github.com/marmotedu/errors"
 
+	"github.com/marmotedu/iam/internal/apiserver/audit"
 	"github.com/marmotedu/iam/internal/pkg/code"
 	"github.com/marmotedu/iam/pkg/log"
 )
@@ -47,5 +48,7 @@ func (u *UserController) Create(c *gin.Context) {
 		return
 	}
 
+	audit.Record(c, audit.ActionCreate, audit.ResourceUser, r.Name, nil, r)
+
 	core.WriteResponse(c, nil, r)
 }
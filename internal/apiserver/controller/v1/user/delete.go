@@ -8,7 +8,10 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/marmotedu/component-base/pkg/core"
 	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
+	"github.com/marmotedu/errors"
 
+	"github.com/marmotedu/iam/internal/apiserver/audit"
+	"github.com/marmotedu/iam/internal/pkg/code"
 	"github.com/marmotedu/iam/pkg/log"
 )
 
@@ -17,11 +20,21 @@ import (
 func (u *UserController) Delete(c *gin.Context) {
 	log.L(c).Info("delete user function called.")
 
+	// the user is audited as it was before the deletion, deleting a missing user succeeds
+	old, err := u.srv.Users().Get(c, c.Param("name"), metav1.GetOptions{})
+	if err != nil && !errors.IsCode(err, code.ErrUserNotFound) {
+		core.WriteResponse(c, err, nil)
+
+		return
+	}
+
 	if err := u.srv.Users().Delete(c, c.Param("name"), metav1.DeleteOptions{Unscoped: true}); err != nil {
 		core.WriteResponse(c, err, nil)
 
 		return
 	}
 
+	audit.Record(c, audit.ActionDelete, audit.ResourceUser, c.Param("name"), old, nil)
+
 	core.WriteResponse(c, nil, nil)
 }
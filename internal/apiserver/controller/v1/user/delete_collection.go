@@ -6,9 +6,13 @@ package user
 
 import (
 	"github.com/gin-gonic/gin"
+	v1 "github.com/marmotedu/api/apiserver/v1"
 	"github.com/marmotedu/component-base/pkg/core"
 	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
+	"github.com/marmotedu/errors"
 
+	"github.com/marmotedu/iam/internal/apiserver/audit"
+	"github.com/marmotedu/iam/internal/pkg/code"
 	"github.com/marmotedu/iam/pkg/log"
 )
 
@@ -19,11 +23,27 @@ func (u *UserController) DeleteCollection(c *gin.Context) {
 
 	usernames := c.QueryArray("name")
 
+	// the users are audited as they were before the deletion
+	olds := make([]*v1.User, len(usernames))
+	for i, username := range usernames {
+		user, err := u.srv.Users().Get(c, username, metav1.GetOptions{})
+		if err != nil && !errors.IsCode(err, code.ErrUserNotFound) {
+			core.WriteResponse(c, err, nil)
+
+			return
+		}
+		olds[i] = user
+	}
+
 	if err := u.srv.Users().DeleteCollection(c, usernames, metav1.DeleteOptions{}); err != nil {
 		core.WriteResponse(c, err, nil)
 
 		return
 	}
 
+	for i, username := range usernames {
+		audit.Record(c, audit.ActionDelete, audit.ResourceUser, username, olds[i], nil)
+	}
+
 	core.WriteResponse(c, nil, nil)
 }
@@ -11,6 +11,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
+	v1 "github.com/marmotedu/api/apiserver/v1"
 
 	srvv1 "github.com/marmotedu/iam/internal/apiserver/service/v1"
 )
@@ -21,8 +22,10 @@ func TestUserController_DeleteCollection(t *testing.T) {
 
 	mockService := srvv1.NewMockService(ctrl)
 	mockUserSrv := srvv1.NewMockUserSrv(ctrl)
+	mockUserSrv.EXPECT().Get(gomock.Any(), gomock.Eq("colin"), gomock.Any()).Return(&v1.User{}, nil)
+	mockUserSrv.EXPECT().Get(gomock.Any(), gomock.Eq("john"), gomock.Any()).Return(&v1.User{}, nil)
 	mockUserSrv.EXPECT().DeleteCollection(gomock.Any(), gomock.Eq([]string{"colin", "john"}), gomock.Any()).Return(nil)
-	mockService.EXPECT().Users().Return(mockUserSrv)
+	mockService.EXPECT().Users().Return(mockUserSrv).Times(3)
 
 	c, _ := gin.CreateTestContext(httptest.NewRecorder())
 	c.Request, _ = http.NewRequest("DELETE", "/v1/users?name=colin&name=john", nil)
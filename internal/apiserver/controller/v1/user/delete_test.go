@@ -11,6 +11,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
+	v1 "github.com/marmotedu/api/apiserver/v1"
 
 	srvv1 "github.com/marmotedu/iam/internal/apiserver/service/v1"
 )
@@ -21,8 +22,9 @@ func TestUserController_Delete(t *testing.T) {
 
 	mockService := srvv1.NewMockService(ctrl)
 	mockUserSrv := srvv1.NewMockUserSrv(ctrl)
+	mockUserSrv.EXPECT().Get(gomock.Any(), gomock.Eq("admin"), gomock.Any()).Return(&v1.User{}, nil)
 	mockUserSrv.EXPECT().Delete(gomock.Any(), gomock.Eq("admin"), gomock.Any()).Return(nil)
-	mockService.EXPECT().Users().Return(mockUserSrv)
+	mockService.EXPECT().Users().Return(mockUserSrv).Times(2)
 
 	c, _ := gin.CreateTestContext(httptest.NewRecorder())
 	c.Request, _ = http.NewRequest("DELETE", "/v1/users/admin", nil)
@@ -9,10 +9,14 @@ import (
 	"github.com/marmotedu/component-base/pkg/core"
 	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
 
+	"github.com/marmotedu/iam/internal/apiserver/store"
+	"github.com/marmotedu/iam/internal/pkg/middleware"
+	"github.com/marmotedu/iam/internal/pkg/util/etagutil"
 	"github.com/marmotedu/iam/pkg/log"
 )
 
 // Get get an user by the user identifier.
+// The login count is only returned to administrators.
 func (u *UserController) Get(c *gin.Context) {
 	log.L(c).Info("get user function called.")
 
@@ -23,5 +27,10 @@ func (u *UserController) Get(c *gin.Context) {
 		return
 	}
 
-	core.WriteResponse(c, nil, user)
+	// login metadata is only available to administrators.
+	if !c.GetBool(middleware.IsAdminKey) {
+		store.SetLoginCount(user, 0)
+	}
+
+	etagutil.WriteResourceResponse(c, user)
 }
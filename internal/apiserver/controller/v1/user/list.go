@@ -6,15 +6,19 @@ package user
 
 import (
 	"github.com/gin-gonic/gin"
+	v1 "github.com/marmotedu/api/apiserver/v1"
 	"github.com/marmotedu/component-base/pkg/core"
 	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
 	"github.com/marmotedu/errors"
 
 	"github.com/marmotedu/iam/internal/pkg/code"
+	"github.com/marmotedu/iam/internal/pkg/util/gormutil"
+	"github.com/marmotedu/iam/internal/pkg/util/listutil"
 	"github.com/marmotedu/iam/pkg/log"
 )
 
-// List list the users in the storage.
+// List list the users in the storage. Users are searched by the name substring
+// given in the `q` query parameter or the `name` field selector.
 // Only administrator can call this function.
 func (u *UserController) List(c *gin.Context) {
 	log.L(c).Info("list user function called.")
@@ -25,6 +29,10 @@ func (u *UserController) List(c *gin.Context) {
 
 		return
 	}
+	if q := c.Query(gormutil.SearchQuery); q != "" {
+		r.FieldSelector = gormutil.WithField(r.FieldSelector, "name", q)
+	}
+	r.FieldSelector = gormutil.WithCursor(r.FieldSelector, c.Query(gormutil.CursorField))
 
 	users, err := u.srv.Users().List(c, r)
 	if err != nil {
@@ -33,5 +41,9 @@ func (u *UserController) List(c *gin.Context) {
 		return
 	}
 
-	core.WriteResponse(c, nil, users)
+	if users == nil {
+		users = &v1.UserList{}
+	}
+
+	listutil.WriteListResponse(c, r, users.TotalCount, users.Items)
 }
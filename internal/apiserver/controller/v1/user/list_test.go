@@ -5,14 +5,22 @@
 package user
 
 import (
+	"context"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
+	v1 "github.com/marmotedu/api/apiserver/v1"
+	"github.com/marmotedu/component-base/pkg/fields"
+	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
+	"github.com/stretchr/testify/assert"
 
 	srvv1 "github.com/marmotedu/iam/internal/apiserver/service/v1"
+	"github.com/marmotedu/iam/internal/pkg/util/gormutil"
+	"github.com/marmotedu/iam/internal/pkg/util/listutil"
 )
 
 func TestUserController_List(t *testing.T) {
@@ -58,3 +66,60 @@ func TestUserController_List(t *testing.T) {
 		})
 	}
 }
+
+func TestUserController_ListResponse(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	users := &v1.UserList{ListMeta: metav1.ListMeta{TotalCount: 3}}
+	for i := 3; i > 1; i-- {
+		users.Items = append(users.Items, &v1.User{ObjectMeta: metav1.ObjectMeta{ID: uint64(i)}})
+	}
+
+	mockService := srvv1.NewMockService(ctrl)
+	mockUserSrv := srvv1.NewMockUserSrv(ctrl)
+	mockUserSrv.EXPECT().List(gomock.Any(), gomock.Any()).Return(users, nil)
+	mockService.EXPECT().Users().Return(mockUserSrv)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/v1/users?offset=0&limit=2", nil)
+
+	u := &UserController{srv: mockService}
+	u.List(c)
+
+	var resp listutil.ListResponse[*v1.User]
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, int64(3), resp.TotalCount)
+	assert.Equal(t, 0, resp.Offset)
+	assert.Equal(t, 2, resp.Limit)
+	assert.Len(t, resp.Items, 2)
+	assert.Equal(t, gormutil.EncodeCursor(2), resp.NextCursor)
+	assert.Equal(t, resp.NextCursor, w.Header().Get(gormutil.NextCursorHeader))
+}
+
+func TestUserController_ListSearch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := srvv1.NewMockService(ctrl)
+	mockUserSrv := srvv1.NewMockUserSrv(ctrl)
+	mockUserSrv.EXPECT().List(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, opts metav1.ListOptions) (*v1.UserList, error) {
+			selector, err := fields.ParseSelector(opts.FieldSelector)
+			assert.Nil(t, err)
+			name, _ := selector.RequiresExactMatch("name")
+			assert.Equal(t, "co,l", name)
+
+			return &v1.UserList{}, nil
+		})
+	mockService.EXPECT().Users().Return(mockUserSrv)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/v1/users?q=co%2Cl", nil)
+
+	u := &UserController{srv: mockService}
+	u.List(c)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
@@ -0,0 +1,38 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package user
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/marmotedu/component-base/pkg/core"
+	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
+
+	"github.com/marmotedu/iam/internal/apiserver/audit"
+	"github.com/marmotedu/iam/pkg/log"
+)
+
+// Restore restores a soft deleted user by the user identifier, the policies of
+// the user are not restored. Restoring a user which is not deleted does nothing.
+// Only administrator can call this function.
+func (u *UserController) Restore(c *gin.Context) {
+	log.L(c).Info("restore user function called.")
+
+	if err := u.srv.Users().Restore(c, c.Param("name")); err != nil {
+		core.WriteResponse(c, err, nil)
+
+		return
+	}
+
+	user, err := u.srv.Users().Get(c, c.Param("name"), metav1.GetOptions{})
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+
+		return
+	}
+
+	audit.Record(c, audit.ActionRestore, audit.ResourceUser, user.Name, nil, user)
+
+	core.WriteResponse(c, nil, user)
+}
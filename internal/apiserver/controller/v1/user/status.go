@@ -0,0 +1,54 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package user
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/marmotedu/component-base/pkg/core"
+	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
+
+	"github.com/marmotedu/iam/internal/apiserver/audit"
+	"github.com/marmotedu/iam/internal/apiserver/store"
+	"github.com/marmotedu/iam/pkg/log"
+)
+
+// Enable allows a disabled user to log in again.
+// Only administrator can call this function.
+func (u *UserController) Enable(c *gin.Context) {
+	log.L(c).Info("enable user function called.")
+
+	u.setStatus(c, store.UserStatusActive)
+}
+
+// Disable forbids a user to log in without deleting it, the secrets of the user
+// are not accepted either until the user is enabled.
+// Only administrator can call this function.
+func (u *UserController) Disable(c *gin.Context) {
+	log.L(c).Info("disable user function called.")
+
+	u.setStatus(c, store.UserStatusDisabled)
+}
+
+func (u *UserController) setStatus(c *gin.Context, status int) {
+	user, err := u.srv.Users().Get(c, c.Param("name"), metav1.GetOptions{})
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+
+		return
+	}
+
+	old := audit.Summary(user)
+
+	user.Status = status
+	if err := u.srv.Users().Update(c, user, metav1.UpdateOptions{}); err != nil {
+		core.WriteResponse(c, err, nil)
+
+		return
+	}
+
+	audit.Record(c, audit.ActionUpdate, audit.ResourceUser, user.Name, old, user)
+
+	core.WriteResponse(c, nil, user)
+}
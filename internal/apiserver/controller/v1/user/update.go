@@ -11,6 +11,8 @@ import (
 	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
 	"github.com/marmotedu/errors"
 
+	"github.com/marmotedu/iam/internal/apiserver/audit"
+	"github.com/marmotedu/iam/internal/apiserver/store"
 	"github.com/marmotedu/iam/internal/pkg/code"
 	"github.com/marmotedu/iam/pkg/log"
 )
@@ -34,10 +36,15 @@ func (u *UserController) Update(c *gin.Context) {
 		return
 	}
 
+	old := audit.Summary(user)
+
 	user.Nickname = r.Nickname
 	user.Email = r.Email
 	user.Phone = r.Phone
+	// login count is maintained by the server, it can't be updated.
+	loginCount := store.LoginCount(user)
 	user.Extend = r.Extend
+	store.SetLoginCount(user, loginCount)
 
 	if errs := user.ValidateUpdate(); len(errs) != 0 {
 		core.WriteResponse(c, errors.WithCode(code.ErrValidation, errs.ToAggregate().Error()), nil)
@@ -52,5 +59,7 @@ func (u *UserController) Update(c *gin.Context) {
 		return
 	}
 
+	audit.Record(c, audit.ActionUpdate, audit.ResourceUser, user.Name, old, user)
+
 	core.WriteResponse(c, nil, user)
 }
@@ -0,0 +1,21 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package apiserver
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/marmotedu/component-base/pkg/core"
+
+	"github.com/marmotedu/iam/pkg/app"
+)
+
+// DebugConfigPath defines the path of the effective configuration of iam-apiserver.
+const DebugConfigPath = "/debug/config"
+
+// debugConfig returns the effective configuration with the secret-looking items redacted,
+// in the same way as `iam-apiserver config view`.
+func debugConfig(c *gin.Context) {
+	core.WriteResponse(c, nil, app.EffectiveConfig(true))
+}
@@ -0,0 +1,40 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package apiserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/marmotedu/component-base/pkg/json"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDebugConfig(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	viper.Set("server.mode", "debug")
+	viper.Set("mysql.password", "iam59!z$")
+	viper.Set("jwt.key", "dfVpOK8LZeJLZHYmHdb1VdyRrACKpqoo")
+	t.Cleanup(viper.Reset)
+
+	r := gin.New()
+	r.GET(DebugConfigPath, debugConfig)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, DebugConfigPath, nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "iam59!z$")
+	assert.NotContains(t, w.Body.String(), "dfVpOK8LZeJLZHYmHdb1VdyRrACKpqoo")
+
+	var config map[string]interface{}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &config))
+	assert.Equal(t, "debug", config["server.mode"])
+	assert.Equal(t, "******", config["mysql.password"])
+	assert.Equal(t, "******", config["jwt.key"])
+}
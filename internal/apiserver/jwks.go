@@ -0,0 +1,36 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package apiserver
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+
+	"github.com/marmotedu/iam/internal/pkg/util/jwksutil"
+)
+
+// JWKSPath defines the path publishing the public keys which verify jwt tokens.
+const JWKSPath = "/.well-known/jwks.json"
+
+// newJWKSHandler returns the handler publishing the public key of the signing key, followed
+// by the additional public keys, so third parties can verify jwt tokens during key rotations.
+func newJWKSHandler() (gin.HandlerFunc, error) {
+	files := append([]string{viper.GetString("jwt.public-key-file")},
+		viper.GetStringSlice("jwt.additional-public-key-files")...)
+
+	pubs, err := jwksutil.LoadPublicKeys(files...)
+	if err != nil {
+		return nil, err
+	}
+
+	set := jwksutil.NewSet(signingAlgorithm(), pubs...)
+
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(http.StatusOK, set)
+	}, nil
+}
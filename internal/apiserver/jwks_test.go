@@ -0,0 +1,81 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package apiserver
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/marmotedu/component-base/pkg/json"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/marmotedu/iam/internal/pkg/util/jwksutil"
+)
+
+// writePublicKey generates a RSA key and writes its public key to a PEM file.
+func writePublicKey(t *testing.T) (*rsa.PublicKey, string) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	assert.Nil(t, err)
+
+	file := filepath.Join(t.TempDir(), "public.pem")
+	assert.Nil(t, os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o600))
+
+	return &key.PublicKey, file
+}
+
+func TestJWKSHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	active, activeFile := writePublicKey(t)
+	rotating, rotatingFile := writePublicKey(t)
+
+	viper.Set("jwt.signing-algorithm", "RS256")
+	viper.Set("jwt.public-key-file", activeFile)
+	viper.Set("jwt.additional-public-key-files", []string{rotatingFile})
+	t.Cleanup(viper.Reset)
+
+	handler, err := newJWKSHandler()
+	assert.Nil(t, err)
+
+	r := gin.New()
+	r.GET(JWKSPath, handler)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, JWKSPath, nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var set jwksutil.Set
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &set))
+
+	if assert.Len(t, set.Keys, 2) {
+		assert.Equal(t, jwksutil.KeyID(active), set.Keys[0].Kid)
+		assert.Equal(t, jwksutil.KeyID(rotating), set.Keys[1].Kid)
+		assert.Equal(t, "RS256", set.Keys[0].Alg)
+		assert.Equal(t, "RSA", set.Keys[0].Kty)
+	}
+}
+
+func TestJWKSHandler_MissingKey(t *testing.T) {
+	viper.Set("jwt.signing-algorithm", "RS256")
+	viper.Set("jwt.public-key-file", filepath.Join(t.TempDir(), "missing.pem"))
+	t.Cleanup(viper.Reset)
+
+	_, err := newJWKSHandler()
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,52 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package apiserver
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OpenIDConfigurationPath defines the path of the OpenID Connect discovery document.
+const OpenIDConfigurationPath = "/.well-known/openid-configuration"
+
+// openIDConfiguration is the OpenID Connect discovery document, see
+// https://openid.net/specs/openid-connect-discovery-1_0.html#ProviderMetadata.
+// Only the issuer and the public keys are published, the tokens are obtained by
+// the /login endpoint, which is not an OAuth 2.0 endpoint.
+type openIDConfiguration struct {
+	Issuer  string `json:"issuer"`
+	JWKSURI string `json:"jwks_uri"`
+}
+
+// isIssuerURL returns true if the issuer can identify an OpenID provider, which must
+// be a http or https URL without query and fragment.
+func isIssuerURL(iss string) bool {
+	u, err := url.Parse(iss)
+	if err != nil {
+		return false
+	}
+
+	return (u.Scheme == "https" || u.Scheme == "http") && u.Host != "" && u.RawQuery == "" && u.Fragment == ""
+}
+
+// newOpenIDConfigurationHandler returns the handler serving the discovery document, whose
+// jwks_uri is derived from the issuer.
+func newOpenIDConfigurationHandler() gin.HandlerFunc {
+	iss := issuer()
+
+	config := openIDConfiguration{
+		Issuer:  iss,
+		JWKSURI: strings.TrimSuffix(iss, "/") + JWKSPath,
+	}
+
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(http.StatusOK, config)
+	}
+}
@@ -0,0 +1,47 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package apiserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/marmotedu/component-base/pkg/json"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenIDConfigurationHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	viper.Set("jwt.signing-algorithm", "RS256")
+	viper.Set("jwt.issuer", "https://iam.api.marmotedu.com")
+	t.Cleanup(viper.Reset)
+
+	r := gin.New()
+	r.GET(OpenIDConfigurationPath, newOpenIDConfigurationHandler())
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, OpenIDConfigurationPath, nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var config map[string]interface{}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &config))
+
+	// /login is not an OAuth 2.0 endpoint, so no endpoint is published
+	assert.Equal(t, map[string]interface{}{
+		"issuer":   "https://iam.api.marmotedu.com",
+		"jwks_uri": "https://iam.api.marmotedu.com" + JWKSPath,
+	}, config)
+}
+
+func TestIsIssuerURL(t *testing.T) {
+	assert.True(t, isIssuerURL("https://iam.api.marmotedu.com"))
+	assert.True(t, isIssuerURL("http://127.0.0.1:8080/iam"))
+	assert.False(t, isIssuerURL(APIServerIssuer))
+	assert.False(t, isIssuerURL("https://iam.api.marmotedu.com?tenant=a"))
+}
@@ -4,6 +4,8 @@
 
 package options
 
+import "fmt"
+
 // Validate checks Options and return a slice of found errs.
 func (o *Options) Validate() []error {
 	var errs []error
@@ -18,5 +20,12 @@ func (o *Options) Validate() []error {
 	errs = append(errs, o.Log.Validate()...)
 	errs = append(errs, o.FeatureOptions.Validate()...)
 
+	if o.GRPCOptions.UseTLS {
+		if !o.SecureServing.ServerCert.CertKey.IsSet() {
+			errs = append(errs, fmt.Errorf("--secure.tls.cert-key.cert-file and "+
+				"--secure.tls.cert-key.private-key-file are required when --grpc.use-tls is true"))
+		}
+	}
+
 	return errs
 }
@@ -8,6 +8,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/marmotedu/component-base/pkg/core"
 	"github.com/marmotedu/errors"
+	"github.com/spf13/viper"
 
 	"github.com/marmotedu/iam/internal/apiserver/controller/v1/policy"
 	"github.com/marmotedu/iam/internal/apiserver/controller/v1/secret"
@@ -16,6 +17,10 @@ import (
 	"github.com/marmotedu/iam/internal/pkg/code"
 	"github.com/marmotedu/iam/internal/pkg/middleware"
 	"github.com/marmotedu/iam/internal/pkg/middleware/auth"
+	"github.com/marmotedu/iam/internal/pkg/pubsub"
+	"github.com/marmotedu/iam/internal/pkg/util/jwksutil"
+	"github.com/marmotedu/iam/pkg/log"
+	"github.com/marmotedu/iam/pkg/storage"
 
 	// custom gin validators.
 	_ "github.com/marmotedu/iam/pkg/validator"
@@ -32,18 +37,45 @@ func installMiddleware(g *gin.Engine) {
 func installController(g *gin.Engine) *gin.Engine {
 	// Middlewares.
 	jwtStrategy, _ := newJWTAuth().(auth.JWTStrategy)
-	g.POST("/login", jwtStrategy.LoginHandler)
+	jwtSigner, err := newSigner()
+	if err != nil {
+		log.Panicf("load jwt signing key failed: %s", err.Error())
+	}
+
+	g.POST("/login", loginHandler(jwtStrategy, jwtSigner))
 	g.POST("/logout", jwtStrategy.LogoutHandler)
 	// Refresh time can be longer than token timeout
-	g.POST("/refresh", jwtStrategy.RefreshHandler)
+	g.POST("/refresh", refreshHandler(jwtStrategy, jwtSigner))
+
+	// public keys are only published when jwt token is signed asymmetrically
+	if jwksutil.IsAsymmetric(signingAlgorithm()) {
+		jwksHandler, err := newJWKSHandler()
+		if err != nil {
+			log.Panicf("load jwks failed: %s", err.Error())
+		}
+
+		g.GET(JWKSPath, jwksHandler)
+
+		// the discovery document requires the issuer to be the URL of the server
+		if isIssuerURL(issuer()) {
+			g.GET(OpenIDConfigurationPath, newOpenIDConfigurationHandler())
+		}
+	}
 
 	auto := newAutoAuth()
 	g.NoRoute(auto.AuthFunc(), func(c *gin.Context) {
 		core.WriteResponse(c, errors.WithCode(code.ErrPageNotFound, "Page not found."), nil)
 	})
 
+	if viper.GetBool("feature.enable-debug-config") {
+		g.GET(DebugConfigPath, auto.AuthFunc(), requireActiveUser(), middleware.Validation(), debugConfig) // admin api
+	}
+
 	// v1 handlers, requiring authentication
 	storeIns, _ := mysql.GetMySQLFactoryOr(nil)
+	// the retried creations return the resources created by the original requests, without the credentials
+	idempotencyStore := &storage.RedisCluster{}
+	idempotent := middleware.Idempotency(idempotencyStore, middleware.DefaultIdempotencyTTL)
 	v1 := g.Group("/v1")
 	{
 		// user RESTful resource
@@ -51,30 +83,41 @@ func installController(g *gin.Engine) *gin.Engine {
 		{
 			userController := user.NewUserController(storeIns)
 
-			userv1.POST("", userController.Create)
-			userv1.Use(auto.AuthFunc(), middleware.Validation())
+			userv1.POST("", middleware.Idempotency(idempotencyStore, middleware.DefaultIdempotencyTTL, "password"),
+				userController.Create)
+			userv1.Use(auto.AuthFunc(), middleware.Tenant(identifyTenant), middleware.Validation())
 			// v1.PUT("/find_password", userController.FindPassword)
 			userv1.DELETE("", userController.DeleteCollection) // admin api
 			userv1.DELETE(":name", userController.Delete)      // admin api
 			userv1.PUT(":name/change-password", userController.ChangePassword)
+			// the secrets of disabled users are rejected by iam-authz-server, so it needs to reload them
+			userv1.PUT(":name/enable", middleware.PublishCommand(pubsub.NoticeSecretChanged), userController.Enable)    // admin api
+			userv1.PUT(":name/disable", middleware.PublishCommand(pubsub.NoticeSecretChanged), userController.Disable)  // admin api
+			userv1.POST(":name/restore", middleware.PublishCommand(pubsub.NoticeSecretChanged), userController.Restore) // admin api
 			userv1.PUT(":name", userController.Update)
 			userv1.GET("", userController.List)
 			userv1.GET(":name", userController.Get) // admin api
 		}
 
-		v1.Use(auto.AuthFunc())
+		// the resources are scoped by the tenant of the authenticated user
+		v1.Use(auto.AuthFunc(), middleware.Tenant(identifyTenant))
 
 		// policy RESTful resource
 		policyv1 := v1.Group("/policies", middleware.Publish())
 		{
 			policyController := policy.NewPolicyController(storeIns)
 
-			policyv1.POST("", policyController.Create)
+			policyv1.POST("", idempotent, policyController.Create)
 			policyv1.DELETE("", policyController.DeleteCollection)
 			policyv1.DELETE(":name", policyController.Delete)
 			policyv1.PUT(":name", policyController.Update)
 			policyv1.GET("", policyController.List)
 			policyv1.GET(":name", policyController.Get)
+			policyv1.GET(":name/audits", middleware.Validation(), policyController.ListAudits) // admin api
+			policyv1.POST(":name/restore", middleware.Validation(), policyController.Restore)  // admin api
+
+			// simulation doesn't change any policy, so there is nothing to publish
+			v1.POST("/policies/simulate", policyController.Simulate)
 		}
 
 		// secret RESTful resource
@@ -82,9 +125,11 @@ func installController(g *gin.Engine) *gin.Engine {
 		{
 			secretController := secret.NewSecretController(storeIns)
 
-			secretv1.POST("", secretController.Create)
+			secretv1.POST("", middleware.Idempotency(idempotencyStore, middleware.DefaultIdempotencyTTL, "secretKey"),
+				secretController.Create)
 			secretv1.DELETE(":name", secretController.Delete)
 			secretv1.PUT(":name", secretController.Update)
+			secretv1.POST(":name/rotate", secretController.Rotate)
 			secretv1.GET("", secretController.List)
 			secretv1.GET(":name", secretController.Get)
 		}
@@ -11,6 +11,7 @@ import (
 	pb "github.com/marmotedu/api/proto/apiserver/v1"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
 	"google.golang.org/grpc/reflection"
 
 	"github.com/marmotedu/iam/internal/apiserver/config"
@@ -40,8 +41,14 @@ type preparedAPIServer struct {
 type ExtraConfig struct {
 	Addr         string
 	MaxMsgSize   int
+	UseTLS       bool
 	ServerCert   genericoptions.GeneratableKeyCert
 	mysqlOptions *genericoptions.MySQLOptions
+
+	// Keepalive and KeepalivePolicy are used to detect dead peers and let long-lived
+	// connections rebalance behind load balancers.
+	Keepalive       keepalive.ServerParameters
+	KeepalivePolicy keepalive.EnforcementPolicy
 	// etcdOptions      *genericoptions.EtcdOptions
 }
 
@@ -68,6 +75,8 @@ func createAPIServer(cfg *config.Config) (*apiServer, error) {
 		return nil, err
 	}
 
+	genericServer.AddEndpoint("grpc", extraConfig.Addr, extraConfig.UseTLS)
+
 	server := &apiServer{
 		gs:               gs,
 		redisOptions:     cfg.RedisOptions,
@@ -84,6 +93,9 @@ func (s *apiServer) PrepareRun() preparedAPIServer {
 	s.initRedisStore()
 
 	s.gs.AddShutdownCallback(shutdown.ShutdownFunc(func(string) error {
+		// stop receiving new requests from load balancers first
+		s.genericAPIServer.Drain()
+
 		mysqlStore, _ := mysql.GetMySQLFactoryOr(nil)
 		if mysqlStore != nil {
 			_ = mysqlStore.Close()
@@ -124,11 +136,10 @@ func (c *ExtraConfig) complete() *completedExtraConfig {
 
 // New create a grpcAPIServer instance.
 func (c *completedExtraConfig) New() (*grpcAPIServer, error) {
-	creds, err := credentials.NewServerTLSFromFile(c.ServerCert.CertKey.CertFile, c.ServerCert.CertKey.KeyFile)
+	opts, err := c.serverOptions()
 	if err != nil {
-		log.Fatalf("Failed to generate credentials %s", err.Error())
+		return nil, err
 	}
-	opts := []grpc.ServerOption{grpc.MaxRecvMsgSize(c.MaxMsgSize), grpc.Creds(creds)}
 	grpcServer := grpc.NewServer(opts...)
 
 	storeIns, _ := mysql.GetMySQLFactoryOr(c.mysqlOptions)
@@ -146,6 +157,29 @@ func (c *completedExtraConfig) New() (*grpcAPIServer, error) {
 	return &grpcAPIServer{grpcServer, c.Addr}, nil
 }
 
+// serverOptions returns the grpc server options, credentials are only used when TLS is enabled.
+func (c *completedExtraConfig) serverOptions() ([]grpc.ServerOption, error) {
+	opts := []grpc.ServerOption{
+		grpc.MaxRecvMsgSize(c.MaxMsgSize),
+		grpc.KeepaliveParams(c.Keepalive),
+		grpc.KeepaliveEnforcementPolicy(c.KeepalivePolicy),
+	}
+
+	if !c.UseTLS {
+		log.Warn("grpc server is serving without TLS")
+
+		return opts, nil
+	}
+
+	tlsConfig, err := c.ServerCert.CertKey.ServerCertKey().TLSConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate credentials: %w", err)
+	}
+	creds := credentials.NewTLS(tlsConfig)
+
+	return append(opts, grpc.Creds(creds)), nil
+}
+
 func buildGenericConfig(cfg *config.Config) (genericConfig *genericapiserver.Config, lastErr error) {
 	genericConfig = genericapiserver.NewConfig()
 	if lastErr = cfg.GenericServerRunOptions.ApplyTo(genericConfig); lastErr != nil {
@@ -170,8 +204,19 @@ func buildGenericConfig(cfg *config.Config) (genericConfig *genericapiserver.Con
 //nolint: unparam
 func buildExtraConfig(cfg *config.Config) (*ExtraConfig, error) {
 	return &ExtraConfig{
-		Addr:         fmt.Sprintf("%s:%d", cfg.GRPCOptions.BindAddress, cfg.GRPCOptions.BindPort),
-		MaxMsgSize:   cfg.GRPCOptions.MaxMsgSize,
+		Addr:       fmt.Sprintf("%s:%d", cfg.GRPCOptions.BindAddress, cfg.GRPCOptions.BindPort),
+		MaxMsgSize: cfg.GRPCOptions.MaxMsgSize,
+		UseTLS:     cfg.GRPCOptions.UseTLS,
+		Keepalive: keepalive.ServerParameters{
+			MaxConnectionAge:      cfg.GRPCOptions.MaxConnectionAge,
+			MaxConnectionAgeGrace: cfg.GRPCOptions.MaxConnectionAgeGrace,
+			Time:                  cfg.GRPCOptions.KeepaliveTime,
+			Timeout:               cfg.GRPCOptions.KeepaliveTimeout,
+		},
+		KeepalivePolicy: keepalive.EnforcementPolicy{
+			MinTime:             cfg.GRPCOptions.KeepaliveMinTime,
+			PermitWithoutStream: cfg.GRPCOptions.PermitWithoutStream,
+		},
 		ServerCert:   cfg.SecureServing.ServerCert,
 		mysqlOptions: cfg.MySQLOptions,
 		// etcdOptions:      cfg.EtcdOptions,
@@ -200,6 +245,8 @@ func (s *apiServer) initRedisStore() {
 		EnableCluster:         s.redisOptions.EnableCluster,
 		UseSSL:                s.redisOptions.UseSSL,
 		SSLInsecureSkipVerify: s.redisOptions.SSLInsecureSkipVerify,
+		Compression:           s.redisOptions.Compression,
+		TTLJitter:             s.redisOptions.TTLJitter,
 	}
 
 	// try to connect to redis
@@ -0,0 +1,105 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package apiserver
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+
+	"github.com/marmotedu/iam/internal/apiserver/config"
+	"github.com/marmotedu/iam/internal/apiserver/options"
+	genericoptions "github.com/marmotedu/iam/internal/pkg/options"
+)
+
+func newTestExtraConfig(useTLS bool, certFile, keyFile string) *completedExtraConfig {
+	return (&ExtraConfig{
+		MaxMsgSize: 4 * 1024 * 1024,
+		UseTLS:     useTLS,
+		ServerCert: genericoptions.GeneratableKeyCert{
+			CertKey: genericoptions.CertKey{CertFile: certFile, KeyFile: keyFile},
+		},
+	}).complete()
+}
+
+func TestServerOptions(t *testing.T) {
+	tests := []struct {
+		name     string
+		useTLS   bool
+		certFile string
+		keyFile  string
+		wantLen  int
+		wantErr  bool
+	}{
+		{name: "plaintext", useTLS: false, wantLen: 3},
+		{
+			name:     "tls",
+			useTLS:   true,
+			certFile: "../../configs/cert/iam.pem",
+			keyFile:  "../../configs/cert/iam-key.pem",
+			wantLen:  4,
+		},
+		{name: "tls without cert", useTLS: true, certFile: "not-exist.pem", keyFile: "not-exist-key.pem", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := newTestExtraConfig(tt.useTLS, tt.certFile, tt.keyFile).serverOptions()
+			if tt.wantErr {
+				assert.NotNil(t, err)
+
+				return
+			}
+
+			assert.Nil(t, err)
+			assert.Len(t, opts, tt.wantLen)
+
+			server := grpc.NewServer(opts...)
+			server.Stop()
+		})
+	}
+}
+
+func TestServerOptions_PEMData(t *testing.T) {
+	certPEM, err := os.ReadFile("../../configs/cert/iam.pem")
+	assert.Nil(t, err)
+	keyPEM, err := os.ReadFile("../../configs/cert/iam-key.pem")
+	assert.Nil(t, err)
+
+	c := newTestExtraConfig(true, "", "")
+	c.ServerCert.CertKey.CertData = string(certPEM)
+	c.ServerCert.CertKey.KeyData = string(keyPEM)
+
+	opts, err := c.serverOptions()
+	assert.Nil(t, err)
+	assert.Len(t, opts, 4)
+
+	server := grpc.NewServer(opts...)
+	server.Stop()
+}
+
+func TestBuildExtraConfig_Keepalive(t *testing.T) {
+	opts := options.NewOptions()
+	opts.GRPCOptions.MaxConnectionAge = 5 * time.Minute
+	opts.GRPCOptions.MaxConnectionAgeGrace = 5 * time.Second
+	opts.GRPCOptions.KeepaliveTime = 30 * time.Second
+	opts.GRPCOptions.KeepaliveTimeout = 10 * time.Second
+	opts.GRPCOptions.KeepaliveMinTime = 15 * time.Second
+	opts.GRPCOptions.PermitWithoutStream = false
+
+	cfg, _ := config.CreateConfigFromOptions(opts)
+	extraConfig, err := buildExtraConfig(cfg)
+	assert.Nil(t, err)
+
+	assert.Equal(t, 5*time.Minute, extraConfig.Keepalive.MaxConnectionAge)
+	assert.Equal(t, 5*time.Second, extraConfig.Keepalive.MaxConnectionAgeGrace)
+	assert.Equal(t, 30*time.Second, extraConfig.Keepalive.Time)
+	assert.Equal(t, 10*time.Second, extraConfig.Keepalive.Timeout)
+	assert.Equal(t, 15*time.Second, extraConfig.KeepalivePolicy.MinTime)
+	assert.False(t, extraConfig.KeepalivePolicy.PermitWithoutStream)
+}
@@ -15,6 +15,7 @@ import (
 	gomock "github.com/golang/mock/gomock"
 	v1 "github.com/marmotedu/api/apiserver/v1"
 	v10 "github.com/marmotedu/component-base/pkg/meta/v1"
+	store "github.com/marmotedu/iam/internal/apiserver/store"
 )
 
 // MockService is a mock of Service interface.
@@ -206,6 +207,20 @@ func (mr *MockUserSrvMockRecorder) ListWithBadPerformance(arg0, arg1 interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWithBadPerformance", reflect.TypeOf((*MockUserSrv)(nil).ListWithBadPerformance), arg0, arg1)
 }
 
+// Restore mocks base method.
+func (m *MockUserSrv) Restore(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restore", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Restore indicates an expected call of Restore.
+func (mr *MockUserSrvMockRecorder) Restore(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockUserSrv)(nil).Restore), arg0, arg1)
+}
+
 // Update mocks base method.
 func (m *MockUserSrv) Update(arg0 context.Context, arg1 *v1.User, arg2 v10.UpdateOptions) error {
 	m.ctrl.T.Helper()
@@ -424,6 +439,35 @@ func (mr *MockPolicySrvMockRecorder) List(arg0, arg1, arg2 interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockPolicySrv)(nil).List), arg0, arg1, arg2)
 }
 
+// ListAudits mocks base method.
+func (m *MockPolicySrv) ListAudits(arg0 context.Context, arg1 string, arg2 v10.ListOptions) (*store.PolicyAuditList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAudits", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*store.PolicyAuditList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAudits indicates an expected call of ListAudits.
+func (mr *MockPolicySrvMockRecorder) ListAudits(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAudits", reflect.TypeOf((*MockPolicySrv)(nil).ListAudits), arg0, arg1, arg2)
+}
+
+// Restore mocks base method.
+func (m *MockPolicySrv) Restore(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restore", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Restore indicates an expected call of Restore.
+func (mr *MockPolicySrvMockRecorder) Restore(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockPolicySrv)(nil).Restore), arg0, arg1, arg2)
+}
+
 // Update mocks base method.
 func (m *MockPolicySrv) Update(arg0 context.Context, arg1 *v1.Policy, arg2 v10.UpdateOptions) error {
 	m.ctrl.T.Helper()
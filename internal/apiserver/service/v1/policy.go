@@ -23,6 +23,8 @@ type PolicySrv interface {
 	DeleteCollection(ctx context.Context, username string, names []string, opts metav1.DeleteOptions) error
 	Get(ctx context.Context, username string, name string, opts metav1.GetOptions) (*v1.Policy, error)
 	List(ctx context.Context, username string, opts metav1.ListOptions) (*v1.PolicyList, error)
+	ListAudits(ctx context.Context, name string, opts metav1.ListOptions) (*store.PolicyAuditList, error)
+	Restore(ctx context.Context, username string, name string) error
 }
 
 type policyService struct {
@@ -90,3 +92,20 @@ func (s *policyService) List(ctx context.Context, username string, opts metav1.L
 
 	return policies, nil
 }
+
+func (s *policyService) ListAudits(
+	ctx context.Context,
+	name string,
+	opts metav1.ListOptions,
+) (*store.PolicyAuditList, error) {
+	audits, err := s.store.PolicyAudits().List(ctx, name, opts)
+	if err != nil {
+		return nil, errors.WithCode(code.ErrDatabase, err.Error())
+	}
+
+	return audits, nil
+}
+
+func (s *policyService) Restore(ctx context.Context, username, name string) error {
+	return s.store.Policies().Restore(ctx, username, name, metav1.CreateOptions{})
+}
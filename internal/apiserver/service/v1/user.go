@@ -28,6 +28,7 @@ type UserSrv interface {
 	List(ctx context.Context, opts metav1.ListOptions) (*v1.UserList, error)
 	ListWithBadPerformance(ctx context.Context, opts metav1.ListOptions) (*v1.UserList, error)
 	ChangePassword(ctx context.Context, user *v1.User) error
+	Restore(ctx context.Context, username string) error
 }
 
 type userService struct {
@@ -143,6 +144,10 @@ func (u *userService) ListWithBadPerformance(ctx context.Context, opts metav1.Li
 
 func (u *userService) Create(ctx context.Context, user *v1.User, opts metav1.CreateOptions) error {
 	if err := u.store.Users().Create(ctx, user, opts); err != nil {
+		if errors.IsCode(err, code.ErrUserAlreadyExist) {
+			return err
+		}
+
 		if match, _ := regexp.MatchString("Duplicate entry '.*' for key 'idx_name'", err.Error()); match {
 			return errors.WithCode(code.ErrUserAlreadyExist, err.Error())
 		}
@@ -194,3 +199,7 @@ func (u *userService) ChangePassword(ctx context.Context, user *v1.User) error {
 
 	return nil
 }
+
+func (u *userService) Restore(ctx context.Context, username string) error {
+	return u.store.Users().Restore(ctx, username, metav1.UpdateOptions{})
+}
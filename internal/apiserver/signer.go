@@ -0,0 +1,144 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package apiserver
+
+import (
+	"crypto/rsa"
+	"net/http"
+	"os"
+	"time"
+
+	ginjwt "github.com/appleboy/gin-jwt/v2"
+	"github.com/gin-gonic/gin"
+	jwt "github.com/golang-jwt/jwt/v4"
+	"github.com/marmotedu/errors"
+	"github.com/spf13/viper"
+
+	"github.com/marmotedu/iam/internal/pkg/middleware/auth"
+	"github.com/marmotedu/iam/internal/pkg/util/jwksutil"
+)
+
+// signer signs the jwt tokens with the kid header naming the public key published at
+// JWKSPath, so third parties pick the right key during key rotations. gin-jwt signs
+// the tokens without it.
+type signer struct {
+	alg string
+	kid string
+	key *rsa.PrivateKey
+}
+
+// newSigner returns the signer of the private key, nil is returned when the tokens are
+// signed symmetrically, there is no public key to name then.
+func newSigner() (*signer, error) {
+	alg := signingAlgorithm()
+	if !jwksutil.IsAsymmetric(alg) {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(viper.GetString("jwt.private-key-file"))
+	if err != nil {
+		return nil, errors.Wrap(err, "read jwt private key failed")
+	}
+
+	key, err := jwt.ParseRSAPrivateKeyFromPEM(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse jwt private key failed")
+	}
+
+	return &signer{alg: alg, kid: jwksutil.KeyID(&key.PublicKey), key: key}, nil
+}
+
+func (s *signer) sign(claims ginjwt.MapClaims) (string, error) {
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(s.alg), jwt.MapClaims(claims))
+	token.Header["kid"] = s.kid
+
+	return token.SignedString(s.key)
+}
+
+// loginHandler issues the jwt token like LoginHandler of gin-jwt, signed by the signer.
+func loginHandler(strategy auth.JWTStrategy, s *signer) gin.HandlerFunc {
+	if s == nil {
+		return strategy.LoginHandler
+	}
+
+	return func(c *gin.Context) {
+		data, err := strategy.Authenticator(c)
+		if err != nil {
+			strategy.Unauthorized(c, http.StatusUnauthorized, strategy.HTTPStatusMessageFunc(err, c))
+
+			return
+		}
+
+		claims := ginjwt.MapClaims{}
+		for key, value := range strategy.PayloadFunc(data) {
+			claims[key] = value
+		}
+
+		token, expire, err := issueToken(c, strategy, s, claims)
+		if err != nil {
+			strategy.Unauthorized(c, http.StatusUnauthorized,
+				strategy.HTTPStatusMessageFunc(ginjwt.ErrFailedTokenCreation, c))
+
+			return
+		}
+
+		strategy.LoginResponse(c, http.StatusOK, token, expire)
+	}
+}
+
+// refreshToken refreshes the jwt token like RefreshHandler of gin-jwt, signed by the signer.
+func refreshToken(c *gin.Context, strategy auth.JWTStrategy, s *signer) {
+	if s == nil {
+		strategy.RefreshHandler(c)
+
+		return
+	}
+
+	claims, err := strategy.CheckIfTokenExpire(c)
+	if err != nil {
+		strategy.Unauthorized(c, http.StatusUnauthorized, strategy.HTTPStatusMessageFunc(err, c))
+
+		return
+	}
+
+	token, expire, err := issueToken(c, strategy, s, ginjwt.MapClaims(claims))
+	if err != nil {
+		strategy.Unauthorized(c, http.StatusUnauthorized, strategy.HTTPStatusMessageFunc(err, c))
+
+		return
+	}
+
+	strategy.RefreshResponse(c, http.StatusOK, token, expire)
+}
+
+// issueToken signs the claims with a new expiration time and sets the token cookie.
+func issueToken(
+	c *gin.Context,
+	strategy auth.JWTStrategy,
+	s *signer,
+	claims ginjwt.MapClaims,
+) (string, time.Time, error) {
+	expire := strategy.TimeFunc().Add(strategy.Timeout)
+	claims["exp"] = expire.Unix()
+	claims["orig_iat"] = strategy.TimeFunc().Unix()
+
+	token, err := s.sign(claims)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	if strategy.SendCookie {
+		maxAge := int(strategy.CookieMaxAge / time.Second)
+
+		if strategy.CookieSameSite != 0 {
+			c.SetSameSite(strategy.CookieSameSite)
+		}
+
+		c.SetCookie(strategy.CookieName, token, maxAge, "/", strategy.CookieDomain,
+			strategy.SecureCookie, strategy.CookieHTTPOnly)
+	}
+
+	return token, expire, nil
+}
@@ -0,0 +1,123 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package apiserver
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	jwt "github.com/golang-jwt/jwt/v4"
+	"github.com/marmotedu/component-base/pkg/json"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/marmotedu/iam/internal/apiserver/store"
+	authstrategy "github.com/marmotedu/iam/internal/pkg/middleware/auth"
+	"github.com/marmotedu/iam/internal/pkg/util/jwksutil"
+)
+
+// writeKeyPair generates a RSA key and writes it and its public key to PEM files.
+func writeKeyPair(t *testing.T) (*rsa.PrivateKey, string, string) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	assert.Nil(t, err)
+
+	dir := t.TempDir()
+	privateFile := filepath.Join(dir, "private.pem")
+	publicFile := filepath.Join(dir, "public.pem")
+	assert.Nil(t, os.WriteFile(privateFile, pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(key),
+	}), 0o600))
+	assert.Nil(t, os.WriteFile(publicFile, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o600))
+
+	return key, privateFile, publicFile
+}
+
+func TestSigner_KeyID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	key, privateFile, publicFile := writeKeyPair(t)
+	viper.Set("jwt.signing-algorithm", "RS256")
+	viper.Set("jwt.private-key-file", privateFile)
+	viper.Set("jwt.public-key-file", publicFile)
+	viper.Set("jwt.timeout", "1h")
+	viper.Set("jwt.max-refresh", "1h")
+	t.Cleanup(viper.Reset)
+
+	setUserStore(t, store.UserStatusActive)
+
+	jwtStrategy, _ := newJWTAuth().(authstrategy.JWTStrategy)
+	jwtSigner, err := newSigner()
+	assert.Nil(t, err)
+
+	r := gin.New()
+	r.POST("/login", loginHandler(jwtStrategy, jwtSigner))
+	r.POST("/refresh", refreshHandler(jwtStrategy, jwtSigner))
+	r.GET("/", jwtStrategy.AuthFunc(), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	// tokenOf returns the token of the response after checking its kid header
+	tokenOf := func(w *httptest.ResponseRecorder) string {
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var resp struct {
+			Token string `json:"token"`
+		}
+		assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &resp))
+
+		token, _, err := new(jwt.Parser).ParseUnverified(resp.Token, jwt.MapClaims{})
+		assert.Nil(t, err)
+		assert.Equal(t, jwksutil.KeyID(&key.PublicKey), token.Header["kid"])
+		assert.Equal(t, "RS256", token.Header["alg"])
+
+		return resp.Token
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/login",
+		bytes.NewBufferString(`{"username":"colin","password":"Colin@2021"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	token := tokenOf(w)
+
+	request := func(method, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		return w
+	}
+
+	// the token is still verified by gin-jwt, and refreshed with the kid header
+	assert.Equal(t, http.StatusOK, request(http.MethodGet, "/").Code)
+	token = tokenOf(request(http.MethodPost, "/refresh"))
+	assert.Equal(t, http.StatusOK, request(http.MethodGet, "/").Code)
+}
+
+func TestSigner_Symmetric(t *testing.T) {
+	viper.Set("jwt.signing-algorithm", "HS256")
+	t.Cleanup(viper.Reset)
+
+	jwtSigner, err := newSigner()
+	assert.Nil(t, err)
+	assert.Nil(t, jwtSigner)
+}
@@ -65,6 +65,12 @@ func (ds *datastore) PolicyAudits() store.PolicyAuditStore {
 	return newPolicyAudits(ds)
 }
 
+// Transaction is not supported by etcd store, because the operations of different resources
+// can not be committed atomically.
+func (ds *datastore) Transaction(ctx context.Context, fn func(txFactory store.Factory) error) error {
+	return errors.New("etcd store does not support transaction")
+}
+
 // Close clsoe the etcdStore clinet.
 func (ds *datastore) Close() error {
 	if ds.cli != nil {
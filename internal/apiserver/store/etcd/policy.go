@@ -111,3 +111,10 @@ func (p *policies) List(ctx context.Context, username string, opts metav1.ListOp
 
 	return ret, nil
 }
+
+// Restore returns an error if the policy doesn't exist, as deleted policies are not kept.
+func (p *policies) Restore(ctx context.Context, username, name string, opts metav1.CreateOptions) error {
+	_, err := p.Get(ctx, username, name, metav1.GetOptions{})
+
+	return err
+}
@@ -7,12 +7,15 @@ package etcd
 import (
 	"context"
 	"fmt"
+	"time"
 
 	v1 "github.com/marmotedu/api/apiserver/v1"
 	"github.com/marmotedu/component-base/pkg/json"
 	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
 	"github.com/marmotedu/component-base/pkg/util/jsonutil"
 	"github.com/marmotedu/errors"
+
+	"github.com/marmotedu/iam/internal/apiserver/store"
 )
 
 type users struct {
@@ -101,3 +104,34 @@ func (u *users) List(ctx context.Context, opts metav1.ListOptions) (*v1.UserList
 
 	return ret, nil
 }
+
+// GetWithTenant returns the user with the default tenant, as the etcd store isn't tenant scoped.
+func (u *users) GetWithTenant(ctx context.Context, username string, opts metav1.GetOptions) (*v1.User, string, error) {
+	user, err := u.Get(ctx, username, opts)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return user, "", nil
+}
+
+// RecordLogin records a successful login of the user. etcd has no partial update, the user is
+// written back as a whole.
+func (u *users) RecordLogin(ctx context.Context, username string, opts metav1.UpdateOptions) error {
+	user, err := u.Get(ctx, username, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	user.LoginedAt = time.Now()
+	store.SetLoginCount(user, store.LoginCount(user)+1)
+
+	return u.Update(ctx, user, opts)
+}
+
+// Restore returns an error if the user doesn't exist, as users are always hard deleted.
+func (u *users) Restore(ctx context.Context, username string, opts metav1.UpdateOptions) error {
+	_, err := u.Get(ctx, username, metav1.GetOptions{})
+
+	return err
+}
@@ -5,6 +5,7 @@
 package fake
 
 import (
+	"context"
 	"fmt"
 	"sync"
 
@@ -42,6 +43,11 @@ func (ds *datastore) PolicyAudits() store.PolicyAuditStore {
 	return newPolicyAudits(ds)
 }
 
+// Transaction runs fn directly, the fake store does not support rollback.
+func (ds *datastore) Transaction(ctx context.Context, fn func(txFactory store.Factory) error) error {
+	return fn(ds)
+}
+
 func (ds *datastore) Close() error {
 	return nil
 }
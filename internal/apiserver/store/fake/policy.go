@@ -191,3 +191,10 @@ func (p *policies) List(ctx context.Context, username string, opts metav1.ListOp
 		Items: policies,
 	}, nil
 }
+
+// Restore returns ErrPolicyNotFound if the policy doesn't exist, as deleted policies are not kept.
+func (p *policies) Restore(ctx context.Context, username, name string, opts metav1.CreateOptions) error {
+	_, err := p.Get(ctx, username, name, metav1.GetOptions{})
+
+	return err
+}
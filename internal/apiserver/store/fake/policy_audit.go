@@ -6,6 +6,10 @@ package fake
 
 import (
 	"context"
+
+	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
+
+	"github.com/marmotedu/iam/internal/apiserver/store"
 )
 
 type policyAudit struct {
@@ -17,6 +21,10 @@ func newPolicyAudits(ds *datastore) *policyAudit {
 }
 
 // ClearOutdated clear data older than a given days.
+func (p *policyAudit) List(ctx context.Context, name string, opts metav1.ListOptions) (*store.PolicyAuditList, error) {
+	return &store.PolicyAuditList{}, nil
+}
+
 func (p *policyAudit) ClearOutdated(ctx context.Context, maxReserveDays int) (int64, error) {
 	return 0, nil
 }
@@ -7,6 +7,7 @@ package fake
 import (
 	"context"
 	"strings"
+	"time"
 
 	v1 "github.com/marmotedu/api/apiserver/v1"
 	"github.com/marmotedu/component-base/pkg/fields"
@@ -14,6 +15,7 @@ import (
 	"github.com/marmotedu/component-base/pkg/util/stringutil"
 	"github.com/marmotedu/errors"
 
+	"github.com/marmotedu/iam/internal/apiserver/store"
 	"github.com/marmotedu/iam/internal/pkg/code"
 	"github.com/marmotedu/iam/internal/pkg/util/gormutil"
 	reflectutil "github.com/marmotedu/iam/internal/pkg/util/reflect"
@@ -153,3 +155,37 @@ func (u *users) List(ctx context.Context, opts metav1.ListOptions) (*v1.UserList
 		Items: users,
 	}, nil
 }
+
+// GetWithTenant returns the user with the default tenant, as the fake store isn't tenant scoped.
+func (u *users) GetWithTenant(ctx context.Context, username string, opts metav1.GetOptions) (*v1.User, string, error) {
+	user, err := u.Get(ctx, username, opts)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return user, "", nil
+}
+
+// RecordLogin records a successful login of the user.
+func (u *users) RecordLogin(ctx context.Context, username string, opts metav1.UpdateOptions) error {
+	u.ds.Lock()
+	defer u.ds.Unlock()
+
+	for _, user := range u.ds.users {
+		if user.Name == username {
+			user.LoginedAt = time.Now()
+			store.SetLoginCount(user, store.LoginCount(user)+1)
+
+			return nil
+		}
+	}
+
+	return errors.WithCode(code.ErrUserNotFound, "record not found")
+}
+
+// Restore returns ErrUserNotFound if the user doesn't exist, as users are always hard deleted.
+func (u *users) Restore(ctx context.Context, username string, opts metav1.UpdateOptions) error {
+	_, err := u.Get(ctx, username, metav1.GetOptions{})
+
+	return err
+}
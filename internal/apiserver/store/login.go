@@ -0,0 +1,41 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package store
+
+import (
+	v1 "github.com/marmotedu/api/apiserver/v1"
+	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
+)
+
+// LoginCountKey is the key in the user's extend fields which stores the number of
+// successful logins.
+const LoginCountKey = "loginCount"
+
+// LoginCount returns the number of successful logins of the user.
+func LoginCount(user *v1.User) int64 {
+	// the value is a float64 once it is decoded from the database
+	switch count := user.Extend[LoginCountKey].(type) {
+	case int64:
+		return count
+	case float64:
+		return int64(count)
+	default:
+		return 0
+	}
+}
+
+// SetLoginCount sets the number of successful logins of the user.
+func SetLoginCount(user *v1.User, count int64) {
+	if count == 0 {
+		delete(user.Extend, LoginCountKey)
+
+		return
+	}
+
+	if user.Extend == nil {
+		user.Extend = metav1.Extend{}
+	}
+	user.Extend[LoginCountKey] = count
+}
@@ -0,0 +1,43 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package store
+
+import (
+	"testing"
+
+	v1 "github.com/marmotedu/api/apiserver/v1"
+	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+// reload simulates saving the user to and reading it back from the database.
+func reload(t *testing.T, user *v1.User) *v1.User {
+	t.Helper()
+
+	assert.Nil(t, user.BeforeUpdate(nil))
+
+	loaded := &v1.User{ObjectMeta: metav1.ObjectMeta{ExtendShadow: user.ExtendShadow}, LoginedAt: user.LoginedAt}
+	assert.Nil(t, loaded.AfterFind(nil))
+
+	return loaded
+}
+
+func TestLoginCount(t *testing.T) {
+	user := &v1.User{ObjectMeta: metav1.ObjectMeta{Name: "colin", Extend: metav1.Extend{"foo": "bar"}}}
+	assert.Equal(t, int64(0), LoginCount(user))
+
+	for i := 1; i <= 3; i++ {
+		SetLoginCount(user, LoginCount(user)+1)
+		assert.Equal(t, int64(i), LoginCount(user))
+
+		user = reload(t, user)
+		assert.Equal(t, int64(i), LoginCount(user))
+	}
+	assert.Equal(t, "bar", user.Extend["foo"])
+
+	SetLoginCount(user, 0)
+	_, found := user.Extend[LoginCountKey]
+	assert.False(t, found)
+}
@@ -96,6 +96,20 @@ func (mr *MockFactoryMockRecorder) Secrets() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Secrets", reflect.TypeOf((*MockFactory)(nil).Secrets))
 }
 
+// Transaction mocks base method.
+func (m *MockFactory) Transaction(arg0 context.Context, arg1 func(Factory) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Transaction", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Transaction indicates an expected call of Transaction.
+func (mr *MockFactoryMockRecorder) Transaction(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Transaction", reflect.TypeOf((*MockFactory)(nil).Transaction), arg0, arg1)
+}
+
 // Users mocks base method.
 func (m *MockFactory) Users() UserStore {
 	m.ctrl.T.Helper()
@@ -190,6 +204,22 @@ func (mr *MockUserStoreMockRecorder) Get(arg0, arg1, arg2 interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockUserStore)(nil).Get), arg0, arg1, arg2)
 }
 
+// GetWithTenant mocks base method.
+func (m *MockUserStore) GetWithTenant(arg0 context.Context, arg1 string, arg2 v10.GetOptions) (*v1.User, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWithTenant", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*v1.User)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetWithTenant indicates an expected call of GetWithTenant.
+func (mr *MockUserStoreMockRecorder) GetWithTenant(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWithTenant", reflect.TypeOf((*MockUserStore)(nil).GetWithTenant), arg0, arg1, arg2)
+}
+
 // List mocks base method.
 func (m *MockUserStore) List(arg0 context.Context, arg1 v10.ListOptions) (*v1.UserList, error) {
 	m.ctrl.T.Helper()
@@ -205,6 +235,34 @@ func (mr *MockUserStoreMockRecorder) List(arg0, arg1 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockUserStore)(nil).List), arg0, arg1)
 }
 
+// RecordLogin mocks base method.
+func (m *MockUserStore) RecordLogin(arg0 context.Context, arg1 string, arg2 v10.UpdateOptions) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordLogin", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordLogin indicates an expected call of RecordLogin.
+func (mr *MockUserStoreMockRecorder) RecordLogin(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordLogin", reflect.TypeOf((*MockUserStore)(nil).RecordLogin), arg0, arg1, arg2)
+}
+
+// Restore mocks base method.
+func (m *MockUserStore) Restore(arg0 context.Context, arg1 string, arg2 v10.UpdateOptions) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restore", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Restore indicates an expected call of Restore.
+func (mr *MockUserStoreMockRecorder) Restore(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockUserStore)(nil).Restore), arg0, arg1, arg2)
+}
+
 // Update mocks base method.
 func (m *MockUserStore) Update(arg0 context.Context, arg1 *v1.User, arg2 v10.UpdateOptions) error {
 	m.ctrl.T.Helper()
@@ -423,6 +481,20 @@ func (mr *MockPolicyStoreMockRecorder) List(arg0, arg1, arg2 interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockPolicyStore)(nil).List), arg0, arg1, arg2)
 }
 
+// Restore mocks base method.
+func (m *MockPolicyStore) Restore(arg0 context.Context, arg1, arg2 string, arg3 v10.CreateOptions) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restore", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Restore indicates an expected call of Restore.
+func (mr *MockPolicyStoreMockRecorder) Restore(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockPolicyStore)(nil).Restore), arg0, arg1, arg2, arg3)
+}
+
 // Update mocks base method.
 func (m *MockPolicyStore) Update(arg0 context.Context, arg1 *v1.Policy, arg2 v10.UpdateOptions) error {
 	m.ctrl.T.Helper()
@@ -5,12 +5,14 @@
 package mysql
 
 import (
+	"context"
 	"fmt"
 	"sync"
 
 	v1 "github.com/marmotedu/api/apiserver/v1"
 	"github.com/marmotedu/errors"
 	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
 
 	"github.com/marmotedu/iam/internal/apiserver/store"
 	"github.com/marmotedu/iam/internal/pkg/logger"
@@ -42,6 +44,12 @@ func (ds *datastore) PolicyAudits() store.PolicyAuditStore {
 	return newPolicyAudits(ds)
 }
 
+func (ds *datastore) Transaction(ctx context.Context, fn func(txFactory store.Factory) error) error {
+	return ds.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&datastore{tx})
+	})
+}
+
 func (ds *datastore) Close() error {
 	db, err := ds.db.DB()
 	if err != nil {
@@ -65,7 +73,14 @@ func GetMySQLFactoryOr(opts *genericoptions.MySQLOptions) (store.Factory, error)
 	var err error
 	var dbIns *gorm.DB
 	once.Do(func() {
+		var level gormlogger.LogLevel
+		level, err = db.ParseLogLevel(opts.LogLevel)
+		if err != nil {
+			return
+		}
+
 		options := &db.Options{
+			Driver:                opts.Driver,
 			Host:                  opts.Host,
 			Username:              opts.Username,
 			Password:              opts.Password,
@@ -73,8 +88,10 @@ func GetMySQLFactoryOr(opts *genericoptions.MySQLOptions) (store.Factory, error)
 			MaxIdleConnections:    opts.MaxIdleConnections,
 			MaxOpenConnections:    opts.MaxOpenConnections,
 			MaxConnectionLifeTime: opts.MaxConnectionLifeTime,
+			MaxConnectRetries:     opts.MaxConnectRetries,
+			ConnectRetryInterval:  opts.ConnectRetryInterval,
 			LogLevel:              opts.LogLevel,
-			Logger:                logger.New(opts.LogLevel),
+			Logger:                logger.New(level),
 		}
 		dbIns, err = db.New(options)
 
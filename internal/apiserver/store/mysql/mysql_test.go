@@ -0,0 +1,59 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package mysql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	v1 "github.com/marmotedu/api/apiserver/v1"
+	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
+	"github.com/marmotedu/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/marmotedu/iam/internal/apiserver/store"
+)
+
+func TestDatastore_TransactionRollback(t *testing.T) {
+	ds, mock := newMockDatastore(t)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT \\* FROM `user` WHERE name = \\?").WithArgs("colin").WillReturnRows(sqlmock.NewRows(nil))
+	mock.ExpectExec("INSERT INTO `user`").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("UPDATE `user` SET").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO `secret`").WillReturnError(errors.New("secret table is broken"))
+	mock.ExpectRollback()
+
+	err := ds.Transaction(context.TODO(), func(txFactory store.Factory) error {
+		if err := txFactory.Users().Create(context.TODO(), newUser(), metav1.CreateOptions{}); err != nil {
+			return err
+		}
+
+		secret := &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "default"},
+			Username:   "colin",
+		}
+
+		return txFactory.Secrets().Create(context.TODO(), secret, metav1.CreateOptions{})
+	})
+	assert.NotNil(t, err)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestDatastore_TransactionCommit(t *testing.T) {
+	ds, mock := newMockDatastore(t)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM `policy`").WithArgs("colin").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM `user`").WithArgs("colin").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err := ds.Transaction(context.TODO(), func(txFactory store.Factory) error {
+		return txFactory.Users().Delete(context.TODO(), "colin", metav1.DeleteOptions{Unscoped: true})
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/marmotedu/errors"
 	"gorm.io/gorm"
 
+	"github.com/marmotedu/iam/internal/apiserver/store"
 	"github.com/marmotedu/iam/internal/pkg/code"
 	"github.com/marmotedu/iam/internal/pkg/util/gormutil"
 )
@@ -27,12 +28,12 @@ func newPolicies(ds *datastore) *policies {
 
 // Create creates a new ladon policy.
 func (p *policies) Create(ctx context.Context, policy *v1.Policy, opts metav1.CreateOptions) error {
-	return p.db.Create(&policy).Error
+	return createInTenant(ctx, p.db, policy)
 }
 
 // Update updates policy by the policy identifier.
 func (p *policies) Update(ctx context.Context, policy *v1.Policy, opts metav1.UpdateOptions) error {
-	return p.db.Save(policy).Error
+	return p.db.Scopes(scopeTenant(ctx)).Save(policy).Error
 }
 
 // Delete deletes the policy by the policy identifier.
@@ -41,7 +42,7 @@ func (p *policies) Delete(ctx context.Context, username, name string, opts metav
 		p.db = p.db.Unscoped()
 	}
 
-	err := p.db.Where("username = ? and name = ?", username, name).Delete(&v1.Policy{}).Error
+	err := p.db.Scopes(scopeTenant(ctx)).Where("username = ? and name = ?", username, name).Delete(&v1.Policy{}).Error
 	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 		return errors.WithCode(code.ErrDatabase, err.Error())
 	}
@@ -55,7 +56,7 @@ func (p *policies) DeleteByUser(ctx context.Context, username string, opts metav
 		p.db = p.db.Unscoped()
 	}
 
-	return p.db.Where("username = ?", username).Delete(&v1.Policy{}).Error
+	return p.db.Scopes(scopeTenant(ctx)).Where("username = ?", username).Delete(&v1.Policy{}).Error
 }
 
 // DeleteCollection batch deletes policies by policies ids.
@@ -69,7 +70,7 @@ func (p *policies) DeleteCollection(
 		p.db = p.db.Unscoped()
 	}
 
-	return p.db.Where("username = ? and name in (?)", username, names).Delete(&v1.Policy{}).Error
+	return p.db.Scopes(scopeTenant(ctx)).Where("username = ? and name in (?)", username, names).Delete(&v1.Policy{}).Error
 }
 
 // DeleteCollectionByUser batch deletes policies usernames.
@@ -78,13 +79,13 @@ func (p *policies) DeleteCollectionByUser(ctx context.Context, usernames []strin
 		p.db = p.db.Unscoped()
 	}
 
-	return p.db.Where("username in (?)", usernames).Delete(&v1.Policy{}).Error
+	return p.db.Scopes(scopeTenant(ctx)).Where("username in (?)", usernames).Delete(&v1.Policy{}).Error
 }
 
 // Get return policy by the policy identifier.
 func (p *policies) Get(ctx context.Context, username, name string, opts metav1.GetOptions) (*v1.Policy, error) {
 	policy := &v1.Policy{}
-	err := p.db.Where("username = ? and name = ?", username, name).First(&policy).Error
+	err := p.db.Scopes(scopeTenant(ctx)).Where("username = ? and name = ?", username, name).First(&policy).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.WithCode(code.ErrPolicyNotFound, err.Error())
@@ -96,7 +97,46 @@ func (p *policies) Get(ctx context.Context, username, name string, opts metav1.G
 	return policy, nil
 }
 
-// List return all policies.
+// Restore recreates the deleted policy from the latest copy saved in the policy_audit table.
+// It does nothing if the policy is not deleted, and returns ErrPolicyNotFound if there
+// is no copy of the policy, e.g. it is cleared by iam-watcher.
+func (p *policies) Restore(ctx context.Context, username, name string, opts metav1.CreateOptions) error {
+	var count int64
+	err := p.db.Scopes(scopeTenant(ctx)).Model(&v1.Policy{}).
+		Where("username = ? and name = ?", username, name).
+		Count(&count).Error
+	if err != nil {
+		return errors.WithCode(code.ErrDatabase, err.Error())
+	}
+
+	if count > 0 {
+		return nil
+	}
+
+	audit := &store.PolicyAudit{}
+	// only the policies deleted from the tenant of ctx are restored into it.
+	err = p.db.Scopes(scopeTenant(ctx)).Where("username = ? and name = ?", username, name).Order("deletedAt desc, id desc").First(&audit).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.WithCode(code.ErrPolicyNotFound, err.Error())
+		}
+
+		return errors.WithCode(code.ErrDatabase, err.Error())
+	}
+
+	// the restored policy gets a new id and instance id.
+	policy := audit.Policy
+	policy.ID = 0
+	policy.InstanceID = ""
+
+	if err := createInTenant(ctx, p.db, &policy); err != nil {
+		return errors.WithCode(code.ErrDatabase, err.Error())
+	}
+
+	return nil
+}
+
+// List return all policies. Keyset pagination is used when a cursor is given in the field selector.
 func (p *policies) List(ctx context.Context, username string, opts metav1.ListOptions) (*v1.PolicyList, error) {
 	ret := &v1.PolicyList{}
 	ol := gormutil.Unpointer(opts.Offset, opts.Limit)
@@ -107,15 +147,20 @@ func (p *policies) List(ctx context.Context, username string, opts metav1.ListOp
 
 	selector, _ := fields.ParseSelector(opts.FieldSelector)
 	name, _ := selector.RequiresExactMatch("name")
+	after, _ := selector.RequiresExactMatch(gormutil.CursorField)
+	cursor, err := gormutil.DecodeCursor(after)
+	if err != nil {
+		return nil, errors.WithCode(code.ErrValidation, err.Error())
+	}
+
+	db := p.db.Scopes(scopeTenant(ctx)).Model(&v1.Policy{}).Where("name like ?", "%"+name+"%").Session(&gorm.Session{})
+	if err := db.Count(&ret.TotalCount).Error; err != nil {
+		return nil, err
+	}
 
-	d := p.db.Where("name like ?", "%"+name+"%").
-		Offset(ol.Offset).
-		Limit(ol.Limit).
+	d := db.Scopes(gormutil.Paginate(ol, cursor)).
 		Order("id desc").
-		Find(&ret.Items).
-		Offset(-1).
-		Limit(-1).
-		Count(&ret.TotalCount)
+		Find(&ret.Items)
 
 	return ret, d.Error
 }
@@ -8,7 +8,14 @@ import (
 	"context"
 	"time"
 
+	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
+	"github.com/marmotedu/errors"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/marmotedu/iam/internal/apiserver/store"
+	"github.com/marmotedu/iam/internal/pkg/code"
+	"github.com/marmotedu/iam/internal/pkg/util/gormutil"
 )
 
 type policyAudit struct {
@@ -19,11 +26,33 @@ func newPolicyAudits(ds *datastore) *policyAudit {
 	return &policyAudit{ds.db}
 }
 
+// List returns the audits of the policies with the given name, the most recently deleted first.
+func (p *policyAudit) List(ctx context.Context, name string, opts metav1.ListOptions) (*store.PolicyAuditList, error) {
+	ret := &store.PolicyAuditList{}
+	ol := gormutil.Unpointer(opts.Offset, opts.Limit)
+
+	db := p.db.Scopes(scopeTenant(ctx)).Model(&store.PolicyAudit{}).Where("name = ?", name).Session(&gorm.Session{})
+	if err := db.Count(&ret.TotalCount).Error; err != nil {
+		return nil, errors.WithCode(code.ErrDatabase, err.Error())
+	}
+
+	d := db.Offset(ol.Offset).
+		Limit(ol.Limit).
+		Order("deletedAt desc, id desc").
+		Find(&ret.Items)
+	if d.Error != nil {
+		return nil, errors.WithCode(code.ErrDatabase, d.Error.Error())
+	}
+
+	return ret, nil
+}
+
 // ClearOutdated clear data older than a given days.
 func (p *policyAudit) ClearOutdated(ctx context.Context, maxReserveDays int) (int64, error) {
 	date := time.Now().AddDate(0, 0, -maxReserveDays).Format("2006-01-02 15:04:05")
 
-	d := p.db.Exec("delete from policy_audit where deletedAt < ?", date)
+	// quote identifiers by the dialector, so the statement works on both mysql and postgres.
+	d := p.db.Exec("DELETE FROM ? WHERE ? < ?", clause.Table{Name: "policy_audit"}, clause.Column{Name: "deletedAt"}, date)
 
 	return d.RowsAffected, d.Error
 }
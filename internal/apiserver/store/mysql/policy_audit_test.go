@@ -0,0 +1,45 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package mysql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/AlekSi/pointer"
+	"github.com/DATA-DOG/go-sqlmock"
+	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicyAudits_List(t *testing.T) {
+	ds, mock := newMockDatastore(t)
+
+	deletedAt := time.Date(2021, 7, 1, 0, 0, 0, 0, time.UTC)
+	rows := sqlmock.NewRows([]string{"id", "name", "username", "policyShadow", "extendShadow", "deletedAt"}).
+		AddRow(2, "policy1", "colin", `{"effect":"deny"}`, "{}", deletedAt).
+		AddRow(1, "policy1", "colin", `{"effect":"allow"}`, "{}", deletedAt.Add(-time.Hour))
+
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM `policy_audit` WHERE name = \\?").
+		WithArgs("policy1").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	mock.ExpectQuery("SELECT \\* FROM `policy_audit` WHERE name = \\? ORDER BY deletedAt desc, id desc LIMIT 2").
+		WithArgs("policy1").
+		WillReturnRows(rows)
+
+	list, err := ds.PolicyAudits().List(context.TODO(), "policy1", metav1.ListOptions{Limit: pointer.ToInt64(2)})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), list.TotalCount)
+
+	if assert.Len(t, list.Items, 2) {
+		assert.Equal(t, "colin", list.Items[0].Username)
+		assert.Equal(t, "deny", list.Items[0].Policy.Policy.Effect)
+		assert.Equal(t, deletedAt, list.Items[0].DeletedAt)
+		assert.Equal(t, "allow", list.Items[1].Policy.Policy.Effect)
+	}
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
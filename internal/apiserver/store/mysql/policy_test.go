@@ -0,0 +1,50 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package mysql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
+	"github.com/marmotedu/errors"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+
+	"github.com/marmotedu/iam/internal/pkg/code"
+)
+
+func TestPolicies_Restore(t *testing.T) {
+	ds, mock := newMockDatastore(t)
+
+	countQuery := "SELECT count\\(\\*\\) FROM `policy` WHERE username = \\? and name = \\?"
+	auditQuery := "SELECT \\* FROM `policy_audit` WHERE username = \\? and name = \\? ORDER BY deletedAt desc, id desc"
+
+	// deleted policy is recreated from the audit
+	mock.ExpectQuery(countQuery).WithArgs("colin", "policy1").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery(auditQuery).WithArgs("colin", "policy1").WillReturnRows(
+		sqlmock.NewRows([]string{"id", "instanceID", "name", "username", "policyShadow", "extendShadow"}).
+			AddRow(7, "policy-7", "policy1", "colin", `{"effect":"allow"}`, "{}"),
+	)
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO `policy`").WillReturnResult(sqlmock.NewResult(9, 1))
+	mock.ExpectExec("UPDATE `policy` SET").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	// existing policy is not touched
+	mock.ExpectQuery(countQuery).WithArgs("colin", "policy1").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	// policy without audit can't be restored
+	mock.ExpectQuery(countQuery).WithArgs("colin", "policy2").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery(auditQuery).WithArgs("colin", "policy2").WillReturnError(gorm.ErrRecordNotFound)
+
+	assert.Nil(t, ds.Policies().Restore(context.TODO(), "colin", "policy1", metav1.CreateOptions{}))
+	assert.Nil(t, ds.Policies().Restore(context.TODO(), "colin", "policy1", metav1.CreateOptions{}))
+
+	err := ds.Policies().Restore(context.TODO(), "colin", "policy2", metav1.CreateOptions{})
+	assert.True(t, errors.IsCode(err, code.ErrPolicyNotFound))
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,49 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package mysql
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"github.com/marmotedu/iam/internal/pkg/middleware"
+)
+
+// tenantColumn is the column of the user, secret and policy tables which holds the tenant of the row.
+const tenantColumn = "tenant_id"
+
+// scopeTenant restricts the query to the rows of the tenant of ctx, set by the tenant middleware.
+// The default tenant, whose id is empty, only sees its own rows. The query is not restricted if
+// ctx has no tenant, e.g. the internal requests of iam-authz-server loading all the resources.
+func scopeTenant(ctx context.Context) func(*gorm.DB) *gorm.DB {
+	tenantID, ok := middleware.TenantFromContext(ctx)
+
+	return func(db *gorm.DB) *gorm.DB {
+		if !ok {
+			return db
+		}
+
+		return db.Where(tenantColumn+" = ?", tenantID)
+	}
+}
+
+// createInTenant creates value, a pointer to the model, and assigns it to the tenant of ctx.
+// The models don't have a tenant field, so the tenant is set after the row is created. The rows
+// created without a tenant belong to the default tenant, which is the default of the column.
+func createInTenant(ctx context.Context, db *gorm.DB, value interface{}) error {
+	tenantID := middleware.TenantIDFromContext(ctx)
+	if tenantID == "" {
+		return db.Create(value).Error
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(value).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(value).UpdateColumn(tenantColumn, tenantID).Error
+	})
+}
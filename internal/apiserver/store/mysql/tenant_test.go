@@ -0,0 +1,155 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package mysql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	v1 "github.com/marmotedu/api/apiserver/v1"
+	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
+	"github.com/marmotedu/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/marmotedu/iam/internal/pkg/code"
+	"github.com/marmotedu/iam/internal/pkg/middleware"
+)
+
+func TestTenant_CrossTenantReads(t *testing.T) {
+	ds, mock := newMockDatastore(t)
+	ctx := middleware.WithTenantID(context.TODO(), "other")
+
+	// the resources of colin belong to another tenant, the scoped queries find nothing
+	mock.ExpectQuery("SELECT \\* FROM `user` WHERE \\(name = \\? and status != \\?\\) AND tenant_id = \\?").
+		WithArgs("colin", userStatusDeleted, "other").
+		WillReturnRows(sqlmock.NewRows(nil))
+	mock.ExpectQuery("SELECT \\* FROM `secret` WHERE \\(username = \\? and name= \\?\\) AND tenant_id = \\?").
+		WithArgs("colin", "default", "other").
+		WillReturnRows(sqlmock.NewRows(nil))
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM `policy` WHERE username = \\? AND name like \\? AND tenant_id = \\?").
+		WithArgs("colin", "%%", "other").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery("SELECT \\* FROM `policy` WHERE username = \\? AND name like \\? AND tenant_id = \\?").
+		WithArgs("colin", "%%", "other").
+		WillReturnRows(sqlmock.NewRows(nil))
+
+	_, err := ds.Users().Get(ctx, "colin", metav1.GetOptions{})
+	assert.True(t, errors.IsCode(err, code.ErrUserNotFound))
+
+	_, err = ds.Secrets().Get(ctx, "colin", "default", metav1.GetOptions{})
+	assert.True(t, errors.IsCode(err, code.ErrSecretNotFound))
+
+	policies, err := ds.Policies().List(ctx, "colin", metav1.ListOptions{})
+	assert.Nil(t, err)
+	assert.Empty(t, policies.Items)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestTenant_Create(t *testing.T) {
+	ds, mock := newMockDatastore(t)
+	ctx := middleware.WithTenantID(context.TODO(), "acme")
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO `secret`").WillReturnResult(sqlmock.NewResult(3, 1))
+	mock.ExpectExec("UPDATE `secret` SET `instanceID`=").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE `secret` SET `tenant_id`=\\? WHERE `id` = \\?").
+		WithArgs("acme", 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "default"},
+		Username:   "colin",
+	}
+	assert.Nil(t, ds.Secrets().Create(ctx, secret, metav1.CreateOptions{}))
+	assert.Equal(t, uint64(3), secret.ID)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestTenant_WithoutTenant(t *testing.T) {
+	ds, mock := newMockDatastore(t)
+
+	// the queries are not scoped without a tenant
+	mock.ExpectQuery("SELECT \\* FROM `secret` WHERE username = \\? and name= \\?").
+		WithArgs("colin", "default").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "username", "extendShadow"}).AddRow(3, "default", "colin", "{}"))
+
+	secret, err := ds.Secrets().Get(context.TODO(), "colin", "default", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "default", secret.Name)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestUsers_GetWithTenant(t *testing.T) {
+	ds, mock := newMockDatastore(t)
+	ctx := middleware.WithTenantID(context.TODO(), "other")
+
+	// the user and its tenant are looked up at once, whatever the tenant of the request
+	mock.ExpectQuery("SELECT \\* FROM `user` WHERE name = \\? and status != \\? ORDER BY `user`.`id` LIMIT 1").
+		WithArgs("colin", userStatusDeleted).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "isAdmin", "extendShadow", "tenant_id"}).
+			AddRow(1, "colin", 1, `{"loginCount":3}`, "acme"))
+	mock.ExpectQuery("SELECT \\* FROM `user` WHERE name = \\? and status != \\?").
+		WithArgs("unknown", userStatusDeleted).
+		WillReturnRows(sqlmock.NewRows(nil))
+
+	user, tenantID, err := ds.Users().GetWithTenant(ctx, "colin", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "acme", tenantID)
+	assert.Equal(t, "colin", user.Name)
+	assert.Equal(t, 1, user.IsAdmin)
+	assert.Equal(t, float64(3), user.Extend["loginCount"])
+
+	_, _, err = ds.Users().GetWithTenant(ctx, "unknown", metav1.GetOptions{})
+	assert.True(t, errors.IsCode(err, code.ErrUserNotFound))
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestTenant_DefaultTenant(t *testing.T) {
+	ds, mock := newMockDatastore(t)
+	ctx := middleware.WithTenantID(context.TODO(), "")
+
+	// the default tenant only sees the resources without a tenant
+	mock.ExpectQuery("SELECT \\* FROM `secret` WHERE \\(username = \\? and name= \\?\\) AND tenant_id = \\?").
+		WithArgs("colin", "default", "").
+		WillReturnRows(sqlmock.NewRows(nil))
+
+	_, err := ds.Secrets().Get(ctx, "colin", "default", metav1.GetOptions{})
+	assert.True(t, errors.IsCode(err, code.ErrSecretNotFound))
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestTenant_CreateUserOfAnotherTenant(t *testing.T) {
+	ds, mock := newMockDatastore(t)
+	ctx := middleware.WithTenantID(context.TODO(), "acme")
+
+	// the soft deleted colin of another tenant still holds the name
+	mock.ExpectQuery("SELECT \\* FROM `user` WHERE name = \\?").WithArgs("colin").WillReturnRows(userRows(userStatusDeleted))
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM `user` WHERE id = \\? AND tenant_id = \\?").
+		WithArgs(1, "acme").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	err := ds.Users().Create(ctx, newUser(), metav1.CreateOptions{})
+	assert.True(t, errors.IsCode(err, code.ErrUserAlreadyExist))
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestTenant_RestorePolicyOfAnotherTenant(t *testing.T) {
+	ds, mock := newMockDatastore(t)
+	ctx := middleware.WithTenantID(context.TODO(), "other")
+
+	// the audits of the policies deleted from another tenant are invisible
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM `policy` WHERE \\(username = \\? and name = \\?\\) AND tenant_id = \\?").
+		WithArgs("colin", "policy1", "other").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery("SELECT \\* FROM `policy_audit` WHERE \\(username = \\? and name = \\?\\) AND tenant_id = \\?").
+		WithArgs("colin", "policy1", "other").
+		WillReturnRows(sqlmock.NewRows(nil))
+
+	err := ds.Policies().Restore(ctx, "colin", "policy1", metav1.CreateOptions{})
+	assert.True(t, errors.IsCode(err, code.ErrPolicyNotFound))
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
@@ -6,6 +6,8 @@ package mysql
 
 import (
 	"context"
+	"strconv"
+	"time"
 
 	v1 "github.com/marmotedu/api/apiserver/v1"
 	"github.com/marmotedu/component-base/pkg/fields"
@@ -13,10 +15,17 @@ import (
 	"github.com/marmotedu/errors"
 	gorm "gorm.io/gorm"
 
+	"github.com/marmotedu/iam/internal/apiserver/store"
 	"github.com/marmotedu/iam/internal/pkg/code"
 	"github.com/marmotedu/iam/internal/pkg/util/gormutil"
 )
 
+// User status stored in the `status` column, the soft deleted user is invisible to Get and List.
+const (
+	userStatusDeleted = store.UserStatusDeleted
+	userStatusActive  = store.UserStatusActive
+)
+
 type users struct {
 	db *gorm.DB
 }
@@ -25,14 +34,44 @@ func newUsers(ds *datastore) *users {
 	return &users{ds.db}
 }
 
-// Create creates a new user account.
+// Create creates a new user account. A soft deleted user with the same name still holds the
+// unique index, so it is restored and overwritten by the new account instead. The user names are
+// unique across the tenants, so a name held by another tenant already exists.
 func (u *users) Create(ctx context.Context, user *v1.User, opts metav1.CreateOptions) error {
-	return u.db.Create(&user).Error
+	existing := &v1.User{}
+	err := u.db.Where("name = ?", user.Name).First(&existing).Error
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.WithCode(code.ErrDatabase, err.Error())
+		}
+
+		return createInTenant(ctx, u.db, user)
+	}
+
+	if existing.Status != userStatusDeleted {
+		return errors.WithCode(code.ErrUserAlreadyExist, "user %s already exist", user.Name)
+	}
+
+	var count int64
+	err = u.db.Scopes(scopeTenant(ctx)).Model(&v1.User{}).Where("id = ?", existing.ID).Count(&count).Error
+	if err != nil {
+		return errors.WithCode(code.ErrDatabase, err.Error())
+	}
+
+	if count == 0 {
+		return errors.WithCode(code.ErrUserAlreadyExist, "user %s already exist in another tenant", user.Name)
+	}
+
+	user.ID = existing.ID
+	user.InstanceID = existing.InstanceID
+	user.CreatedAt = time.Now()
+
+	return u.db.Scopes(scopeTenant(ctx)).Save(user).Error
 }
 
 // Update updates an user account information.
 func (u *users) Update(ctx context.Context, user *v1.User, opts metav1.UpdateOptions) error {
-	return u.db.Save(user).Error
+	return u.db.Scopes(scopeTenant(ctx)).Save(user).Error
 }
 
 // Delete deletes the user by the user identifier.
@@ -43,11 +82,12 @@ func (u *users) Delete(ctx context.Context, username string, opts metav1.DeleteO
 		return err
 	}
 
+	var err error
 	if opts.Unscoped {
-		u.db = u.db.Unscoped()
+		err = u.db.Scopes(scopeTenant(ctx)).Where("name = ?", username).Delete(&v1.User{}).Error
+	} else {
+		err = u.db.Scopes(scopeTenant(ctx)).Model(&v1.User{}).Where("name = ?", username).Update("status", userStatusDeleted).Error
 	}
-
-	err := u.db.Where("name = ?", username).Delete(&v1.User{}).Error
 	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 		return errors.WithCode(code.ErrDatabase, err.Error())
 	}
@@ -64,16 +104,16 @@ func (u *users) DeleteCollection(ctx context.Context, usernames []string, opts m
 	}
 
 	if opts.Unscoped {
-		u.db = u.db.Unscoped()
+		return u.db.Scopes(scopeTenant(ctx)).Where("name in (?)", usernames).Delete(&v1.User{}).Error
 	}
 
-	return u.db.Where("name in (?)", usernames).Delete(&v1.User{}).Error
+	return u.db.Scopes(scopeTenant(ctx)).Model(&v1.User{}).Where("name in (?)", usernames).Update("status", userStatusDeleted).Error
 }
 
 // Get return an user by the user identifier.
 func (u *users) Get(ctx context.Context, username string, opts metav1.GetOptions) (*v1.User, error) {
 	user := &v1.User{}
-	err := u.db.Where("name = ? and status = 1", username).First(&user).Error
+	err := u.db.Scopes(scopeTenant(ctx)).Where("name = ? and status != ?", username, userStatusDeleted).First(&user).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.WithCode(code.ErrUserNotFound, err.Error())
@@ -85,25 +125,127 @@ func (u *users) Get(ctx context.Context, username string, opts metav1.GetOptions
 	return user, nil
 }
 
-// List return all users.
+// loginCountPath is the JSON path of the login count in the extend fields.
+var loginCountPath = "$." + store.LoginCountKey
+
+// RecordLogin records a successful login of the user. Only the login columns are written, so the
+// concurrent changes of the user, e.g. disabling it, are kept, and the login count is incremented
+// by the database, so the concurrent logins are all counted.
+func (u *users) RecordLogin(ctx context.Context, username string, opts metav1.UpdateOptions) error {
+	err := u.db.Scopes(scopeTenant(ctx)).Model(&v1.User{}).
+		Where("name = ? and status != ?", username, userStatusDeleted).
+		UpdateColumns(map[string]interface{}{
+			"loginedAt": time.Now(),
+			"extendShadow": gorm.Expr(
+				"JSON_SET(COALESCE(NULLIF(extendShadow, ''), '{}'), ?, "+
+					"COALESCE(JSON_EXTRACT(NULLIF(extendShadow, ''), ?), 0) + 1)",
+				loginCountPath, loginCountPath,
+			),
+		}).Error
+	if err != nil {
+		return errors.WithCode(code.ErrDatabase, err.Error())
+	}
+
+	return nil
+}
+
+// userWithTenant is an user row together with its tenant, v1.User has no tenant field.
+type userWithTenant struct {
+	v1.User
+	TenantID string `gorm:"column:tenant_id"`
+}
+
+// GetWithTenant returns the user and its tenant in a single query. The user names are unique across
+// the tenants, so the query isn't scoped by the tenant of ctx.
+func (u *users) GetWithTenant(ctx context.Context, username string, opts metav1.GetOptions) (*v1.User, string, error) {
+	row := &userWithTenant{}
+	err := u.db.Where("name = ? and status != ?", username, userStatusDeleted).First(row).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "", errors.WithCode(code.ErrUserNotFound, err.Error())
+		}
+
+		return nil, "", errors.WithCode(code.ErrDatabase, err.Error())
+	}
+
+	return &row.User, row.TenantID, nil
+}
+
+// Restore restores the soft deleted user. It does nothing if the user is not deleted,
+// and returns ErrUserNotFound if the user is hard deleted.
+func (u *users) Restore(ctx context.Context, username string, opts metav1.UpdateOptions) error {
+	user := &v1.User{}
+	err := u.db.Scopes(scopeTenant(ctx)).Where("name = ?", username).First(&user).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.WithCode(code.ErrUserNotFound, err.Error())
+		}
+
+		return errors.WithCode(code.ErrDatabase, err.Error())
+	}
+
+	if user.Status != userStatusDeleted {
+		return nil
+	}
+
+	err = u.db.Scopes(scopeTenant(ctx)).Model(&v1.User{}).
+		Where("name = ? and status = ?", username, userStatusDeleted).
+		Update("status", userStatusActive).Error
+	if err != nil {
+		return errors.WithCode(code.ErrDatabase, err.Error())
+	}
+
+	return nil
+}
+
+// List return all users. Keyset pagination is used when a cursor is given in the field selector.
 func (u *users) List(ctx context.Context, opts metav1.ListOptions) (*v1.UserList, error) {
 	ret := &v1.UserList{}
 	ol := gormutil.Unpointer(opts.Offset, opts.Limit)
 
 	selector, _ := fields.ParseSelector(opts.FieldSelector)
 	username, _ := selector.RequiresExactMatch("name")
-	d := u.db.Where("name like ? and status = 1", "%"+username+"%").
-		Offset(ol.Offset).
-		Limit(ol.Limit).
+	after, _ := selector.RequiresExactMatch(gormutil.CursorField)
+	cursor, err := gormutil.DecodeCursor(after)
+	if err != nil {
+		return nil, errors.WithCode(code.ErrValidation, err.Error())
+	}
+
+	status, err := listStatus(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	db := u.db.Scopes(scopeTenant(ctx)).Model(&v1.User{}).
+		Where("name like ? and status = ?", gormutil.ContainsPattern(username), status).
+		Session(&gorm.Session{})
+	if err := db.Count(&ret.TotalCount).Error; err != nil {
+		return nil, err
+	}
+
+	d := db.Scopes(gormutil.Paginate(ol, cursor)).
 		Order("id desc").
-		Find(&ret.Items).
-		Offset(-1).
-		Limit(-1).
-		Count(&ret.TotalCount)
+		Find(&ret.Items)
 
 	return ret, d.Error
 }
 
+// listStatus returns the user status required by the `status` field selector, which
+// defaults to active. Soft deleted users can't be listed.
+func listStatus(selector fields.Selector) (int, error) {
+	value, found := selector.RequiresExactMatch("status")
+	if !found {
+		return userStatusActive, nil
+	}
+
+	status, err := strconv.Atoi(value)
+	if err != nil || status == userStatusDeleted {
+		return 0, errors.WithCode(code.ErrValidation, "unsupported user status %q", value)
+	}
+
+	return status, nil
+}
+
 // ListOptional show a more graceful query method.
 func (u *users) ListOptional(ctx context.Context, opts metav1.ListOptions) (*v1.UserList, error) {
 	ret := &v1.UserList{}
@@ -119,7 +261,7 @@ func (u *users) ListOptional(ctx context.Context, opts metav1.ListOptions) (*v1.
 		where.Name = username
 	}
 
-	d := u.db.Where(where).
+	d := u.db.Scopes(scopeTenant(ctx)).Where(where).
 		Not(whereNot).
 		Offset(ol.Offset).
 		Limit(ol.Limit).
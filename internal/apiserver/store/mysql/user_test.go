@@ -0,0 +1,233 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/AlekSi/pointer"
+	"github.com/DATA-DOG/go-sqlmock"
+	v1 "github.com/marmotedu/api/apiserver/v1"
+	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
+	"github.com/marmotedu/errors"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/marmotedu/iam/internal/pkg/code"
+	"github.com/marmotedu/iam/internal/pkg/util/gormutil"
+)
+
+func newMockDatastore(t *testing.T) (*datastore, sqlmock.Sqlmock) {
+	t.Helper()
+
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("create sqlmock failed: %s", err.Error())
+	}
+	t.Cleanup(func() { _ = sqlDB.Close() })
+
+	db, err := gorm.Open(mysql.New(mysql.Config{Conn: sqlDB, SkipInitializeWithVersion: true}), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("open gorm db failed: %s", err.Error())
+	}
+
+	return &datastore{db}, mock
+}
+
+func userRows(status int) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "instanceID", "name", "status", "extendShadow"}).
+		AddRow(1, "user-22v2fg", "colin", status, "{}")
+}
+
+func newUser() *v1.User {
+	return &v1.User{
+		ObjectMeta: metav1.ObjectMeta{Name: "colin"},
+		Status:     userStatusActive,
+		Nickname:   "colin",
+		Password:   "Admin@2021",
+		Email:      "colin@foxmail.com",
+	}
+}
+
+func TestUsers_CreateAfterDelete(t *testing.T) {
+	ds, mock := newMockDatastore(t)
+
+	// soft delete policies of the user first, then the user itself
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM `policy`").WithArgs("colin").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE `user` SET `status`=").
+		WithArgs(userStatusDeleted, sqlmock.AnyArg(), "colin").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	// recreate restores the soft deleted record
+	mock.ExpectQuery("SELECT \\* FROM `user` WHERE name = \\?").WithArgs("colin").WillReturnRows(userRows(userStatusDeleted))
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM `user` WHERE id = \\?").WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE `user` SET").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err := ds.Users().Delete(context.TODO(), "colin", metav1.DeleteOptions{})
+	assert.Nil(t, err)
+
+	user := newUser()
+	err = ds.Users().Create(context.TODO(), user, metav1.CreateOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1), user.ID)
+	assert.Equal(t, "user-22v2fg", user.InstanceID)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestUsers_CreateAlreadyExist(t *testing.T) {
+	ds, mock := newMockDatastore(t)
+
+	mock.ExpectQuery("SELECT \\* FROM `user` WHERE name = \\?").WithArgs("colin").WillReturnRows(userRows(userStatusActive))
+
+	err := ds.Users().Create(context.TODO(), newUser(), metav1.CreateOptions{})
+	assert.True(t, errors.IsCode(err, code.ErrUserAlreadyExist))
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestUsers_CreateNew(t *testing.T) {
+	ds, mock := newMockDatastore(t)
+
+	mock.ExpectQuery("SELECT \\* FROM `user` WHERE name = \\?").WithArgs("colin").WillReturnError(gorm.ErrRecordNotFound)
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO `user`").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("UPDATE `user` SET").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err := ds.Users().Create(context.TODO(), newUser(), metav1.CreateOptions{})
+	assert.Nil(t, err)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestUsers_ListKeyset(t *testing.T) {
+	ds, mock := newMockDatastore(t)
+
+	page := func(ids ...int) *sqlmock.Rows {
+		rows := sqlmock.NewRows([]string{"id", "name", "status", "extendShadow"})
+		for _, id := range ids {
+			rows.AddRow(id, fmt.Sprintf("user%d", id), userStatusActive, "{}")
+		}
+
+		return rows
+	}
+
+	// first page uses offset pagination
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM `user`").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(4))
+	mock.ExpectQuery("SELECT \\* FROM `user` WHERE .* ORDER BY id desc LIMIT 2$").WillReturnRows(page(10, 9))
+	// a new user(id 11) is inserted before fetching the next page, which must not shift the
+	// second page because it is anchored at the last seen id.
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM `user`").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(5))
+	mock.ExpectQuery("SELECT \\* FROM `user` WHERE .* AND id < \\? ORDER BY id desc LIMIT 2$").
+		WithArgs("%%", userStatusActive, 9).
+		WillReturnRows(page(8, 7))
+
+	opts := metav1.ListOptions{Limit: pointer.ToInt64(2)}
+	first, err := ds.Users().List(context.TODO(), opts)
+	assert.Nil(t, err)
+
+	cursor := gormutil.NextCursor(len(first.Items), 2, first.Items[len(first.Items)-1].ID)
+	opts.FieldSelector = gormutil.WithCursor("", cursor)
+	second, err := ds.Users().List(context.TODO(), opts)
+	assert.Nil(t, err)
+
+	seen := make(map[uint64]bool)
+	for _, user := range append(first.Items, second.Items...) {
+		assert.False(t, seen[user.ID], "user %d is listed twice", user.ID)
+		seen[user.ID] = true
+	}
+	assert.Equal(t, 4, len(seen))
+	assert.Equal(t, int64(5), second.TotalCount)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestUsers_ListSearch(t *testing.T) {
+	ds, mock := newMockDatastore(t)
+
+	rows := sqlmock.NewRows([]string{"id", "name", "status", "extendShadow"}).
+		AddRow(2, "colin", userStatusActive, "{}").
+		AddRow(1, "colinkong", userStatusActive, "{}")
+
+	// the keyword is passed as a parameter, with its wildcards escaped
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM `user` WHERE name like \\? and status = \\?").
+		WithArgs("%col%", userStatusActive).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+	mock.ExpectQuery("SELECT \\* FROM `user` WHERE name like \\? and status = \\? ORDER BY id desc").
+		WithArgs("%col%", userStatusActive).
+		WillReturnRows(rows)
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM `user` WHERE name like \\? and status = \\?").
+		WithArgs(`%\%' or 1=1 --%`, userStatusActive).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery("SELECT \\* FROM `user` WHERE name like \\? and status = \\? ORDER BY id desc").
+		WithArgs(`%\%' or 1=1 --%`, userStatusActive).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "status", "extendShadow"}))
+
+	list, err := ds.Users().List(context.TODO(), metav1.ListOptions{FieldSelector: gormutil.WithField("", "name", "col")})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), list.TotalCount)
+	assert.Len(t, list.Items, 2)
+
+	list, err = ds.Users().List(context.TODO(), metav1.ListOptions{
+		FieldSelector: gormutil.WithField("", "name", "%' or 1=1 --"),
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), list.TotalCount)
+	assert.Empty(t, list.Items)
+
+	_, err = ds.Users().List(context.TODO(), metav1.ListOptions{FieldSelector: "status=0"})
+	assert.True(t, errors.IsCode(err, code.ErrValidation))
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestUsers_Restore(t *testing.T) {
+	ds, mock := newMockDatastore(t)
+
+	// soft deleted user is restored to active
+	mock.ExpectQuery("SELECT \\* FROM `user` WHERE name = \\?").WithArgs("colin").WillReturnRows(userRows(userStatusDeleted))
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE `user` SET `status`=.* WHERE name = \\? and status = \\?").
+		WithArgs(userStatusActive, sqlmock.AnyArg(), "colin", userStatusDeleted).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	// restoring an active user does nothing
+	mock.ExpectQuery("SELECT \\* FROM `user` WHERE name = \\?").WithArgs("colin").WillReturnRows(userRows(userStatusActive))
+
+	// hard deleted user can't be restored
+	mock.ExpectQuery("SELECT \\* FROM `user` WHERE name = \\?").WithArgs("kong").WillReturnError(gorm.ErrRecordNotFound)
+
+	assert.Nil(t, ds.Users().Restore(context.TODO(), "colin", metav1.UpdateOptions{}))
+	assert.Nil(t, ds.Users().Restore(context.TODO(), "colin", metav1.UpdateOptions{}))
+
+	err := ds.Users().Restore(context.TODO(), "kong", metav1.UpdateOptions{})
+	assert.True(t, errors.IsCode(err, code.ErrUserNotFound))
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestUsers_RecordLogin(t *testing.T) {
+	ds, mock := newMockDatastore(t)
+
+	// only the login columns are written, the login count is incremented by the database
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE `user` SET `extendShadow`=JSON_SET\\(.*JSON_EXTRACT\\(.*\\) \\+ 1\\),`loginedAt`=\\? "+
+		"WHERE name = \\? and status != \\?$").
+		WithArgs("$.loginCount", "$.loginCount", sqlmock.AnyArg(), "colin", userStatusDeleted).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	assert.Nil(t, ds.Users().RecordLogin(context.TODO(), "colin", metav1.UpdateOptions{}))
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
@@ -6,9 +6,36 @@ package store
 
 import (
 	"context"
+	"time"
+
+	v1 "github.com/marmotedu/api/apiserver/v1"
+	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
 )
 
+// PolicyAudit is a policy saved to the policy_audit table when it is deleted.
+type PolicyAudit struct {
+	v1.Policy
+
+	// The time when the policy is deleted.
+	DeletedAt time.Time `json:"deletedAt" gorm:"column:deletedAt"`
+}
+
+// TableName maps to mysql table name.
+func (p *PolicyAudit) TableName() string {
+	return "policy_audit"
+}
+
+// PolicyAuditList is the list of policy audits.
+type PolicyAuditList struct {
+	// Standard list metadata.
+	metav1.ListMeta `json:",inline"`
+
+	// List of policy audits.
+	Items []*PolicyAudit `json:"items"`
+}
+
 // PolicyAuditStore defines the policy_audit storage interface.
 type PolicyAuditStore interface {
+	List(ctx context.Context, name string, opts metav1.ListOptions) (*PolicyAuditList, error)
 	ClearOutdated(ctx context.Context, maxReserveDays int) (int64, error)
 }
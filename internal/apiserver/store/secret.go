@@ -11,6 +11,38 @@ import (
 	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
 )
 
+// Keys in the secret's extend fields which store the secret key replaced by the
+// last rotation, it's accepted until its grace period ends.
+const (
+	SecretPreviousKeyKey        = "previousSecretKey"
+	SecretPreviousKeyExpiresKey = "previousSecretKeyExpires"
+)
+
+// SecretScopeKey is the key in the secret's extend fields which stores the scope
+// restricting the access granted by the secret, see auth.Scope.
+const SecretScopeKey = "scope"
+
+// SecretPreviousKey returns the secret key replaced by the last rotation, and the
+// unix time when it stops being accepted. Empty key is returned if there is none.
+func SecretPreviousKey(secret *v1.Secret) (string, int64) {
+	key, _ := secret.Extend[SecretPreviousKeyKey].(string)
+
+	// the value is a float64 once it is decoded from the database
+	var expires int64
+	switch v := secret.Extend[SecretPreviousKeyExpiresKey].(type) {
+	case int64:
+		expires = v
+	case float64:
+		expires = int64(v)
+	}
+
+	if key == "" || expires == 0 {
+		return "", 0
+	}
+
+	return key, expires
+}
+
 // SecretStore defines the secret storage interface.
 type SecretStore interface {
 	Create(ctx context.Context, secret *v1.Secret, opts metav1.CreateOptions) error
@@ -4,6 +4,8 @@
 
 package store
 
+import "context"
+
 //go:generate mockgen -self_package=github.com/marmotedu/iam/internal/apiserver/store -destination mock_store.go -package store github.com/marmotedu/iam/internal/apiserver/store Factory,UserStore,SecretStore,PolicyStore
 
 var client Factory
@@ -14,6 +16,9 @@ type Factory interface {
 	Secrets() SecretStore
 	Policies() PolicyStore
 	PolicyAudits() PolicyAuditStore
+	// Transaction runs fn in a transaction, the stores returned by txFactory are bound to the
+	// transaction, which is committed if fn returns nil, otherwise rolled back.
+	Transaction(ctx context.Context, fn func(txFactory Factory) error) error
 	Close() error
 }
 
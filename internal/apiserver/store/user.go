@@ -11,6 +11,16 @@ import (
 	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
 )
 
+// User status stored in v1.User.Status.
+const (
+	// UserStatusDeleted is the status of soft deleted users, which are invisible to Get and List.
+	UserStatusDeleted = 0
+	// UserStatusActive is the status of users who are able to log in.
+	UserStatusActive = 1
+	// UserStatusDisabled is the status of users who are temporarily not allowed to log in.
+	UserStatusDisabled = 2
+)
+
 // UserStore defines the user storage interface.
 type UserStore interface {
 	Create(ctx context.Context, user *v1.User, opts metav1.CreateOptions) error
@@ -18,5 +28,11 @@ type UserStore interface {
 	Delete(ctx context.Context, username string, opts metav1.DeleteOptions) error
 	DeleteCollection(ctx context.Context, usernames []string, opts metav1.DeleteOptions) error
 	Get(ctx context.Context, username string, opts metav1.GetOptions) (*v1.User, error)
+	// GetWithTenant returns the user and its tenant whatever the tenant of ctx, the users of the
+	// default tenant have an empty tenant.
+	GetWithTenant(ctx context.Context, username string, opts metav1.GetOptions) (*v1.User, string, error)
 	List(ctx context.Context, opts metav1.ListOptions) (*v1.UserList, error)
+	Restore(ctx context.Context, username string, opts metav1.UpdateOptions) error
+	// RecordLogin records a successful login of the user, only the login time and count are written.
+	RecordLogin(ctx context.Context, username string, opts metav1.UpdateOptions) error
 }
@@ -0,0 +1,55 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package apiserver
+
+import (
+	"github.com/gin-gonic/gin"
+	v1 "github.com/marmotedu/api/apiserver/v1"
+	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
+
+	"github.com/marmotedu/iam/internal/apiserver/store"
+	"github.com/marmotedu/iam/internal/pkg/middleware"
+)
+
+// authUserKey defines the key in gin context which holds the authenticated user of the request.
+const authUserKey = "authUser"
+
+// authUser is the authenticated user of the request and its tenant.
+type authUser struct {
+	user     *v1.User
+	tenantID string
+}
+
+// loadAuthUser returns the user and its tenant. The user is looked up once per request, the
+// authentication and the tenant middlewares share it through the gin context.
+func loadAuthUser(c *gin.Context, username string) (*authUser, error) {
+	if v, ok := c.Get(authUserKey); ok {
+		if u, ok := v.(*authUser); ok && u.user.Name == username {
+			return u, nil
+		}
+	}
+
+	user, tenantID, err := store.Client().Users().GetWithTenant(c, username, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	u := &authUser{user: user, tenantID: tenantID}
+	c.Set(authUserKey, u)
+
+	return u, nil
+}
+
+// identifyTenant returns the tenant of the authenticated user and whether the user is an administrator.
+// It must run after the authentication middleware which sets the username in the gin context. The users
+// who are not active anymore are rejected.
+func identifyTenant(c *gin.Context) (string, bool, error) {
+	u, err := activeUser(c, c.GetString(middleware.UsernameKey))
+	if err != nil {
+		return "", false, err
+	}
+
+	return u.tenantID, u.user.IsAdmin == 1, nil
+}
@@ -0,0 +1,60 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package apiserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	v1 "github.com/marmotedu/api/apiserver/v1"
+	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/marmotedu/iam/internal/apiserver/store"
+	"github.com/marmotedu/iam/internal/pkg/middleware"
+)
+
+func TestIdentifyTenant(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFactory := store.NewMockFactory(ctrl)
+	mockUserStore := store.NewMockUserStore(ctrl)
+	mockFactory.EXPECT().Users().Return(mockUserStore).AnyTimes()
+	// the user is looked up once per request
+	mockUserStore.EXPECT().GetWithTenant(gomock.Any(), gomock.Eq("colin"), gomock.Any()).
+		Return(&v1.User{ObjectMeta: metav1.ObjectMeta{Name: "colin"}, Status: store.UserStatusActive}, "acme", nil).
+		Times(2)
+
+	previous := store.Client()
+	store.SetClient(mockFactory)
+	defer store.SetClient(previous)
+
+	var tenantID string
+	r := gin.New()
+	r.GET("/", func(c *gin.Context) {
+		c.Set(middleware.UsernameKey, "colin")
+	}, middleware.Tenant(identifyTenant), func(c *gin.Context) {
+		tenantID = middleware.TenantIDFromContext(c)
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "acme", tenantID)
+
+	// colin isn't an administrator, so he can't act on another tenant
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(middleware.XTenantIDKey, "other")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
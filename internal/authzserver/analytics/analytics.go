@@ -10,7 +10,7 @@ import (
 	"sync/atomic"
 	"time"
 
-	"github.com/vmihailenco/msgpack/v5"
+	"github.com/ory/ladon"
 
 	"github.com/marmotedu/iam/pkg/log"
 	"github.com/marmotedu/iam/pkg/storage"
@@ -26,12 +26,17 @@ const (
 type AnalyticsRecord struct {
 	TimeStamp  int64     `json:"timestamp"`
 	Username   string    `json:"username"`
+	RequestID  string    `json:"requestID"`
+	ClientIP   string    `json:"clientIP"`
 	Effect     string    `json:"effect"`
 	Conclusion string    `json:"conclusion"`
 	Request    string    `json:"request"`
 	Policies   string    `json:"policies"`
 	Deciders   string    `json:"deciders"`
 	ExpireAt   time.Time `json:"expireAt"   bson:"expireAt"`
+
+	// ClaimedClientIP is the ip of its client passed by the caller, which is not verified.
+	ClaimedClientIP string `json:"claimedClientIP,omitempty"`
 }
 
 var analytics *Analytics
@@ -56,15 +61,31 @@ type Analytics struct {
 	recordsChan                chan *AnalyticsRecord
 	workerBufferSize           uint64
 	recordsBufferFlushInterval uint64
+	encoding                   string
+	maxListLength              int64
+	trimInterval               time.Duration
+	allowSampleRate            uint64
+	allowCount                 uint64
+	stopCh                     chan struct{}
 	shouldStop                 uint32
 	poolWg                     sync.WaitGroup
+	// flushChans is used to ask each worker to flush its buffer, the worker closes
+	// the received channel when done.
+	flushChans []chan chan struct{}
 }
 
 // NewAnalytics returns a new analytics instance.
 func NewAnalytics(options *AnalyticsOptions, store storage.AnalyticsHandler) *Analytics {
 	ps := options.PoolSize
+	if ps < 1 {
+		ps = 1
+	}
 	recordsBufferSize := options.RecordsBufferSize
 	workerBufferSize := recordsBufferSize / uint64(ps)
+	// make sure every worker buffers at least one record before flushing
+	if workerBufferSize < 1 {
+		workerBufferSize = 1
+	}
 	log.Debug("Analytics pool worker buffer size", log.Uint64("workerBufferSize", workerBufferSize))
 
 	recordsChan := make(chan *AnalyticsRecord, recordsBufferSize)
@@ -75,6 +96,10 @@ func NewAnalytics(options *AnalyticsOptions, store storage.AnalyticsHandler) *An
 		recordsChan:                recordsChan,
 		workerBufferSize:           workerBufferSize,
 		recordsBufferFlushInterval: options.FlushInterval,
+		encoding:                   options.Encoding,
+		maxListLength:              options.MaxListLength,
+		trimInterval:               options.TrimInterval,
+		allowSampleRate:            options.AllowSampleRate,
 	}
 
 	return analytics
@@ -92,9 +117,46 @@ func (r *Analytics) Start() {
 
 	// start worker pool
 	atomic.SwapUint32(&r.shouldStop, 0)
+	r.flushChans = make([]chan chan struct{}, r.poolSize)
 	for i := 0; i < r.poolSize; i++ {
+		r.flushChans[i] = make(chan chan struct{})
 		r.poolWg.Add(1)
-		go r.recordWorker()
+		go r.recordWorker(r.flushChans[i])
+	}
+
+	r.stopCh = make(chan struct{})
+	if r.maxListLength > 0 {
+		r.poolWg.Add(1)
+		go r.trimWorker()
+	}
+}
+
+// Flush asks all workers to send the records they hold to the store, and
+// returns when done. The records queued before Flush is called are included.
+// It returns early if the analytics service is stopped meanwhile, the workers
+// then send the records they hold before exiting.
+func (r *Analytics) Flush() {
+	if atomic.LoadUint32(&r.shouldStop) > 0 {
+		return
+	}
+
+	dones := make([]chan struct{}, 0, len(r.flushChans))
+	for _, flushChan := range r.flushChans {
+		done := make(chan struct{})
+		select {
+		case flushChan <- done:
+			dones = append(dones, done)
+		case <-r.stopCh:
+			return
+		}
+	}
+
+	for _, done := range dones {
+		select {
+		case <-done:
+		case <-r.stopCh:
+			return
+		}
 	}
 }
 
@@ -105,18 +167,29 @@ func (r *Analytics) Stop() {
 
 	// close channel to stop workers
 	close(r.recordsChan)
+	close(r.stopCh)
 
 	// wait for all workers to be done
 	r.poolWg.Wait()
 }
 
 // RecordHit will store an AnalyticsRecord in Redis.
+// It does nothing on a nil instance, which is the case when analytics is disabled.
+// Only 1 in allowSampleRate allowed requests are stored, denied requests are always stored.
 func (r *Analytics) RecordHit(record *AnalyticsRecord) error {
+	if r == nil {
+		return nil
+	}
+
 	// check if we should stop sending records 1st
 	if atomic.LoadUint32(&r.shouldStop) > 0 {
 		return nil
 	}
 
+	if !r.sampled(record) {
+		return nil
+	}
+
 	// just send record to channel consumed by pool of workers
 	// leave all data crunching and Redis I/O work for pool workers
 	r.recordsChan <- record
@@ -124,7 +197,16 @@ func (r *Analytics) RecordHit(record *AnalyticsRecord) error {
 	return nil
 }
 
-func (r *Analytics) recordWorker() {
+// sampled reports whether the record should be stored, it never drops a denied request.
+func (r *Analytics) sampled(record *AnalyticsRecord) bool {
+	if r.allowSampleRate <= 1 || record.Effect != ladon.AllowAccess {
+		return true
+	}
+
+	return (atomic.AddUint64(&r.allowCount, 1)-1)%r.allowSampleRate == 0
+}
+
+func (r *Analytics) recordWorker(flushChan chan chan struct{}) {
 	defer r.poolWg.Done()
 
 	// this is buffer to send one pipelined command to redis
@@ -140,22 +222,29 @@ func (r *Analytics) recordWorker() {
 			// check if channel was closed and it is time to exit from worker
 			if !ok {
 				// send what is left in buffer
-				r.store.AppendToSetPipelined(analyticsKeyName, recordsBuffer)
+				r.sendRecords(recordsBuffer)
 
 				return
 			}
 
 			// we have new record - prepare it and add to buffer
-
-			if encoded, err := msgpack.Marshal(record); err != nil {
-				log.Errorf("Error encoding analytics data: %s", err.Error())
-			} else {
-				recordsBuffer = append(recordsBuffer, encoded)
-			}
+			recordsBuffer = r.appendRecord(recordsBuffer, record)
 
 			// identify that buffer is ready to be sent
 			readyToSend = uint64(len(recordsBuffer)) == r.workerBufferSize
 
+		case done := <-flushChan:
+			// take the queued records, then send everything we have
+			recordsBuffer = r.drainRecords(recordsBuffer)
+			if len(recordsBuffer) > 0 {
+				r.sendRecords(recordsBuffer)
+				recordsBuffer = recordsBuffer[:0]
+				lastSentTS = time.Now()
+			}
+			close(done)
+
+			continue
+
 		case <-time.After(time.Duration(r.recordsBufferFlushInterval) * time.Millisecond):
 			// nothing was received for that period of time
 			// anyways send whatever we have, don't hold data too long in buffer
@@ -164,13 +253,83 @@ func (r *Analytics) recordWorker() {
 
 		// send data to Redis and reset buffer
 		if len(recordsBuffer) > 0 && (readyToSend || time.Since(lastSentTS) >= recordsBufferForcedFlushInterval) {
-			r.store.AppendToSetPipelined(analyticsKeyName, recordsBuffer)
+			r.sendRecords(recordsBuffer)
 			recordsBuffer = recordsBuffer[:0]
 			lastSentTS = time.Now()
 		}
 	}
 }
 
+// sendRecords writes the buffered records to redis, records which failed to be
+// written are dropped so the worker doesn't block on an unavailable redis.
+func (r *Analytics) sendRecords(records [][]byte) {
+	if err := r.store.AppendToSetPipelined(analyticsKeyName, records); err != nil {
+		if storage.IsUnavailable(err) {
+			log.Warnf("Redis is unavailable, dropped %d analytics records: %s", len(records), err.Error())
+		} else {
+			log.Errorf("Failed to store %d analytics records: %s", len(records), err.Error())
+		}
+		recordsWritten.WithLabelValues(resultDropped).Add(float64(len(records)))
+
+		return
+	}
+
+	recordsWritten.WithLabelValues(resultStored).Add(float64(len(records)))
+}
+
+// trimWorker caps the analytics records in redis periodically, so a stalled
+// iam-pump doesn't exhaust the memory of redis.
+func (r *Analytics) trimWorker() {
+	defer r.poolWg.Done()
+
+	ticker := time.NewTicker(r.trimInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			trimmed, err := r.store.TrimList(analyticsKeyName, r.maxListLength)
+			if err != nil {
+				log.Errorf("Failed to trim analytics records: %s", err.Error())
+
+				continue
+			}
+
+			if trimmed > 0 {
+				log.Warnf("Dropped %d analytics records exceeding the max list length %d", trimmed, r.maxListLength)
+			}
+		case <-r.stopCh:
+			return
+		}
+	}
+}
+
+func (r *Analytics) appendRecord(recordsBuffer [][]byte, record *AnalyticsRecord) [][]byte {
+	encoded, err := record.Encode(r.encoding)
+	if err != nil {
+		log.Errorf("Error encoding analytics data: %s", err.Error())
+
+		return recordsBuffer
+	}
+
+	return append(recordsBuffer, encoded)
+}
+
+// drainRecords moves the records queued in the channel to the buffer without blocking.
+func (r *Analytics) drainRecords(recordsBuffer [][]byte) [][]byte {
+	for {
+		select {
+		case record, ok := <-r.recordsChan:
+			if !ok {
+				return recordsBuffer
+			}
+			recordsBuffer = r.appendRecord(recordsBuffer, record)
+		default:
+			return recordsBuffer
+		}
+	}
+}
+
 // DurationToMillisecond convert time duration type to float64.
 func DurationToMillisecond(d time.Duration) float64 {
 	return float64(d) / 1e6
@@ -19,6 +19,11 @@ type AnalyticsOptions struct {
 	StorageExpirationTime   time.Duration `json:"storage-expiration-time"   mapstructure:"storage-expiration-time"`
 	Enable                  bool          `json:"enable"                    mapstructure:"enable"`
 	EnableDetailedRecording bool          `json:"enable-detailed-recording" mapstructure:"enable-detailed-recording"`
+	Encoding                string        `json:"encoding"                  mapstructure:"encoding"`
+	MaxPipelineSize         int           `json:"max-pipeline-size"         mapstructure:"max-pipeline-size"`
+	MaxListLength           int64         `json:"max-list-length"           mapstructure:"max-list-length"`
+	TrimInterval            time.Duration `json:"trim-interval"             mapstructure:"trim-interval"`
+	AllowSampleRate         uint64        `json:"allow-sample-rate"         mapstructure:"allow-sample-rate"`
 }
 
 // NewAnalyticsOptions creates a AnalyticsOptions object with default parameters.
@@ -30,6 +35,11 @@ func NewAnalyticsOptions() *AnalyticsOptions {
 		FlushInterval:           200,
 		EnableDetailedRecording: true,
 		StorageExpirationTime:   time.Duration(24) * time.Hour,
+		Encoding:                EncodingMsgpack,
+		MaxPipelineSize:         500,
+		MaxListLength:           0,
+		TrimInterval:            time.Minute,
+		AllowSampleRate:         1,
 	}
 }
 
@@ -45,6 +55,32 @@ func (o *AnalyticsOptions) Validate() []error {
 		errors = append(errors, fmt.Errorf("--analytics.flush-interval %v must be between 1 and 1000", o.FlushInterval))
 	}
 
+	if o.Encoding != EncodingMsgpack && o.Encoding != EncodingJSON {
+		errors = append(errors, fmt.Errorf("--analytics.encoding %q must be %s or %s",
+			o.Encoding, EncodingMsgpack, EncodingJSON))
+	}
+
+	if o.Enable && o.MaxPipelineSize < 1 {
+		errors = append(errors, fmt.Errorf("--analytics.max-pipeline-size %v must be greater than 0", o.MaxPipelineSize))
+	}
+
+	if o.Enable && o.MaxListLength < 0 {
+		errors = append(errors, fmt.Errorf("--analytics.max-list-length %v cannot be negative", o.MaxListLength))
+	}
+
+	if o.Enable && o.MaxListLength > 0 && o.TrimInterval <= 0 {
+		errors = append(errors, fmt.Errorf("--analytics.trim-interval %v must be greater than 0", o.TrimInterval))
+	}
+
+	if o.Enable && o.PoolSize < 1 {
+		errors = append(errors, fmt.Errorf("--analytics.pool-size %v must be greater than 0", o.PoolSize))
+	}
+
+	if o.Enable && o.PoolSize > 0 && o.RecordsBufferSize < uint64(o.PoolSize) {
+		errors = append(errors, fmt.Errorf("--analytics.records-buffer-size %v must be greater than or equal to "+
+			"--analytics.pool-size %v", o.RecordsBufferSize, o.PoolSize))
+	}
+
 	return errors
 }
 
@@ -70,4 +106,22 @@ func (o *AnalyticsOptions) AddFlags(fs *pflag.FlagSet) {
 	fs.DurationVar(&o.StorageExpirationTime, "analytics.storage-expiration-time", o.StorageExpirationTime, ""+
 		"Set to a value larger than the Pump's purge_delay. "+
 		"This allows the analytics data to exist long enough in Redis to be processed by the Pump.")
+
+	fs.StringVar(&o.Encoding, "analytics.encoding", o.Encoding, ""+
+		"The encoding of the analytics records stored in Redis, msgpack or json. "+
+		"Must be the same as the analytics-encoding of iam-pump.")
+
+	fs.IntVar(&o.MaxPipelineSize, "analytics.max-pipeline-size", o.MaxPipelineSize, ""+
+		"The maximum number of records pushed to Redis in one pipeline, larger buffers are split into batches.")
+
+	fs.Int64Var(&o.MaxListLength, "analytics.max-list-length", o.MaxListLength, ""+
+		"The maximum number of records kept in Redis, the oldest records are dropped when iam-pump falls behind. "+
+		"0 means unlimited.")
+
+	fs.DurationVar(&o.TrimInterval, "analytics.trim-interval", o.TrimInterval, ""+
+		"The interval to cap the records in Redis to --analytics.max-list-length.")
+
+	fs.Uint64Var(&o.AllowSampleRate, "analytics.allow-sample-rate", o.AllowSampleRate, ""+
+		"Record 1 in N allowed authorization requests, denied requests are always recorded. "+
+		"0 or 1 means recording all the allowed requests.")
 }
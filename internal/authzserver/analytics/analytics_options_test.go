@@ -0,0 +1,57 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package analytics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnalyticsOptions_Validate(t *testing.T) {
+	tests := []struct {
+		name              string
+		enable            bool
+		poolSize          int
+		recordsBufferSize uint64
+		wantErrs          int
+	}{
+		{name: "default", enable: true, poolSize: 50, recordsBufferSize: 1000},
+		{name: "buffer equals pool size", enable: true, poolSize: 1, recordsBufferSize: 1},
+		{name: "zero pool size", enable: true, poolSize: 0, recordsBufferSize: 1000, wantErrs: 1},
+		{name: "negative pool size", enable: true, poolSize: -1, recordsBufferSize: 1000, wantErrs: 1},
+		{name: "buffer smaller than pool size", enable: true, poolSize: 50, recordsBufferSize: 49, wantErrs: 1},
+		{name: "disabled", enable: false, poolSize: 0, recordsBufferSize: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := NewAnalyticsOptions()
+			o.Enable = tt.enable
+			o.PoolSize = tt.poolSize
+			o.RecordsBufferSize = tt.recordsBufferSize
+
+			assert.Len(t, o.Validate(), tt.wantErrs)
+		})
+	}
+}
+
+func TestNewAnalytics_WorkerBufferSize(t *testing.T) {
+	tests := []struct {
+		poolSize          int
+		recordsBufferSize uint64
+		want              uint64
+	}{
+		{poolSize: 50, recordsBufferSize: 1000, want: 20},
+		{poolSize: 50, recordsBufferSize: 10, want: 1},
+		{poolSize: 0, recordsBufferSize: 10, want: 10},
+	}
+
+	for _, tt := range tests {
+		a := NewAnalytics(&AnalyticsOptions{PoolSize: tt.poolSize, RecordsBufferSize: tt.recordsBufferSize}, nil)
+		assert.Equal(t, tt.want, a.workerBufferSize)
+		assert.GreaterOrEqual(t, a.poolSize, 1)
+	}
+}
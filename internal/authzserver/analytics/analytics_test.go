@@ -0,0 +1,155 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package analytics
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/marmotedu/component-base/pkg/json"
+	"github.com/ory/ladon"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeAnalyticsStore struct {
+	mu      sync.Mutex
+	records [][]byte
+	trims   int
+}
+
+func (s *fakeAnalyticsStore) Connect() bool { return true }
+
+func (s *fakeAnalyticsStore) AppendToSetPipelined(key string, values [][]byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records = append(s.records, values...)
+
+	return nil
+}
+
+func (s *fakeAnalyticsStore) GetAndDeleteSet(string) []interface{} { return nil }
+
+func (s *fakeAnalyticsStore) SetExp(string, time.Duration) error { return nil }
+
+func (s *fakeAnalyticsStore) GetExp(string) (int64, error) { return 0, nil }
+
+func (s *fakeAnalyticsStore) TrimList(key string, maxLength int64) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.trims++
+	if int64(len(s.records)) <= maxLength {
+		return 0, nil
+	}
+
+	trimmed := int64(len(s.records)) - maxLength
+	s.records = s.records[trimmed:]
+
+	return trimmed, nil
+}
+
+func (s *fakeAnalyticsStore) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.records)
+}
+
+func TestAnalytics_Flush(t *testing.T) {
+	store := &fakeAnalyticsStore{}
+	a := NewAnalytics(&AnalyticsOptions{PoolSize: 4, RecordsBufferSize: 1000, FlushInterval: 1000}, store)
+	a.Start()
+	defer a.Stop()
+
+	const n = 100
+	for i := 0; i < n; i++ {
+		assert.Nil(t, a.RecordHit(&AnalyticsRecord{Username: "colin"}))
+	}
+
+	a.Flush()
+	assert.Equal(t, n, store.count())
+
+	// flushing an empty buffer is a no-op
+	a.Flush()
+	assert.Equal(t, n, store.count())
+}
+
+func TestAnalytics_FlushConcurrentStop(t *testing.T) {
+	a := NewAnalytics(&AnalyticsOptions{PoolSize: 2, RecordsBufferSize: 10, FlushInterval: 1000}, &fakeAnalyticsStore{})
+	a.Start()
+	a.Stop()
+	// Flush checked shouldStop before Stop was called, the workers are gone.
+	atomic.StoreUint32(&a.shouldStop, 0)
+
+	done := make(chan struct{})
+	go func() {
+		a.Flush()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Flush is blocked after Stop")
+	}
+}
+
+func TestAnalytics_TrimWorker(t *testing.T) {
+	store := &fakeAnalyticsStore{}
+	a := NewAnalytics(&AnalyticsOptions{
+		PoolSize:          1,
+		RecordsBufferSize: 1000,
+		FlushInterval:     1000,
+		MaxListLength:     10,
+		TrimInterval:      10 * time.Millisecond,
+	}, store)
+	a.Start()
+
+	for i := 0; i < 100; i++ {
+		assert.Nil(t, a.RecordHit(&AnalyticsRecord{Username: "colin"}))
+	}
+	a.Flush()
+
+	assert.Eventually(t, func() bool { return store.count() == 10 }, 5*time.Second, 10*time.Millisecond)
+	a.Stop()
+}
+
+func TestAnalytics_AllowSampleRate(t *testing.T) {
+	store := &fakeAnalyticsStore{}
+	a := NewAnalytics(&AnalyticsOptions{
+		PoolSize:          4,
+		RecordsBufferSize: 1000,
+		FlushInterval:     1000,
+		Encoding:          EncodingJSON,
+		AllowSampleRate:   10,
+	}, store)
+	a.Start()
+	defer a.Stop()
+
+	const n = 1000
+	for i := 0; i < n; i++ {
+		assert.Nil(t, a.RecordHit(&AnalyticsRecord{Username: "colin", Effect: ladon.AllowAccess}))
+		assert.Nil(t, a.RecordHit(&AnalyticsRecord{Username: "colin", Effect: ladon.DenyAccess}))
+	}
+
+	a.Flush()
+
+	allows, denies := 0, 0
+	for _, v := range store.records {
+		record := AnalyticsRecord{}
+		assert.NoError(t, json.Unmarshal(v, &record))
+		if record.Effect == ladon.AllowAccess {
+			allows++
+		} else {
+			denies++
+		}
+	}
+
+	assert.Equal(t, n/10, allows)
+	assert.Equal(t, n, denies)
+}
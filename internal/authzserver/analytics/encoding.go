@@ -0,0 +1,31 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package analytics
+
+import (
+	"fmt"
+
+	"github.com/marmotedu/component-base/pkg/json"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Supported encodings of the analytics records stored in redis.
+// iam-pump must be configured with the same encoding.
+const (
+	EncodingMsgpack = "msgpack"
+	EncodingJSON    = "json"
+)
+
+// Encode encodes the analytics record with the given encoding.
+func (a *AnalyticsRecord) Encode(encoding string) ([]byte, error) {
+	switch encoding {
+	case EncodingMsgpack, "":
+		return msgpack.Marshal(a)
+	case EncodingJSON:
+		return json.Marshal(a)
+	default:
+		return nil, fmt.Errorf("unsupported analytics encoding: %s", encoding)
+	}
+}
@@ -0,0 +1,25 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package analytics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/marmotedu/iam/internal/pkg/metrics"
+)
+
+// the results of writing the analytics records to redis.
+const (
+	resultStored  = "stored"
+	resultDropped = "dropped"
+)
+
+var recordsWritten = metrics.Register(prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "iam_analytics_records_total",
+		Help: "The number of the analytics records written to redis, by result.",
+	},
+	[]string{"result"},
+)).(*prometheus.CounterVec)
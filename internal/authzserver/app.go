@@ -29,6 +29,7 @@ func NewApp(basename string) *app.App {
 		app.WithOptions(opts),
 		app.WithDescription(commandDesc),
 		app.WithDefaultValidArgs(),
+		app.WithCompletion(),
 		app.WithRunFunc(run(opts)),
 	)
 
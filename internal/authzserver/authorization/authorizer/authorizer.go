@@ -14,7 +14,7 @@ import (
 	"github.com/ory/ladon"
 
 	"github.com/marmotedu/iam/internal/authzserver/analytics"
-	"github.com/marmotedu/iam/internal/authzserver/authorization"
+	"github.com/marmotedu/iam/internal/pkg/authorization"
 )
 
 // PolicyGetter defines function to get policy for a given user.
@@ -81,37 +81,47 @@ func (auth *Authorization) LogRejectedAccessRequest(r *ladon.Request, p ladon.Po
 		conclusion = "no policy allowed access"
 	}
 
-	rstring, pstring, dstring := convertToString(r, p, d)
-	record := analytics.AnalyticsRecord{
-		TimeStamp:  time.Now().Unix(),
-		Username:   r.Context["username"].(string),
-		Effect:     ladon.DenyAccess,
-		Conclusion: conclusion,
-		Request:    rstring,
-		Policies:   pstring,
-		Deciders:   dstring,
-	}
-
-	record.SetExpiry(0)
-	_ = analytics.GetAnalytics().RecordHit(&record)
+	record := newAnalyticsRecord(r, p, d, ladon.DenyAccess, conclusion)
+	_ = analytics.GetAnalytics().RecordHit(record)
 }
 
 // LogGrantedAccessRequest write granted subject access to redis.
 func (auth *Authorization) LogGrantedAccessRequest(r *ladon.Request, p ladon.Policies, d ladon.Policies) {
 	conclusion := fmt.Sprintf("policies %s allow access", joinPoliciesNames(d))
+	record := newAnalyticsRecord(r, p, d, ladon.AllowAccess, conclusion)
+	_ = analytics.GetAnalytics().RecordHit(record)
+}
+
+func newAnalyticsRecord(r *ladon.Request, p ladon.Policies, d ladon.Policies,
+	effect, conclusion string) *analytics.AnalyticsRecord {
 	rstring, pstring, dstring := convertToString(r, p, d)
-	record := analytics.AnalyticsRecord{
+	record := &analytics.AnalyticsRecord{
 		TimeStamp:  time.Now().Unix(),
-		Username:   r.Context["username"].(string),
-		Effect:     ladon.AllowAccess,
+		Username:   contextString(r, "username"),
+		RequestID:  contextString(r, "requestID"),
+		ClientIP:   contextString(r, "clientIP"),
+		Effect:     effect,
 		Conclusion: conclusion,
 		Request:    rstring,
 		Policies:   pstring,
 		Deciders:   dstring,
 	}
+	// the ip passed by the caller of iam-authz-server is not verified, so it is kept apart from ClientIP.
+	record.ClaimedClientIP = contextString(r, "claimedClientIP")
 
 	record.SetExpiry(0)
-	_ = analytics.GetAnalytics().RecordHit(&record)
+
+	return record
+}
+
+// contextString returns the string value of the key in the request context,
+// returns an empty string if the key is absent or is not a string.
+func contextString(r *ladon.Request, key string) string {
+	if v, ok := r.Context[key].(string); ok {
+		return v
+	}
+
+	return ""
 }
 
 func joinPoliciesNames(policies ladon.Policies) string {
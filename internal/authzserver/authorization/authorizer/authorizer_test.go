@@ -0,0 +1,44 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package authorizer
+
+import (
+	"testing"
+
+	"github.com/ory/ladon"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/marmotedu/iam/internal/authzserver/analytics"
+)
+
+func TestAuthorization_LogAccessRequestWithoutUsername(t *testing.T) {
+	analytics.NewAnalytics(&analytics.AnalyticsOptions{PoolSize: 1, RecordsBufferSize: 10}, nil)
+
+	auth := &Authorization{}
+	r := &ladon.Request{Resource: "resources:articles:ladon-introduction", Action: "delete"}
+
+	assert.NotPanics(t, func() {
+		auth.LogRejectedAccessRequest(r, nil, nil)
+		auth.LogGrantedAccessRequest(r, nil, nil)
+	})
+}
+
+func Test_newAnalyticsRecord(t *testing.T) {
+	r := &ladon.Request{
+		Resource: "resources:articles:ladon-introduction",
+		Action:   "delete",
+		Context:  ladon.Context{"username": "colin", "requestID": "2d6c3a8b-3c1e-4b5e-9f0e-6b1d2a3c4d5e"},
+	}
+
+	record := newAnalyticsRecord(r, nil, nil, ladon.AllowAccess, "policies allow access")
+	assert.Equal(t, "colin", record.Username)
+	assert.Equal(t, "2d6c3a8b-3c1e-4b5e-9f0e-6b1d2a3c4d5e", record.RequestID)
+	assert.Equal(t, ladon.AllowAccess, record.Effect)
+
+	r.Context = ladon.Context{"username": 1}
+	record = newAnalyticsRecord(r, nil, nil, ladon.DenyAccess, "no policy allowed access")
+	assert.Equal(t, "", record.Username)
+	assert.Equal(t, "", record.RequestID)
+}
@@ -8,43 +8,119 @@ package authorize
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/marmotedu/component-base/pkg/core"
+	"github.com/marmotedu/component-base/pkg/validation/field"
 	"github.com/marmotedu/errors"
 	"github.com/ory/ladon"
+	"go.opentelemetry.io/otel/attribute"
 
-	"github.com/marmotedu/iam/internal/authzserver/authorization"
 	"github.com/marmotedu/iam/internal/authzserver/authorization/authorizer"
+	"github.com/marmotedu/iam/internal/pkg/authorization"
 	"github.com/marmotedu/iam/internal/pkg/code"
+	"github.com/marmotedu/iam/internal/pkg/middleware"
+	"github.com/marmotedu/iam/internal/pkg/middleware/auth"
+	"github.com/marmotedu/iam/internal/pkg/tracing"
+	"github.com/marmotedu/iam/pkg/log"
 )
 
 // AuthzController create a authorize handler used to handle authorize request.
 type AuthzController struct {
 	store authorizer.PolicyGetter
+	// auth is shared by all the requests, it only keeps the policy getter.
+	auth *authorization.Authorizer
 }
 
 // NewAuthzController creates a authorize handler.
 func NewAuthzController(store authorizer.PolicyGetter) *AuthzController {
 	return &AuthzController{
 		store: store,
+		auth:  authorization.NewAuthorizer(authorizer.NewAuthorization(store)),
 	}
 }
 
 // Authorize returns whether a request is allow or deny to access a resource and do some action
 // under specified condition.
 func (a *AuthzController) Authorize(c *gin.Context) {
+	_, span := tracing.Tracer().Start(c.Request.Context(), "authz.Authorize")
+	defer span.End()
+
 	var r ladon.Request
 	if err := c.ShouldBind(&r); err != nil {
+		span.RecordError(err)
 		core.WriteResponse(c, errors.WithCode(code.ErrBind, err.Error()), nil)
 
 		return
 	}
 
-	auth := authorization.NewAuthorizer(authorizer.NewAuthorization(a.store))
+	if errs := validateRequest(&r); len(errs) != 0 {
+		writeValidationError(c, errs)
+
+		return
+	}
+
 	if r.Context == nil {
 		r.Context = ladon.Context{}
 	}
 
+	// the span and the audit records carry the same request id, so they are correlatable.
+	requestID := middleware.GetRequestIDFromContext(c)
+	span.SetAttributes(
+		attribute.String("authz.subject", r.Subject),
+		attribute.String("authz.action", r.Action),
+		attribute.String("authz.resource", r.Resource),
+		attribute.String("request.id", requestID),
+	)
+
 	r.Context["username"] = c.GetString("username")
-	rsp := auth.Authorize(&r)
+	r.Context["requestID"] = requestID
+	// the ip passed by the caller can't be trusted, it is only kept aside of the ip of the caller.
+	if ip, ok := r.Context["clientIP"].(string); ok && ip != "" {
+		r.Context["claimedClientIP"] = ip
+	}
+	r.Context["clientIP"] = c.ClientIP()
+
+	// the secret's scope restricts the access further than the user's policies.
+	var scope authorization.Scope
+	if v, ok := c.Get(auth.ScopeKey); ok {
+		scope = v.(*auth.Scope)
+	}
+
+	rsp := a.auth.AuthorizeInScope(&r, scope)
+	span.SetAttributes(attribute.Bool("authz.denied", rsp.Denied))
 
 	core.WriteResponse(c, nil, rsp)
 }
+
+// validateRequest validates the fields an authorization request must have, without
+// them the request would be authorized against empty fields and always denied.
+func validateRequest(r *ladon.Request) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if r.Subject == "" {
+		allErrs = append(allErrs, field.Required(field.NewPath("subject"), "subject must be specified"))
+	}
+
+	if r.Resource == "" {
+		allErrs = append(allErrs, field.Required(field.NewPath("resource"), "resource must be specified"))
+	}
+
+	if r.Action == "" {
+		allErrs = append(allErrs, field.Required(field.NewPath("action"), "action must be specified"))
+	}
+
+	return allErrs
+}
+
+// writeValidationError writes a code.ErrValidation response. Unlike
+// core.WriteResponse, the message carries the invalid fields, so integrators
+// know how to fix the request.
+func writeValidationError(c *gin.Context, errs field.ErrorList) {
+	err := errors.WithCode(code.ErrValidation, errs.ToAggregate().Error())
+	log.L(c).Errorf("%#+v", err)
+
+	coder := errors.ParseCoder(err)
+	c.JSON(coder.HTTPStatus(), core.ErrResponse{
+		Code:      coder.Code(),
+		Message:   coder.String() + ": " + errs.ToAggregate().Error(),
+		Reference: coder.Reference(),
+	})
+}
@@ -0,0 +1,203 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package authorize
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	authzv1 "github.com/marmotedu/api/authz/v1"
+	"github.com/marmotedu/component-base/pkg/json"
+	"github.com/ory/ladon"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/marmotedu/iam/internal/pkg/code"
+	"github.com/marmotedu/iam/internal/pkg/middleware"
+	"github.com/marmotedu/iam/internal/pkg/middleware/auth"
+)
+
+type fakePolicyGetter struct{}
+
+func (fakePolicyGetter) GetPolicy(key string) ([]*ladon.DefaultPolicy, error) {
+	return nil, nil
+}
+
+func authorize(t *testing.T, body string) (int, map[string]interface{}) {
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/authz", bytes.NewBufferString(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	NewAuthzController(fakePolicyGetter{}).Authorize(c)
+
+	var resp map[string]interface{}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &resp))
+
+	return w.Code, resp
+}
+
+func TestAuthzController_Authorize_Validation(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  string
+		field string
+	}{
+		{"missing subject", `{"resource":"resources:articles:ladon","action":"delete"}`, "subject"},
+		{"missing resource", `{"subject":"users:peter","action":"delete"}`, "resource"},
+		{"missing action", `{"subject":"users:peter","resource":"resources:articles:ladon"}`, "action"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, resp := authorize(t, tt.body)
+			assert.Equal(t, http.StatusBadRequest, status)
+			assert.Equal(t, float64(code.ErrValidation), resp["code"])
+			assert.Contains(t, resp["message"], tt.field+": Required value")
+		})
+	}
+}
+
+func TestValidateRequest(t *testing.T) {
+	errs := validateRequest(&ladon.Request{})
+	assert.Len(t, errs, 3)
+	assert.Equal(t, "subject", errs[0].Field)
+	assert.Equal(t, "resource", errs[1].Field)
+	assert.Equal(t, "action", errs[2].Field)
+
+	assert.Empty(t, validateRequest(&ladon.Request{
+		Subject:  "users:peter",
+		Resource: "resources:articles:ladon",
+		Action:   "delete",
+	}))
+}
+
+// allowPolicyGetter returns a policy which allows users:peter to do anything.
+type allowPolicyGetter struct{}
+
+func (allowPolicyGetter) GetPolicy(key string) ([]*ladon.DefaultPolicy, error) {
+	return []*ladon.DefaultPolicy{{
+		ID:        "allow-peter",
+		Subjects:  []string{"users:peter"},
+		Resources: []string{"<.*>"},
+		Actions:   []string{"<.*>"},
+		Effect:    ladon.AllowAccess,
+	}}, nil
+}
+
+func TestAuthzController_Authorize_Scope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	scope := &auth.Scope{
+		Resources: []string{"resources:articles:<.*>"},
+		Actions:   []string{"get"},
+	}
+	controller := NewAuthzController(allowPolicyGetter{})
+
+	tests := []struct {
+		name    string
+		scope   *auth.Scope
+		body    string
+		allowed bool
+	}{
+		{
+			name:    "unscoped",
+			body:    `{"subject":"users:peter","resource":"resources:printer","action":"delete"}`,
+			allowed: true,
+		},
+		{
+			name:    "in scope",
+			scope:   scope,
+			body:    `{"subject":"users:peter","resource":"resources:articles:ladon","action":"get"}`,
+			allowed: true,
+		},
+		{
+			name:  "out of scope action",
+			scope: scope,
+			body:  `{"subject":"users:peter","resource":"resources:articles:ladon","action":"delete"}`,
+		},
+		{
+			name:  "out of scope resource",
+			scope: scope,
+			body:  `{"subject":"users:peter","resource":"resources:printer","action":"get"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/v1/authz", bytes.NewBufferString(tt.body))
+			c.Request.Header.Set("Content-Type", "application/json")
+			if tt.scope != nil {
+				c.Set(auth.ScopeKey, tt.scope)
+			}
+
+			controller.Authorize(c)
+
+			var resp authzv1.Response
+			assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, tt.allowed, resp.Allowed)
+			assert.Equal(t, !tt.allowed, resp.Denied)
+		})
+	}
+}
+
+func TestAuthzController_Authorize_Span(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp, propagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer func() {
+		otel.SetTracerProvider(tp)
+		otel.SetTextMapPropagator(propagator)
+	}()
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(middleware.RequestID())
+	engine.POST("/v1/authz", NewAuthzController(fakePolicyGetter{}).Authorize)
+
+	body := `{"subject":"users:peter","resource":"resources:articles:ladon","action":"delete"}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/authz", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(middleware.XRequestIDKey, "7a7b9f24-4cae-4b2a-9464-69088b45b904")
+	// the span joins the trace of the caller
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	engine.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "authz.Authorize", spans[0].Name())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spans[0].SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", spans[0].Parent().SpanID().String())
+	assert.Contains(t, spans[0].Attributes(), attribute.String("authz.subject", "users:peter"))
+	assert.Contains(t, spans[0].Attributes(), attribute.String("request.id", "7a7b9f24-4cae-4b2a-9464-69088b45b904"))
+	// there is no policy of the subject
+	assert.Contains(t, spans[0].Attributes(), attribute.Bool("authz.denied", true))
+
+	// the request id generated for the requests without one is recorded too
+	req = httptest.NewRequest(http.MethodPost, "/v1/authz", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+
+	spans = recorder.Ended()
+	require.Len(t, spans, 2)
+	requestID := w.Header().Get(middleware.XRequestIDKey)
+	assert.NotEmpty(t, requestID)
+	assert.Contains(t, spans[1].Attributes(), attribute.String("request.id", requestID))
+}
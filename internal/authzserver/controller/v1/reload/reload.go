@@ -0,0 +1,110 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+// Package reload implements the handler forcing a full reload of the cache.
+package reload
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/marmotedu/component-base/pkg/core"
+	"github.com/marmotedu/errors"
+
+	"github.com/marmotedu/iam/internal/pkg/code"
+	"github.com/marmotedu/iam/internal/pkg/middleware"
+	"github.com/marmotedu/iam/internal/pkg/middleware/auth"
+	"github.com/marmotedu/iam/pkg/log"
+)
+
+// Reloader reloads all the secrets and policies synchronously.
+type Reloader interface {
+	DoReload() error
+}
+
+// Counter returns the number of the secrets and policies loaded.
+type Counter interface {
+	Count() (secrets int, policies int)
+}
+
+// Response is the response of a forced reload.
+type Response struct {
+	Secrets  int `json:"secrets"`
+	Policies int `json:"policies"`
+}
+
+// ReloadController create a reload handler used to force a full reload of the cache.
+type ReloadController struct {
+	reloader    Reloader
+	counter     Counter
+	minInterval time.Duration
+	// admins is the usernames and secret ids allowed to force a reload.
+	admins map[string]bool
+
+	lock       sync.Mutex
+	lastReload time.Time
+}
+
+// NewReloadController creates a reload handler, only the requests authenticated by the users or
+// the secrets in admins may force a reload, and not within minInterval of the previous one.
+func NewReloadController(reloader Reloader, counter Counter, minInterval time.Duration, admins []string) *ReloadController {
+	r := &ReloadController{
+		reloader:    reloader,
+		counter:     counter,
+		minInterval: minInterval,
+		admins:      make(map[string]bool, len(admins)),
+	}
+	for _, admin := range admins {
+		r.admins[admin] = true
+	}
+
+	return r
+}
+
+// Reload reloads all the secrets and policies from iam-apiserver, it's the escape hatch
+// when the cache is suspected to be stale, e.g. a notification was missed.
+func (r *ReloadController) Reload(c *gin.Context) {
+	username := c.GetString(middleware.UsernameKey)
+	if !r.admins[username] && !r.admins[c.GetString(auth.SecretIDKey)] {
+		core.WriteResponse(c, errors.WithCode(code.ErrPermissionDenied,
+			"user %s is not allowed to reload the cache", username), nil)
+
+		return
+	}
+
+	if !r.allow() {
+		core.WriteResponse(c, errors.WithCode(code.ErrTooManyRequests,
+			"the cache can be reloaded once every %s", r.minInterval), nil)
+
+		return
+	}
+
+	log.L(c).Infow("Force reloading secrets and policies", "username", username)
+
+	if err := r.reloader.DoReload(); err != nil {
+		core.WriteResponse(c, errors.WithCode(code.ErrUnknown, err.Error()), nil)
+
+		return
+	}
+
+	secrets, policies := r.counter.Count()
+
+	core.WriteResponse(c, nil, &Response{Secrets: secrets, Policies: policies})
+}
+
+// allow returns whether minInterval elapsed since the previous forced reload.
+func (r *ReloadController) allow() bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	now := time.Now()
+	if !r.lastReload.IsZero() && now.Sub(r.lastReload) < r.minInterval {
+		return false
+	}
+
+	r.lastReload = now
+
+	return true
+}
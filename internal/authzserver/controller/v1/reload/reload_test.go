@@ -0,0 +1,131 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package reload
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/marmotedu/component-base/pkg/json"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/marmotedu/iam/internal/pkg/code"
+	"github.com/marmotedu/iam/internal/pkg/middleware"
+	"github.com/marmotedu/iam/internal/pkg/middleware/auth"
+)
+
+// fakeCache loads the secrets and policies of its source on reload.
+type fakeCache struct {
+	source   [2]int
+	loaded   [2]int
+	failWith error
+}
+
+func (f *fakeCache) DoReload() error {
+	if f.failWith != nil {
+		return f.failWith
+	}
+
+	f.loaded = f.source
+
+	return nil
+}
+
+func (f *fakeCache) Count() (int, int) {
+	return f.loaded[0], f.loaded[1]
+}
+
+func reload(t *testing.T, cache *fakeCache) (int, map[string]interface{}) {
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/v1/reload", authenticate("admin", "secret-admin"),
+		NewReloadController(cache, cache, time.Minute, []string{"admin"}).Reload)
+
+	return request(t, r)
+}
+
+// authenticate sets the user and the secret of the request like the cache authentication.
+func authenticate(username, secretID string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(middleware.UsernameKey, username)
+		c.Set(auth.SecretIDKey, secretID)
+	}
+}
+
+func request(t *testing.T, r *gin.Engine) (int, map[string]interface{}) {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/reload", nil))
+
+	var resp map[string]interface{}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &resp))
+
+	return w.Code, resp
+}
+
+func TestReloadController_Reload(t *testing.T) {
+	cache := &fakeCache{source: [2]int{3, 5}, loaded: [2]int{1, 1}}
+
+	status, resp := reload(t, cache)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, map[string]interface{}{"secrets": float64(3), "policies": float64(5)}, resp)
+	assert.Equal(t, [2]int{3, 5}, cache.loaded)
+}
+
+func TestReloadController_ReloadFailed(t *testing.T) {
+	cache := &fakeCache{source: [2]int{3, 5}, failWith: errors.New("iam-apiserver is unavailable")}
+
+	status, resp := reload(t, cache)
+	assert.Equal(t, http.StatusInternalServerError, status)
+	assert.Equal(t, float64(code.ErrUnknown), resp["code"])
+	assert.Equal(t, [2]int{0, 0}, cache.loaded)
+}
+
+func TestReloadController_RateLimited(t *testing.T) {
+	cache := &fakeCache{source: [2]int{3, 5}}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/v1/reload", authenticate("admin", "secret-admin"),
+		NewReloadController(cache, cache, time.Minute, []string{"admin"}).Reload)
+
+	status, _ := request(t, r)
+	assert.Equal(t, http.StatusOK, status)
+
+	status, resp := request(t, r)
+	assert.Equal(t, http.StatusTooManyRequests, status)
+	assert.Equal(t, float64(code.ErrTooManyRequests), resp["code"])
+}
+
+func TestReloadController_NotAdmin(t *testing.T) {
+	cache := &fakeCache{source: [2]int{3, 5}}
+	controller := NewReloadController(cache, cache, 0, []string{"admin", "secret-ops"})
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/v1/reload", authenticate("colin", "secret-colin"), controller.Reload)
+
+	status, resp := request(t, r)
+	assert.Equal(t, http.StatusForbidden, status)
+	assert.Equal(t, float64(code.ErrPermissionDenied), resp["code"])
+	assert.Equal(t, [2]int{0, 0}, cache.loaded)
+
+	// the administrators are identified by their username or their secret
+	for _, admin := range [][2]string{{"admin", "secret-admin"}, {"colin", "secret-ops"}} {
+		r := gin.New()
+		r.POST("/v1/reload", authenticate(admin[0], admin[1]), controller.Reload)
+
+		status, _ := request(t, r)
+		assert.Equal(t, http.StatusOK, status)
+	}
+	assert.Equal(t, [2]int{3, 5}, cache.loaded)
+}
@@ -10,10 +10,19 @@ import (
 	"github.com/marmotedu/iam/internal/authzserver/load/cache"
 	"github.com/marmotedu/iam/internal/pkg/middleware"
 	"github.com/marmotedu/iam/internal/pkg/middleware/auth"
+	genericoptions "github.com/marmotedu/iam/internal/pkg/options"
+	"github.com/marmotedu/iam/pkg/storage"
 )
 
-func newCacheAuth() middleware.AuthStrategy {
-	return auth.NewCacheStrategy(getSecretFunc())
+func newCacheAuth(rateLimitOptions *genericoptions.RateLimitOptions) middleware.AuthStrategy {
+	strategy := auth.NewCacheStrategy(getSecretFunc())
+	if rateLimitOptions.Enabled() {
+		// the hits beyond the rate don't change the decision, so they are not kept
+		store := &storage.RedisCluster{RollingWindowMaxElements: int64(rateLimitOptions.Rate)}
+		strategy = strategy.WithRateLimiter(auth.NewRollingWindowLimiter(store, rateLimitOptions.Rate, rateLimitOptions.Per))
+	}
+
+	return strategy
 }
 
 func getSecretFunc() func(string) (auth.Secret, error) {
@@ -28,11 +37,19 @@ func getSecretFunc() func(string) (auth.Secret, error) {
 			return auth.Secret{}, err
 		}
 
-		return auth.Secret{
+		ret := auth.Secret{
 			Username: secret.Username,
 			ID:       secret.SecretId,
 			Key:      secret.SecretKey,
 			Expires:  secret.Expires,
-		}, nil
+			Scope:    secret.Scope,
+		}
+
+		if previous, err := cli.GetSecret(auth.PreviousSecretID(kid)); err == nil {
+			ret.PreviousKey = previous.SecretKey
+			ret.PreviousExpires = previous.Expires
+		}
+
+		return ret, nil
 	}
 }
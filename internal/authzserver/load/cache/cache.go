@@ -8,7 +8,6 @@ import (
 	"sync"
 
 	"github.com/dgraph-io/ristretto"
-	pb "github.com/marmotedu/api/proto/apiserver/v1"
 	"github.com/marmotedu/errors"
 	"github.com/ory/ladon"
 
@@ -21,6 +20,9 @@ type Cache struct {
 	cli      store.Factory
 	secrets  *ristretto.Cache
 	policies *ristretto.Cache
+	// the number of the secrets and policies loaded by the last reload.
+	secretCount int
+	policyCount int
 }
 
 var (
@@ -74,7 +76,7 @@ func GetCacheInsOr(cli store.Factory) (*Cache, error) {
 }
 
 // GetSecret return secret detail for the given key.
-func (c *Cache) GetSecret(key string) (*pb.SecretInfo, error) {
+func (c *Cache) GetSecret(key string) (*store.Secret, error) {
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
@@ -83,7 +85,7 @@ func (c *Cache) GetSecret(key string) (*pb.SecretInfo, error) {
 		return nil, ErrSecretNotFound
 	}
 
-	return value.(*pb.SecretInfo), nil
+	return value.(*store.Secret), nil
 }
 
 // GetPolicy return user's ladon policies for the given user.
@@ -99,14 +101,32 @@ func (c *Cache) GetPolicy(key string) ([]*ladon.DefaultPolicy, error) {
 	return value.([]*ladon.DefaultPolicy), nil
 }
 
+// Count returns the number of the secrets and policies loaded by the last reload.
+func (c *Cache) Count() (secrets int, policies int) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return c.secretCount, c.policyCount
+}
+
 // Reload reload secrets and policies.
 func (c *Cache) Reload() error {
+	if err := c.ReloadSecrets(); err != nil {
+		return err
+	}
+
+	return c.ReloadPolicies()
+}
+
+// ReloadSecrets reload secrets.
+func (c *Cache) ReloadSecrets() error {
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
-	// reload secrets
 	secrets, err := c.cli.Secrets().List()
 	if err != nil {
+		reloadFailures.WithLabelValues(kindSecret).Inc()
+
 		return errors.Wrap(err, "list secrets failed")
 	}
 
@@ -114,10 +134,21 @@ func (c *Cache) Reload() error {
 	for key, val := range secrets {
 		c.secrets.Set(key, val, 1)
 	}
+	c.secretCount = len(secrets)
+	cachedItems.WithLabelValues(kindSecret).Set(float64(len(secrets)))
+
+	return nil
+}
+
+// ReloadPolicies reload policies.
+func (c *Cache) ReloadPolicies() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
 
-	// reload policies
 	policies, err := c.cli.Policies().List()
 	if err != nil {
+		reloadFailures.WithLabelValues(kindPolicy).Inc()
+
 		return errors.Wrap(err, "list policies failed")
 	}
 
@@ -125,6 +156,8 @@ func (c *Cache) Reload() error {
 	for key, val := range policies {
 		c.policies.Set(key, val, 1)
 	}
+	c.policyCount = len(policies)
+	cachedItems.WithLabelValues(kindPolicy).Set(float64(len(policies)))
 
 	return nil
 }
@@ -0,0 +1,66 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/dgraph-io/ristretto"
+	"github.com/golang/mock/gomock"
+	pb "github.com/marmotedu/api/proto/apiserver/v1"
+	"github.com/ory/ladon"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/marmotedu/iam/internal/authzserver/store"
+)
+
+func newTestCache(t *testing.T, cli store.Factory) *Cache {
+	t.Helper()
+
+	c := &ristretto.Config{NumCounters: 1e3, MaxCost: 1 << 20, BufferItems: 64}
+	secrets, err := ristretto.NewCache(c)
+	require.NoError(t, err)
+	policies, err := ristretto.NewCache(c)
+	require.NoError(t, err)
+
+	return &Cache{
+		cli:      cli,
+		lock:     new(sync.RWMutex),
+		secrets:  secrets,
+		policies: policies,
+	}
+}
+
+func TestCache_ReloadCount(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	secretStore := store.NewMockSecretStore(ctrl)
+	policyStore := store.NewMockPolicyStore(ctrl)
+	factory := store.NewMockFactory(ctrl)
+	factory.EXPECT().Secrets().Return(secretStore).AnyTimes()
+	factory.EXPECT().Policies().Return(policyStore).AnyTimes()
+
+	secretStore.EXPECT().List().Return(map[string]*store.Secret{
+		"id1": {SecretInfo: &pb.SecretInfo{SecretId: "id1"}},
+		"id2": {SecretInfo: &pb.SecretInfo{SecretId: "id2"}},
+	}, nil)
+	policyStore.EXPECT().List().Return(map[string][]*ladon.DefaultPolicy{
+		"colin": {{ID: "policy1"}},
+	}, nil)
+
+	c := newTestCache(t, factory)
+	secrets, policies := c.Count()
+	assert.Equal(t, 0, secrets)
+	assert.Equal(t, 0, policies)
+
+	require.NoError(t, c.Reload())
+
+	secrets, policies = c.Count()
+	assert.Equal(t, 2, secrets)
+	assert.Equal(t, 1, policies)
+}
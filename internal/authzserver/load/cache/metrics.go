@@ -0,0 +1,35 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/marmotedu/iam/internal/pkg/metrics"
+)
+
+// the kinds of the cached items.
+const (
+	kindSecret = "secret"
+	kindPolicy = "policy"
+)
+
+var (
+	cachedItems = metrics.Register(prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "iam_authz_cache_items",
+			Help: "The number of the items loaded into the authz cache by the last reload, by kind.",
+		},
+		[]string{"kind"},
+	)).(*prometheus.GaugeVec)
+
+	reloadFailures = metrics.Register(prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "iam_authz_cache_reload_failures_total",
+			Help: "The number of the failed reloads of the authz cache, by kind.",
+		},
+		[]string{"kind"},
+	)).(*prometheus.CounterVec)
+)
@@ -6,10 +6,14 @@ package load
 
 import (
 	"context"
-	"errors"
+	"math/rand"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	redis "github.com/go-redis/redis/v7"
+
+	"github.com/marmotedu/iam/internal/pkg/pubsub"
 	"github.com/marmotedu/iam/pkg/log"
 	"github.com/marmotedu/iam/pkg/storage"
 )
@@ -19,67 +23,203 @@ type Loader interface {
 	Reload() error
 }
 
+// ResourceLoader is implemented by loaders which are able to reload secrets and
+// policies separately, so a change of one doesn't reload the other.
+type ResourceLoader interface {
+	Loader
+	ReloadSecrets() error
+	ReloadPolicies() error
+}
+
+// Define the default reload throttling.
+const (
+	DefaultReloadDebounce    = 200 * time.Millisecond
+	DefaultMaxReloadDelay    = 5 * time.Second
+	DefaultMinReloadInterval = 1 * time.Second
+)
+
+// DefaultPubSubReconnectDelay is the default delay before subscribing to the redis
+// notifications again when redis is unavailable.
+const DefaultPubSubReconnectDelay = 10 * time.Second
+
+// warmUpRetryInterval is how often the first reload is retried until it succeeds.
+const warmUpRetryInterval = 5 * time.Second
+
+// reloadCheckInterval is how often the queued reloads are checked.
+const reloadCheckInterval = 100 * time.Millisecond
+
 // Load is used to reload given storage.
 type Load struct {
 	ctx    context.Context
 	lock   *sync.RWMutex
 	loader Loader
+
+	debounce          time.Duration
+	maxReloadDelay    time.Duration
+	minReloadInterval time.Duration
+
+	reconnectDelay  time.Duration
+	reconnectJitter time.Duration
+
+	// warmedUp is set to 1 once a full reload succeeded.
+	warmedUp int32
+}
+
+// Option defines optional parameters for initializing the loader.
+type Option func(*Load)
+
+// WithReloadDebounce collapses the notifications received within d of each
+// other into a single reload. The reload is delayed for at most maxDelay, so
+// a steady stream of notifications doesn't postpone it forever.
+func WithReloadDebounce(d, maxDelay time.Duration) Option {
+	return func(l *Load) {
+		l.debounce = d
+		l.maxReloadDelay = maxDelay
+	}
+}
+
+// WithMinReloadInterval sets the minimum amount of time between reloads.
+func WithMinReloadInterval(d time.Duration) Option {
+	return func(l *Load) {
+		l.minReloadInterval = d
+	}
+}
+
+// WithPubSubReconnectDelay sets the delay before subscribing to the redis notifications
+// again when redis is unavailable, a random duration up to jitter is added to every delay.
+func WithPubSubReconnectDelay(delay, jitter time.Duration) Option {
+	return func(l *Load) {
+		l.reconnectDelay = delay
+		l.reconnectJitter = jitter
+	}
 }
 
 // NewLoader return a loader with a loader implement.
-func NewLoader(ctx context.Context, loader Loader) *Load {
-	return &Load{
-		ctx:    ctx,
-		lock:   new(sync.RWMutex),
-		loader: loader,
+func NewLoader(ctx context.Context, loader Loader, opts ...Option) *Load {
+	l := &Load{
+		ctx:               ctx,
+		lock:              new(sync.RWMutex),
+		loader:            loader,
+		debounce:          DefaultReloadDebounce,
+		maxReloadDelay:    DefaultMaxReloadDelay,
+		minReloadInterval: DefaultMinReloadInterval,
+		reconnectDelay:    DefaultPubSubReconnectDelay,
 	}
+
+	for _, o := range opts {
+		o(l)
+	}
+
+	return l
 }
 
 // Start start a loop service.
 func (l *Load) Start() {
-	go startPubSubLoop()
+	cacheStore := &storage.RedisCluster{}
+	cacheStore.Connect()
+
+	go l.startPubSubLoop(cacheStore)
 	go l.reloadQueueLoop()
-	// 1s is the minimum amount of time between hot reloads. The
+	// minReloadInterval is the minimum amount of time between hot reloads. The
 	// interval counts from the start of one reload to the next.
 	go l.reloadLoop()
-	l.DoReload()
+
+	if err := l.DoReload(); err != nil {
+		go l.warmUp(warmUpRetryInterval)
+	}
 }
 
-func startPubSubLoop() {
-	cacheStore := storage.RedisCluster{}
-	cacheStore.Connect()
-	// On message, synchronize
+// warmUp retries the full reload every interval until it succeeds, so the server
+// becomes ready once iam-apiserver is available.
+func (l *Load) warmUp(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
 	for {
-		err := cacheStore.StartPubSubHandler(RedisPubSubChannel, func(v interface{}) {
-			handleRedisEvent(v, nil, nil)
-		})
+		select {
+		case <-l.ctx.Done():
+			return
+		case <-ticker.C:
+			if l.WarmedUp() || l.DoReload() == nil {
+				return
+			}
+		}
+	}
+}
+
+// WarmedUp returns whether the secrets and policies have been fully loaded once.
+func (l *Load) WarmedUp() bool {
+	return atomic.LoadInt32(&l.warmedUp) == 1
+}
+
+// subscriber subscribes to the channels matching a pattern, it is implemented by storage.RedisCluster.
+type subscriber interface {
+	PSubscribeChannel(ctx context.Context, pattern string) (<-chan *redis.Message, error)
+}
+
+// startPubSubLoop handles the notifications until l.ctx is cancelled, the subscription is
+// closed by the subscriber when l.ctx is cancelled.
+func (l *Load) startPubSubLoop(sub subscriber) {
+	// On message, synchronize
+	for l.ctx.Err() == nil {
+		msgs, err := sub.PSubscribeChannel(l.ctx, pubsub.RedisPubSubPattern)
 		if err != nil {
-			if !errors.Is(err, storage.ErrRedisIsDown) {
-				log.Errorf("Connection to Redis failed, reconnect in 10s: %s", err.Error())
+			delay := l.nextReconnectDelay()
+			if !storage.IsUnavailable(err) {
+				log.Errorf("Connection to Redis failed, reconnect in %s: %s", delay, err.Error())
+			}
+
+			select {
+			case <-l.ctx.Done():
+				return
+			case <-time.After(delay):
 			}
 
-			time.Sleep(10 * time.Second)
 			log.Warnf("Reconnecting: %s", err.Error())
+
+			continue
+		}
+
+		for msg := range msgs {
+			handleRedisEvent(msg, nil, nil)
 		}
 	}
+
+	log.Info("Stop handling the redis notifications")
+}
+
+// nextReconnectDelay returns the reconnect delay with a random jitter.
+func (l *Load) nextReconnectDelay() time.Duration {
+	if l.reconnectJitter <= 0 {
+		return l.reconnectDelay
+	}
+
+	return l.reconnectDelay + time.Duration(rand.Int63n(int64(l.reconnectJitter))) // nolint: gosec
 }
 
 // shouldReload returns true if we should perform any reload. Reloads happens if
-// we have reload callback queued.
-func shouldReload() ([]func(), bool) {
+// we have reload callback queued, and no other reload was queued within the
+// debounce period, or the first queued reload has waited for maxDelay.
+func shouldReload(debounce, maxDelay time.Duration) ([]reloadRequest, bool) {
 	requeueLock.Lock()
 	defer requeueLock.Unlock()
 	if len(requeue) == 0 {
 		return nil, false
 	}
+	if time.Since(lastQueued) < debounce && time.Since(firstQueued) < maxDelay {
+		return nil, false
+	}
 	n := requeue
-	requeue = []func(){}
+	requeue = []reloadRequest{}
 
 	return n, true
 }
 
 func (l *Load) reloadLoop(complete ...func()) {
-	ticker := time.NewTicker(1 * time.Second)
+	ticker := time.NewTicker(reloadCheckInterval)
+	defer ticker.Stop()
+
+	var lastReload time.Time
 	for {
 		select {
 		case <-l.ctx.Done():
@@ -88,17 +228,21 @@ func (l *Load) reloadLoop(complete ...func()) {
 		// startup sequence. We expect to start checking on the first tick after the
 		// gateway is up and running.
 		case <-ticker.C:
-			cb, ok := shouldReload()
+			if time.Since(lastReload) < l.minReloadInterval {
+				continue
+			}
+			cb, ok := shouldReload(l.debounce, l.maxReloadDelay)
 			if !ok {
 				continue
 			}
 			start := time.Now()
-			l.DoReload()
+			lastReload = start
+			l.reload(cb)
 			for _, c := range cb {
 				// most of the callbacks are nil, we don't want to execute nil functions to
 				// avoid panics.
-				if c != nil {
-					c()
+				if c.done != nil {
+					c.done()
 				}
 			}
 			if len(complete) != 0 {
@@ -109,24 +253,39 @@ func (l *Load) reloadLoop(complete ...func()) {
 	}
 }
 
+// reloadRequest is a queued reload of the resources changed by command, done
+// is called after the reload.
+type reloadRequest struct {
+	command pubsub.NotificationCommand
+	done    func()
+}
+
 // reloadQueue used to queue a reload. It's not
 // buffered, as reloadQueueLoop should pick these up immediately.
-var reloadQueue = make(chan func())
+var reloadQueue = make(chan reloadRequest)
 
 var requeueLock sync.Mutex
 
-// This is a list of callbacks to execute on the next reload. It is protected by
+// This is a list of reloads to execute on the next reload. It is protected by
 // requeueLock for concurrent use.
-var requeue []func()
+var requeue []reloadRequest
+
+// firstQueued and lastQueued are the times the first and the last reloads in
+// requeue were queued. They are protected by requeueLock.
+var firstQueued, lastQueued time.Time
 
 func (l *Load) reloadQueueLoop(cb ...func()) {
 	for {
 		select {
 		case <-l.ctx.Done():
 			return
-		case fn := <-reloadQueue:
+		case req := <-reloadQueue:
 			requeueLock.Lock()
-			requeue = append(requeue, fn)
+			lastQueued = time.Now()
+			if len(requeue) == 0 {
+				firstQueued = lastQueued
+			}
+			requeue = append(requeue, req)
 			requeueLock.Unlock()
 			log.Info("Reload queued")
 			if len(cb) != 0 {
@@ -136,14 +295,59 @@ func (l *Load) reloadQueueLoop(cb ...func()) {
 	}
 }
 
-// DoReload reload secrets and policies.
-func (l *Load) DoReload() {
+// DoReload reload secrets and policies synchronously.
+func (l *Load) DoReload() error {
 	l.lock.Lock()
 	defer l.lock.Unlock()
 
 	if err := l.loader.Reload(); err != nil {
 		log.Errorf("faild to refresh target storage: %s", err.Error())
+
+		return err
 	}
 
+	atomic.StoreInt32(&l.warmedUp, 1)
 	log.Debug("refresh target storage succ")
+
+	return nil
+}
+
+// reload reloads the resources changed by the queued requests, everything is
+// reloaded if the loader can't reload the resources separately.
+func (l *Load) reload(requests []reloadRequest) {
+	loader, ok := l.loader.(ResourceLoader)
+	if !ok {
+		_ = l.DoReload()
+
+		return
+	}
+
+	var secrets, policies bool
+	for _, req := range requests {
+		switch req.command {
+		case pubsub.NoticeSecretChanged:
+			secrets = true
+		case pubsub.NoticePolicyChanged:
+			policies = true
+		default:
+			secrets, policies = true, true
+		}
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if secrets {
+		if err := loader.ReloadSecrets(); err != nil {
+			log.Errorf("faild to refresh secrets: %s", err.Error())
+		}
+	}
+
+	if policies {
+		if err := loader.ReloadPolicies(); err != nil {
+			log.Errorf("faild to refresh policies: %s", err.Error())
+		}
+	}
+
+	log.Debugw("refresh target storage succ", "secrets", secrets, "policies", policies)
 }
@@ -0,0 +1,61 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package load
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// LoadOptions contains configuration items related to reloading secrets and policies.
+type LoadOptions struct {
+	PubSubReconnectDelay  time.Duration `json:"pubsub-reconnect-delay"  mapstructure:"pubsub-reconnect-delay"`
+	PubSubReconnectJitter time.Duration `json:"pubsub-reconnect-jitter" mapstructure:"pubsub-reconnect-jitter"`
+}
+
+// NewLoadOptions creates a LoadOptions object with default parameters.
+func NewLoadOptions() *LoadOptions {
+	return &LoadOptions{
+		PubSubReconnectDelay:  DefaultPubSubReconnectDelay,
+		PubSubReconnectJitter: 0,
+	}
+}
+
+// Validate is used to parse and validate the parameters entered by the user at
+// the command line when the program starts.
+func (o *LoadOptions) Validate() []error {
+	if o == nil {
+		return nil
+	}
+
+	errs := []error{}
+
+	if o.PubSubReconnectDelay <= 0 {
+		errs = append(errs, fmt.Errorf("--load.pubsub-reconnect-delay %v must be greater than 0", o.PubSubReconnectDelay))
+	}
+
+	if o.PubSubReconnectJitter < 0 {
+		errs = append(errs, fmt.Errorf("--load.pubsub-reconnect-jitter %v cannot be negative", o.PubSubReconnectJitter))
+	}
+
+	return errs
+}
+
+// AddFlags adds flags related to reloading for a specific api server to the
+// specified FlagSet.
+func (o *LoadOptions) AddFlags(fs *pflag.FlagSet) {
+	if fs == nil {
+		return
+	}
+
+	fs.DurationVar(&o.PubSubReconnectDelay, "load.pubsub-reconnect-delay", o.PubSubReconnectDelay, ""+
+		"The delay before subscribing to the redis notifications again when redis is unavailable.")
+
+	fs.DurationVar(&o.PubSubReconnectJitter, "load.pubsub-reconnect-jitter", o.PubSubReconnectJitter, ""+
+		"The maximum random duration added to --load.pubsub-reconnect-delay, so the instances "+
+		"don't reconnect to redis at the same time.")
+}
@@ -0,0 +1,377 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package load
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	redis "github.com/go-redis/redis/v7"
+	"github.com/marmotedu/component-base/pkg/json"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/marmotedu/iam/internal/pkg/pubsub"
+)
+
+type fakeLoader struct {
+	mu       sync.Mutex
+	all      int
+	secrets  int
+	policies int
+}
+
+func (f *fakeLoader) Reload() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.all++
+
+	return nil
+}
+
+func (f *fakeLoader) ReloadSecrets() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.secrets++
+
+	return nil
+}
+
+func (f *fakeLoader) ReloadPolicies() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.policies++
+
+	return nil
+}
+
+func (f *fakeLoader) counts() (int, int, int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.all, f.secrets, f.policies
+}
+
+// resetReloadQueue drops the reloads queued by previous tests.
+func resetReloadQueue() {
+	requeueLock.Lock()
+	defer requeueLock.Unlock()
+
+	requeue = nil
+}
+
+// flakyLoader fails the first failures reloads.
+type flakyLoader struct {
+	mu       sync.Mutex
+	failures int
+	reloads  int
+}
+
+func (f *flakyLoader) Reload() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.reloads++
+	if f.reloads <= f.failures {
+		return errors.New("iam-apiserver is unavailable")
+	}
+
+	return nil
+}
+
+// startReloadLoops starts the loops queueing and performing reloads, the
+// returned function returns the number of reloads performed.
+func startReloadLoops(t *testing.T, loader Loader, opts ...Option) func() int {
+	t.Helper()
+
+	resetReloadQueue()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	var mu sync.Mutex
+	var reloads int
+
+	l := NewLoader(ctx, loader, opts...)
+	go l.reloadQueueLoop()
+	go l.reloadLoop(func() {
+		mu.Lock()
+		defer mu.Unlock()
+
+		reloads++
+	})
+
+	return func() int {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return reloads
+	}
+}
+
+// handleAndReload handles the message and waits for the reload it triggers.
+func handleAndReload(t *testing.T, loader Loader, msg *redis.Message) {
+	t.Helper()
+
+	resetReloadQueue()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	l := NewLoader(ctx, loader)
+	completed := make(chan struct{}, 1)
+	go l.reloadQueueLoop()
+	go l.reloadLoop(func() { completed <- struct{}{} })
+
+	handleRedisEvent(msg, nil, nil)
+
+	select {
+	case <-completed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("reload was not triggered")
+	}
+}
+
+func TestLoad_WarmUp(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	loader := &flakyLoader{failures: 2}
+	l := NewLoader(ctx, loader)
+
+	assert.Error(t, l.DoReload())
+	assert.False(t, l.WarmedUp())
+
+	go l.warmUp(10 * time.Millisecond)
+
+	assert.Eventually(t, l.WarmedUp, time.Second, 5*time.Millisecond)
+	assert.Equal(t, 3, loader.reloads)
+}
+
+func TestHandleRedisEvent_PolicyChannel(t *testing.T) {
+	loader := &fakeLoader{}
+	handleAndReload(t, loader, &redis.Message{
+		Channel: pubsub.PolicyChangedChannel,
+		Pattern: pubsub.RedisPubSubPattern,
+	})
+
+	all, secrets, policies := loader.counts()
+	assert.Equal(t, 0, all)
+	assert.Equal(t, 0, secrets)
+	assert.Equal(t, 1, policies)
+}
+
+func TestHandleRedisEvent_SecretChannel(t *testing.T) {
+	loader := &fakeLoader{}
+	handleAndReload(t, loader, &redis.Message{
+		Channel: pubsub.SecretChangedChannel,
+		Pattern: pubsub.RedisPubSubPattern,
+	})
+
+	all, secrets, policies := loader.counts()
+	assert.Equal(t, 0, all)
+	assert.Equal(t, 1, secrets)
+	assert.Equal(t, 0, policies)
+}
+
+func TestHandleRedisEvent_SharedChannel(t *testing.T) {
+	payload, _ := json.Marshal(pubsub.Notification{Command: pubsub.NoticePolicyChanged})
+
+	loader := &fakeLoader{}
+	handleAndReload(t, loader, &redis.Message{
+		Channel: pubsub.RedisPubSubChannel,
+		Pattern: pubsub.RedisPubSubPattern,
+		Payload: string(payload),
+	})
+
+	all, secrets, policies := loader.counts()
+	assert.Equal(t, 0, all)
+	assert.Equal(t, 0, secrets)
+	assert.Equal(t, 1, policies)
+}
+
+func TestHandleRedisEvent_Mirrored(t *testing.T) {
+	_, ok := pubsub.MirroredNotification("Unknown")
+	assert.False(t, ok)
+
+	notif, ok := pubsub.MirroredNotification(pubsub.NoticePolicyChanged)
+	assert.True(t, ok)
+
+	mirrored := notificationsIgnored.WithLabelValues(ignoredMirrored)
+	before := testutil.ToFloat64(mirrored)
+
+	// the copy on the shared channel is ignored, the resource channel one reloads
+	var handled []pubsub.NotificationCommand
+	payload, _ := json.Marshal(notif)
+	handleRedisEvent(&redis.Message{Channel: pubsub.RedisPubSubChannel, Payload: string(payload)}, func(command pubsub.NotificationCommand) {
+		handled = append(handled, command)
+	}, nil)
+
+	assert.Empty(t, handled)
+	assert.Equal(t, before+1, testutil.ToFloat64(mirrored))
+}
+
+func TestHandleRedisEvent_Metrics(t *testing.T) {
+	received := notificationsReceived.WithLabelValues(string(pubsub.NoticeSecretChanged))
+	malformed := notificationsIgnored.WithLabelValues(ignoredMalformed)
+	unknown := notificationsIgnored.WithLabelValues(ignoredUnknownCommand)
+	invalid := notificationsIgnored.WithLabelValues(ignoredInvalidType)
+
+	before := []float64{
+		testutil.ToFloat64(received),
+		testutil.ToFloat64(malformed),
+		testutil.ToFloat64(unknown),
+		testutil.ToFloat64(invalid),
+	}
+
+	handleAndReload(t, &fakeLoader{}, &redis.Message{Channel: pubsub.SecretChangedChannel, Pattern: pubsub.RedisPubSubPattern})
+
+	payload, _ := json.Marshal(pubsub.Notification{Command: "Unknown"})
+	handleRedisEvent(&redis.Message{Channel: pubsub.RedisPubSubChannel, Payload: string(payload)}, nil, nil)
+	handleRedisEvent(&redis.Message{Channel: pubsub.RedisPubSubChannel, Payload: "{malformed"}, nil, nil)
+	handleRedisEvent("not a message", nil, nil)
+
+	assert.Equal(t, before[0]+1, testutil.ToFloat64(received))
+	assert.Equal(t, before[1]+1, testutil.ToFloat64(malformed))
+	assert.Equal(t, before[2]+1, testutil.ToFloat64(unknown))
+	assert.Equal(t, before[3]+1, testutil.ToFloat64(invalid))
+}
+
+func TestReloadLoop_Debounce(t *testing.T) {
+	loader := &fakeLoader{}
+	reloads := startReloadLoops(t, loader)
+
+	for i := 0; i < 500; i++ {
+		handleRedisEvent(&redis.Message{Channel: pubsub.PolicyChangedChannel}, nil, nil)
+	}
+
+	time.Sleep(DefaultReloadDebounce + 500*time.Millisecond)
+
+	assert.Equal(t, 1, reloads())
+	_, _, policies := loader.counts()
+	assert.Equal(t, 1, policies)
+}
+
+func TestReloadLoop_MinReloadInterval(t *testing.T) {
+	minInterval := 300 * time.Millisecond
+
+	loader := &fakeLoader{}
+	reloads := startReloadLoops(t, loader,
+		// notifications keep coming, so only the max delay triggers reloads
+		WithReloadDebounce(time.Hour, 10*time.Millisecond),
+		WithMinReloadInterval(minInterval),
+	)
+
+	start := time.Now()
+	for time.Since(start) < 1500*time.Millisecond {
+		handleRedisEvent(&redis.Message{Channel: pubsub.SecretChangedChannel}, nil, nil)
+		time.Sleep(time.Millisecond)
+	}
+
+	n := reloads()
+	assert.GreaterOrEqual(t, n, 2)
+	assert.LessOrEqual(t, n, int(time.Since(start)/minInterval)+1)
+}
+
+// fakeSubscriber fails the first failures subscriptions, then delivers the messages until
+// ctx is cancelled.
+type fakeSubscriber struct {
+	mu       sync.Mutex
+	failures int
+	calls    []time.Time
+}
+
+func (s *fakeSubscriber) PSubscribeChannel(ctx context.Context, pattern string) (<-chan *redis.Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls = append(s.calls, time.Now())
+	if len(s.calls) <= s.failures {
+		return nil, errors.New("connection refused")
+	}
+
+	msgs := make(chan *redis.Message)
+	go func() {
+		<-ctx.Done()
+		close(msgs)
+	}()
+
+	return msgs, nil
+}
+
+func (s *fakeSubscriber) callTimes() []time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]time.Time(nil), s.calls...)
+}
+
+func TestStartPubSubLoop_ExitOnCancel(t *testing.T) {
+	for name, failures := range map[string]int{"reconnecting": 1, "subscribed": 0} {
+		failures := failures
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			sub := &fakeSubscriber{failures: failures}
+			l := NewLoader(ctx, nil)
+
+			done := make(chan struct{})
+			go func() {
+				l.startPubSubLoop(sub)
+				close(done)
+			}()
+
+			cancel()
+
+			select {
+			case <-done:
+			case <-time.After(time.Second):
+				t.Fatal("startPubSubLoop did not exit after the context was cancelled")
+			}
+		})
+	}
+}
+
+func TestStartPubSubLoop_ReconnectDelay(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	delay, jitter := 50*time.Millisecond, 20*time.Millisecond
+	sub := &fakeSubscriber{failures: 3}
+	l := NewLoader(ctx, nil, WithPubSubReconnectDelay(delay, jitter))
+
+	done := make(chan struct{})
+	go func() {
+		l.startPubSubLoop(sub)
+		close(done)
+	}()
+
+	assert.Eventually(t, func() bool { return len(sub.callTimes()) == 4 }, 2*time.Second, 5*time.Millisecond)
+
+	calls := sub.callTimes()
+	for i := 1; i < len(calls); i++ {
+		gap := calls[i].Sub(calls[i-1])
+		assert.GreaterOrEqual(t, gap, delay)
+		assert.Less(t, gap, DefaultPubSubReconnectDelay)
+	}
+
+	cancel()
+	<-done
+}
+
+func TestLoadOptions_Validate(t *testing.T) {
+	o := NewLoadOptions()
+	assert.Equal(t, DefaultPubSubReconnectDelay, o.PubSubReconnectDelay)
+	assert.Empty(t, o.Validate())
+
+	o.PubSubReconnectDelay = 0
+	o.PubSubReconnectJitter = -time.Second
+	assert.Len(t, o.Validate(), 2)
+}
@@ -0,0 +1,46 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package load
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/marmotedu/iam/internal/pkg/metrics"
+)
+
+// the reasons a notification is ignored.
+const (
+	ignoredInvalidType    = "invalid_type"
+	ignoredMalformed      = "malformed"
+	ignoredUnknownCommand = "unknown_command"
+	ignoredMirrored       = "mirrored"
+)
+
+var (
+	notificationsReceived = registerCounterVec(prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "iam_pubsub_notifications_received_total",
+			Help: "The number of the reload notifications received, by command.",
+		},
+		[]string{"command"},
+	))
+
+	notificationsIgnored = registerCounterVec(prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "iam_pubsub_notifications_ignored_total",
+			Help: "The number of the malformed or unknown notifications ignored, by reason.",
+		},
+		[]string{"reason"},
+	))
+)
+
+// registerCounterVec registers c to the registry of the business metrics.
+func registerCounterVec(c *prometheus.CounterVec) *prometheus.CounterVec {
+	if existing, ok := metrics.Register(c).(*prometheus.CounterVec); ok {
+		return existing
+	}
+
+	return c
+}
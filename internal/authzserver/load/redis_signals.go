@@ -5,63 +5,63 @@
 package load
 
 import (
-	"crypto"
-	"crypto/sha256"
-	"encoding/hex"
-	"errors"
-
 	redis "github.com/go-redis/redis/v7"
 	"github.com/marmotedu/component-base/pkg/json"
 
+	"github.com/marmotedu/iam/internal/pkg/pubsub"
 	"github.com/marmotedu/iam/pkg/log"
 	"github.com/marmotedu/iam/pkg/storage"
 )
 
-// NotificationCommand defines a new notification type.
-type NotificationCommand string
-
-// Define Redis pub/sub events.
-const (
-	RedisPubSubChannel                      = "iam.cluster.notifications"
-	NoticePolicyChanged NotificationCommand = "PolicyChanged"
-	NoticeSecretChanged NotificationCommand = "SecretChanged"
-)
-
-// Notification is a type that encodes a message published to a pub sub channel (shared between implementations).
-type Notification struct {
-	Command       NotificationCommand `json:"command"`
-	Payload       string              `json:"payload"`
-	Signature     string              `json:"signature"`
-	SignatureAlgo crypto.Hash         `json:"algorithm"`
-}
-
-// Sign sign Notification with SHA256 algorithm.
-func (n *Notification) Sign() {
-	n.SignatureAlgo = crypto.SHA256
-	hash := sha256.Sum256([]byte(string(n.Command) + n.Payload))
-	n.Signature = hex.EncodeToString(hash[:])
+// channelCommand returns the notification command of a resource channel, it
+// returns false for the shared channel, whose command is in the payload.
+func channelCommand(channel string) (pubsub.NotificationCommand, bool) {
+	switch channel {
+	case pubsub.PolicyChangedChannel:
+		return pubsub.NoticePolicyChanged, true
+	case pubsub.SecretChangedChannel:
+		return pubsub.NoticeSecretChanged, true
+	default:
+		return "", false
+	}
 }
 
-func handleRedisEvent(v interface{}, handled func(NotificationCommand), reloaded func()) {
+func handleRedisEvent(v interface{}, handled func(pubsub.NotificationCommand), reloaded func()) {
 	message, ok := v.(*redis.Message)
 	if !ok {
+		notificationsIgnored.WithLabelValues(ignoredInvalidType).Inc()
+
 		return
 	}
 
-	notif := Notification{}
-	if err := json.Unmarshal([]byte(message.Payload), &notif); err != nil {
+	notif := pubsub.Notification{}
+	if command, ok := channelCommand(message.Channel); ok {
+		notif.Command = command
+	} else if err := json.Unmarshal([]byte(message.Payload), &notif); err != nil {
 		log.Errorf("Unmarshalling message body failed, malformed: ", err)
+		notificationsIgnored.WithLabelValues(ignoredMalformed).Inc()
+
+		return
+	} else if notif.Mirrored {
+		log.Debugw("ignore mirrored redis message", "command", notif.Command)
+		notificationsIgnored.WithLabelValues(ignoredMirrored).Inc()
 
 		return
 	}
-	log.Infow("receive redis message", "command", notif.Command, "payload", message.Payload)
+	log.Infow("receive redis message", "channel", message.Channel, "command", notif.Command, "payload", message.Payload)
 
 	switch notif.Command {
-	case NoticePolicyChanged, NoticeSecretChanged:
-		log.Info("Reloading secrets and policies")
-		reloadQueue <- reloaded
+	case pubsub.NoticePolicyChanged:
+		notificationsReceived.WithLabelValues(string(notif.Command)).Inc()
+		log.Info("Reloading policies")
+		reloadQueue <- reloadRequest{command: notif.Command, done: reloaded}
+	case pubsub.NoticeSecretChanged:
+		notificationsReceived.WithLabelValues(string(notif.Command)).Inc()
+		log.Info("Reloading secrets")
+		reloadQueue <- reloadRequest{command: notif.Command, done: reloaded}
 	default:
 		log.Warnf("Unknown notification command: %q", notif.Command)
+		notificationsIgnored.WithLabelValues(ignoredUnknownCommand).Inc()
 
 		return
 	}
@@ -80,14 +80,17 @@ type RedisNotifier struct {
 
 // Notify will send a notification to a channel.
 func (r *RedisNotifier) Notify(notif interface{}) bool {
-	if n, ok := notif.(Notification); ok {
+	var command pubsub.NotificationCommand
+	if n, ok := notif.(pubsub.Notification); ok {
 		n.Sign()
 		notif = n
+		command = n.Command
 	}
 
 	toSend, err := json.Marshal(notif)
 	if err != nil {
 		log.Errorf("Problem marshaling notification: %s", err.Error())
+		pubsub.RecordPublishFailure(command)
 
 		return false
 	}
@@ -95,9 +98,10 @@ func (r *RedisNotifier) Notify(notif interface{}) bool {
 	log.Debugf("Sending notification: %v", notif)
 
 	if err := r.store.Publish(r.channel, string(toSend)); err != nil {
-		if !errors.Is(err, storage.ErrRedisIsDown) {
+		if !storage.IsUnavailable(err) {
 			log.Errorf("Could not send notification: %s", err.Error())
 		}
+		pubsub.RecordPublishFailure(command)
 
 		return false
 	}
@@ -10,6 +10,8 @@ import (
 	"github.com/marmotedu/component-base/pkg/json"
 
 	"github.com/marmotedu/iam/internal/authzserver/analytics"
+	"github.com/marmotedu/iam/internal/authzserver/load"
+	"github.com/marmotedu/iam/internal/authzserver/store/apiserver"
 	genericoptions "github.com/marmotedu/iam/internal/pkg/options"
 	"github.com/marmotedu/iam/internal/pkg/server"
 	"github.com/marmotedu/iam/pkg/log"
@@ -24,8 +26,12 @@ type Options struct {
 	SecureServing           *genericoptions.SecureServingOptions   `json:"secure"         mapstructure:"secure"`
 	RedisOptions            *genericoptions.RedisOptions           `json:"redis"          mapstructure:"redis"`
 	FeatureOptions          *genericoptions.FeatureOptions         `json:"feature"        mapstructure:"feature"`
+	RateLimitOptions        *genericoptions.RateLimitOptions       `json:"ratelimit"      mapstructure:"ratelimit"`
 	Log                     *log.Options                           `json:"log"            mapstructure:"log"`
 	AnalyticsOptions        *analytics.AnalyticsOptions            `json:"analytics"      mapstructure:"analytics"`
+	LoadOptions             *load.LoadOptions                      `json:"load"           mapstructure:"load"`
+	RPCClientOptions        *apiserver.ClientOptions               `json:"rpcclient"      mapstructure:"rpcclient"`
+	TracingOptions          *genericoptions.TracingOptions         `json:"tracing"        mapstructure:"tracing"`
 }
 
 // NewOptions creates a new Options object with default parameters.
@@ -38,8 +44,12 @@ func NewOptions() *Options {
 		SecureServing:           genericoptions.NewSecureServingOptions(),
 		RedisOptions:            genericoptions.NewRedisOptions(),
 		FeatureOptions:          genericoptions.NewFeatureOptions(),
+		RateLimitOptions:        genericoptions.NewRateLimitOptions(),
 		Log:                     log.NewOptions(),
 		AnalyticsOptions:        analytics.NewAnalyticsOptions(),
+		LoadOptions:             load.NewLoadOptions(),
+		RPCClientOptions:        apiserver.NewClientOptions(),
+		TracingOptions:          genericoptions.NewTracingOptions("iam-authz-server"),
 	}
 
 	return &o
@@ -54,11 +64,15 @@ func (o *Options) ApplyTo(c *server.Config) error {
 func (o *Options) Flags() (fss cliflag.NamedFlagSets) {
 	o.GenericServerRunOptions.AddFlags(fss.FlagSet("generic"))
 	o.AnalyticsOptions.AddFlags(fss.FlagSet("analytics"))
+	o.LoadOptions.AddFlags(fss.FlagSet("load"))
+	o.RPCClientOptions.AddFlags(fss.FlagSet("rpcclient"))
 	o.RedisOptions.AddFlags(fss.FlagSet("redis"))
 	o.FeatureOptions.AddFlags(fss.FlagSet("features"))
+	o.RateLimitOptions.AddFlags(fss.FlagSet("ratelimit"))
 	o.InsecureServing.AddFlags(fss.FlagSet("insecure serving"))
 	o.SecureServing.AddFlags(fss.FlagSet("secure serving"))
 	o.Log.AddFlags(fss.FlagSet("logs"))
+	o.TracingOptions.AddFlags(fss.FlagSet("tracing"))
 
 	// Note: the weird ""+ in below lines seems to be the only way to get gofmt to
 	// arrange these text blocks sensibly. Grrr.
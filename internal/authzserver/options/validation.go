@@ -13,8 +13,12 @@ func (o *Options) Validate() []error {
 	errs = append(errs, o.SecureServing.Validate()...)
 	errs = append(errs, o.RedisOptions.Validate()...)
 	errs = append(errs, o.FeatureOptions.Validate()...)
+	errs = append(errs, o.RateLimitOptions.Validate()...)
 	errs = append(errs, o.Log.Validate()...)
 	errs = append(errs, o.AnalyticsOptions.Validate()...)
+	errs = append(errs, o.LoadOptions.Validate()...)
+	errs = append(errs, o.RPCClientOptions.Validate()...)
+	errs = append(errs, o.TracingOptions.Validate()...)
 
 	return errs
 }
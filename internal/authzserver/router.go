@@ -5,26 +5,35 @@
 package authzserver
 
 import (
+	"time"
+
 	"github.com/gin-gonic/gin"
 	"github.com/marmotedu/component-base/pkg/core"
 	"github.com/marmotedu/errors"
+	"github.com/spf13/viper"
 
 	"github.com/marmotedu/iam/internal/authzserver/controller/v1/authorize"
+	"github.com/marmotedu/iam/internal/authzserver/controller/v1/reload"
+	"github.com/marmotedu/iam/internal/authzserver/load"
 	"github.com/marmotedu/iam/internal/authzserver/load/cache"
 	"github.com/marmotedu/iam/internal/pkg/code"
+	genericoptions "github.com/marmotedu/iam/internal/pkg/options"
 	"github.com/marmotedu/iam/pkg/log"
 )
 
-func initRouter(g *gin.Engine) {
+// forcedReloadInterval is the minimum amount of time between two forced reloads.
+const forcedReloadInterval = 10 * time.Second
+
+func initRouter(g *gin.Engine, rateLimitOptions *genericoptions.RateLimitOptions, loader *load.Load) {
 	installMiddleware(g)
-	installController(g)
+	installController(g, rateLimitOptions, loader)
 }
 
 func installMiddleware(g *gin.Engine) {
 }
 
-func installController(g *gin.Engine) *gin.Engine {
-	auth := newCacheAuth()
+func installController(g *gin.Engine, rateLimitOptions *genericoptions.RateLimitOptions, loader *load.Load) *gin.Engine {
+	auth := newCacheAuth(rateLimitOptions)
 	g.NoRoute(auth.AuthFunc(), func(c *gin.Context) {
 		core.WriteResponse(c, errors.WithCode(code.ErrPageNotFound, "page not found."), nil)
 	})
@@ -40,6 +49,14 @@ func installController(g *gin.Engine) *gin.Engine {
 
 		// Router for authorization
 		apiv1.POST("/authz", authzController.Authorize)
+
+		// Router for forcing a full reload of the cache, only the configured administrators
+		// may call it, it's disabled by default.
+		if loader != nil && viper.GetBool("feature.enable-reload") {
+			reloadController := reload.NewReloadController(loader, cacheIns, forcedReloadInterval,
+				viper.GetStringSlice("feature.reload-admins"))
+			apiv1.POST("/reload", reloadController.Reload)
+		}
 	}
 
 	return g
@@ -16,6 +16,7 @@ import (
 	"github.com/marmotedu/iam/internal/authzserver/store/apiserver"
 	genericoptions "github.com/marmotedu/iam/internal/pkg/options"
 	genericapiserver "github.com/marmotedu/iam/internal/pkg/server"
+	"github.com/marmotedu/iam/internal/pkg/tracing"
 	"github.com/marmotedu/iam/pkg/log"
 	"github.com/marmotedu/iam/pkg/shutdown"
 	"github.com/marmotedu/iam/pkg/shutdown/shutdownmanagers/posixsignal"
@@ -25,6 +26,13 @@ import (
 // RedisKeyPrefix defines the prefix key in redis for analytics data.
 const RedisKeyPrefix = "analytics-"
 
+// AnalyticsRedisInstance is the name of the redis instance the analytics data is written to
+// when it is configured in redis.instances.
+const AnalyticsRedisInstance = "analytics"
+
+// redisConnected returns whether redis is connected.
+var redisConnected = storage.Connected
+
 type authzServer struct {
 	gs               *shutdown.GracefulShutdown
 	rpcServer        string
@@ -32,7 +40,14 @@ type authzServer struct {
 	redisOptions     *genericoptions.RedisOptions
 	genericAPIServer *genericapiserver.GenericAPIServer
 	analyticsOptions *analytics.AnalyticsOptions
+	rateLimitOptions *genericoptions.RateLimitOptions
+	loadOptions      *load.LoadOptions
+	rpcClientOptions *apiserver.ClientOptions
+	tracingOptions   *genericoptions.TracingOptions
+	loader           *load.Load
 	redisCancelFunc  context.CancelFunc
+	// shutdownTracing flushes the pending spans, it's nil when tracing is disabled.
+	shutdownTracing func(context.Context) error
 }
 
 type preparedAuthzServer struct {
@@ -58,6 +73,10 @@ func createAuthzServer(cfg *config.Config) (*authzServer, error) {
 		gs:               gs,
 		redisOptions:     cfg.RedisOptions,
 		analyticsOptions: cfg.AnalyticsOptions,
+		rateLimitOptions: cfg.RateLimitOptions,
+		loadOptions:      cfg.LoadOptions,
+		rpcClientOptions: cfg.RPCClientOptions,
+		tracingOptions:   cfg.TracingOptions,
 		rpcServer:        cfg.RPCServer,
 		clientCA:         cfg.ClientCA,
 		genericAPIServer: genericServer,
@@ -69,21 +88,48 @@ func createAuthzServer(cfg *config.Config) (*authzServer, error) {
 func (s *authzServer) PrepareRun() preparedAuthzServer {
 	_ = s.initialize()
 
-	initRouter(s.genericAPIServer.Engine)
+	initRouter(s.genericAPIServer.Engine, s.rateLimitOptions, s.loader)
+	s.installReadyzChecks()
 
 	return preparedAuthzServer{s}
 }
 
+// installReadyzChecks makes /readyz fail until the secrets and policies are loaded and
+// while redis is disconnected, /healthz keeps checking the process only.
+func (s *authzServer) installReadyzChecks() {
+	s.genericAPIServer.AddReadyzCheck("cache", func() error {
+		if s.loader == nil || !s.loader.WarmedUp() {
+			return errors.New("secrets and policies are not loaded")
+		}
+
+		return nil
+	})
+
+	s.genericAPIServer.AddReadyzCheck("redis", func() error {
+		if !redisConnected() {
+			return errors.New("redis is disconnected")
+		}
+
+		return nil
+	})
+}
+
 // Run start to run AuthzServer.
 func (s preparedAuthzServer) Run() error {
 	// in order to ensure that the reported data is not lost,
 	// please ensure the following graceful shutdown sequence
 	s.gs.AddShutdownCallback(shutdown.ShutdownFunc(func(string) error {
+		s.genericAPIServer.Drain()
 		s.genericAPIServer.Close()
 		if s.analyticsOptions.Enable {
 			analytics.GetAnalytics().Stop()
 		}
 		s.redisCancelFunc()
+		if s.shutdownTracing != nil {
+			if err := s.shutdownTracing(context.Background()); err != nil {
+				log.Warnf("Shutdown tracing failed: %s", err.Error())
+			}
+		}
 
 		return nil
 	}))
@@ -117,21 +163,23 @@ func buildGenericConfig(cfg *config.Config) (genericConfig *genericapiserver.Con
 	return
 }
 
-func (s *authzServer) buildStorageConfig() *storage.Config {
+func buildStorageConfig(redisOptions *genericoptions.RedisOptions) *storage.Config {
 	return &storage.Config{
-		Host:                  s.redisOptions.Host,
-		Port:                  s.redisOptions.Port,
-		Addrs:                 s.redisOptions.Addrs,
-		MasterName:            s.redisOptions.MasterName,
-		Username:              s.redisOptions.Username,
-		Password:              s.redisOptions.Password,
-		Database:              s.redisOptions.Database,
-		MaxIdle:               s.redisOptions.MaxIdle,
-		MaxActive:             s.redisOptions.MaxActive,
-		Timeout:               s.redisOptions.Timeout,
-		EnableCluster:         s.redisOptions.EnableCluster,
-		UseSSL:                s.redisOptions.UseSSL,
-		SSLInsecureSkipVerify: s.redisOptions.SSLInsecureSkipVerify,
+		Host:                  redisOptions.Host,
+		Port:                  redisOptions.Port,
+		Addrs:                 redisOptions.Addrs,
+		MasterName:            redisOptions.MasterName,
+		Username:              redisOptions.Username,
+		Password:              redisOptions.Password,
+		Database:              redisOptions.Database,
+		MaxIdle:               redisOptions.MaxIdle,
+		MaxActive:             redisOptions.MaxActive,
+		Timeout:               redisOptions.Timeout,
+		EnableCluster:         redisOptions.EnableCluster,
+		UseSSL:                redisOptions.UseSSL,
+		SSLInsecureSkipVerify: redisOptions.SSLInsecureSkipVerify,
+		Compression:           redisOptions.Compression,
+		TTLJitter:             redisOptions.TTLJitter,
 	}
 }
 
@@ -139,20 +187,38 @@ func (s *authzServer) initialize() error {
 	ctx, cancel := context.WithCancel(context.Background())
 	s.redisCancelFunc = cancel
 
+	if s.tracingOptions.Enabled {
+		s.shutdownTracing = tracing.Init(s.tracingOptions.ServiceName, s.tracingOptions.SamplingRatio)
+	}
+
 	// keep redis connected
-	go storage.ConnectToRedis(ctx, s.buildStorageConfig())
+	go storage.ConnectToRedis(ctx, buildStorageConfig(s.redisOptions))
+	for name, instance := range s.redisOptions.Instances {
+		go storage.ConnectToNamedRedis(ctx, name, buildStorageConfig(instance))
+	}
 
 	// cron to reload all secrets and policies from iam-apiserver
-	cacheIns, err := cache.GetCacheInsOr(apiserver.GetAPIServerFactoryOrDie(s.rpcServer, s.clientCA))
+	cacheIns, err := cache.GetCacheInsOr(apiserver.GetAPIServerFactoryOrDie(s.rpcServer, s.clientCA, s.rpcClientOptions))
 	if err != nil {
 		return errors.Wrap(err, "get cache instance failed")
 	}
 
-	load.NewLoader(ctx, cacheIns).Start()
+	s.loader = load.NewLoader(
+		ctx,
+		cacheIns,
+		load.WithPubSubReconnectDelay(s.loadOptions.PubSubReconnectDelay, s.loadOptions.PubSubReconnectJitter),
+	)
+	s.loader.Start()
 
 	// start analytics service
 	if s.analyticsOptions.Enable {
-		analyticsStore := storage.RedisCluster{KeyPrefix: RedisKeyPrefix}
+		analyticsStore := storage.RedisCluster{
+			KeyPrefix:       RedisKeyPrefix,
+			MaxPipelineSize: s.analyticsOptions.MaxPipelineSize,
+		}
+		if _, ok := s.redisOptions.Instances[AnalyticsRedisInstance]; ok {
+			analyticsStore.Name = AnalyticsRedisInstance
+		}
 		analyticsIns := analytics.NewAnalytics(s.analyticsOptions, &analyticsStore)
 		analyticsIns.Start()
 	}
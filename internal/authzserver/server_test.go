@@ -0,0 +1,74 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package authzserver
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/marmotedu/iam/internal/authzserver/load"
+	genericapiserver "github.com/marmotedu/iam/internal/pkg/server"
+)
+
+// fakeLoader fails the reloads until fail is cleared.
+type fakeLoader struct {
+	mu   sync.Mutex
+	fail bool
+}
+
+func (f *fakeLoader) Reload() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.fail {
+		return errors.New("iam-apiserver is unavailable")
+	}
+
+	return nil
+}
+
+func probe(s *genericapiserver.GenericAPIServer, path string) int {
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+	return w.Code
+}
+
+func TestAuthzServer_ReadyzChecks(t *testing.T) {
+	connected := true
+	defer func(f func() bool) { redisConnected = f }(redisConnected)
+	redisConnected = func() bool { return connected }
+
+	genericServer, err := genericapiserver.NewConfig().Complete().New()
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	loader := &fakeLoader{fail: true}
+	s := &authzServer{genericAPIServer: genericServer, loader: load.NewLoader(ctx, loader)}
+	s.installReadyzChecks()
+
+	// not ready before the first reload succeeds
+	assert.Error(t, s.loader.DoReload())
+	assert.Equal(t, http.StatusServiceUnavailable, probe(genericServer, "/readyz"))
+	assert.Equal(t, http.StatusOK, probe(genericServer, "/healthz"))
+
+	loader.fail = false
+	assert.NoError(t, s.loader.DoReload())
+	assert.Equal(t, http.StatusOK, probe(genericServer, "/readyz"))
+
+	// not ready while redis is disconnected
+	connected = false
+	assert.Equal(t, http.StatusServiceUnavailable, probe(genericServer, "/readyz"))
+	assert.Equal(t, http.StatusOK, probe(genericServer, "/healthz"))
+}
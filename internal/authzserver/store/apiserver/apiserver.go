@@ -5,11 +5,16 @@
 package apiserver
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"os"
 	"sync"
 
 	pb "github.com/marmotedu/api/proto/apiserver/v1"
+	"github.com/marmotedu/errors"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
 
 	"github.com/marmotedu/iam/internal/authzserver/store"
 	"github.com/marmotedu/iam/pkg/log"
@@ -17,6 +22,9 @@ import (
 
 type datastore struct {
 	cli pb.CacheClient
+	// pageSize is the number of the policies fetched per request, -1 fetches all of them.
+	pageSize int64
+	breaker  *breaker
 }
 
 func (ds *datastore) Secrets() store.SecretStore {
@@ -33,7 +41,11 @@ var (
 )
 
 // GetAPIServerFactoryOrDie return cache instance and panics on any error.
-func GetAPIServerFactoryOrDie(address string, clientCA string) store.Factory {
+func GetAPIServerFactoryOrDie(address string, clientCA string, opts *ClientOptions) store.Factory {
+	if opts == nil {
+		opts = NewClientOptions()
+	}
+
 	once.Do(func() {
 		var (
 			err   error
@@ -41,17 +53,21 @@ func GetAPIServerFactoryOrDie(address string, clientCA string) store.Factory {
 			creds credentials.TransportCredentials
 		)
 
-		creds, err = credentials.NewClientTLSFromFile(clientCA, "")
+		creds, err = newTransportCredentials(clientCA, opts)
 		if err != nil {
-			log.Panicf("credentials.NewClientTLSFromFile err: %v", err)
+			log.Panicf("Create grpc client credentials failed, error: %s", err.Error())
 		}
 
-		conn, err = grpc.Dial(address, grpc.WithBlock(), grpc.WithTransportCredentials(creds))
+		conn, err = grpc.Dial(address, dialOptions(creds, opts)...)
 		if err != nil {
 			log.Panicf("Connect to grpc server failed, error: %s", err.Error())
 		}
 
-		apiServerFactory = &datastore{pb.NewCacheClient(conn)}
+		apiServerFactory = &datastore{
+			cli:      pb.NewCacheClient(conn),
+			pageSize: opts.PageSize,
+			breaker:  newBreaker(opts.BreakerFailureThreshold, opts.BreakerOpenTimeout),
+		}
 		log.Infof("Connected to grpc server, address: %s", address)
 	})
 
@@ -61,3 +77,53 @@ func GetAPIServerFactoryOrDie(address string, clientCA string) store.Factory {
 
 	return apiServerFactory
 }
+
+// withKeepaliveParams is replaced by tests to inspect the keepalive parameters.
+var withKeepaliveParams = grpc.WithKeepaliveParams
+
+// dialOptions returns the options to dial iam-apiserver, the keepalive pings detect the
+// connections silently dropped, e.g. by a load balancer, so they are re-established.
+func dialOptions(creds credentials.TransportCredentials, opts *ClientOptions) []grpc.DialOption {
+	dialOpts := []grpc.DialOption{grpc.WithBlock(), grpc.WithTransportCredentials(creds)}
+
+	if opts.KeepaliveTime > 0 {
+		dialOpts = append(dialOpts, withKeepaliveParams(keepalive.ClientParameters{
+			Time:                opts.KeepaliveTime,
+			Timeout:             opts.KeepaliveTimeout,
+			PermitWithoutStream: opts.PermitWithoutStream,
+		}))
+	}
+
+	return dialOpts
+}
+
+// newTransportCredentials creates the TLS credentials of the grpc client, the certificate
+// of iam-apiserver is verified with clientCA. The client presents its certificate for
+// mutual TLS if the certificate and key files are set.
+func newTransportCredentials(clientCA string, opts *ClientOptions) (credentials.TransportCredentials, error) {
+	if opts.CertFile == "" && opts.KeyFile == "" {
+		return credentials.NewClientTLSFromFile(clientCA, opts.ServerNameOverride)
+	}
+
+	ca, err := os.ReadFile(clientCA)
+	if err != nil {
+		return nil, errors.Wrap(err, "read client ca file failed")
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, errors.Errorf("no certificate found in client ca file %s", clientCA)
+	}
+
+	cert, err := tls.LoadX509KeyPair(opts.CertFile, opts.KeyFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "load client certificate failed")
+	}
+
+	return credentials.NewTLS(&tls.Config{
+		RootCAs:      pool,
+		Certificates: []tls.Certificate{cert},
+		ServerName:   opts.ServerNameOverride,
+		MinVersion:   tls.VersionTLS12,
+	}), nil
+}
@@ -0,0 +1,120 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package apiserver
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
+)
+
+// writeCert writes a self-signed certificate and its key to dir, it returns the file names.
+func writeCert(t *testing.T, dir, name string) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile := filepath.Join(dir, name+".pem")
+	keyFile := filepath.Join(dir, name+"-key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+
+	return certFile, keyFile
+}
+
+func TestNewTransportCredentials(t *testing.T) {
+	dir := t.TempDir()
+	ca, _ := writeCert(t, dir, "ca")
+	cert, key := writeCert(t, dir, "iam-authz-server")
+
+	opts := NewClientOptions()
+	opts.ServerNameOverride = "iam.api.marmotedu.com"
+
+	creds, err := newTransportCredentials(ca, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "tls", creds.Info().SecurityProtocol)
+	assert.Equal(t, "iam.api.marmotedu.com", creds.Info().ServerName)
+
+	opts.CertFile, opts.KeyFile = cert, key
+	creds, err = newTransportCredentials(ca, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "iam.api.marmotedu.com", creds.Info().ServerName)
+
+	opts.KeyFile = filepath.Join(dir, "absent.pem")
+	_, err = newTransportCredentials(ca, opts)
+	assert.Error(t, err)
+}
+
+func TestDialOptions_Keepalive(t *testing.T) {
+	var params []keepalive.ClientParameters
+	defer func(f func(keepalive.ClientParameters) grpc.DialOption) { withKeepaliveParams = f }(withKeepaliveParams)
+	withKeepaliveParams = func(kp keepalive.ClientParameters) grpc.DialOption {
+		params = append(params, kp)
+
+		return grpc.WithKeepaliveParams(kp)
+	}
+
+	opts := NewClientOptions()
+	opts.KeepaliveTime = 2 * time.Minute
+	opts.KeepaliveTimeout = 10 * time.Second
+	opts.PermitWithoutStream = false
+
+	withKeepalive := dialOptions(insecure.NewCredentials(), opts)
+	assert.Equal(t, []keepalive.ClientParameters{{
+		Time:                2 * time.Minute,
+		Timeout:             10 * time.Second,
+		PermitWithoutStream: false,
+	}}, params)
+
+	// no keepalive ping is sent if the keepalive time is 0
+	params = nil
+	opts.KeepaliveTime = 0
+	withoutKeepalive := dialOptions(insecure.NewCredentials(), opts)
+	assert.Empty(t, params)
+	assert.Len(t, withoutKeepalive, len(withKeepalive)-1)
+}
+
+func TestClientOptions_Validate(t *testing.T) {
+	opts := NewClientOptions()
+	assert.Empty(t, opts.Validate())
+
+	opts.CertFile = "cert.pem"
+	assert.Len(t, opts.Validate(), 1)
+
+	opts.KeyFile = "key.pem"
+	opts.PageSize = 0
+	assert.Len(t, opts.Validate(), 1)
+}
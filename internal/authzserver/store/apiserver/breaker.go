@@ -0,0 +1,140 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package apiserver
+
+import (
+	"sync"
+	"time"
+
+	"github.com/avast/retry-go"
+	"github.com/marmotedu/errors"
+
+	"github.com/marmotedu/iam/pkg/log"
+)
+
+// errBreakerOpen is returned instead of calling iam-apiserver while the circuit breaker is open.
+var errBreakerOpen = errors.New("circuit breaker of iam-apiserver is open")
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// breaker is a circuit breaker around the calls to iam-apiserver. It opens after
+// threshold consecutive failures and rejects the calls for openTimeout, then lets a
+// single probe through, the breaker closes if the probe succeeds and opens again otherwise.
+// A nil breaker never opens.
+type breaker struct {
+	threshold   int
+	openTimeout time.Duration
+	now         func() time.Time
+
+	mu       sync.Mutex
+	state    breakerState
+	failures int
+	openedAt time.Time
+}
+
+func newBreaker(threshold int, openTimeout time.Duration) *breaker {
+	if threshold <= 0 {
+		return nil
+	}
+
+	return &breaker{
+		threshold:   threshold,
+		openTimeout: openTimeout,
+		now:         time.Now,
+	}
+}
+
+// do calls fn unless the breaker is open, the result of fn is recorded.
+func (b *breaker) do(fn func() error) error {
+	if b == nil {
+		return fn()
+	}
+
+	if !b.allow() {
+		return errBreakerOpen
+	}
+
+	err := fn()
+	b.record(err)
+
+	return err
+}
+
+func (b *breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		if b.now().Sub(b.openedAt) < b.openTimeout {
+			return false
+		}
+
+		// let a single probe through
+		b.state = breakerHalfOpen
+
+		return true
+	case breakerHalfOpen:
+		return false
+	default:
+		return true
+	}
+}
+
+func (b *breaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err == nil {
+		if b.state != breakerClosed {
+			log.Info("Circuit breaker of iam-apiserver is closed")
+		}
+
+		b.state = breakerClosed
+		b.failures = 0
+
+		return
+	}
+
+	b.failures++
+	if b.state == breakerHalfOpen || b.failures >= b.threshold {
+		if b.state != breakerOpen {
+			log.Warnf("Circuit breaker of iam-apiserver is open for %s after %d failures", b.openTimeout, b.failures)
+		}
+
+		b.state = breakerOpen
+		b.openedAt = b.now()
+	}
+}
+
+// callAPIServer calls fn through the breaker, the retries are stopped once the breaker is open.
+func callAPIServer(b *breaker, fn func() error) error {
+	err := b.do(fn)
+	if errors.Is(err, errBreakerOpen) {
+		return retry.Unrecoverable(err)
+	}
+
+	return err
+}
+
+// isBreakerOpen returns whether the calls failed because the breaker is open.
+func isBreakerOpen(err error) bool {
+	var retryErr retry.Error
+	if errors.As(err, &retryErr) {
+		for _, e := range retryErr.WrappedErrors() {
+			if errors.Is(e, errBreakerOpen) {
+				return true
+			}
+		}
+	}
+
+	return errors.Is(err, errBreakerOpen)
+}
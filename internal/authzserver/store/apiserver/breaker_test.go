@@ -0,0 +1,73 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package apiserver
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreaker_OpenAndRecover(t *testing.T) {
+	now := time.Now()
+	b := newBreaker(2, time.Minute)
+	b.now = func() time.Time { return now }
+
+	failure := errors.New("iam-apiserver is unavailable")
+	var calls int
+	fail := func() error { calls++; return failure }
+	succeed := func() error { calls++; return nil }
+
+	// opens after the consecutive failures
+	assert.Equal(t, failure, b.do(fail))
+	assert.Equal(t, failure, b.do(fail))
+	assert.Equal(t, errBreakerOpen, b.do(succeed))
+	assert.Equal(t, 2, calls)
+
+	// the failed probe opens the breaker again
+	now = now.Add(time.Minute)
+	assert.Equal(t, failure, b.do(fail))
+	assert.Equal(t, errBreakerOpen, b.do(succeed))
+	assert.Equal(t, 3, calls)
+
+	// the succeeded probe closes the breaker
+	now = now.Add(time.Minute)
+	assert.NoError(t, b.do(succeed))
+	assert.NoError(t, b.do(succeed))
+	assert.Equal(t, 5, calls)
+
+	// a single failure doesn't open the closed breaker
+	assert.Equal(t, failure, b.do(fail))
+	assert.NoError(t, b.do(succeed))
+}
+
+func TestBreaker_Disabled(t *testing.T) {
+	b := newBreaker(0, time.Minute)
+	assert.Nil(t, b)
+
+	failure := errors.New("iam-apiserver is unavailable")
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, failure, b.do(func() error { return failure }))
+	}
+}
+
+func TestSecrets_ListBreakerOpen(t *testing.T) {
+	withListRetry(t, 3, time.Millisecond, time.Millisecond)
+
+	cli := &flakyCacheClient{failures: 100}
+	ds := &datastore{cli: cli, breaker: newBreaker(2, time.Minute)}
+
+	// the retries stop once the breaker is open
+	_, err := newSecrets(ds).List()
+	assert.True(t, isBreakerOpen(err))
+	assert.Len(t, cli.calls, 2)
+
+	// no request is sent while the breaker is open
+	_, err = newPolicies(ds).List()
+	assert.True(t, isBreakerOpen(err))
+	assert.Len(t, cli.calls, 2)
+}
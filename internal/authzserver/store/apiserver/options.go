@@ -0,0 +1,109 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package apiserver
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// ClientOptions contains configuration items related to the grpc client of iam-apiserver.
+type ClientOptions struct {
+	PageSize           int64  `json:"page-size"            mapstructure:"page-size"`
+	ServerNameOverride string `json:"server-name-override" mapstructure:"server-name-override"`
+	CertFile           string `json:"cert-file"            mapstructure:"cert-file"`
+	KeyFile            string `json:"key-file"             mapstructure:"key-file"`
+
+	BreakerFailureThreshold int           `json:"breaker-failure-threshold" mapstructure:"breaker-failure-threshold"`
+	BreakerOpenTimeout      time.Duration `json:"breaker-open-timeout"      mapstructure:"breaker-open-timeout"`
+
+	KeepaliveTime       time.Duration `json:"keepalive-time"        mapstructure:"keepalive-time"`
+	KeepaliveTimeout    time.Duration `json:"keepalive-timeout"     mapstructure:"keepalive-timeout"`
+	PermitWithoutStream bool          `json:"permit-without-stream" mapstructure:"permit-without-stream"`
+}
+
+// NewClientOptions creates a ClientOptions object with default parameters.
+func NewClientOptions() *ClientOptions {
+	return &ClientOptions{
+		PageSize:                1000,
+		BreakerFailureThreshold: 5,
+		BreakerOpenTimeout:      30 * time.Second,
+		KeepaliveTime:           time.Minute,
+		KeepaliveTimeout:        20 * time.Second,
+		PermitWithoutStream:     true,
+	}
+}
+
+// Validate is used to parse and validate the parameters entered by the user at
+// the command line when the program starts.
+func (o *ClientOptions) Validate() []error {
+	if o == nil {
+		return nil
+	}
+
+	errs := []error{}
+
+	if o.PageSize == 0 || o.PageSize < -1 {
+		errs = append(errs, fmt.Errorf("--rpcclient.page-size %d must be greater than 0 or -1", o.PageSize))
+	}
+
+	if (o.CertFile == "") != (o.KeyFile == "") {
+		errs = append(errs, fmt.Errorf("--rpcclient.cert-file and --rpcclient.key-file must be specified together"))
+	}
+
+	if o.BreakerFailureThreshold > 0 && o.BreakerOpenTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("--rpcclient.breaker-open-timeout %v must be greater than 0", o.BreakerOpenTimeout))
+	}
+
+	if o.KeepaliveTime < 0 {
+		errs = append(errs, fmt.Errorf("--rpcclient.keepalive-time cannot be negative"))
+	}
+
+	if o.KeepaliveTimeout < 0 {
+		errs = append(errs, fmt.Errorf("--rpcclient.keepalive-timeout cannot be negative"))
+	}
+
+	return errs
+}
+
+// AddFlags adds flags related to the grpc client of iam-apiserver to the specified FlagSet.
+func (o *ClientOptions) AddFlags(fs *pflag.FlagSet) {
+	if fs == nil {
+		return
+	}
+
+	fs.Int64Var(&o.PageSize, "rpcclient.page-size", o.PageSize, ""+
+		"The number of the policies fetched from iam-apiserver per request, so a large policy set "+
+		"doesn't exceed the grpc message size limit. -1 fetches all the policies in a single request.")
+
+	fs.StringVar(&o.ServerNameOverride, "rpcclient.server-name-override", o.ServerNameOverride, ""+
+		"The server name used to verify the certificate of iam-apiserver, set it when the certificate "+
+		"doesn't match the address of --rpcserver.")
+
+	fs.StringVar(&o.CertFile, "rpcclient.cert-file", o.CertFile, ""+
+		"File containing the x509 certificate presented to iam-apiserver for mutual TLS.")
+
+	fs.StringVar(&o.KeyFile, "rpcclient.key-file", o.KeyFile, ""+
+		"File containing the x509 private key matching --rpcclient.cert-file.")
+
+	fs.IntVar(&o.BreakerFailureThreshold, "rpcclient.breaker-failure-threshold", o.BreakerFailureThreshold, ""+
+		"The number of consecutive failed requests to iam-apiserver which opens the circuit breaker, "+
+		"no request is sent while the breaker is open. 0 disables the circuit breaker.")
+
+	fs.DurationVar(&o.BreakerOpenTimeout, "rpcclient.breaker-open-timeout", o.BreakerOpenTimeout, ""+
+		"The time the circuit breaker stays open before a request is sent to check iam-apiserver is back.")
+
+	fs.DurationVar(&o.KeepaliveTime, "rpcclient.keepalive-time", o.KeepaliveTime, ""+
+		"Ping iam-apiserver after the connection has been idle for this duration to see if it's still alive, "+
+		"it must not be less than --grpc.keepalive-min-time of iam-apiserver. 0 disables the keepalive pings.")
+
+	fs.DurationVar(&o.KeepaliveTimeout, "rpcclient.keepalive-timeout", o.KeepaliveTimeout, ""+
+		"Wait this duration for the ping ack before closing the connection, it's re-established then.")
+
+	fs.BoolVar(&o.PermitWithoutStream, "rpcclient.permit-without-stream", o.PermitWithoutStream, ""+
+		"Send keepalive pings even when there are no active requests.")
+}
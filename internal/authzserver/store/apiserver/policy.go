@@ -13,48 +13,47 @@ import (
 	pb "github.com/marmotedu/api/proto/apiserver/v1"
 	"github.com/marmotedu/errors"
 	"github.com/ory/ladon"
+	"go.opentelemetry.io/otel/codes"
 
+	"github.com/marmotedu/iam/internal/pkg/tracing"
 	"github.com/marmotedu/iam/pkg/log"
 )
 
 type policies struct {
-	cli pb.CacheClient
+	cli      pb.CacheClient
+	pageSize int64
+	breaker  *breaker
 }
 
 func newPolicies(ds *datastore) *policies {
-	return &policies{ds.cli}
+	return &policies{cli: ds.cli, pageSize: ds.pageSize, breaker: ds.breaker}
 }
 
-// List returns all the authorization policies.
+// List returns all the authorization policies, they are fetched page by page, so a
+// large policy set doesn't exceed the grpc message size limit.
 func (p *policies) List() (map[string][]*ladon.DefaultPolicy, error) {
 	pols := make(map[string][]*ladon.DefaultPolicy)
 
+	ctx, span := tracing.Tracer().Start(context.Background(), "apiserver.ListPolicies")
+	defer span.End()
+
 	log.Info("Loading policies")
 
-	req := &pb.ListPoliciesRequest{
-		Offset: pointer.ToInt64(0),
-		Limit:  pointer.ToInt64(-1),
-	}
+	items, err := p.listAll(ctx)
+	if err != nil {
+		if isBreakerOpen(err) {
+			log.Warn("iam-apiserver is unavailable, the cached policies may be stale")
+		}
 
-	var resp *pb.ListPoliciesResponse
-	err := retry.Do(
-		func() error {
-			var listErr error
-			resp, listErr = p.cli.ListPolicies(context.Background(), req)
-			if listErr != nil {
-				return listErr
-			}
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "list policies failed")
 
-			return nil
-		}, retry.Attempts(3),
-	)
-	if err != nil {
 		return nil, errors.Wrap(err, "list policies failed")
 	}
 
-	log.Infof("Policies found (%d total)[username:name]:", len(resp.Items))
+	log.Infof("Policies found (%d total)[username:name]:", len(items))
 
-	for _, v := range resp.Items {
+	for _, v := range items {
 		log.Infof(" - %s:%s", v.Username, v.Name)
 
 		var policy ladon.DefaultPolicy
@@ -70,3 +69,86 @@ func (p *policies) List() (map[string][]*ladon.DefaultPolicy, error) {
 
 	return pols, nil
 }
+
+// maxListRestarts is the number of times the listing restarts from the first page
+// when the policies change while they are listed.
+const maxListRestarts = 3
+
+// listAll fetches the policies page by page until the last page. The pages are
+// taken by offset, so a policy created or deleted in the meantime shifts the
+// following pages and a policy is skipped or listed twice. The listing restarts
+// when the total count changes or a policy is listed twice.
+func (p *policies) listAll(ctx context.Context) ([]*pb.PolicyInfo, error) {
+	for restarts := 0; ; restarts++ {
+		items, consistent, err := p.listPages(ctx)
+		if err != nil || consistent {
+			return items, err
+		}
+
+		if restarts == maxListRestarts {
+			return nil, errors.Errorf("policies kept changing during %d listings", maxListRestarts+1)
+		}
+
+		log.Warn("Policies changed while they were listed, listing them again")
+	}
+}
+
+// listPages fetches the policies page by page, consistent is false if they changed
+// between the pages.
+func (p *policies) listPages(ctx context.Context) ([]*pb.PolicyInfo, bool, error) {
+	limit := p.pageSize
+	if limit == 0 {
+		limit = -1
+	}
+
+	var items []*pb.PolicyInfo
+	totalCount := int64(-1)
+	seen := make(map[string]bool)
+	for offset := int64(0); ; {
+		resp, err := p.listPage(ctx, offset, limit)
+		if err != nil {
+			return nil, false, err
+		}
+
+		if totalCount >= 0 && resp.TotalCount != totalCount {
+			return nil, false, nil
+		}
+		totalCount = resp.TotalCount
+
+		for _, v := range resp.Items {
+			key := v.Username + "/" + v.Name
+			if seen[key] {
+				return nil, false, nil
+			}
+			seen[key] = true
+		}
+
+		items = append(items, resp.Items...)
+		offset += int64(len(resp.Items))
+
+		if limit < 0 || int64(len(resp.Items)) < limit || offset >= resp.TotalCount {
+			return items, true, nil
+		}
+	}
+}
+
+func (p *policies) listPage(ctx context.Context, offset, limit int64) (*pb.ListPoliciesResponse, error) {
+	req := &pb.ListPoliciesRequest{
+		Offset: pointer.ToInt64(offset),
+		Limit:  pointer.ToInt64(limit),
+	}
+
+	var resp *pb.ListPoliciesResponse
+	err := retry.Do(
+		func() error {
+			return callAPIServer(p.breaker, func() error {
+				var listErr error
+				resp, listErr = p.cli.ListPolicies(ctx, req)
+
+				return listErr
+			})
+		}, listRetryOptions()...,
+	)
+
+	return resp, err
+}
@@ -0,0 +1,158 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package apiserver
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	pb "github.com/marmotedu/api/proto/apiserver/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+)
+
+// pagedCacheClient serves the policies page by page like iam-apiserver.
+type pagedCacheClient struct {
+	pb.CacheClient
+	policies []*pb.PolicyInfo
+	limits   []int64
+	// spans are the spans of the contexts the policies are listed with.
+	spans []trace.SpanContext
+	// onList is called before every page is served.
+	onList func(c *pagedCacheClient)
+}
+
+func (c *pagedCacheClient) ListPolicies(
+	ctx context.Context,
+	in *pb.ListPoliciesRequest,
+	opts ...grpc.CallOption,
+) (*pb.ListPoliciesResponse, error) {
+	c.limits = append(c.limits, in.GetLimit())
+	c.spans = append(c.spans, trace.SpanContextFromContext(ctx))
+
+	if c.onList != nil {
+		c.onList(c)
+	}
+
+	offset, limit := in.GetOffset(), in.GetLimit()
+	total := int64(len(c.policies))
+	end := total
+	if limit >= 0 && offset+limit < total {
+		end = offset + limit
+	}
+
+	if offset > total {
+		offset = total
+	}
+
+	return &pb.ListPoliciesResponse{TotalCount: total, Items: c.policies[offset:end]}, nil
+}
+
+func newPagedCacheClient(n int) *pagedCacheClient {
+	c := &pagedCacheClient{}
+	for i := 0; i < n; i++ {
+		c.policies = append(c.policies, &pb.PolicyInfo{
+			Name:         fmt.Sprintf("policy%d", i),
+			Username:     fmt.Sprintf("user%d", i%3),
+			PolicyShadow: fmt.Sprintf(`{"id":"policy%d","effect":"allow"}`, i),
+		})
+	}
+
+	return c
+}
+
+func TestPolicies_ListPages(t *testing.T) {
+	tests := []struct {
+		name     string
+		total    int
+		pageSize int64
+		requests int
+	}{
+		{"more than a page", 25, 10, 3},
+		{"exact pages", 20, 10, 2},
+		{"all at once", 25, -1, 1},
+		{"empty", 0, 10, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cli := newPagedCacheClient(tt.total)
+			pols, err := newPolicies(&datastore{cli: cli, pageSize: tt.pageSize}).List()
+			require.NoError(t, err)
+
+			var loaded int
+			for _, v := range pols {
+				loaded += len(v)
+			}
+			assert.Equal(t, tt.total, loaded)
+			assert.Len(t, cli.limits, tt.requests)
+
+			for _, limit := range cli.limits {
+				assert.Equal(t, tt.pageSize, limit)
+			}
+		})
+	}
+}
+
+func TestPolicies_ListChanged(t *testing.T) {
+	// a policy deleted after the first page shifts the next pages
+	cli := newPagedCacheClient(25)
+	cli.onList = func(c *pagedCacheClient) {
+		if len(c.limits) == 2 {
+			c.policies = c.policies[1:]
+		}
+	}
+
+	pols, err := newPolicies(&datastore{cli: cli, pageSize: 10}).List()
+	require.NoError(t, err)
+
+	var loaded int
+	for _, v := range pols {
+		loaded += len(v)
+	}
+	assert.Equal(t, 24, loaded)
+	// the listing restarted after the second page
+	assert.Len(t, cli.limits, 2+3)
+
+	// a policy created on every page never gives a consistent listing
+	cli = newPagedCacheClient(25)
+	cli.onList = func(c *pagedCacheClient) {
+		c.policies = append([]*pb.PolicyInfo{{
+			Name:     fmt.Sprintf("new%d", len(c.limits)),
+			Username: "user0",
+		}}, c.policies...)
+	}
+
+	_, err = newPolicies(&datastore{cli: cli, pageSize: 10}).List()
+	assert.NotNil(t, err)
+	assert.Len(t, cli.limits, 2*(maxListRestarts+1))
+}
+
+func TestPolicies_ListSpan(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(tp)
+
+	cli := newPagedCacheClient(15)
+	_, err := newPolicies(&datastore{cli: cli, pageSize: 10}).List()
+	require.NoError(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "apiserver.ListPolicies", spans[0].Name())
+
+	// every page is listed within the span
+	require.Len(t, cli.spans, 2)
+	for _, sc := range cli.spans {
+		assert.Equal(t, spans[0].SpanContext().SpanID(), sc.SpanID())
+	}
+}
@@ -0,0 +1,29 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package apiserver
+
+import (
+	"time"
+
+	"github.com/avast/retry-go"
+)
+
+// The retry policy of listing the secrets and policies from iam-apiserver. The delay
+// doubles after every attempt and a random jitter is added, so the retries of the
+// iam-authz-server instances are spread over an iam-apiserver restart.
+var (
+	listRetryAttempts  uint = 3
+	listRetryDelay          = 500 * time.Millisecond
+	listRetryMaxJitter      = 200 * time.Millisecond
+)
+
+func listRetryOptions() []retry.Option {
+	return []retry.Option{
+		retry.Attempts(listRetryAttempts),
+		retry.Delay(listRetryDelay),
+		retry.MaxJitter(listRetryMaxJitter),
+		retry.DelayType(retry.CombineDelay(retry.BackOffDelay, retry.RandomDelay)),
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package apiserver
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	pb "github.com/marmotedu/api/proto/apiserver/v1"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+)
+
+// flakyCacheClient fails the first failures calls and records the time of every call.
+type flakyCacheClient struct {
+	failures int
+	calls    []time.Time
+}
+
+func (f *flakyCacheClient) call() error {
+	f.calls = append(f.calls, time.Now())
+	if len(f.calls) <= f.failures {
+		return errors.New("iam-apiserver is unavailable")
+	}
+
+	return nil
+}
+
+func (f *flakyCacheClient) ListSecrets(
+	ctx context.Context,
+	in *pb.ListSecretsRequest,
+	opts ...grpc.CallOption,
+) (*pb.ListSecretsResponse, error) {
+	if err := f.call(); err != nil {
+		return nil, err
+	}
+
+	return &pb.ListSecretsResponse{Items: []*pb.SecretInfo{{SecretId: "id"}}}, nil
+}
+
+func (f *flakyCacheClient) ListPolicies(
+	ctx context.Context,
+	in *pb.ListPoliciesRequest,
+	opts ...grpc.CallOption,
+) (*pb.ListPoliciesResponse, error) {
+	if err := f.call(); err != nil {
+		return nil, err
+	}
+
+	return &pb.ListPoliciesResponse{}, nil
+}
+
+func withListRetry(t *testing.T, attempts uint, delay, jitter time.Duration) {
+	t.Helper()
+
+	a, d, j := listRetryAttempts, listRetryDelay, listRetryMaxJitter
+	t.Cleanup(func() { listRetryAttempts, listRetryDelay, listRetryMaxJitter = a, d, j })
+
+	listRetryAttempts, listRetryDelay, listRetryMaxJitter = attempts, delay, jitter
+}
+
+// assertBackOff asserts the delays between the calls increase.
+func assertBackOff(t *testing.T, calls []time.Time, delay time.Duration) {
+	t.Helper()
+
+	var last time.Duration
+	for i := 1; i < len(calls); i++ {
+		gap := calls[i].Sub(calls[i-1])
+		assert.GreaterOrEqual(t, gap, delay<<(i-1))
+		assert.Greater(t, gap, last)
+		last = gap
+	}
+}
+
+func TestSecrets_ListRetry(t *testing.T) {
+	delay := 20 * time.Millisecond
+	withListRetry(t, 4, delay, 5*time.Millisecond)
+
+	cli := &flakyCacheClient{failures: 3}
+	secrets, err := newSecrets(&datastore{cli: cli}).List()
+	assert.NoError(t, err)
+	assert.Len(t, secrets, 1)
+	assert.Len(t, cli.calls, 4)
+	assertBackOff(t, cli.calls, delay)
+}
+
+func TestPolicies_ListRetry(t *testing.T) {
+	delay := 20 * time.Millisecond
+	withListRetry(t, 3, delay, 5*time.Millisecond)
+
+	cli := &flakyCacheClient{failures: 3}
+	_, err := newPolicies(&datastore{cli: cli}).List()
+	assert.Error(t, err)
+	assert.Len(t, cli.calls, 3)
+	assertBackOff(t, cli.calls, delay)
+}
@@ -11,21 +11,32 @@ import (
 	"github.com/avast/retry-go"
 	pb "github.com/marmotedu/api/proto/apiserver/v1"
 	"github.com/marmotedu/errors"
+	"go.opentelemetry.io/otel/codes"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 
+	"github.com/marmotedu/iam/internal/authzserver/store"
+	"github.com/marmotedu/iam/internal/pkg/middleware/auth"
+	"github.com/marmotedu/iam/internal/pkg/tracing"
 	"github.com/marmotedu/iam/pkg/log"
 )
 
 type secrets struct {
-	cli pb.CacheClient
+	cli     pb.CacheClient
+	breaker *breaker
 }
 
 func newSecrets(ds *datastore) *secrets {
-	return &secrets{ds.cli}
+	return &secrets{cli: ds.cli, breaker: ds.breaker}
 }
 
 // List returns all the authorization secrets.
-func (s *secrets) List() (map[string]*pb.SecretInfo, error) {
-	secrets := make(map[string]*pb.SecretInfo)
+func (s *secrets) List() (map[string]*store.Secret, error) {
+	secrets := make(map[string]*store.Secret)
+
+	// iam-apiserver only returns the scoped secrets to the clients which enforce the scopes.
+	ctx, span := tracing.Tracer().Start(auth.WithScopeSupport(context.Background()), "apiserver.ListSecrets")
+	defer span.End()
 
 	log.Info("Loading secrets")
 
@@ -34,19 +45,38 @@ func (s *secrets) List() (map[string]*pb.SecretInfo, error) {
 		Limit:  pointer.ToInt64(-1),
 	}
 
-	var resp *pb.ListSecretsResponse
+	var (
+		resp   *pb.ListSecretsResponse
+		header metadata.MD
+	)
 	err := retry.Do(
 		func() error {
-			var listErr error
-			resp, listErr = s.cli.ListSecrets(context.Background(), req)
-			if listErr != nil {
-				return listErr
-			}
+			return callAPIServer(s.breaker, func() error {
+				var listErr error
+				resp, listErr = s.cli.ListSecrets(ctx, req, grpc.Header(&header))
 
-			return nil
-		}, retry.Attempts(3),
+				return listErr
+			})
+		}, listRetryOptions()...,
 	)
 	if err != nil {
+		if isBreakerOpen(err) {
+			log.Warn("iam-apiserver is unavailable, the cached secrets may be stale")
+		}
+
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "list secrets failed")
+
+		return nil, errors.Wrap(err, "list secrets failed")
+	}
+
+	// the secrets are not loaded if their scopes are broken, rather than granting
+	// the scoped ones the full access of their owner.
+	scopes, err := auth.ScopesFromHeader(header)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "list secrets failed")
+
 		return nil, errors.Wrap(err, "list secrets failed")
 	}
 
@@ -54,7 +84,7 @@ func (s *secrets) List() (map[string]*pb.SecretInfo, error) {
 
 	for _, v := range resp.Items {
 		log.Infof(" - %s:%s", v.Username, v.SecretId)
-		secrets[v.SecretId] = v
+		secrets[v.SecretId] = &store.Secret{SecretInfo: v, Scope: scopes[v.SecretId]}
 	}
 
 	return secrets, nil
@@ -12,7 +12,6 @@ import (
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
-	v1 "github.com/marmotedu/api/proto/apiserver/v1"
 	ladon "github.com/ory/ladon"
 )
 
@@ -91,10 +90,10 @@ func (m *MockSecretStore) EXPECT() *MockSecretStoreMockRecorder {
 }
 
 // List mocks base method.
-func (m *MockSecretStore) List() (map[string]*v1.SecretInfo, error) {
+func (m *MockSecretStore) List() (map[string]*Secret, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "List")
-	ret0, _ := ret[0].(map[string]*v1.SecretInfo)
+	ret0, _ := ret[0].(map[string]*Secret)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
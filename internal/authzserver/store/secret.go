@@ -4,10 +4,21 @@
 
 package store
 
-import pb "github.com/marmotedu/api/proto/apiserver/v1"
+import (
+	pb "github.com/marmotedu/api/proto/apiserver/v1"
+
+	"github.com/marmotedu/iam/internal/pkg/middleware/auth"
+)
+
+// Secret is a secret with the scope iam-apiserver sends along in the response header,
+// the scope is nil if the secret isn't scoped.
+type Secret struct {
+	*pb.SecretInfo
+	Scope *auth.Scope
+}
 
 // SecretStore defines the secret storage interface.
 type SecretStore interface {
 	// List(ctx context.Context, username string, opts metav1.ListOptions) (*v1.SecretList, error)
-	List() (map[string]*pb.SecretInfo, error)
+	List() (map[string]*Secret, error)
 }
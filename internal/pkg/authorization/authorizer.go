@@ -0,0 +1,82 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package authorization
+
+import (
+	authzv1 "github.com/marmotedu/api/authz/v1"
+	"github.com/ory/ladon"
+
+	// register the custom conditions the policies may reference.
+	_ "github.com/marmotedu/iam/internal/pkg/condition"
+	"github.com/marmotedu/iam/pkg/log"
+)
+
+// Authorizer implement the authorize interface that use local repository to
+// authorize the subject access review.
+type Authorizer struct {
+	warden      ladon.Warden
+	auditLogger ladon.AuditLogger
+}
+
+// Scope restricts the requests which are authorized against the policies.
+type Scope interface {
+	Allows(resource, action string) bool
+}
+
+// scopePolicy is the decider audited for the requests out of their scope.
+var scopePolicy = &ladon.DefaultPolicy{
+	ID:          "scope",
+	Description: "Denies the requests out of the scope of the secret",
+	Effect:      ladon.DenyAccess,
+}
+
+// NewAuthorizer creates a local repository authorizer and returns it.
+// The authorizer is safe for concurrent use, all the fields of the warden are
+// set here, because ladon lazily sets the missing ones on every request.
+func NewAuthorizer(authorizationClient AuthorizationInterface) *Authorizer {
+	auditLogger := NewAuditLogger(authorizationClient)
+
+	return &Authorizer{
+		warden: &ladon.Ladon{
+			Manager:     NewPolicyManager(authorizationClient),
+			Matcher:     ladon.DefaultMatcher,
+			AuditLogger: auditLogger,
+			Metric:      ladon.DefaultMetric,
+		},
+		auditLogger: auditLogger,
+	}
+}
+
+// Authorize to determine the subject access.
+func (a *Authorizer) Authorize(request *ladon.Request) *authzv1.Response {
+	log.Debug("authorize request", log.Any("request", request))
+
+	if err := a.warden.IsAllowed(request); err != nil {
+		return &authzv1.Response{
+			Denied: true,
+			Reason: err.Error(),
+		}
+	}
+
+	return &authzv1.Response{
+		Allowed: true,
+	}
+}
+
+// AuthorizeInScope is like Authorize, but the requests out of scope are denied
+// without evaluating the policies. The denial is audited like the ones of the
+// policies. A nil scope covers all the requests.
+func (a *Authorizer) AuthorizeInScope(request *ladon.Request, scope Scope) *authzv1.Response {
+	if scope != nil && !scope.Allows(request.Resource, request.Action) {
+		a.auditLogger.LogRejectedAccessRequest(request, nil, ladon.Policies{scopePolicy})
+
+		return &authzv1.Response{
+			Denied: true,
+			Reason: "Request is out of the scope of the secret",
+		}
+	}
+
+	return a.Authorize(request)
+}
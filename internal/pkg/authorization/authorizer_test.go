@@ -6,6 +6,7 @@ package authorization
 
 import (
 	"reflect"
+	"sync"
 	"testing"
 
 	gomock "github.com/golang/mock/gomock"
@@ -35,8 +36,11 @@ func TestNewAuthorizer(t *testing.T) {
 			want: &Authorizer{
 				warden: &ladon.Ladon{
 					Manager:     NewPolicyManager(mockAuthz),
+					Matcher:     ladon.DefaultMatcher,
 					AuditLogger: NewAuditLogger(mockAuthz),
+					Metric:      ladon.DefaultMetric,
 				},
+				auditLogger: NewAuditLogger(mockAuthz),
 			},
 		},
 	}
@@ -157,3 +161,99 @@ func TestAuthorizer_Authorize(t *testing.T) {
 		})
 	}
 }
+
+func newStaticAuthorization() AuthorizationInterface {
+	return NewStaticAuthorization(&ladon.DefaultPolicy{
+		ID:        "68819e5a-738b-41ec-b03c-b58a1b19d043",
+		Subjects:  []string{"users:<peter|ken>"},
+		Resources: []string{"resources:articles:<.*>"},
+		Actions:   []string{"delete", "<create|update>"},
+		Effect:    ladon.AllowAccess,
+	})
+}
+
+func newAuthorizeRequest() *ladon.Request {
+	return &ladon.Request{
+		Subject:  "users:peter",
+		Resource: "resources:articles:ladon-introduction",
+		Action:   "delete",
+		Context:  ladon.Context{"username": "colin"},
+	}
+}
+
+func TestAuthorizer_AuthorizeConcurrently(t *testing.T) {
+	auth := NewAuthorizer(newStaticAuthorization())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < 100; j++ {
+				if rsp := auth.Authorize(newAuthorizeRequest()); !rsp.Allowed {
+					t.Errorf("Authorize() = %v, want allowed", rsp)
+
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func BenchmarkAuthorizer_Authorize(b *testing.B) {
+	authz := newStaticAuthorization()
+
+	b.Run("shared", func(b *testing.B) {
+		auth := NewAuthorizer(authz)
+
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			auth.Authorize(newAuthorizeRequest())
+		}
+	})
+
+	b.Run("per-request", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			NewAuthorizer(authz).Authorize(newAuthorizeRequest())
+		}
+	})
+}
+
+type fakeScope struct {
+	action string
+}
+
+func (s fakeScope) Allows(resource, action string) bool {
+	return action == s.action
+}
+
+func TestAuthorizer_AuthorizeInScope(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAuthz := NewMockAuthorizationInterface(ctrl)
+	mockAuthz.EXPECT().List(gomock.Any()).Return([]*ladon.DefaultPolicy{}, nil)
+	// the request out of scope is audited without evaluating the policies
+	mockAuthz.EXPECT().LogRejectedAccessRequest(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ *ladon.Request, _ ladon.Policies, d ladon.Policies) {
+			if len(d) != 1 || d[0].GetID() != scopePolicy.ID {
+				t.Errorf("LogRejectedAccessRequest() deciders = %v, want the scope policy", d)
+			}
+		})
+	mockAuthz.EXPECT().LogRejectedAccessRequest(gomock.Any(), gomock.Any(), gomock.Any())
+
+	a := NewAuthorizer(mockAuthz)
+
+	rsp := a.AuthorizeInScope(newAuthorizeRequest(), fakeScope{action: "get"})
+	if !rsp.Denied || rsp.Reason != "Request is out of the scope of the secret" {
+		t.Errorf("AuthorizeInScope() = %v, want denied by the scope", rsp)
+	}
+
+	rsp = a.AuthorizeInScope(newAuthorizeRequest(), fakeScope{action: "delete"})
+	if !rsp.Denied || rsp.Reason != "Request was denied by default" {
+		t.Errorf("AuthorizeInScope() = %v, want denied by the policies", rsp)
+	}
+}
@@ -50,7 +50,7 @@ func (*PolicyManager) GetAll(limit, offset int64) (ladon.Policies, error) {
 
 // FindRequestCandidates returns candidates that could match the request object. It either returns
 // a set that exactly matches the request, or a superset of it. If an error occurs, it returns nil and
-// the error.
+// the error. Policies out of their validity window are not candidates.
 func (m *PolicyManager) FindRequestCandidates(r *ladon.Request) (ladon.Policies, error) {
 	username := ""
 
@@ -63,8 +63,13 @@ func (m *PolicyManager) FindRequestCandidates(r *ladon.Request) (ladon.Policies,
 		return nil, errors.Wrap(err, "list policies failed")
 	}
 
+	// policies are cached, so their validity window is checked for each request.
 	ret := make([]ladon.Policy, 0, len(policies))
 	for _, policy := range policies {
+		if !inEffect(policy) {
+			continue
+		}
+
 		ret = append(ret, policy)
 	}
 
@@ -3,7 +3,7 @@
 // license that can be found in the LICENSE file.
 
 // Code generated by MockGen. DO NOT EDIT.
-// Source: github.com/marmotedu/iam/internal/authzserver/authorization (interfaces: AuthorizationInterface)
+// Source: github.com/marmotedu/iam/internal/pkg/authorization (interfaces: AuthorizationInterface)
 
 // Package authorization is a generated GoMock package.
 package authorization
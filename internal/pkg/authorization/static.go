@@ -0,0 +1,66 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package authorization
+
+import (
+	"github.com/marmotedu/errors"
+	"github.com/ory/ladon"
+)
+
+// staticAuthorization authorizes the requests of all the users against a fixed
+// set of policies, nothing is persisted or recorded.
+type staticAuthorization struct {
+	policies []*ladon.DefaultPolicy
+}
+
+// NewStaticAuthorization returns an AuthorizationInterface which serves the
+// given policies to every user, e.g. to simulate a policy before saving it.
+func NewStaticAuthorization(policies ...*ladon.DefaultPolicy) AuthorizationInterface {
+	return &staticAuthorization{policies: policies}
+}
+
+// Create does nothing, the policies are fixed.
+func (s *staticAuthorization) Create(*ladon.DefaultPolicy) error {
+	return nil
+}
+
+// Update does nothing, the policies are fixed.
+func (s *staticAuthorization) Update(*ladon.DefaultPolicy) error {
+	return nil
+}
+
+// Delete does nothing, the policies are fixed.
+func (s *staticAuthorization) Delete(string) error {
+	return nil
+}
+
+// DeleteCollection does nothing, the policies are fixed.
+func (s *staticAuthorization) DeleteCollection([]string) error {
+	return nil
+}
+
+// Get returns the policy with the given identifier.
+func (s *staticAuthorization) Get(id string) (*ladon.DefaultPolicy, error) {
+	for _, p := range s.policies {
+		if p.ID == id {
+			return p, nil
+		}
+	}
+
+	return nil, errors.Errorf("policy %s not found", id)
+}
+
+// List returns all the policies whoever the user is.
+func (s *staticAuthorization) List(string) ([]*ladon.DefaultPolicy, error) {
+	return s.policies, nil
+}
+
+// LogRejectedAccessRequest does nothing, nothing is recorded.
+func (s *staticAuthorization) LogRejectedAccessRequest(*ladon.Request, ladon.Policies, ladon.Policies) {
+}
+
+// LogGrantedAccessRequest does nothing, nothing is recorded.
+func (s *staticAuthorization) LogGrantedAccessRequest(*ladon.Request, ladon.Policies, ladon.Policies) {
+}
@@ -0,0 +1,111 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package authorization
+
+import (
+	"time"
+
+	"github.com/marmotedu/component-base/pkg/json"
+	"github.com/marmotedu/errors"
+	"github.com/ory/ladon"
+)
+
+// Keys of the validity window in the policy meta.
+const (
+	EffectiveFromKey = "effectiveFrom"
+	ExpiresAtKey     = "expiresAt"
+)
+
+// now returns the current time, it's replaced in tests.
+var now = time.Now
+
+// Validity is the window in which a policy is in effect, policies outside their
+// window neither grant nor deny access. A nil bound means the window is unbounded
+// on that side.
+type Validity struct {
+	EffectiveFrom *time.Time `json:"effectiveFrom,omitempty"`
+	ExpiresAt     *time.Time `json:"expiresAt,omitempty"`
+}
+
+// Validate validates the validity window.
+func (v Validity) Validate() error {
+	if v.EffectiveFrom != nil && v.ExpiresAt != nil && !v.ExpiresAt.After(*v.EffectiveFrom) {
+		return errors.Errorf("%s must be after %s", ExpiresAtKey, EffectiveFromKey)
+	}
+
+	return nil
+}
+
+// Contains reports whether t is in the validity window.
+func (v Validity) Contains(t time.Time) bool {
+	if v.EffectiveFrom != nil && t.Before(*v.EffectiveFrom) {
+		return false
+	}
+
+	return v.ExpiresAt == nil || t.Before(*v.ExpiresAt)
+}
+
+// PolicyValidity returns the validity window stored in the policy meta.
+func PolicyValidity(policy ladon.Policy) (Validity, error) {
+	var v Validity
+
+	meta := policy.GetMeta()
+	if len(meta) == 0 {
+		return v, nil
+	}
+
+	if err := json.Unmarshal(meta, &v); err != nil {
+		return v, errors.Wrap(err, "decode policy meta failed")
+	}
+
+	return v, nil
+}
+
+// SetPolicyValidity stores the validity window in the policy meta, the other
+// fields of the meta are kept.
+func SetPolicyValidity(policy *ladon.DefaultPolicy, v Validity) error {
+	meta := make(map[string]interface{})
+	if len(policy.Meta) != 0 {
+		if err := json.Unmarshal(policy.Meta, &meta); err != nil {
+			return errors.Wrap(err, "policy meta must be a json object")
+		}
+	}
+
+	delete(meta, EffectiveFromKey)
+	delete(meta, ExpiresAtKey)
+
+	if v.EffectiveFrom != nil {
+		meta[EffectiveFromKey] = v.EffectiveFrom
+	}
+
+	if v.ExpiresAt != nil {
+		meta[ExpiresAtKey] = v.ExpiresAt
+	}
+
+	if len(meta) == 0 {
+		policy.Meta = nil
+
+		return nil
+	}
+
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	policy.Meta = data
+
+	return nil
+}
+
+// inEffect reports whether the policy is in its validity window now. Policies
+// with an undecodable meta have no validity window.
+func inEffect(policy ladon.Policy) bool {
+	v, err := PolicyValidity(policy)
+	if err != nil {
+		return true
+	}
+
+	return v.Contains(now())
+}
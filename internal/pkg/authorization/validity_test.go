@@ -0,0 +1,118 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package authorization
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ory/ladon"
+	"github.com/stretchr/testify/assert"
+)
+
+func validityPolicy(t *testing.T, id, effect string, v Validity) *ladon.DefaultPolicy {
+	t.Helper()
+
+	policy := &ladon.DefaultPolicy{
+		ID:        id,
+		Subjects:  []string{"users:peter"},
+		Resources: []string{"resources:articles:<.*>"},
+		Actions:   []string{"delete"},
+		Effect:    effect,
+		Meta:      []byte(`{"owner":"colin"}`),
+	}
+	assert.Nil(t, SetPolicyValidity(policy, v))
+
+	return policy
+}
+
+func TestPolicyValidity(t *testing.T) {
+	from := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+
+	policy := validityPolicy(t, "p", ladon.AllowAccess, Validity{EffectiveFrom: &from, ExpiresAt: &to})
+	assert.JSONEq(t, `{"owner":"colin","effectiveFrom":"2021-01-01T00:00:00Z","expiresAt":"2021-01-02T00:00:00Z"}`,
+		string(policy.Meta))
+
+	v, err := PolicyValidity(policy)
+	assert.Nil(t, err)
+	assert.True(t, from.Equal(*v.EffectiveFrom))
+	assert.True(t, to.Equal(*v.ExpiresAt))
+
+	assert.False(t, v.Contains(from.Add(-time.Second)))
+	assert.True(t, v.Contains(from))
+	assert.True(t, v.Contains(to.Add(-time.Second)))
+	assert.False(t, v.Contains(to))
+
+	assert.NotNil(t, Validity{EffectiveFrom: &to, ExpiresAt: &from}.Validate())
+
+	assert.Nil(t, SetPolicyValidity(policy, Validity{}))
+	assert.JSONEq(t, `{"owner":"colin"}`, string(policy.Meta))
+}
+
+func TestAuthorizer_PolicyValidity(t *testing.T) {
+	defer func(f func() time.Time) { now = f }(now)
+	current := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	now = func() time.Time { return current }
+
+	past := current.Add(-time.Hour)
+	future := current.Add(time.Hour)
+	request := &ladon.Request{
+		Subject:  "users:peter",
+		Resource: "resources:articles:ladon-introduction",
+		Action:   "delete",
+	}
+
+	tests := []struct {
+		name     string
+		policies []*ladon.DefaultPolicy
+		allowed  bool
+	}{
+		{
+			name: "in effect",
+			policies: []*ladon.DefaultPolicy{
+				validityPolicy(t, "allow", ladon.AllowAccess, Validity{EffectiveFrom: &past, ExpiresAt: &future}),
+			},
+			allowed: true,
+		},
+		{
+			name: "not yet effective",
+			policies: []*ladon.DefaultPolicy{
+				validityPolicy(t, "allow", ladon.AllowAccess, Validity{EffectiveFrom: &future}),
+			},
+			allowed: false,
+		},
+		{
+			name: "expired",
+			policies: []*ladon.DefaultPolicy{
+				validityPolicy(t, "allow", ladon.AllowAccess, Validity{ExpiresAt: &past}),
+			},
+			allowed: false,
+		},
+		{
+			name: "expired deny",
+			policies: []*ladon.DefaultPolicy{
+				validityPolicy(t, "allow", ladon.AllowAccess, Validity{}),
+				validityPolicy(t, "deny", ladon.DenyAccess, Validity{ExpiresAt: &past}),
+			},
+			allowed: true,
+		},
+		{
+			name: "not yet effective deny",
+			policies: []*ladon.DefaultPolicy{
+				validityPolicy(t, "allow", ladon.AllowAccess, Validity{}),
+				validityPolicy(t, "deny", ladon.DenyAccess, Validity{EffectiveFrom: &future}),
+			},
+			allowed: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := NewAuthorizer(NewStaticAuthorization(tt.policies...)).Authorize(request)
+			assert.Equal(t, tt.allowed, resp.Allowed)
+		})
+	}
+}
@@ -13,6 +13,9 @@ const (
 
 	// ErrUserAlreadyExist - 400: User already exist.
 	ErrUserAlreadyExist
+
+	// ErrUserDisabled - 403: User is disabled.
+	ErrUserDisabled
 )
 
 // iam-apiserver: secret errors.
@@ -27,6 +27,15 @@ const (
 
 	// ErrPageNotFound - 404: Page not found.
 	ErrPageNotFound
+
+	// ErrRequestTimeout - 504: Request timed out.
+	ErrRequestTimeout
+
+	// ErrRequestEntityTooLarge - 413: Request body too large.
+	ErrRequestEntityTooLarge
+
+	// ErrServiceUnavailable - 503: Service unavailable.
+	ErrServiceUnavailable
 )
 
 // common: database errors.
@@ -57,6 +66,9 @@ const (
 
 	// PermissionDenied - 403: Permission denied.
 	ErrPermissionDenied
+
+	// ErrTooManyRequests - 429: Too many requests.
+	ErrTooManyRequests
 )
 
 // common: encode/decode errors.
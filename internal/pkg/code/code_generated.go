@@ -10,6 +10,7 @@ package code
 func init() {
 	register(ErrUserNotFound, 404, "User not found")
 	register(ErrUserAlreadyExist, 400, "User already exist")
+	register(ErrUserDisabled, 403, "User is disabled")
 	register(ErrReachMaxCount, 400, "Secret reach the max count")
 	register(ErrSecretNotFound, 404, "Secret not found")
 	register(ErrPolicyNotFound, 404, "Policy not found")
@@ -19,6 +20,9 @@ func init() {
 	register(ErrValidation, 400, "Validation failed")
 	register(ErrTokenInvalid, 401, "Token invalid")
 	register(ErrPageNotFound, 404, "Page not found")
+	register(ErrRequestTimeout, 504, "Request timed out")
+	register(ErrRequestEntityTooLarge, 413, "Request body too large")
+	register(ErrServiceUnavailable, 503, "Service unavailable")
 	register(ErrDatabase, 500, "Database error")
 	register(ErrEncrypt, 401, "Error occurred while encrypting the user password")
 	register(ErrSignatureInvalid, 401, "Signature is invalid")
@@ -27,6 +31,7 @@ func init() {
 	register(ErrMissingHeader, 401, "The `Authorization` header was empty")
 	register(ErrPasswordIncorrect, 401, "Password was incorrect")
 	register(ErrPermissionDenied, 403, "Permission denied")
+	register(ErrTooManyRequests, 429, "Too many requests")
 	register(ErrEncodingFailed, 500, "Encoding failed due to an error with the data")
 	register(ErrDecodingFailed, 500, "Decoding failed due to an error with the data")
 	register(ErrInvalidJSON, 500, "Data is not valid JSON")
@@ -0,0 +1,51 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package condition
+
+import (
+	"sort"
+
+	"github.com/marmotedu/errors"
+	"github.com/ory/ladon"
+)
+
+func init() {
+	Register(func() ladon.Condition { return new(TimeCondition) })
+}
+
+// Register registers a custom ladon condition, so policies are able to
+// reference it by the name returned by its GetName method. It's not safe for
+// concurrent use, call it in init functions.
+func Register(factory func() ladon.Condition) {
+	ladon.ConditionFactories[factory().GetName()] = factory
+}
+
+// Validator is implemented by the conditions which are able to check their options,
+// a policy with invalid options is rejected instead of never being fulfilled.
+type Validator interface {
+	Validate() error
+}
+
+// Validate validates the options of the conditions which implement Validator.
+func Validate(conditions ladon.Conditions) error {
+	keys := make([]string, 0, len(conditions))
+	for key := range conditions {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		v, ok := conditions[key].(Validator)
+		if !ok {
+			continue
+		}
+
+		if err := v.Validate(); err != nil {
+			return errors.Wrapf(err, "invalid condition %s", key)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,25 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package condition
+
+import (
+	"testing"
+
+	"github.com/ory/ladon"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	assert.Nil(t, Validate(ladon.Conditions{
+		"businessHours":   &TimeCondition{After: "09:00", Before: "18:00"},
+		"remoteIPAddress": &ladon.CIDRCondition{CIDR: "192.168.0.0/16"},
+	}))
+
+	err := Validate(ladon.Conditions{
+		"businessHours": &TimeCondition{After: "9am"},
+	})
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "invalid condition businessHours")
+}
@@ -0,0 +1,33 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+/*
+Package condition registers the custom ladon conditions supported by iam.
+
+Policies reference a condition by its name in the "type" field of a condition,
+the condition is checked against the value of the request context which has the
+same key as the condition, e.g. the following policy is only fulfilled during
+business hours, and for the requests from 192.168.0.0/16:
+
+	"conditions": {
+	    "businessHours": {
+	        "type": "TimeCondition",
+	        "options": {"after": "09:00", "before": "18:00", "location": "Asia/Shanghai", "weekdays": [1, 2, 3, 4, 5]}
+	    },
+	    "remoteIPAddress": {
+	        "type": "CIDRCondition",
+	        "options": {"cidr": "192.168.0.0/16"}
+	    }
+	}
+
+TimeCondition doesn't read the request context, it's checked against the clock
+of iam-authz-server, so the requester can't fake it. CIDRCondition and the other
+built-in conditions are provided by ladon.
+
+Conditions must be registered before any policy is decoded, the authorization
+package, which both iam-apiserver and iam-authz-server import, imports this package
+for its side effect. The options of a condition implementing Validator are validated
+when the policy is created or updated.
+*/
+package condition // import "github.com/marmotedu/iam/internal/pkg/condition"
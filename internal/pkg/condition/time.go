@@ -0,0 +1,116 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package condition
+
+import (
+	"time"
+
+	"github.com/marmotedu/errors"
+	"github.com/ory/ladon"
+)
+
+// now is replaced in tests.
+var now = time.Now
+
+// timeOfDayLayout is the layout of the After and Before fields.
+const timeOfDayLayout = "15:04"
+
+// TimeCondition is fulfilled when the request is made in the time of day range
+// [After, Before), on one of Weekdays. The range wraps around midnight when
+// After is later than Before, e.g. 22:00 - 06:00.
+type TimeCondition struct {
+	// After defaults to 00:00.
+	After string `json:"after,omitempty"`
+	// Before defaults to 24:00.
+	Before string `json:"before,omitempty"`
+	// Location is the IANA time zone name of After and Before, defaults to UTC.
+	Location string `json:"location,omitempty"`
+	// Weekdays is the days of week the condition is fulfilled on, 0 is Sunday.
+	// Empty means every day.
+	Weekdays []time.Weekday `json:"weekdays,omitempty"`
+}
+
+// GetName returns the condition's name.
+func (c *TimeCondition) GetName() string {
+	return "TimeCondition"
+}
+
+// Fulfills returns true if the request is made in the time range. The request
+// context is ignored, the current time of the server is used.
+func (c *TimeCondition) Fulfills(_ interface{}, _ *ladon.Request) bool {
+	loc, err := time.LoadLocation(c.Location)
+	if err != nil {
+		return false
+	}
+	t := now().In(loc)
+
+	if len(c.Weekdays) > 0 && !containsWeekday(c.Weekdays, t.Weekday()) {
+		return false
+	}
+
+	after, ok := minuteOfDay(c.After, 0)
+	if !ok {
+		return false
+	}
+	before, ok := minuteOfDay(c.Before, 24*60)
+	if !ok {
+		return false
+	}
+
+	minute := t.Hour()*60 + t.Minute()
+	if after <= before {
+		return after <= minute && minute < before
+	}
+
+	// the range wraps around midnight
+	return minute >= after || minute < before
+}
+
+// Validate validates the time range, the time zone and the days of week.
+func (c *TimeCondition) Validate() error {
+	if _, err := time.LoadLocation(c.Location); err != nil {
+		return errors.Errorf("unknown location %q", c.Location)
+	}
+
+	if _, ok := minuteOfDay(c.After, 0); !ok {
+		return errors.Errorf("after %q must be in HH:MM format", c.After)
+	}
+
+	if _, ok := minuteOfDay(c.Before, 24*60); !ok {
+		return errors.Errorf("before %q must be in HH:MM format", c.Before)
+	}
+
+	for _, day := range c.Weekdays {
+		if day < time.Sunday || day > time.Saturday {
+			return errors.Errorf("weekday %d must be in [0, 6]", day)
+		}
+	}
+
+	return nil
+}
+
+// minuteOfDay parses the time of day in HH:MM format into minutes since midnight.
+func minuteOfDay(value string, defaultMinute int) (int, bool) {
+	if value == "" {
+		return defaultMinute, true
+	}
+
+	t, err := time.Parse(timeOfDayLayout, value)
+	if err != nil {
+		return 0, false
+	}
+
+	return t.Hour()*60 + t.Minute(), true
+}
+
+func containsWeekday(weekdays []time.Weekday, day time.Weekday) bool {
+	for _, d := range weekdays {
+		if d == day {
+			return true
+		}
+	}
+
+	return false
+}
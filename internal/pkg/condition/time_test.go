@@ -0,0 +1,125 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package condition
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ory/ladon"
+	"github.com/ory/ladon/manager/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+const conditionalPolicy = `{
+	"id": "business-hours",
+	"subjects": ["users:peter"],
+	"resources": ["resources:articles:<.*>"],
+	"actions": ["delete"],
+	"effect": "allow",
+	"conditions": {
+		"businessHours": {
+			"type": "TimeCondition",
+			"options": {"after": "09:00", "before": "18:00", "location": "Asia/Shanghai", "weekdays": [1, 2, 3, 4, 5]}
+		},
+		"remoteIPAddress": {
+			"type": "CIDRCondition",
+			"options": {"cidr": "192.168.0.0/16"}
+		}
+	}
+}`
+
+func setNow(t *testing.T, value string) {
+	t.Helper()
+
+	tm, err := time.Parse(time.RFC3339, value)
+	assert.Nil(t, err)
+
+	now = func() time.Time { return tm }
+	t.Cleanup(func() { now = time.Now })
+}
+
+func TestConditions_Authorize(t *testing.T) {
+	var policy ladon.DefaultPolicy
+	assert.Nil(t, json.Unmarshal([]byte(conditionalPolicy), &policy))
+	assert.IsType(t, &TimeCondition{}, policy.Conditions["businessHours"])
+
+	warden := &ladon.Ladon{Manager: memory.NewMemoryManager()}
+	assert.Nil(t, warden.Manager.Create(&policy))
+
+	tests := []struct {
+		name    string
+		now     string
+		ip      string
+		allowed bool
+	}{
+		// 2022-05-09 is a Monday
+		{"business hours", "2022-05-09T10:00:00+08:00", "192.168.1.10", true},
+		{"before business hours", "2022-05-09T08:59:00+08:00", "192.168.1.10", false},
+		{"after business hours", "2022-05-09T18:00:00+08:00", "192.168.1.10", false},
+		{"business hours in another time zone", "2022-05-09T02:00:00Z", "192.168.1.10", true},
+		{"weekend", "2022-05-08T10:00:00+08:00", "192.168.1.10", false},
+		{"outside of cidr", "2022-05-09T10:00:00+08:00", "10.0.0.1", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setNow(t, tt.now)
+
+			err := warden.IsAllowed(&ladon.Request{
+				Subject:  "users:peter",
+				Resource: "resources:articles:ladon-introduction",
+				Action:   "delete",
+				Context:  ladon.Context{"remoteIPAddress": tt.ip},
+			})
+			assert.Equal(t, tt.allowed, err == nil)
+		})
+	}
+}
+
+func TestTimeCondition_Fulfills(t *testing.T) {
+	tests := []struct {
+		name      string
+		condition TimeCondition
+		now       string
+		want      bool
+	}{
+		{"no restriction", TimeCondition{}, "2022-05-09T03:00:00Z", true},
+		{"wraps around midnight, late", TimeCondition{After: "22:00", Before: "06:00"}, "2022-05-09T23:30:00Z", true},
+		{"wraps around midnight, early", TimeCondition{After: "22:00", Before: "06:00"}, "2022-05-09T05:59:00Z", true},
+		{"wraps around midnight, daytime", TimeCondition{After: "22:00", Before: "06:00"}, "2022-05-09T12:00:00Z", false},
+		{"invalid time", TimeCondition{After: "9am"}, "2022-05-09T12:00:00Z", false},
+		{"invalid location", TimeCondition{Location: "Nowhere/Nowhere"}, "2022-05-09T12:00:00Z", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setNow(t, tt.now)
+			assert.Equal(t, tt.want, tt.condition.Fulfills(nil, &ladon.Request{}))
+		})
+	}
+}
+
+func TestTimeCondition_Validate(t *testing.T) {
+	tests := []struct {
+		name      string
+		condition TimeCondition
+		wantErr   bool
+	}{
+		{"no restriction", TimeCondition{}, false},
+		{"valid", TimeCondition{After: "09:00", Before: "18:00", Location: "Asia/Shanghai", Weekdays: []time.Weekday{1, 5}}, false},
+		{"invalid after", TimeCondition{After: "9am"}, true},
+		{"invalid before", TimeCondition{Before: "25:00"}, true},
+		{"invalid location", TimeCondition{Location: "Nowhere/Nowhere"}, true},
+		{"invalid weekday", TimeCondition{Weekdays: []time.Weekday{7}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantErr, tt.condition.Validate() != nil)
+		})
+	}
+}
@@ -56,7 +56,7 @@ type Config struct {
 }
 
 // New create a gorm logger instance.
-func New(level int) gormlogger.Interface {
+func New(level gormlogger.LogLevel) gormlogger.Interface {
 	var (
 		infoStr      = "%s[info] "
 		warnStr      = "%s[warn] "
@@ -69,7 +69,7 @@ func New(level int) gormlogger.Interface {
 	config := Config{
 		SlowThreshold: 200 * time.Millisecond,
 		Colorful:      false,
-		LogLevel:      gormlogger.LogLevel(level),
+		LogLevel:      level,
 	}
 
 	if config.Colorful {
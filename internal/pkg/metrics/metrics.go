@@ -0,0 +1,40 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+// Package metrics holds the registry of the business metrics of the iam services, e.g. the metrics of
+// the authz cache, analytics and reload notifications. They are served by /metrics whether the http
+// request metrics are enabled or not.
+package metrics
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Registry is the registry of the business metrics.
+var Registry = prometheus.NewRegistry()
+
+// Register registers c to Registry, it returns the registered collector if one with the same
+// description has been registered, so the process won't panic when a package is initialized
+// with another collector of the same metric.
+func Register(c prometheus.Collector) prometheus.Collector {
+	if err := Registry.Register(c); err != nil {
+		var are prometheus.AlreadyRegisteredError
+		if errors.As(err, &are) {
+			return are.ExistingCollector
+		}
+	}
+
+	return c
+}
+
+// Handler returns the handler serving the business metrics, together with the metrics of the
+// default registry, which are the go runtime and process metrics, and the http request metrics
+// if they are enabled.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(prometheus.Gatherers{Registry, prometheus.DefaultGatherer}, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,32 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegister(t *testing.T) {
+	opts := prometheus.CounterOpts{Name: "iam_test_events_total", Help: "The number of the events of the test."}
+
+	first := Register(prometheus.NewCounter(opts)).(prometheus.Counter)
+	first.Inc()
+
+	// the collector of the same metric registered again is the registered one
+	second := Register(prometheus.NewCounter(opts)).(prometheus.Counter)
+	assert.Equal(t, first, second)
+
+	w := httptest.NewRecorder()
+	Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "iam_test_events_total 1")
+	// the metrics of the default registry are served too
+	assert.Contains(t, w.Body.String(), "go_goroutines")
+}
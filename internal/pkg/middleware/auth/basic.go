@@ -18,13 +18,15 @@ import (
 
 // BasicStrategy defines Basic authentication strategy.
 type BasicStrategy struct {
-	compare func(username string, password string) bool
+	compare func(c *gin.Context, username string, password string) error
 }
 
 var _ middleware.AuthStrategy = &BasicStrategy{}
 
 // NewBasicStrategy create basic strategy with compare function.
-func NewBasicStrategy(compare func(username string, password string) bool) BasicStrategy {
+// The error returned by compare is written to the client when the authentication fails.
+// compare receives the gin context, so what it loads can be shared with the later handlers.
+func NewBasicStrategy(compare func(c *gin.Context, username string, password string) error) BasicStrategy {
 	return BasicStrategy{
 		compare: compare,
 	}
@@ -49,7 +51,7 @@ func (b BasicStrategy) AuthFunc() gin.HandlerFunc {
 		payload, _ := base64.StdEncoding.DecodeString(auth[1])
 		pair := strings.SplitN(string(payload), ":", 2)
 
-		if len(pair) != 2 || !b.compare(pair[0], pair[1]) {
+		if len(pair) != 2 {
 			core.WriteResponse(
 				c,
 				errors.WithCode(code.ErrSignatureInvalid, "Authorization header format is wrong."),
@@ -60,6 +62,13 @@ func (b BasicStrategy) AuthFunc() gin.HandlerFunc {
 			return
 		}
 
+		if err := b.compare(c, pair[0], pair[1]); err != nil {
+			core.WriteResponse(c, err, nil)
+			c.Abort()
+
+			return
+		}
+
 		c.Set(middleware.UsernameKey, pair[0])
 
 		c.Next()
@@ -24,25 +24,56 @@ var (
 	ErrMissingSecret = errors.New("Can not obtain secret information from cache")
 )
 
+// SecretIDKey defines the key in gin context which holds the id of the secret authenticating the request.
+const SecretIDKey = "secretID"
+
+// previousSecretIDSuffix is appended to the secret id to identify the secret key
+// replaced by the last rotation.
+const previousSecretIDSuffix = ".previous"
+
 // Secret contains the basic information of the secret key.
 type Secret struct {
 	Username string
 	ID       string
 	Key      string
 	Expires  int64
+
+	// PreviousKey is the key replaced by the last rotation, which is accepted
+	// until PreviousExpires so that the tokens signed with it don't fail at once.
+	PreviousKey     string
+	PreviousExpires int64
+
+	// Scope restricts the access of the requests authenticated with the secret,
+	// nil means the secret has the same access as its owner.
+	Scope *Scope
+}
+
+// PreviousSecretID returns the id under which the key replaced by the last rotation
+// of the secret is cached.
+func PreviousSecretID(secretID string) string {
+	return secretID + previousSecretIDSuffix
 }
 
 // CacheStrategy defines jwt bearer authentication strategy which called `cache strategy`.
 // Secrets are obtained through grpc api interface and cached in memory.
 type CacheStrategy struct {
-	get func(kid string) (Secret, error)
+	get     func(kid string) (Secret, error)
+	limiter RateLimiter
 }
 
 var _ middleware.AuthStrategy = &CacheStrategy{}
 
 // NewCacheStrategy create cache strategy with function which can list and cache secrets.
 func NewCacheStrategy(get func(kid string) (Secret, error)) CacheStrategy {
-	return CacheStrategy{get}
+	return CacheStrategy{get: get}
+}
+
+// WithRateLimiter returns a copy of the cache strategy which rejects the requests
+// of the secrets exceeding the rate of limiter.
+func (cache CacheStrategy) WithRateLimiter(limiter RateLimiter) CacheStrategy {
+	cache.limiter = limiter
+
+	return cache
 }
 
 // AuthFunc defines cache strategy as the gin authentication middleware.
@@ -84,6 +115,13 @@ func (cache CacheStrategy) AuthFunc() gin.HandlerFunc {
 
 			return []byte(secret.Key), nil
 		})
+		if isSignatureInvalid(err) && secret.PreviousKey != "" && !KeyExpired(secret.PreviousExpires) {
+			// the token may be signed with the key replaced by a rotation, which is
+			// accepted during its grace period. The signing method is already checked.
+			parsedT, err = jwt.ParseWithClaims(rawJWT, claims, func(token *jwt.Token) (interface{}, error) {
+				return []byte(secret.PreviousKey), nil
+			})
+		}
 		if err != nil || !parsedT.Valid {
 			core.WriteResponse(c, errors.WithCode(code.ErrSignatureInvalid, err.Error()), nil)
 			c.Abort()
@@ -99,11 +137,28 @@ func (cache CacheStrategy) AuthFunc() gin.HandlerFunc {
 			return
 		}
 
+		if cache.limiter != nil && !cache.limiter.Allow(secret.ID) {
+			core.WriteResponse(c, errors.WithCode(code.ErrTooManyRequests, "rate limit exceeded for secret: %s", secret.ID), nil)
+			c.Abort()
+
+			return
+		}
+
 		c.Set(middleware.UsernameKey, secret.Username)
+		c.Set(SecretIDKey, secret.ID)
+		if secret.Scope != nil {
+			c.Set(ScopeKey, secret.Scope)
+		}
 		c.Next()
 	}
 }
 
+func isSignatureInvalid(err error) bool {
+	var verr *jwt.ValidationError
+
+	return errors.As(err, &verr) && verr.Errors&jwt.ValidationErrorSignatureInvalid != 0
+}
+
 // KeyExpired checks if a key has expired, if the value of user.SessionState.Expires is 0, it will be ignored.
 func KeyExpired(expires int64) bool {
 	if expires >= 1 {
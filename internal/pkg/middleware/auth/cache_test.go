@@ -0,0 +1,95 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	jwt "github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func signToken(t *testing.T, kid, key string) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"exp": time.Now().Add(time.Hour).Unix(),
+		"iat": time.Now().Unix(),
+	})
+	token.Header["kid"] = kid
+
+	signed, err := token.SignedString([]byte(key))
+	assert.Nil(t, err)
+
+	return signed
+}
+
+func TestCacheStrategy_RotatedKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	secret := Secret{
+		Username:        "colin",
+		ID:              "kid",
+		Key:             "new-key",
+		PreviousKey:     "old-key",
+		PreviousExpires: time.Now().Add(time.Minute).Unix(),
+	}
+
+	r := gin.New()
+	r.GET("/", NewCacheStrategy(func(kid string) (Secret, error) {
+		return secret, nil
+	}).AuthFunc(), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	request := func(token string) int {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, request(signToken(t, "kid", "new-key")))
+	// the replaced key is accepted during the grace period
+	assert.Equal(t, http.StatusOK, request(signToken(t, "kid", "old-key")))
+	assert.Equal(t, http.StatusUnauthorized, request(signToken(t, "kid", "other-key")))
+
+	secret.PreviousExpires = time.Now().Add(-time.Second).Unix()
+	assert.Equal(t, http.StatusUnauthorized, request(signToken(t, "kid", "old-key")))
+	assert.Equal(t, http.StatusOK, request(signToken(t, "kid", "new-key")))
+}
+
+func TestCacheStrategy_Scope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	scope := &Scope{Actions: []string{"get"}}
+	var got interface{}
+	var secretID string
+
+	r := gin.New()
+	r.GET("/", NewCacheStrategy(func(kid string) (Secret, error) {
+		return Secret{Username: "colin", ID: kid, Key: "key", Scope: scope}, nil
+	}).AuthFunc(), func(c *gin.Context) {
+		got, _ = c.Get(ScopeKey)
+		secretID = c.GetString(SecretIDKey)
+		c.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+signToken(t, "kid", "key"))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, scope, got)
+	assert.Equal(t, "kid", secretID)
+}
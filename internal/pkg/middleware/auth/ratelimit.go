@@ -0,0 +1,45 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package auth
+
+import "time"
+
+// RateLimitKeyPrefix defines the prefix of the keys used to count the requests of secrets.
+const RateLimitKeyPrefix = "ratelimit-secret-"
+
+// RateLimiter decides whether a request authenticated with a secret is allowed.
+type RateLimiter interface {
+	Allow(secretID string) bool
+}
+
+// RollingWindow records a hit in a rolling window and returns the number of hits
+// recorded before it, which is implemented by storage.RedisCluster.
+type RollingWindow interface {
+	SetRollingWindow(keyName string, per int64, valueOverride string, pipeline bool) (int, []interface{})
+}
+
+type rollingWindowLimiter struct {
+	store RollingWindow
+	rate  int
+	per   int64
+}
+
+// NewRollingWindowLimiter creates a rate limiter which allows at most rate requests
+// per secret within the rolling window per.
+func NewRollingWindowLimiter(store RollingWindow, rate int, per time.Duration) RateLimiter {
+	return &rollingWindowLimiter{
+		store: store,
+		rate:  rate,
+		per:   int64(per / time.Second),
+	}
+}
+
+// Allow records the request of the secret and checks if the secret exceeds its rate.
+// Requests are allowed when the hits can not be counted, e.g. redis is down.
+func (l *rollingWindowLimiter) Allow(secretID string) bool {
+	count, _ := l.store.SetRollingWindow(RateLimitKeyPrefix+secretID, l.per, "-1", false)
+
+	return count < l.rate
+}
@@ -0,0 +1,56 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeRollingWindow counts the hits of every key without expiring them.
+type fakeRollingWindow map[string]int
+
+func (f fakeRollingWindow) SetRollingWindow(keyName string, _ int64, _ string, _ bool) (int, []interface{}) {
+	count := f[keyName]
+	f[keyName]++
+
+	return count, nil
+}
+
+func TestCacheStrategy_RateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	strategy := NewCacheStrategy(func(kid string) (Secret, error) {
+		return Secret{Username: "colin", ID: kid, Key: "key-" + kid}, nil
+	}).WithRateLimiter(NewRollingWindowLimiter(fakeRollingWindow{}, 2, time.Second))
+
+	r := gin.New()
+	r.GET("/", strategy.AuthFunc(), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	do := func(kid string) int {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+signToken(t, kid, "key-"+kid))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, do("abusive"))
+	assert.Equal(t, http.StatusOK, do("abusive"))
+	assert.Equal(t, http.StatusTooManyRequests, do("abusive"))
+	assert.Equal(t, http.StatusTooManyRequests, do("abusive"))
+
+	// the limit of a secret doesn't affect the others.
+	assert.Equal(t, http.StatusOK, do("normal"))
+	assert.Equal(t, http.StatusOK, do("normal"))
+}
@@ -0,0 +1,172 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+
+	"github.com/marmotedu/component-base/pkg/json"
+	"github.com/marmotedu/errors"
+	"github.com/ory/ladon"
+	"github.com/ory/ladon/compiler"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// ScopeKey defines the key in gin context which holds the scope of the secret
+// used to authenticate the request.
+const ScopeKey = "secretScope"
+
+// scopeMetadataKey is the grpc metadata key by which iam-authz-server tells
+// iam-apiserver that it enforces the scopes of the secrets, and by which
+// iam-apiserver sends the scopes back in the response header.
+const scopeMetadataKey = "x-iam-secret-scope"
+
+// Scope restricts the access granted by a secret to a subset of the resources and
+// actions of its owner. The patterns have the same syntax as the ones of ladon
+// policies, e.g. `resources:articles:<.*>`. An empty list matches everything.
+type Scope struct {
+	Resources []string `json:"resources,omitempty"`
+	Actions   []string `json:"actions,omitempty"`
+}
+
+// ParseScope decodes the scope from its json string, or from the value of an
+// extend field. nil is returned when there is no scope.
+func ParseScope(value interface{}) (*Scope, error) {
+	var data []byte
+
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		if v == "" {
+			return nil, nil
+		}
+		data = []byte(v)
+	default:
+		var err error
+		if data, err = json.Marshal(v); err != nil {
+			return nil, errors.Wrap(err, "encode scope failed")
+		}
+	}
+
+	scope := &Scope{}
+	if err := json.Unmarshal(data, scope); err != nil {
+		return nil, errors.Wrap(err, "decode scope failed")
+	}
+
+	return scope, scope.Validate()
+}
+
+// String returns the json string of the scope.
+func (s *Scope) String() string {
+	data, _ := json.Marshal(s)
+
+	return string(data)
+}
+
+// Validate checks that the patterns of the scope are valid.
+func (s *Scope) Validate() error {
+	for _, pattern := range append(append([]string{}, s.Resources...), s.Actions...) {
+		if !strings.Contains(pattern, "<") {
+			continue
+		}
+
+		if _, err := compiler.CompileRegex(pattern, '<', '>'); err != nil {
+			return errors.Wrapf(err, "invalid scope pattern %q", pattern)
+		}
+	}
+
+	return nil
+}
+
+// Allows reports whether the scope covers the action on the resource. A nil scope
+// covers everything.
+func (s *Scope) Allows(resource, action string) bool {
+	if s == nil {
+		return true
+	}
+
+	return matches(s.Resources, resource) && matches(s.Actions, action)
+}
+
+func matches(patterns []string, needle string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+
+	// the policy only provides the `<` `>` delimiters of the patterns
+	ok, err := ladon.DefaultMatcher.Matches(&ladon.DefaultPolicy{}, patterns, needle)
+
+	return err == nil && ok
+}
+
+// WithScopeSupport returns a copy of the grpc client context which tells the
+// server that the client enforces the scopes of the secrets.
+func WithScopeSupport(ctx context.Context) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, scopeMetadataKey, "true")
+}
+
+// ScopeSupported reports whether the grpc client of the request enforces the
+// scopes of the secrets. A client which predates the scopes would grant the
+// scoped secrets the full access of their owner.
+func ScopeSupported(ctx context.Context) bool {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return false
+	}
+
+	values := md.Get(scopeMetadataKey)
+
+	return len(values) > 0 && values[0] == "true"
+}
+
+// SendScopes sends the scopes of the listed secrets, keyed by secret id, to the
+// grpc client in the response header.
+func SendScopes(ctx context.Context, scopes map[string]*Scope) error {
+	data, err := json.Marshal(scopes)
+	if err != nil {
+		return errors.Wrap(err, "encode scopes failed")
+	}
+
+	// the patterns may hold characters which are not allowed in metadata values
+	value := base64.StdEncoding.EncodeToString(data)
+
+	return grpc.SetHeader(ctx, metadata.Pairs(scopeMetadataKey, value))
+}
+
+// ScopesFromHeader returns the scopes of the listed secrets, keyed by secret id,
+// from the response header sent by iam-apiserver.
+func ScopesFromHeader(header metadata.MD) (map[string]*Scope, error) {
+	scopes := make(map[string]*Scope)
+
+	values := header.Get(scopeMetadataKey)
+	if len(values) == 0 {
+		return scopes, nil
+	}
+
+	data, err := base64.StdEncoding.DecodeString(values[0])
+	if err != nil {
+		return nil, errors.Wrap(err, "decode scopes failed")
+	}
+
+	if err := json.Unmarshal(data, &scopes); err != nil {
+		return nil, errors.Wrap(err, "decode scopes failed")
+	}
+
+	for id, scope := range scopes {
+		if scope == nil {
+			continue
+		}
+
+		if err := scope.Validate(); err != nil {
+			return nil, errors.Wrapf(err, "secret %s has an invalid scope", id)
+		}
+	}
+
+	return scopes, nil
+}
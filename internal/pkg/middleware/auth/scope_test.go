@@ -0,0 +1,66 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package auth
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestParseScope(t *testing.T) {
+	scope, err := ParseScope(nil)
+	assert.Nil(t, err)
+	assert.Nil(t, scope)
+	assert.True(t, scope.Allows("resources:printer", "delete"))
+
+	// the value of an extend field decoded from the database
+	scope, err = ParseScope(map[string]interface{}{
+		"resources": []interface{}{"resources:articles:<.*>"},
+		"actions":   []interface{}{"<get|list>"},
+	})
+	assert.Nil(t, err)
+	assert.True(t, scope.Allows("resources:articles:ladon", "get"))
+	assert.True(t, scope.Allows("resources:articles:ladon", "list"))
+	assert.False(t, scope.Allows("resources:articles:ladon", "delete"))
+	assert.False(t, scope.Allows("resources:printer", "get"))
+
+	// the scope in its json string
+	scope, err = ParseScope(scope.String())
+	assert.Nil(t, err)
+	assert.False(t, scope.Allows("resources:articles:ladon", "delete"))
+
+	scope, err = ParseScope(`{"actions":["get"]}`)
+	assert.Nil(t, err)
+	assert.True(t, scope.Allows("resources:printer", "get"))
+
+	_, err = ParseScope(`{"resources":["resources:<[>"]}`)
+	assert.NotNil(t, err)
+	_, err = ParseScope(`["get"]`)
+	assert.NotNil(t, err)
+}
+
+func TestScopesFromHeader(t *testing.T) {
+	scopes, err := ScopesFromHeader(metadata.MD{})
+	assert.Nil(t, err)
+	assert.Empty(t, scopes)
+
+	value := base64.StdEncoding.EncodeToString([]byte(`{"id1":{"actions":["get"]}}`))
+	scopes, err = ScopesFromHeader(metadata.Pairs(scopeMetadataKey, value))
+	assert.Nil(t, err)
+	if assert.Len(t, scopes, 1) {
+		assert.False(t, scopes["id1"].Allows("resources:printer", "delete"))
+	}
+
+	// a broken scope fails the whole list rather than granting full access
+	value = base64.StdEncoding.EncodeToString([]byte(`{"id1":{"resources":["resources:<[>"]}}`))
+	_, err = ScopesFromHeader(metadata.Pairs(scopeMetadataKey, value))
+	assert.NotNil(t, err)
+
+	_, err = ScopesFromHeader(metadata.Pairs(scopeMetadataKey, "{"))
+	assert.NotNil(t, err)
+}
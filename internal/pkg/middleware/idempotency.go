@@ -0,0 +1,234 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/marmotedu/component-base/pkg/core"
+	"github.com/marmotedu/component-base/pkg/json"
+	"github.com/marmotedu/errors"
+
+	"github.com/marmotedu/iam/internal/pkg/code"
+	"github.com/marmotedu/iam/pkg/log"
+)
+
+const (
+	// XIdempotencyKey defines the header carrying the idempotency key of a request.
+	XIdempotencyKey = "Idempotency-Key"
+	// XIdempotentReplayedKey defines the header set on the responses replayed for an idempotency key.
+	XIdempotentReplayedKey = "Idempotent-Replayed"
+
+	// DefaultIdempotencyTTL is the time the result of a request is kept for its idempotency key.
+	DefaultIdempotencyTTL = 24 * time.Hour
+
+	idempotencyKeyPrefix    = "iam-idempotency:"
+	idempotencyMaxKeyLength = 255
+	// idempotencyLockTimeout bounds the time a key is held by a request in progress, so that the key
+	// is released if the server dies before the request completes.
+	idempotencyLockTimeout = time.Minute
+)
+
+// IdempotencyStore stores the results of the requests by their idempotency keys.
+type IdempotencyStore interface {
+	GetRawKey(keyName string) (string, error)
+	SetRawKey(keyName, value string, timeout time.Duration) error
+	SetRawKeyIfNotExists(keyName, value string, timeout time.Duration) (bool, error)
+	DeleteRawKey(keyName string) bool
+}
+
+// idempotencyRecord is the state of an idempotency key, the response is only set once the request is done.
+type idempotencyRecord struct {
+	Fingerprint string `json:"fingerprint"`
+	Done        bool   `json:"done"`
+	Status      int    `json:"status,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
+
+// idempotencyWriter records the response body written by the handlers.
+type idempotencyWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *idempotencyWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *idempotencyWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Idempotency is a middleware for the create routes which makes the POST requests carrying an
+// Idempotency-Key header idempotent: the result of the first successful request is stored in store
+// for ttl, and the requests retried with the same key get the stored response, with the
+// Idempotent-Replayed header set, instead of creating the resource again.
+// The keys are scoped by the tenant and the user of the request, a key reused with a different request
+// is rejected with 400, and a key whose request is still in progress with 429. The failed requests
+// don't hold their key so they can be retried. The requests are served without the guarantee if
+// store is unavailable.
+// The top level fields of the json response named by redactedFields, e.g. the credentials, are not
+// stored in cleartext, so they are omitted from the replayed responses.
+func Idempotency(store IdempotencyStore, ttl time.Duration, redactedFields ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := strings.TrimSpace(c.GetHeader(XIdempotencyKey))
+		if key == "" || c.Request.Method != http.MethodPost {
+			c.Next()
+
+			return
+		}
+
+		if len(key) > idempotencyMaxKeyLength {
+			c.Abort()
+			core.WriteResponse(c, errors.WithCode(code.ErrValidation,
+				"%s must be at most %d characters", XIdempotencyKey, idempotencyMaxKeyLength), nil)
+
+			return
+		}
+
+		fingerprint, err := requestFingerprint(c)
+		if err != nil {
+			c.Abort()
+			core.WriteResponse(c, errors.WithCode(code.ErrBind, err.Error()), nil)
+
+			return
+		}
+
+		storeKey := idempotencyKeyPrefix + TenantIDFromContext(c) + ":" + c.GetString(UsernameKey) + ":" + key
+		lock, _ := json.Marshal(idempotencyRecord{Fingerprint: fingerprint})
+
+		acquired, err := store.SetRawKeyIfNotExists(storeKey, string(lock), idempotencyLockTimeout)
+		if err != nil {
+			log.L(c).Warnw("idempotency store is unavailable, serve the request without idempotency",
+				"key", key, "error", err.Error())
+			c.Next()
+
+			return
+		}
+
+		if !acquired {
+			replay(c, store, storeKey, fingerprint)
+
+			return
+		}
+
+		writer := &idempotencyWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+
+		if status := writer.Status(); status < http.StatusOK || status >= http.StatusMultipleChoices {
+			store.DeleteRawKey(storeKey)
+
+			return
+		}
+
+		body, err := redact(writer.body.Bytes(), redactedFields)
+		if err != nil {
+			log.L(c).Errorw("failed to redact the result of the idempotency key", "key", key, "error", err.Error())
+			store.DeleteRawKey(storeKey)
+
+			return
+		}
+
+		result, _ := json.Marshal(idempotencyRecord{
+			Fingerprint: fingerprint,
+			Done:        true,
+			Status:      writer.Status(),
+			ContentType: writer.Header().Get("Content-Type"),
+			Body:        body,
+		})
+		if err := store.SetRawKey(storeKey, string(result), ttl); err != nil {
+			log.L(c).Errorw("failed to store the result of the idempotency key", "key", key, "error", err.Error())
+			store.DeleteRawKey(storeKey)
+		}
+	}
+}
+
+// replay writes the stored response of the idempotency key.
+func replay(c *gin.Context, store IdempotencyStore, storeKey, fingerprint string) {
+	c.Abort()
+
+	value, err := store.GetRawKey(storeKey)
+	if err != nil {
+		// the key expired or was released by a failed request in the meantime
+		core.WriteResponse(c, errors.WithCode(code.ErrTooManyRequests,
+			"the request with the same %s is being processed, retry later", XIdempotencyKey), nil)
+
+		return
+	}
+
+	var record idempotencyRecord
+	if err := json.Unmarshal([]byte(value), &record); err != nil {
+		core.WriteResponse(c, errors.WithCode(code.ErrUnknown, "invalid idempotency record: %s", err.Error()), nil)
+
+		return
+	}
+
+	if record.Fingerprint != fingerprint {
+		core.WriteResponse(c, errors.WithCode(code.ErrValidation,
+			"%s is already used by a different request", XIdempotencyKey), nil)
+
+		return
+	}
+
+	if !record.Done {
+		core.WriteResponse(c, errors.WithCode(code.ErrTooManyRequests,
+			"the request with the same %s is being processed, retry later", XIdempotencyKey), nil)
+
+		return
+	}
+
+	c.Header(XIdempotentReplayedKey, "true")
+	c.Data(record.Status, record.ContentType, record.Body)
+}
+
+// redact removes fields from body, a json object.
+func redact(body []byte, fields []string) ([]byte, error) {
+	if len(fields) == 0 {
+		return body, nil
+	}
+
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(body, &object); err != nil {
+		return nil, err
+	}
+
+	for _, field := range fields {
+		delete(object, field)
+	}
+
+	return json.Marshal(object)
+}
+
+// requestFingerprint returns the hash of the method, path and body of the request, the body is
+// restored to be read by the handlers.
+func requestFingerprint(c *gin.Context) (string, error) {
+	var body []byte
+	if c.Request.Body != nil {
+		var err error
+		if body, err = io.ReadAll(c.Request.Body); err != nil {
+			return "", err
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	h := sha256.New()
+	h.Write([]byte(c.Request.Method + " " + c.Request.URL.Path + "\n"))
+	h.Write(body)
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
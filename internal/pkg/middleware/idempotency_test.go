@@ -0,0 +1,213 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/marmotedu/component-base/pkg/json"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeIdempotencyStore struct {
+	mu     sync.Mutex
+	values map[string]string
+	down   bool
+}
+
+func newFakeIdempotencyStore() *fakeIdempotencyStore {
+	return &fakeIdempotencyStore{values: map[string]string{}}
+}
+
+func (s *fakeIdempotencyStore) GetRawKey(keyName string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	value, ok := s.values[keyName]
+	if !ok {
+		return "", errors.New("key not found")
+	}
+
+	return value, nil
+}
+
+func (s *fakeIdempotencyStore) SetRawKey(keyName, value string, timeout time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.values[keyName] = value
+
+	return nil
+}
+
+func (s *fakeIdempotencyStore) SetRawKeyIfNotExists(keyName, value string, timeout time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.down {
+		return false, errors.New("redis is down")
+	}
+
+	if _, ok := s.values[keyName]; ok {
+		return false, nil
+	}
+	s.values[keyName] = value
+
+	return true, nil
+}
+
+func (s *fakeIdempotencyStore) DeleteRawKey(keyName string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.values, keyName)
+
+	return true
+}
+
+// newIdempotentRouter returns a router creating a secret per request, and the number of the secrets created.
+func newIdempotentRouter(store IdempotencyStore, status int) (*gin.Engine, *int) {
+	gin.SetMode(gin.TestMode)
+
+	created := 0
+	r := gin.New()
+	r.POST("/v1/secrets", Idempotency(store, time.Hour), func(c *gin.Context) {
+		if status != http.StatusOK {
+			c.Status(status)
+
+			return
+		}
+
+		created++
+		c.JSON(http.StatusOK, gin.H{"name": fmt.Sprintf("secret-%d", created)})
+	})
+
+	return r, &created
+}
+
+func doCreate(r http.Handler, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/v1/secrets", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(XIdempotencyKey, key)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	return w
+}
+
+func TestIdempotency(t *testing.T) {
+	r, created := newIdempotentRouter(newFakeIdempotencyStore(), http.StatusOK)
+
+	first := doCreate(r, "key-1", `{"name":"foo"}`)
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Empty(t, first.Header().Get(XIdempotentReplayedKey))
+
+	// the retried request gets the original result
+	second := doCreate(r, "key-1", `{"name":"foo"}`)
+	assert.Equal(t, http.StatusOK, second.Code)
+	assert.Equal(t, "true", second.Header().Get(XIdempotentReplayedKey))
+	assert.Equal(t, first.Body.String(), second.Body.String())
+	assert.Equal(t, first.Header().Get("Content-Type"), second.Header().Get("Content-Type"))
+	assert.Equal(t, 1, *created)
+
+	// the key can't be reused by a different request
+	w := doCreate(r, "key-1", `{"name":"bar"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, 1, *created)
+
+	// another key, or no key, creates a new resource
+	assert.Equal(t, `{"name":"secret-2"}`, doCreate(r, "key-2", `{"name":"foo"}`).Body.String())
+	assert.Equal(t, `{"name":"secret-3"}`, doCreate(r, "", `{"name":"foo"}`).Body.String())
+	assert.Equal(t, `{"name":"secret-4"}`, doCreate(r, "", `{"name":"foo"}`).Body.String())
+}
+
+func TestIdempotency_InProgress(t *testing.T) {
+	store := newFakeIdempotencyStore()
+	r, created := newIdempotentRouter(store, http.StatusOK)
+
+	// a request holding the key hasn't completed
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/secrets", strings.NewReader(`{"name":"foo"}`))
+	fingerprint, _ := requestFingerprint(c)
+	_, _ = store.SetRawKeyIfNotExists(idempotencyKeyPrefix+"::key-1",
+		fmt.Sprintf(`{"fingerprint":%q}`, fingerprint), time.Minute)
+
+	w := doCreate(r, "key-1", `{"name":"foo"}`)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, 0, *created)
+}
+
+func TestIdempotency_FailedRequest(t *testing.T) {
+	store := newFakeIdempotencyStore()
+	r, _ := newIdempotentRouter(store, http.StatusInternalServerError)
+
+	assert.Equal(t, http.StatusInternalServerError, doCreate(r, "key-1", `{"name":"foo"}`).Code)
+	// the failed request releases its key
+	assert.Empty(t, store.values)
+}
+
+func TestIdempotency_StoreDown(t *testing.T) {
+	store := newFakeIdempotencyStore()
+	store.down = true
+	r, created := newIdempotentRouter(store, http.StatusOK)
+
+	assert.Equal(t, http.StatusOK, doCreate(r, "key-1", `{"name":"foo"}`).Code)
+	assert.Equal(t, http.StatusOK, doCreate(r, "key-1", `{"name":"foo"}`).Code)
+	assert.Equal(t, 2, *created)
+}
+
+func TestIdempotency_ConcurrentRequests(t *testing.T) {
+	r, created := newIdempotentRouter(newFakeIdempotencyStore(), http.StatusOK)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	codes := map[int]int{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			code := doCreate(r, "key-1", `{"name":"foo"}`).Code
+			mu.Lock()
+			codes[code]++
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 1, *created)
+	assert.Equal(t, 10, codes[http.StatusOK]+codes[http.StatusTooManyRequests])
+}
+
+func TestIdempotency_Redacted(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	store := newFakeIdempotencyStore()
+	r := gin.New()
+	r.POST("/v1/secrets", Idempotency(store, time.Hour, "secretKey"), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"name": "secret-1", "secretKey": "cleartext"})
+	})
+
+	assert.Equal(t, `{"name":"secret-1","secretKey":"cleartext"}`, doCreate(r, "key-1", `{"name":"foo"}`).Body.String())
+	for _, value := range store.values {
+		var record idempotencyRecord
+		assert.Nil(t, json.Unmarshal([]byte(value), &record))
+		assert.Equal(t, `{"name":"secret-1"}`, string(record.Body))
+	}
+
+	// the replayed response omits the secret key
+	w := doCreate(r, "key-1", `{"name":"foo"}`)
+	assert.Equal(t, "true", w.Header().Get(XIdempotentReplayedKey))
+	assert.Equal(t, `{"name":"secret-1"}`, w.Body.String())
+}
@@ -0,0 +1,71 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/marmotedu/component-base/pkg/core"
+	"github.com/marmotedu/errors"
+
+	"github.com/marmotedu/iam/internal/pkg/code"
+)
+
+// DefaultMaxRequestBodySize is the request body size limit used by the registered maxbody middleware.
+const DefaultMaxRequestBodySize int64 = 10 << 20
+
+// MaxBodySize is a middleware that limits the size of the request body to limit bytes, the requests
+// with a larger body are rejected with 413 before the handlers bind the body.
+// The body of a request without Content-Length is read up front, so it's never held in memory beyond
+// the limit either.
+func MaxBodySize(limit int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if limit <= 0 || c.Request.Body == nil || c.Request.Body == http.NoBody {
+			c.Next()
+
+			return
+		}
+
+		if c.Request.ContentLength > limit {
+			abortEntityTooLarge(c, limit)
+
+			return
+		}
+
+		body := http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+		if c.Request.ContentLength < 0 {
+			buf, err := io.ReadAll(body)
+			if err != nil && int64(len(buf)) >= limit {
+				abortEntityTooLarge(c, limit)
+
+				return
+			}
+
+			if err != nil {
+				c.Abort()
+				core.WriteResponse(c, errors.WithCode(code.ErrBind, err.Error()), nil)
+
+				return
+			}
+
+			c.Request.Body = io.NopCloser(bytes.NewReader(buf))
+			c.Next()
+
+			return
+		}
+
+		c.Request.Body = body
+		c.Next()
+	}
+}
+
+func abortEntityTooLarge(c *gin.Context, limit int64) {
+	c.Abort()
+	core.WriteResponse(c, errors.WithCode(code.ErrRequestEntityTooLarge,
+		"request body exceeds the limit of %d bytes", limit), nil)
+}
@@ -0,0 +1,91 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/marmotedu/component-base/pkg/json"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/marmotedu/iam/internal/pkg/code"
+)
+
+func TestMaxBodySize(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var bound bool
+	r := gin.New()
+	r.Use(MaxBodySize(16))
+	r.POST("/v1/secrets", func(c *gin.Context) {
+		var body map[string]interface{}
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.String(http.StatusBadRequest, err.Error())
+
+			return
+		}
+
+		bound = true
+		c.String(http.StatusOK, "done")
+	})
+
+	tests := []struct {
+		name   string
+		body   string
+		length int64
+		status int
+	}{
+		{"small body", `{"name":"foo"}`, 14, http.StatusOK},
+		{"oversized body", `{"name":"foo","description":"bar"}`, 34, http.StatusRequestEntityTooLarge},
+		{"small chunked body", `{"name":"foo"}`, -1, http.StatusOK},
+		{"oversized chunked body", `{"name":"foo","description":"bar"}`, -1, http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bound = false
+
+			// hide the length of the body from the request for the chunked bodies
+			req := httptest.NewRequest(http.MethodPost, "/v1/secrets", io.MultiReader(strings.NewReader(tt.body)))
+			req.ContentLength = tt.length
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tt.status, w.Code)
+			assert.Equal(t, tt.status == http.StatusOK, bound)
+
+			if tt.status != http.StatusRequestEntityTooLarge {
+				return
+			}
+
+			var resp struct {
+				Code int `json:"code"`
+			}
+			assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, code.ErrRequestEntityTooLarge, resp.Code)
+		})
+	}
+}
+
+func TestMaxBodySize_Unlimited(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(MaxBodySize(0))
+	r.POST("/v1/secrets", func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.String(http.StatusOK, "%d", len(body))
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/secrets", strings.NewReader(strings.Repeat("a", 1024))))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "1024", w.Body.String())
+}
@@ -12,19 +12,24 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/marmotedu/component-base/pkg/json"
 
-	"github.com/marmotedu/iam/internal/authzserver/load"
+	"github.com/marmotedu/iam/internal/pkg/pubsub"
 	"github.com/marmotedu/iam/pkg/log"
 	"github.com/marmotedu/iam/pkg/storage"
 )
 
+// publishMessage publishes the message to the redis channel.
+var publishMessage = func(channel, message string) error {
+	redisStore := &storage.RedisCluster{}
+
+	return redisStore.Publish(channel, message)
+}
+
 // Publish publish a redis event to specified redis channel when some action occurred.
 func Publish() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Next()
 
-		if c.Writer.Status() != http.StatusOK {
-			log.L(c).Debugf("request failed with http status code `%d`, ignore publish message", c.Writer.Status())
-
+		if !succeeded(c) {
 			return
 		}
 
@@ -39,22 +44,55 @@ func Publish() gin.HandlerFunc {
 
 		switch resource {
 		case "policies":
-			notify(c, method, load.NoticePolicyChanged)
+			notify(c, method, pubsub.NoticePolicyChanged)
 		case "secrets":
-			notify(c, method, load.NoticeSecretChanged)
+			notify(c, method, pubsub.NoticeSecretChanged)
 		default:
 		}
 	}
 }
 
-func notify(ctx context.Context, method string, command load.NotificationCommand) {
+// PublishCommand publish the command to its redis channel when the request succeeded,
+// it's used by the routes which change resources of other kinds, e.g. users' secrets.
+func PublishCommand(command pubsub.NotificationCommand) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if !succeeded(c) {
+			return
+		}
+
+		notify(c, c.Request.Method, command)
+	}
+}
+
+func succeeded(c *gin.Context) bool {
+	if status := c.Writer.Status(); status < http.StatusOK || status >= http.StatusMultipleChoices {
+		log.L(c).Debugf("request failed with http status code `%d`, ignore publish message", c.Writer.Status())
+
+		return false
+	}
+
+	return true
+}
+
+func notify(ctx context.Context, method string, command pubsub.NotificationCommand) {
 	switch method {
-	case "POST", "PUT", "DELETE", "PATH":
-		redisStore := &storage.RedisCluster{}
-		message, _ := json.Marshal(load.Notification{Command: command})
+	case http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodPatch:
+		message, _ := json.Marshal(pubsub.Notification{Command: command})
 
-		if err := redisStore.Publish(load.RedisPubSubChannel, string(message)); err != nil {
+		if err := publishMessage(pubsub.NotificationChannel(command), string(message)); err != nil {
 			log.L(ctx).Errorw("publish redis message failed", "error", err.Error())
+			pubsub.RecordPublishFailure(command)
+		}
+
+		// the subscribers which predate the resource channels only listen on the shared channel
+		if mirrored, ok := pubsub.MirroredNotification(command); ok {
+			message, _ := json.Marshal(mirrored)
+			if err := publishMessage(pubsub.RedisPubSubChannel, string(message)); err != nil {
+				log.L(ctx).Errorw("publish mirrored redis message failed", "error", err.Error())
+				pubsub.RecordPublishFailure(command)
+			}
 		}
 		log.L(ctx).Debugw("publish redis message", "method", method, "command", command)
 	default:
@@ -0,0 +1,95 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/marmotedu/iam/internal/pkg/pubsub"
+)
+
+func TestPublish(t *testing.T) {
+	var channels []string
+	defer func(f func(string, string) error) { publishMessage = f }(publishMessage)
+	publishMessage = func(channel, message string) error {
+		channels = append(channels, channel)
+
+		return nil
+	}
+
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		status  int
+		channel string
+	}{
+		{"post created", http.MethodPost, "/v1/policies", http.StatusCreated, pubsub.PolicyChangedChannel},
+		{"post ok", http.MethodPost, "/v1/secrets", http.StatusOK, pubsub.SecretChangedChannel},
+		{"put", http.MethodPut, "/v1/policies/foo", http.StatusOK, pubsub.PolicyChangedChannel},
+		{"patch", http.MethodPatch, "/v1/secrets/foo", http.StatusOK, pubsub.SecretChangedChannel},
+		{"delete", http.MethodDelete, "/v1/policies/foo", http.StatusNoContent, pubsub.PolicyChangedChannel},
+		{"get", http.MethodGet, "/v1/policies/foo", http.StatusOK, ""},
+		{"bad request", http.MethodPost, "/v1/policies", http.StatusBadRequest, ""},
+		{"server error", http.MethodDelete, "/v1/secrets/foo", http.StatusInternalServerError, ""},
+		{"other resource", http.MethodPost, "/v1/users", http.StatusCreated, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			channels = nil
+
+			r := gin.New()
+			r.Use(Publish())
+			r.Handle(tt.method, tt.path, func(c *gin.Context) {
+				c.Status(tt.status)
+			})
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+			assert.Equal(t, tt.status, w.Code)
+
+			if tt.channel == "" {
+				assert.Empty(t, channels)
+
+				return
+			}
+			assert.Equal(t, []string{tt.channel, pubsub.RedisPubSubChannel}, channels)
+		})
+	}
+}
+
+func TestPublishCommand(t *testing.T) {
+	var channels []string
+	defer func(f func(string, string) error) { publishMessage = f }(publishMessage)
+	publishMessage = func(channel, message string) error {
+		channels = append(channels, channel)
+
+		return nil
+	}
+
+	gin.SetMode(gin.TestMode)
+
+	status := http.StatusOK
+	r := gin.New()
+	r.PUT("/v1/users/:name/disable", PublishCommand(pubsub.NoticeSecretChanged), func(c *gin.Context) {
+		c.Status(status)
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPut, "/v1/users/colin/disable", nil))
+	assert.Equal(t, []string{pubsub.SecretChangedChannel, pubsub.RedisPubSubChannel}, channels)
+
+	channels = nil
+	status = http.StatusForbidden
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPut, "/v1/users/colin/disable", nil))
+	assert.Empty(t, channels)
+}
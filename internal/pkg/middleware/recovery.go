@@ -0,0 +1,51 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"net/http"
+	"runtime/debug"
+
+	"github.com/gin-gonic/gin"
+	"github.com/marmotedu/component-base/pkg/core"
+	"github.com/marmotedu/errors"
+
+	"github.com/marmotedu/iam/internal/pkg/code"
+	"github.com/marmotedu/iam/pkg/log"
+)
+
+// Recovery is a middleware that recovers from panics, logs them with their
+// stack traces through pkg/log, and responds with a 500 error.
+func Recovery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			err := recover()
+			if err == nil {
+				return
+			}
+
+			// http.ErrAbortHandler is used to abort the handler on purpose, leave it to net/http.
+			if err == http.ErrAbortHandler {
+				panic(err)
+			}
+
+			log.L(c).Errorw("Recovered from panic",
+				"error", err,
+				"method", c.Request.Method,
+				"path", c.Request.URL.Path,
+				"stack", string(debug.Stack()),
+			)
+
+			c.Abort()
+			if c.Writer.Written() {
+				return
+			}
+
+			core.WriteResponse(c, errors.WithCode(code.ErrUnknown, "%v", err), nil)
+		}()
+
+		c.Next()
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/marmotedu/component-base/pkg/json"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/marmotedu/iam/internal/pkg/code"
+	"github.com/marmotedu/iam/pkg/log"
+)
+
+func TestRecovery(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "iam.log")
+	opts := log.NewOptions()
+	opts.Format = "json"
+	opts.OutputPaths = []string{output}
+	log.Init(opts)
+	defer log.Init(log.NewOptions())
+
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(RequestID(), Context(), Recovery())
+	r.GET("/panic", func(c *gin.Context) {
+		panic("something went wrong")
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+	var resp struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, code.ErrUnknown, resp.Code)
+	assert.NotContains(t, resp.Message, "something went wrong")
+
+	log.Flush()
+	data, err := os.ReadFile(output)
+	assert.Nil(t, err)
+
+	var entry map[string]interface{}
+	assert.Nil(t, json.Unmarshal(data, &entry))
+	assert.Equal(t, "Recovered from panic", entry["message"])
+	assert.Equal(t, "something went wrong", entry["error"])
+	assert.NotEmpty(t, w.Header().Get(XRequestIDKey))
+	assert.Equal(t, w.Header().Get(XRequestIDKey), entry[log.KeyRequestID])
+	assert.Contains(t, entry["stack"], "recovery_test.go")
+}
@@ -0,0 +1,99 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/marmotedu/component-base/pkg/core"
+	"github.com/marmotedu/errors"
+
+	"github.com/marmotedu/iam/internal/pkg/code"
+	"github.com/marmotedu/iam/pkg/storage"
+)
+
+// redisConnected reports whether redis is connected.
+var redisConnected = storage.Connected
+
+// redisRoute is a route requiring redis, an empty method matches all the methods.
+type redisRoute struct {
+	method string
+	path   string
+	prefix bool
+}
+
+func (r redisRoute) match(method, path string) bool {
+	if r.method != "" && r.method != method {
+		return false
+	}
+
+	if r.prefix {
+		return strings.HasPrefix(path, r.path)
+	}
+
+	return path == r.path
+}
+
+// ParseRedisRoute parses a route requiring redis, which is "METHOD /path" or "/path" for all the
+// methods. The path is the route pattern, e.g. /v1/policies/:name, a path ending with * matches
+// all the routes with the prefix.
+func ParseRedisRoute(route string) (method, path string, prefix bool, err error) {
+	fields := strings.Fields(route)
+	switch len(fields) {
+	case 1:
+		path = fields[0]
+	case 2:
+		method, path = strings.ToUpper(fields[0]), fields[1]
+	default:
+		return "", "", false, errors.Errorf("invalid route %q, must be \"METHOD /path\" or \"/path\"", route)
+	}
+
+	if !strings.HasPrefix(path, "/") {
+		return "", "", false, errors.Errorf("invalid route %q, the path must start with /", route)
+	}
+
+	if strings.HasSuffix(path, "*") {
+		return method, strings.TrimSuffix(path, "*"), true, nil
+	}
+
+	return method, path, false, nil
+}
+
+// RequireRedis is a middleware that rejects the requests of the routes which declare a hard redis
+// dependency with 503 when redis is down, e.g. the writes which publish the changes by redis, so
+// the clients know about the outage instead of getting a successful response with the changes not
+// published. The invalid routes are ignored, see ParseRedisRoute for the format of routes.
+func RequireRedis(routes []string) gin.HandlerFunc {
+	parsed := make([]redisRoute, 0, len(routes))
+	for _, route := range routes {
+		method, path, prefix, err := ParseRedisRoute(route)
+		if err != nil {
+			continue
+		}
+
+		parsed = append(parsed, redisRoute{method: method, path: path, prefix: prefix})
+	}
+
+	return func(c *gin.Context) {
+		if redisConnected() {
+			c.Next()
+
+			return
+		}
+
+		for _, route := range parsed {
+			if route.match(c.Request.Method, c.FullPath()) {
+				c.Abort()
+				core.WriteResponse(c, errors.WithCode(code.ErrServiceUnavailable,
+					"redis is unavailable, which is required by %s %s", c.Request.Method, c.FullPath()), nil)
+
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
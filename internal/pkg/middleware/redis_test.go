@@ -0,0 +1,92 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/marmotedu/component-base/pkg/json"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/marmotedu/iam/internal/pkg/code"
+)
+
+func TestRequireRedis(t *testing.T) {
+	connected := true
+	defer func(f func() bool) { redisConnected = f }(redisConnected)
+	redisConnected = func() bool { return connected }
+
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(RequireRedis([]string{"POST /v1/policies", "put /v1/policies/:name", "/v1/secrets*", "invalid"}))
+	for _, route := range []struct{ method, path string }{
+		{http.MethodPost, "/v1/policies"},
+		{http.MethodPut, "/v1/policies/:name"},
+		{http.MethodGet, "/v1/policies/:name"},
+		{http.MethodGet, "/v1/secrets/:name"},
+		{http.MethodGet, "/v1/users/:name"},
+	} {
+		r.Handle(route.method, route.path, func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+	}
+
+	tests := []struct {
+		method string
+		path   string
+		status int
+	}{
+		{http.MethodPost, "/v1/policies", http.StatusServiceUnavailable},
+		{http.MethodPut, "/v1/policies/foo", http.StatusServiceUnavailable},
+		{http.MethodGet, "/v1/policies/foo", http.StatusOK},
+		{http.MethodGet, "/v1/secrets/foo", http.StatusServiceUnavailable},
+		{http.MethodGet, "/v1/users/foo", http.StatusOK},
+	}
+
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+
+		return w
+	}
+
+	// all the routes are served while redis is up
+	for _, tt := range tests {
+		assert.Equal(t, http.StatusOK, serve(tt.method, tt.path).Code, tt.method+" "+tt.path)
+	}
+
+	connected = false
+	for _, tt := range tests {
+		w := serve(tt.method, tt.path)
+		assert.Equal(t, tt.status, w.Code, tt.method+" "+tt.path)
+
+		if tt.status != http.StatusServiceUnavailable {
+			continue
+		}
+
+		var resp struct {
+			Code int `json:"code"`
+		}
+		assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, code.ErrServiceUnavailable, resp.Code)
+	}
+}
+
+func TestParseRedisRoute(t *testing.T) {
+	method, path, prefix, err := ParseRedisRoute("delete /v1/policies/*")
+	assert.Nil(t, err)
+	assert.Equal(t, http.MethodDelete, method)
+	assert.Equal(t, "/v1/policies/", path)
+	assert.True(t, prefix)
+
+	for _, route := range []string{"", "v1/policies", "POST /v1/policies extra"} {
+		_, _, _, err := ParseRedisRoute(route)
+		assert.NotNil(t, err, route)
+	}
+}
@@ -11,6 +11,8 @@ import (
 
 	"github.com/gin-gonic/gin"
 	uuid "github.com/satori/go.uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
 )
 
 const (
@@ -19,6 +21,8 @@ const (
 )
 
 // RequestID is a middleware that injects a 'X-Request-ID' into the context and request/response header of each request.
+// The trace context of the caller is propagated to the request context as well, so the spans of the request join
+// the trace of the caller when tracing is enabled.
 func RequestID() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Check for incoming header, use it if exists
@@ -27,11 +31,15 @@ func RequestID() gin.HandlerFunc {
 		if rid == "" {
 			rid = uuid.Must(uuid.NewV4()).String()
 			c.Request.Header.Set(XRequestIDKey, rid)
-			c.Set(XRequestIDKey, rid)
 		}
+		c.Set(XRequestIDKey, rid)
 
 		// Set XRequestIDKey header
 		c.Writer.Header().Set(XRequestIDKey, rid)
+
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+		c.Request = c.Request.WithContext(ctx)
+
 		c.Next()
 	}
 }
@@ -0,0 +1,124 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"context"
+	"strings"
+
+	jwt "github.com/appleboy/gin-jwt/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/marmotedu/component-base/pkg/core"
+	"github.com/marmotedu/component-base/pkg/validation"
+	"github.com/marmotedu/errors"
+
+	"github.com/marmotedu/iam/internal/pkg/code"
+)
+
+const (
+	// XTenantIDKey defines the header carrying the tenant id of the request.
+	XTenantIDKey = "X-Tenant-ID"
+	// TenantKey defines the key in gin context which represents the tenant of the request.
+	TenantKey = "tenant"
+	// TenantClaim defines the jwt claim carrying the tenant id.
+	TenantClaim = "tenant"
+)
+
+type tenantContextKey struct{}
+
+// TenantIdentifier returns the tenant of the authenticated user of the request, and whether the user is
+// an administrator.
+type TenantIdentifier func(c *gin.Context) (tenantID string, isAdmin bool, err error)
+
+// Tenant is a middleware that sets the tenant of the request in the gin context and the request context.
+// The tenant is the one of the authenticated user returned by identify, so Tenant must be installed after
+// the authentication middleware. The X-Tenant-ID header must match it, only the administrators may act on
+// another tenant with the header. The users without a tenant belong to the default tenant, whose id is
+// empty. The requests with an invalid tenant id are rejected with 400.
+func Tenant(identify TenantIdentifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenantID, err := extractTenantID(c, identify)
+		if err != nil {
+			c.Abort()
+			core.WriteResponse(c, err, nil)
+
+			return
+		}
+
+		c.Set(TenantKey, tenantID)
+		c.Request = c.Request.WithContext(WithTenantID(c.Request.Context(), tenantID))
+		c.Next()
+	}
+}
+
+func extractTenantID(c *gin.Context, identify TenantIdentifier) (string, error) {
+	tenantID, isAdmin, err := identify(c)
+	if err != nil {
+		return "", err
+	}
+
+	if header := strings.TrimSpace(c.GetHeader(XTenantIDKey)); header != "" && header != tenantID {
+		if !isAdmin {
+			return "", errors.WithCode(code.ErrPermissionDenied, "%s header doesn't match the tenant of the user", XTenantIDKey)
+		}
+
+		tenantID = header
+	}
+
+	if tenantID == "" {
+		return "", nil
+	}
+
+	if errs := validation.IsDNS1123Label(tenantID); len(errs) > 0 {
+		return "", errors.WithCode(code.ErrValidation, "invalid tenant id %q: %s", tenantID, strings.Join(errs, ", "))
+	}
+
+	return tenantID, nil
+}
+
+// TenantClaimFromContext returns the tenant claim of the jwt token of the request, it returns an empty
+// string if the request isn't authenticated by a jwt token or the token has no tenant claim.
+func TenantClaimFromContext(c *gin.Context) string {
+	if _, ok := c.Get("JWT_PAYLOAD"); !ok {
+		return ""
+	}
+
+	claim, _ := jwt.ExtractClaims(c)[TenantClaim].(string)
+
+	return claim
+}
+
+// WithTenantID returns a copy of ctx in which the tenant id is set, an empty id is the default tenant.
+func WithTenantID(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, tenantID)
+}
+
+// TenantIDFromContext returns the tenant id of ctx, which is either a gin context or a request
+// context, it returns an empty string if the tenant isn't known or is the default tenant.
+func TenantIDFromContext(ctx context.Context) string {
+	tenantID, _ := TenantFromContext(ctx)
+
+	return tenantID
+}
+
+// TenantFromContext returns the tenant id of ctx, which is either a gin context or a request
+// context, and whether the tenant is known. The default tenant has an empty id.
+func TenantFromContext(ctx context.Context) (string, bool) {
+	if c, ok := ctx.(*gin.Context); ok {
+		if tenantID, ok := c.Get(TenantKey); ok {
+			return tenantID.(string), true
+		}
+
+		if c.Request == nil {
+			return "", false
+		}
+
+		ctx = c.Request.Context()
+	}
+
+	tenantID, ok := ctx.Value(tenantContextKey{}).(string)
+
+	return tenantID, ok
+}
@@ -0,0 +1,121 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	jwt "github.com/appleboy/gin-jwt/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/marmotedu/component-base/pkg/json"
+	"github.com/marmotedu/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/marmotedu/iam/internal/pkg/code"
+)
+
+func TestTenant(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name     string
+		header   string
+		identity string
+		isAdmin  bool
+		status   int
+		code     int
+		tenant   string
+	}{
+		{"identity", "", "acme", false, http.StatusOK, 0, "acme"},
+		{"header matches identity", "acme", "acme", false, http.StatusOK, 0, "acme"},
+		{"header doesn't match identity", "other", "acme", false, http.StatusForbidden, code.ErrPermissionDenied, ""},
+		{"header of a user without tenant", "acme", "", false, http.StatusForbidden, code.ErrPermissionDenied, ""},
+		{"admin selects another tenant", "other", "acme", true, http.StatusOK, 0, "other"},
+		{"admin without tenant", "other", "", true, http.StatusOK, 0, "other"},
+		{"default tenant", "", "", false, http.StatusOK, 0, ""},
+		{"invalid", "Acme_Corp", "", true, http.StatusBadRequest, code.ErrValidation, ""},
+		{"too long", strings.Repeat("a", 64), "", true, http.StatusBadRequest, code.ErrValidation, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ginTenant, requestTenant string
+			var known bool
+			r := gin.New()
+			r.Use(Tenant(func(*gin.Context) (string, bool, error) {
+				return tt.identity, tt.isAdmin, nil
+			}))
+			r.GET("/", func(c *gin.Context) {
+				ginTenant = TenantIDFromContext(c)
+				requestTenant, known = TenantFromContext(c.Request.Context())
+				c.String(http.StatusOK, "done")
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set(XTenantIDKey, tt.header)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.status, w.Code)
+			assert.Equal(t, tt.tenant, ginTenant)
+			assert.Equal(t, tt.tenant, requestTenant)
+			assert.Equal(t, tt.status == http.StatusOK, known)
+
+			if tt.status != http.StatusOK {
+				var resp map[string]interface{}
+				assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &resp))
+				assert.Equal(t, float64(tt.code), resp["code"])
+			}
+		})
+	}
+}
+
+func TestTenant_IdentifyError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(Tenant(func(*gin.Context) (string, bool, error) {
+		return "", false, errors.WithCode(code.ErrSignatureInvalid, "unknown user")
+	}))
+	r.GET("/", func(c *gin.Context) {
+		t.Error("the request should be rejected")
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestTenantClaimFromContext(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	assert.Equal(t, "", TenantClaimFromContext(c))
+
+	c.Set("JWT_PAYLOAD", jwt.MapClaims{"sub": "admin"})
+	assert.Equal(t, "", TenantClaimFromContext(c))
+
+	c.Set("JWT_PAYLOAD", jwt.MapClaims{TenantClaim: "acme"})
+	assert.Equal(t, "acme", TenantClaimFromContext(c))
+}
+
+func TestTenantFromContext(t *testing.T) {
+	_, ok := TenantFromContext(context.TODO())
+	assert.False(t, ok)
+
+	tenantID, ok := TenantFromContext(WithTenantID(context.TODO(), ""))
+	assert.True(t, ok)
+	assert.Equal(t, "", tenantID)
+
+	tenantID, ok = TenantFromContext(WithTenantID(context.TODO(), "acme"))
+	assert.True(t, ok)
+	assert.Equal(t, "acme", tenantID)
+}
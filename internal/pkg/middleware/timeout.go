@@ -0,0 +1,48 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/marmotedu/component-base/pkg/core"
+	"github.com/marmotedu/errors"
+
+	"github.com/marmotedu/iam/internal/pkg/code"
+)
+
+// DefaultRequestTimeout is the request timeout used by the registered timeout middleware.
+const DefaultRequestTimeout = 30 * time.Second
+
+// Timeout is a middleware that sets a deadline on the request context. Calls
+// made with c.Request.Context() are cancelled once the deadline is exceeded,
+// and if the handler returns without writing a response after that, a 504
+// response is written.
+// Note that gin.Context itself doesn't carry the deadline, handlers must pass
+// c.Request.Context() downstream to be cancelled.
+func Timeout(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if timeout <= 0 {
+			c.Next()
+
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+
+		if !errors.Is(ctx.Err(), context.DeadlineExceeded) || c.Writer.Written() {
+			return
+		}
+
+		c.Abort()
+		core.WriteResponse(c, errors.WithCode(code.ErrRequestTimeout, "request exceeded the timeout %s", timeout), nil)
+	}
+}
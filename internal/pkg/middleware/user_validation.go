@@ -5,6 +5,7 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -16,10 +17,15 @@ import (
 	"github.com/marmotedu/iam/internal/pkg/code"
 )
 
+// IsAdminKey defines the key in gin context which is true when the requester is an administrator.
+const IsAdminKey = "isAdmin"
+
 // Validation make sure users have the right resource permission and operation.
 func Validation() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		if err := isAdmin(c); err != nil {
+		err := isAdmin(c)
+		c.Set(IsAdminKey, err == nil)
+		if err != nil {
 			switch c.FullPath() {
 			case "/v1/users":
 				if c.Request.Method != http.MethodPost {
@@ -28,6 +34,12 @@ func Validation() gin.HandlerFunc {
 
 					return
 				}
+			case "/v1/users/:name/enable", "/v1/users/:name/disable", "/v1/users/:name/restore",
+				"/v1/policies/:name/audits", "/v1/policies/:name/restore", "/debug/config":
+				core.WriteResponse(c, errors.WithCode(code.ErrPermissionDenied, ""), nil)
+				c.Abort()
+
+				return
 			case "/v1/users/:name", "/v1/users/:name/change_password":
 				username := c.GetString("username")
 				if c.Request.Method == http.MethodDelete ||
@@ -49,7 +61,8 @@ func Validation() gin.HandlerFunc {
 // It returns a `github.com/marmotedu/errors.withCode` error.
 func isAdmin(c *gin.Context) error {
 	username := c.GetString(UsernameKey)
-	user, err := store.Client().Users().Get(c, username, metav1.GetOptions{})
+	// the administrators may act on another tenant, so the user is not looked up in the tenant of the request
+	user, err := store.Client().Users().Get(context.TODO(), username, metav1.GetOptions{})
 	if err != nil {
 		return errors.WithCode(code.ErrDatabase, err.Error())
 	}
@@ -5,6 +5,8 @@
 package options
 
 import (
+	"fmt"
+
 	"github.com/spf13/pflag"
 
 	"github.com/marmotedu/iam/internal/pkg/server"
@@ -12,8 +14,13 @@ import (
 
 // FeatureOptions contains configuration items related to API server features.
 type FeatureOptions struct {
-	EnableProfiling bool `json:"profiling"      mapstructure:"profiling"`
-	EnableMetrics   bool `json:"enable-metrics" mapstructure:"enable-metrics"`
+	EnableProfiling   bool      `json:"profiling"           mapstructure:"profiling"`
+	EnableMetrics     bool      `json:"enable-metrics"      mapstructure:"enable-metrics"`
+	MetricsBuckets    []float64 `json:"metrics-buckets"     mapstructure:"metrics-buckets"`
+	EnableVersion     bool      `json:"enable-version"      mapstructure:"enable-version"`
+	EnableDebugConfig bool      `json:"enable-debug-config" mapstructure:"enable-debug-config"`
+	EnableReload      bool      `json:"enable-reload"       mapstructure:"enable-reload"`
+	ReloadAdmins      []string  `json:"reload-admins"       mapstructure:"reload-admins"`
 }
 
 // NewFeatureOptions creates a FeatureOptions object with default parameters.
@@ -23,6 +30,7 @@ func NewFeatureOptions() *FeatureOptions {
 	return &FeatureOptions{
 		EnableMetrics:   defaults.EnableMetrics,
 		EnableProfiling: defaults.EnableProfiling,
+		EnableVersion:   defaults.EnableVersion,
 	}
 }
 
@@ -30,6 +38,8 @@ func NewFeatureOptions() *FeatureOptions {
 func (o *FeatureOptions) ApplyTo(c *server.Config) error {
 	c.EnableProfiling = o.EnableProfiling
 	c.EnableMetrics = o.EnableMetrics
+	c.MetricsBuckets = o.MetricsBuckets
+	c.EnableVersion = o.EnableVersion
 
 	return nil
 }
@@ -37,7 +47,21 @@ func (o *FeatureOptions) ApplyTo(c *server.Config) error {
 // Validate is used to parse and validate the parameters entered by the user at
 // the command line when the program starts.
 func (o *FeatureOptions) Validate() []error {
-	return []error{}
+	errors := []error{}
+
+	for i, bucket := range o.MetricsBuckets {
+		if i > 0 && bucket <= o.MetricsBuckets[i-1] {
+			errors = append(errors, fmt.Errorf("--feature.metrics-buckets must be in increasing order"))
+
+			break
+		}
+	}
+
+	if o.EnableReload && len(o.ReloadAdmins) == 0 {
+		errors = append(errors, fmt.Errorf("--feature.reload-admins must be set when --feature.enable-reload is true"))
+	}
+
+	return errors
 }
 
 // AddFlags adds flags related to features for a specific api server to the
@@ -51,5 +75,24 @@ func (o *FeatureOptions) AddFlags(fs *pflag.FlagSet) {
 		"Enable profiling via web interface host:port/debug/pprof/")
 
 	fs.BoolVar(&o.EnableMetrics, "feature.enable-metrics", o.EnableMetrics,
-		"Enables metrics on the apiserver at /metrics")
+		"Enables the http request metrics at /metrics, the business metrics are always served.")
+
+	fs.Float64SliceVar(&o.MetricsBuckets, "feature.metrics-buckets", o.MetricsBuckets, ""+
+		"The upper bounds in seconds of the buckets of the http request latency histogram, in increasing order. "+
+		"The default prometheus buckets are used if it's empty.")
+
+	fs.BoolVar(&o.EnableVersion, "feature.enable-version", o.EnableVersion,
+		"Enables the version information of the binary at /version")
+
+	fs.BoolVar(&o.EnableDebugConfig, "feature.enable-debug-config", o.EnableDebugConfig, ""+
+		"Enables the effective configuration with secrets redacted at /debug/config, "+
+		"only administrators can access it. Only supported by iam-apiserver.")
+
+	fs.BoolVar(&o.EnableReload, "feature.enable-reload", o.EnableReload, ""+
+		"Enables forcing a full reload of the secrets and policies at /v1/reload, "+
+		"only the administrators in --feature.reload-admins can call it. Only supported by iam-authz-server.")
+
+	fs.StringSliceVar(&o.ReloadAdmins, "feature.reload-admins", o.ReloadAdmins, ""+
+		"The usernames and secret ids allowed to force a full reload at /v1/reload, "+
+		"the other clients are rejected with 403. Only supported by iam-authz-server.")
 }
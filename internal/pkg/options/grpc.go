@@ -6,6 +6,7 @@ package options
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/spf13/pflag"
 )
@@ -16,6 +17,14 @@ type GRPCOptions struct {
 	BindAddress string `json:"bind-address" mapstructure:"bind-address"`
 	BindPort    int    `json:"bind-port"    mapstructure:"bind-port"`
 	MaxMsgSize  int    `json:"max-msg-size" mapstructure:"max-msg-size"`
+	UseTLS      bool   `json:"use-tls"      mapstructure:"use-tls"`
+
+	MaxConnectionAge      time.Duration `json:"max-connection-age"       mapstructure:"max-connection-age"`
+	MaxConnectionAgeGrace time.Duration `json:"max-connection-age-grace" mapstructure:"max-connection-age-grace"`
+	KeepaliveTime         time.Duration `json:"keepalive-time"           mapstructure:"keepalive-time"`
+	KeepaliveTimeout      time.Duration `json:"keepalive-timeout"        mapstructure:"keepalive-timeout"`
+	KeepaliveMinTime      time.Duration `json:"keepalive-min-time"       mapstructure:"keepalive-min-time"`
+	PermitWithoutStream   bool          `json:"permit-without-stream"    mapstructure:"permit-without-stream"`
 }
 
 // NewGRPCOptions is for creating an unauthenticated, unauthorized, insecure port.
@@ -25,6 +34,14 @@ func NewGRPCOptions() *GRPCOptions {
 		BindAddress: "0.0.0.0",
 		BindPort:    8081,
 		MaxMsgSize:  4 * 1024 * 1024,
+		UseTLS:      true,
+
+		MaxConnectionAge:      30 * time.Minute,
+		MaxConnectionAgeGrace: 10 * time.Second,
+		KeepaliveTime:         time.Minute,
+		KeepaliveTimeout:      20 * time.Second,
+		KeepaliveMinTime:      30 * time.Second,
+		PermitWithoutStream:   true,
 	}
 }
 
@@ -43,6 +60,21 @@ func (s *GRPCOptions) Validate() []error {
 		)
 	}
 
+	for _, d := range []struct {
+		name  string
+		value time.Duration
+	}{
+		{"--grpc.max-connection-age", s.MaxConnectionAge},
+		{"--grpc.max-connection-age-grace", s.MaxConnectionAgeGrace},
+		{"--grpc.keepalive-time", s.KeepaliveTime},
+		{"--grpc.keepalive-timeout", s.KeepaliveTimeout},
+		{"--grpc.keepalive-min-time", s.KeepaliveMinTime},
+	} {
+		if d.value < 0 {
+			errors = append(errors, fmt.Errorf("%s cannot be negative", d.name))
+		}
+	}
+
 	return errors
 }
 
@@ -59,4 +91,28 @@ func (s *GRPCOptions) AddFlags(fs *pflag.FlagSet) {
 		"port. This is performed by nginx in the default setup. Set to zero to disable.")
 
 	fs.IntVar(&s.MaxMsgSize, "grpc.max-msg-size", s.MaxMsgSize, "gRPC max message size.")
+
+	fs.BoolVar(&s.UseTLS, "grpc.use-tls", s.UseTLS, ""+
+		"Serve grpc with TLS using the --secure.tls certificate. Set to false to serve plaintext grpc, "+
+		"e.g. for local development or behind a service mesh which terminates TLS.")
+
+	fs.DurationVar(&s.MaxConnectionAge, "grpc.max-connection-age", s.MaxConnectionAge, ""+
+		"The maximum amount of time a grpc connection may exist before it is closed by sending a GoAway, "+
+		"this lets clients rebalance across servers. Zero means infinity.")
+
+	fs.DurationVar(&s.MaxConnectionAgeGrace, "grpc.max-connection-age-grace", s.MaxConnectionAgeGrace, ""+
+		"The additive period after --grpc.max-connection-age after which the connection will be forcibly closed. "+
+		"Zero means infinity.")
+
+	fs.DurationVar(&s.KeepaliveTime, "grpc.keepalive-time", s.KeepaliveTime, ""+
+		"Ping the client after it has been idle for this duration to see if the transport is still alive.")
+
+	fs.DurationVar(&s.KeepaliveTimeout, "grpc.keepalive-timeout", s.KeepaliveTimeout, ""+
+		"Wait this duration for the ping ack before closing the connection.")
+
+	fs.DurationVar(&s.KeepaliveMinTime, "grpc.keepalive-min-time", s.KeepaliveMinTime, ""+
+		"The minimum amount of time a client should wait before sending a keepalive ping.")
+
+	fs.BoolVar(&s.PermitWithoutStream, "grpc.permit-without-stream", s.PermitWithoutStream, ""+
+		"Allow clients to send keepalive pings even when there are no active streams.")
 }
@@ -19,6 +19,8 @@ import (
 type InsecureServingOptions struct {
 	BindAddress string `json:"bind-address" mapstructure:"bind-address"`
 	BindPort    int    `json:"bind-port"    mapstructure:"bind-port"`
+	// H2C enables HTTP/2 over cleartext on the insecure port, e.g. for the clients behind a service mesh.
+	H2C bool `json:"h2c" mapstructure:"h2c"`
 }
 
 // NewInsecureServingOptions is for creating an unauthenticated, unauthorized, insecure port.
@@ -34,6 +36,7 @@ func NewInsecureServingOptions() *InsecureServingOptions {
 func (s *InsecureServingOptions) ApplyTo(c *server.Config) error {
 	c.InsecureServing = &server.InsecureServingInfo{
 		Address: net.JoinHostPort(s.BindAddress, strconv.Itoa(s.BindPort)),
+		H2C:     s.H2C,
 	}
 
 	return nil
@@ -68,4 +71,6 @@ func (s *InsecureServingOptions) AddFlags(fs *pflag.FlagSet) {
 		"that firewall rules are set up such that this port is not reachable from outside of "+
 		"the deployed machine and that port 443 on the iam public address is proxied to this "+
 		"port. This is performed by nginx in the default setup. Set to zero to disable.")
+	fs.BoolVar(&s.H2C, "insecure.h2c", s.H2C, ""+
+		"Serve HTTP/2 over cleartext (h2c) on the insecure port in addition to HTTP/1.1.")
 }
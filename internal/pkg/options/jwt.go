@@ -12,14 +12,20 @@ import (
 	"github.com/spf13/pflag"
 
 	"github.com/marmotedu/iam/internal/pkg/server"
+	"github.com/marmotedu/iam/internal/pkg/util/jwksutil"
 )
 
 // JwtOptions contains configuration items related to API server features.
 type JwtOptions struct {
-	Realm      string        `json:"realm"       mapstructure:"realm"`
-	Key        string        `json:"key"         mapstructure:"key"`
-	Timeout    time.Duration `json:"timeout"     mapstructure:"timeout"`
-	MaxRefresh time.Duration `json:"max-refresh" mapstructure:"max-refresh"`
+	Realm                    string        `json:"realm"                       mapstructure:"realm"`
+	Key                      string        `json:"key"                         mapstructure:"key"`
+	Timeout                  time.Duration `json:"timeout"                     mapstructure:"timeout"`
+	MaxRefresh               time.Duration `json:"max-refresh"                 mapstructure:"max-refresh"`
+	SigningAlgorithm         string        `json:"signing-algorithm"           mapstructure:"signing-algorithm"`
+	PrivateKeyFile           string        `json:"private-key-file"            mapstructure:"private-key-file"`
+	PublicKeyFile            string        `json:"public-key-file"             mapstructure:"public-key-file"`
+	AdditionalPublicKeyFiles []string      `json:"additional-public-key-files" mapstructure:"additional-public-key-files"`
+	Issuer                   string        `json:"issuer"                      mapstructure:"issuer"`
 }
 
 // NewJwtOptions creates a JwtOptions object with default parameters.
@@ -27,20 +33,30 @@ func NewJwtOptions() *JwtOptions {
 	defaults := server.NewConfig()
 
 	return &JwtOptions{
-		Realm:      defaults.Jwt.Realm,
-		Key:        defaults.Jwt.Key,
-		Timeout:    defaults.Jwt.Timeout,
-		MaxRefresh: defaults.Jwt.MaxRefresh,
+		Realm:                    defaults.Jwt.Realm,
+		Key:                      defaults.Jwt.Key,
+		Timeout:                  defaults.Jwt.Timeout,
+		MaxRefresh:               defaults.Jwt.MaxRefresh,
+		SigningAlgorithm:         defaults.Jwt.SigningAlgorithm,
+		PrivateKeyFile:           defaults.Jwt.PrivateKeyFile,
+		PublicKeyFile:            defaults.Jwt.PublicKeyFile,
+		AdditionalPublicKeyFiles: defaults.Jwt.AdditionalPublicKeyFiles,
+		Issuer:                   defaults.Jwt.Issuer,
 	}
 }
 
 // ApplyTo applies the run options to the method receiver and returns self.
 func (s *JwtOptions) ApplyTo(c *server.Config) error {
 	c.Jwt = &server.JwtInfo{
-		Realm:      s.Realm,
-		Key:        s.Key,
-		Timeout:    s.Timeout,
-		MaxRefresh: s.MaxRefresh,
+		Realm:                    s.Realm,
+		Key:                      s.Key,
+		Timeout:                  s.Timeout,
+		MaxRefresh:               s.MaxRefresh,
+		SigningAlgorithm:         s.SigningAlgorithm,
+		PrivateKeyFile:           s.PrivateKeyFile,
+		PublicKeyFile:            s.PublicKeyFile,
+		AdditionalPublicKeyFiles: s.AdditionalPublicKeyFiles,
+		Issuer:                   s.Issuer,
 	}
 
 	return nil
@@ -51,8 +67,18 @@ func (s *JwtOptions) ApplyTo(c *server.Config) error {
 func (s *JwtOptions) Validate() []error {
 	var errs []error
 
-	if !govalidator.StringLength(s.Key, "6", "32") {
-		errs = append(errs, fmt.Errorf("--secret-key must larger than 5 and little than 33"))
+	switch {
+	case jwksutil.IsAsymmetric(s.SigningAlgorithm):
+		if s.PrivateKeyFile == "" || s.PublicKeyFile == "" {
+			errs = append(errs, fmt.Errorf("--jwt.private-key-file and --jwt.public-key-file are required by %s",
+				s.SigningAlgorithm))
+		}
+	case s.SigningAlgorithm == "HS256" || s.SigningAlgorithm == "HS384" || s.SigningAlgorithm == "HS512":
+		if !govalidator.StringLength(s.Key, "6", "32") {
+			errs = append(errs, fmt.Errorf("--secret-key must larger than 5 and little than 33"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("--jwt.signing-algorithm %q is not supported", s.SigningAlgorithm))
 	}
 
 	return errs
@@ -71,4 +97,17 @@ func (s *JwtOptions) AddFlags(fs *pflag.FlagSet) {
 
 	fs.DurationVar(&s.MaxRefresh, "jwt.max-refresh", s.MaxRefresh, ""+
 		"This field allows clients to refresh their token until MaxRefresh has passed.")
+
+	fs.StringVar(&s.SigningAlgorithm, "jwt.signing-algorithm", s.SigningAlgorithm, ""+
+		"The algorithm used to sign jwt token, one of HS256, HS384, HS512, RS256, RS384 and RS512.")
+	fs.StringVar(&s.PrivateKeyFile, "jwt.private-key-file", s.PrivateKeyFile, ""+
+		"File containing the PEM encoded RSA private key used to sign jwt token with RS algorithms.")
+	fs.StringVar(&s.PublicKeyFile, "jwt.public-key-file", s.PublicKeyFile, ""+
+		"File containing the PEM encoded RSA public key of --jwt.private-key-file.")
+	fs.StringSliceVar(&s.AdditionalPublicKeyFiles, "jwt.additional-public-key-files", s.AdditionalPublicKeyFiles, ""+
+		"Files containing the PEM encoded RSA public keys published at /.well-known/jwks.json besides "+
+		"--jwt.public-key-file, e.g. the keys being rotated in or out.")
+	fs.StringVar(&s.Issuer, "jwt.issuer", s.Issuer, ""+
+		"The issuer of jwt token. Set it to the external URL of the server, e.g. https://iam.api.marmotedu.com, "+
+		"to serve the OpenID Connect discovery document.")
 }
@@ -5,8 +5,11 @@
 package options
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
+	"github.com/marmotedu/component-base/pkg/util/stringutil"
 	"github.com/spf13/pflag"
 	"gorm.io/gorm"
 
@@ -15,27 +18,35 @@ import (
 
 // MySQLOptions defines options for mysql database.
 type MySQLOptions struct {
+	Driver                string        `json:"driver,omitempty"                   mapstructure:"driver"`
 	Host                  string        `json:"host,omitempty"                     mapstructure:"host"`
 	Username              string        `json:"username,omitempty"                 mapstructure:"username"`
 	Password              string        `json:"-"                                  mapstructure:"password"`
 	Database              string        `json:"database"                           mapstructure:"database"`
+	SSLMode               string        `json:"ssl-mode,omitempty"                 mapstructure:"ssl-mode"`
 	MaxIdleConnections    int           `json:"max-idle-connections,omitempty"     mapstructure:"max-idle-connections"`
 	MaxOpenConnections    int           `json:"max-open-connections,omitempty"     mapstructure:"max-open-connections"`
 	MaxConnectionLifeTime time.Duration `json:"max-connection-life-time,omitempty" mapstructure:"max-connection-life-time"`
-	LogLevel              int           `json:"log-level"                          mapstructure:"log-level"`
+	MaxConnectRetries     int           `json:"max-connect-retries,omitempty"      mapstructure:"max-connect-retries"`
+	ConnectRetryInterval  time.Duration `json:"connect-retry-interval,omitempty"   mapstructure:"connect-retry-interval"`
+	LogLevel              string        `json:"log-level"                          mapstructure:"log-level"`
 }
 
 // NewMySQLOptions create a `zero` value instance.
 func NewMySQLOptions() *MySQLOptions {
 	return &MySQLOptions{
+		Driver:                db.DriverMySQL,
 		Host:                  "127.0.0.1:3306",
 		Username:              "",
 		Password:              "",
 		Database:              "",
+		SSLMode:               db.DefaultPostgresSSLMode,
 		MaxIdleConnections:    100,
 		MaxOpenConnections:    100,
 		MaxConnectionLifeTime: time.Duration(10) * time.Second,
-		LogLevel:              1, // Silent
+		MaxConnectRetries:     5,
+		ConnectRetryInterval:  time.Duration(1) * time.Second,
+		LogLevel:              db.LogLevelSilent,
 	}
 }
 
@@ -43,11 +54,31 @@ func NewMySQLOptions() *MySQLOptions {
 func (o *MySQLOptions) Validate() []error {
 	errs := []error{}
 
+	if o.Driver != db.DriverMySQL && o.Driver != db.DriverPostgres {
+		errs = append(errs, fmt.Errorf("--mysql.driver %s must be one of: %s, %s", o.Driver, db.DriverMySQL, db.DriverPostgres))
+	}
+
+	if o.Driver == db.DriverPostgres && !stringutil.StringIn(o.SSLMode, db.PostgresSSLModes) {
+		errs = append(errs, fmt.Errorf("--mysql.ssl-mode %s must be one of: %s",
+			o.SSLMode, strings.Join(db.PostgresSSLModes, ", ")))
+	}
+
+	if _, err := db.ParseLogLevel(o.LogLevel); err != nil {
+		errs = append(errs, err)
+	}
+
+	if o.MaxConnectRetries < 0 {
+		errs = append(errs, fmt.Errorf("--mysql.max-connect-retries %v must be greater than or equal to 0", o.MaxConnectRetries))
+	}
+
 	return errs
 }
 
 // AddFlags adds flags related to mysql storage for a specific APIServer to the specified FlagSet.
 func (o *MySQLOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.Driver, "mysql.driver", o.Driver, ""+
+		"Database driver to use, one of: mysql, postgres. The store layer is dialect-agnostic.")
+
 	fs.StringVar(&o.Host, "mysql.host", o.Host, ""+
 		"MySQL service host address. If left blank, the following related mysql options will be ignored.")
 
@@ -60,6 +91,10 @@ func (o *MySQLOptions) AddFlags(fs *pflag.FlagSet) {
 	fs.StringVar(&o.Database, "mysql.database", o.Database, ""+
 		"Database name for the server to use.")
 
+	fs.StringVar(&o.SSLMode, "mysql.ssl-mode", o.SSLMode, ""+
+		"SSL mode of the postgres connections, one of: "+strings.Join(db.PostgresSSLModes, ", ")+
+		". It's ignored by mysql.")
+
 	fs.IntVar(&o.MaxIdleConnections, "mysql.max-idle-connections", o.MaxOpenConnections, ""+
 		"Maximum idle connections allowed to connect to mysql.")
 
@@ -69,20 +104,30 @@ func (o *MySQLOptions) AddFlags(fs *pflag.FlagSet) {
 	fs.DurationVar(&o.MaxConnectionLifeTime, "mysql.max-connection-life-time", o.MaxConnectionLifeTime, ""+
 		"Maximum connection life time allowed to connect to mysql.")
 
-	fs.IntVar(&o.LogLevel, "mysql.log-mode", o.LogLevel, ""+
-		"Specify gorm log level.")
+	fs.IntVar(&o.MaxConnectRetries, "mysql.max-connect-retries", o.MaxConnectRetries, ""+
+		"Maximum number of retries when failed to connect to mysql, 0 means no retry.")
+
+	fs.DurationVar(&o.ConnectRetryInterval, "mysql.connect-retry-interval", o.ConnectRetryInterval, ""+
+		"Initial interval between connect retries, it will be doubled after each retry.")
+
+	fs.StringVar(&o.LogLevel, "mysql.log-mode", o.LogLevel, ""+
+		"Specify gorm log level, one of: silent, error, warn, info. The legacy levels 1-4 are accepted as aliases.")
 }
 
 // NewClient create mysql store with the given config.
 func (o *MySQLOptions) NewClient() (*gorm.DB, error) {
 	opts := &db.Options{
+		Driver:                o.Driver,
 		Host:                  o.Host,
 		Username:              o.Username,
 		Password:              o.Password,
 		Database:              o.Database,
+		SSLMode:               o.SSLMode,
 		MaxIdleConnections:    o.MaxIdleConnections,
 		MaxOpenConnections:    o.MaxOpenConnections,
 		MaxConnectionLifeTime: o.MaxConnectionLifeTime,
+		MaxConnectRetries:     o.MaxConnectRetries,
+		ConnectRetryInterval:  o.ConnectRetryInterval,
 		LogLevel:              o.LogLevel,
 	}
 
@@ -0,0 +1,66 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package options
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// RateLimitOptions contains configuration items related to the rate limiting of
+// the requests authenticated with the same secret.
+type RateLimitOptions struct {
+	Rate int           `json:"rate" mapstructure:"rate"`
+	Per  time.Duration `json:"per"  mapstructure:"per"`
+}
+
+// NewRateLimitOptions creates a RateLimitOptions object with default parameters.
+func NewRateLimitOptions() *RateLimitOptions {
+	return &RateLimitOptions{
+		Rate: 0,
+		Per:  time.Second,
+	}
+}
+
+// Enabled returns true if the requests of a secret are rate limited.
+func (o *RateLimitOptions) Enabled() bool {
+	return o != nil && o.Rate > 0
+}
+
+// Validate is used to parse and validate the parameters entered by the user at
+// the command line when the program starts.
+func (o *RateLimitOptions) Validate() []error {
+	if o == nil {
+		return nil
+	}
+
+	errs := []error{}
+
+	if o.Rate < 0 {
+		errs = append(errs, fmt.Errorf("--ratelimit.rate %v cannot be negative", o.Rate))
+	}
+
+	if o.Rate > 0 && o.Per < time.Second {
+		errs = append(errs, fmt.Errorf("--ratelimit.per %v must be at least 1s", o.Per))
+	}
+
+	return errs
+}
+
+// AddFlags adds flags related to rate limiting for a specific server to the
+// specified FlagSet.
+func (o *RateLimitOptions) AddFlags(fs *pflag.FlagSet) {
+	if fs == nil {
+		return
+	}
+
+	fs.IntVar(&o.Rate, "ratelimit.rate", o.Rate, ""+
+		"The maximum number of requests a secret can make within --ratelimit.per, 0 means no limit.")
+
+	fs.DurationVar(&o.Per, "ratelimit.per", o.Per, ""+
+		"The rolling window of the per-secret rate limit.")
+}
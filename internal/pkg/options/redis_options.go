@@ -5,6 +5,8 @@
 package options
 
 import (
+	"fmt"
+
 	"github.com/spf13/pflag"
 )
 
@@ -23,6 +25,11 @@ type RedisOptions struct {
 	EnableCluster         bool     `json:"enable-cluster"           mapstructure:"enable-cluster"`
 	UseSSL                bool     `json:"use-ssl"                  mapstructure:"use-ssl"`
 	SSLInsecureSkipVerify bool     `json:"ssl-insecure-skip-verify" mapstructure:"ssl-insecure-skip-verify"`
+	Compression           bool     `json:"compression"              mapstructure:"compression"`
+	TTLJitter             float64  `json:"ttl-jitter"               mapstructure:"ttl-jitter"`
+	// Instances are the named redis deployments, e.g. analytics, used instead of this one by the
+	// stores of the same name. They are only set in the configuration file.
+	Instances map[string]*RedisOptions `json:"instances,omitempty" mapstructure:"instances"`
 }
 
 // NewRedisOptions create a `zero` value instance.
@@ -41,6 +48,8 @@ func NewRedisOptions() *RedisOptions {
 		EnableCluster:         false,
 		UseSSL:                false,
 		SSLInsecureSkipVerify: false,
+		Compression:           false,
+		TTLJitter:             0,
 	}
 }
 
@@ -48,6 +57,20 @@ func NewRedisOptions() *RedisOptions {
 func (o *RedisOptions) Validate() []error {
 	errs := []error{}
 
+	if o.TTLJitter < 0 || o.TTLJitter >= 1 {
+		errs = append(errs, fmt.Errorf("--redis.ttl-jitter %v must be in [0, 1)", o.TTLJitter))
+	}
+
+	for name, instance := range o.Instances {
+		if instance == nil {
+			errs = append(errs, fmt.Errorf("redis.instances.%s must be configured", name))
+
+			continue
+		}
+
+		errs = append(errs, instance.Validate()...)
+	}
+
 	return errs
 }
 
@@ -85,4 +108,12 @@ func (o *RedisOptions) AddFlags(fs *pflag.FlagSet) {
 
 	fs.BoolVar(&o.SSLInsecureSkipVerify, "redis.ssl-insecure-skip-verify", o.SSLInsecureSkipVerify, ""+
 		"Allows usage of self-signed certificates when connecting to an encrypted Redis database.")
+
+	fs.BoolVar(&o.Compression, "redis.compression", o.Compression, ""+
+		"Compress the large values written to Redis with gzip, the compressed values are always "+
+		"decompressed when they are read.")
+
+	fs.Float64Var(&o.TTLJitter, "redis.ttl-jitter", o.TTLJitter, ""+
+		"The fraction the expirations of the keys are randomly changed by, e.g. 0.1 for ±10%, so the keys "+
+		"set with the same expiration don't expire all at once. 0 disables it.")
 }
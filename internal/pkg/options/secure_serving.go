@@ -12,6 +12,7 @@ import (
 	"github.com/spf13/pflag"
 
 	"github.com/marmotedu/iam/internal/pkg/server"
+	"github.com/marmotedu/iam/pkg/log"
 )
 
 // SecureServingOptions contains configuration items related to HTTPS server startup.
@@ -32,6 +33,27 @@ type CertKey struct {
 	CertFile string `json:"cert-file"        mapstructure:"cert-file"`
 	// KeyFile is a file containing a PEM-encoded private key for the certificate specified by CertFile
 	KeyFile string `json:"private-key-file" mapstructure:"private-key-file"`
+	// CertData is the PEM-encoded certificate, e.g. from a secret manager, it takes precedence
+	// over CertFile. It's never logged, and it's better set by the environment variable than
+	// by the flag, which is visible to the other users of the host.
+	CertData string `json:"-" mapstructure:"cert-data"`
+	// KeyData is the PEM-encoded private key for the certificate specified by CertData.
+	KeyData string `json:"-" mapstructure:"private-key-data"`
+}
+
+// IsSet returns whether the certificate and key are provided, either as PEM data or files.
+func (c CertKey) IsSet() bool {
+	return c.ServerCertKey().IsSet()
+}
+
+// ServerCertKey returns the certificate and key used by the servers.
+func (c CertKey) ServerCertKey() server.CertKey {
+	return server.CertKey{
+		CertFile: c.CertFile,
+		KeyFile:  c.KeyFile,
+		CertData: []byte(c.CertData),
+		KeyData:  []byte(c.KeyData),
+	}
 }
 
 // GeneratableKeyCert contains configuration items related to certificate.
@@ -46,6 +68,10 @@ type GeneratableKeyCert struct {
 	// PairName is the name which will be used with CertDirectory to make a cert and key filenames.
 	// It becomes CertDirectory/PairName.crt and CertDirectory/PairName.key
 	PairName string `json:"pair-name" mapstructure:"pair-name"`
+
+	// GenerateSelfSignedCerts generates an in-memory self-signed certificate if the
+	// cert/key files aren't explicitly set, it's meant for local development only.
+	GenerateSelfSignedCerts bool `json:"generate-self-signed-certs" mapstructure:"generate-self-signed-certs"`
 }
 
 // NewSecureServingOptions creates a SecureServingOptions object with default parameters.
@@ -67,10 +93,7 @@ func (s *SecureServingOptions) ApplyTo(c *server.Config) error {
 	c.SecureServing = &server.SecureServingInfo{
 		BindAddress: s.BindAddress,
 		BindPort:    s.BindPort,
-		CertKey: server.CertKey{
-			CertFile: s.ServerCert.CertKey.CertFile,
-			KeyFile:  s.ServerCert.CertKey.KeyFile,
-		},
+		CertKey:     s.ServerCert.CertKey.ServerCertKey(),
 	}
 
 	return nil
@@ -124,6 +147,12 @@ func (s *SecureServingOptions) AddFlags(fs *pflag.FlagSet) {
 		"The name which will be used with --secure.tls.cert-dir to make a cert and key filenames. "+
 		"It becomes <cert-dir>/<pair-name>.crt and <cert-dir>/<pair-name>.key")
 
+	fs.BoolVar(&s.ServerCert.GenerateSelfSignedCerts, "secure.tls.generate-self-signed-certs",
+		s.ServerCert.GenerateSelfSignedCerts, ""+
+			"Generate an in-memory self-signed certificate for --secure.bind-address if "+
+			"--secure.tls.cert-key.cert-file and --secure.tls.cert-key.private-key-file are not provided, "+
+			"--secure.tls.cert-dir is ignored then. For development only, never enable it in production.")
+
 	fs.StringVar(&s.ServerCert.CertKey.CertFile, "secure.tls.cert-key.cert-file", s.ServerCert.CertKey.CertFile, ""+
 		"File containing the default x509 Certificate for HTTPS. (CA cert, if any, concatenated "+
 		"after server cert).")
@@ -131,6 +160,16 @@ func (s *SecureServingOptions) AddFlags(fs *pflag.FlagSet) {
 	fs.StringVar(&s.ServerCert.CertKey.KeyFile, "secure.tls.cert-key.private-key-file",
 		s.ServerCert.CertKey.KeyFile, ""+
 			"File containing the default x509 private key matching --secure.tls.cert-key.cert-file.")
+
+	fs.StringVar(&s.ServerCert.CertKey.CertData, "secure.tls.cert-key.cert-data",
+		s.ServerCert.CertKey.CertData, ""+
+			"The PEM-encoded x509 Certificate for HTTPS, it takes precedence over --secure.tls.cert-key.cert-file. "+
+			"Prefer the environment variable, the flag is visible to the other users of the host.")
+
+	fs.StringVar(&s.ServerCert.CertKey.KeyData, "secure.tls.cert-key.private-key-data",
+		s.ServerCert.CertKey.KeyData, ""+
+			"The PEM-encoded x509 private key matching --secure.tls.cert-key.cert-data. "+
+			"Prefer the environment variable, the flag is visible to the other users of the host.")
 }
 
 // Complete fills in any fields not set that are required to have valid data.
@@ -140,7 +179,19 @@ func (s *SecureServingOptions) Complete() error {
 	}
 
 	keyCert := &s.ServerCert.CertKey
-	if len(keyCert.CertFile) != 0 || len(keyCert.KeyFile) != 0 {
+	if len(keyCert.CertFile) != 0 || len(keyCert.KeyFile) != 0 || len(keyCert.CertData) != 0 {
+		return nil
+	}
+
+	if s.ServerCert.GenerateSelfSignedCerts {
+		certData, keyData, err := server.GenerateSelfSignedCertKey(s.BindAddress)
+		if err != nil {
+			return fmt.Errorf("failed to generate self-signed certificate: %w", err)
+		}
+
+		log.Warnf("Serving HTTPS with a generated self-signed certificate for %s, it's for development only", s.BindAddress)
+		keyCert.CertData, keyCert.KeyData = string(certData), string(keyData)
+
 		return nil
 	}
 
@@ -5,16 +5,25 @@
 package options
 
 import (
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
 	"github.com/spf13/pflag"
 
+	"github.com/marmotedu/iam/internal/pkg/middleware"
 	"github.com/marmotedu/iam/internal/pkg/server"
 )
 
 // ServerRunOptions contains the options while running a generic api server.
 type ServerRunOptions struct {
-	Mode        string   `json:"mode"        mapstructure:"mode"`
-	Healthz     bool     `json:"healthz"     mapstructure:"healthz"`
-	Middlewares []string `json:"middlewares" mapstructure:"middlewares"`
+	Mode                string        `json:"mode"                  mapstructure:"mode"`
+	Healthz             bool          `json:"healthz"               mapstructure:"healthz"`
+	Middlewares         []string      `json:"middlewares"           mapstructure:"middlewares"`
+	RequestTimeout      time.Duration `json:"request-timeout"       mapstructure:"request-timeout"`
+	MaxRequestBodySize  int64         `json:"max-request-body-size" mapstructure:"max-request-body-size"`
+	RedisRequiredRoutes []string      `json:"redis-required-routes" mapstructure:"redis-required-routes"`
+	PreStopDelay        time.Duration `json:"pre-stop-delay"        mapstructure:"pre-stop-delay"`
 }
 
 // NewServerRunOptions creates a new ServerRunOptions object with default parameters.
@@ -22,9 +31,13 @@ func NewServerRunOptions() *ServerRunOptions {
 	defaults := server.NewConfig()
 
 	return &ServerRunOptions{
-		Mode:        defaults.Mode,
-		Healthz:     defaults.Healthz,
-		Middlewares: defaults.Middlewares,
+		Mode:                defaults.Mode,
+		Healthz:             defaults.Healthz,
+		Middlewares:         defaults.Middlewares,
+		RequestTimeout:      defaults.RequestTimeout,
+		MaxRequestBodySize:  defaults.MaxRequestBodySize,
+		RedisRequiredRoutes: defaults.RedisRequiredRoutes,
+		PreStopDelay:        defaults.PreStopDelay,
 	}
 }
 
@@ -33,6 +46,10 @@ func (s *ServerRunOptions) ApplyTo(c *server.Config) error {
 	c.Mode = s.Mode
 	c.Healthz = s.Healthz
 	c.Middlewares = s.Middlewares
+	c.RequestTimeout = s.RequestTimeout
+	c.MaxRequestBodySize = s.MaxRequestBodySize
+	c.RedisRequiredRoutes = s.RedisRequiredRoutes
+	c.PreStopDelay = s.PreStopDelay
 
 	return nil
 }
@@ -41,6 +58,31 @@ func (s *ServerRunOptions) ApplyTo(c *server.Config) error {
 func (s *ServerRunOptions) Validate() []error {
 	errors := []error{}
 
+	switch s.Mode {
+	case gin.DebugMode, gin.TestMode, gin.ReleaseMode:
+	default:
+		errors = append(errors, fmt.Errorf("--server.mode %q is invalid, must be one of: %s, %s, %s",
+			s.Mode, gin.DebugMode, gin.TestMode, gin.ReleaseMode))
+	}
+
+	if s.RequestTimeout < 0 {
+		errors = append(errors, fmt.Errorf("--server.request-timeout cannot be negative"))
+	}
+
+	if s.MaxRequestBodySize < 0 {
+		errors = append(errors, fmt.Errorf("--server.max-request-body-size cannot be negative"))
+	}
+
+	for _, route := range s.RedisRequiredRoutes {
+		if _, _, _, err := middleware.ParseRedisRoute(route); err != nil {
+			errors = append(errors, fmt.Errorf("--server.redis-required-routes: %w", err))
+		}
+	}
+
+	if s.PreStopDelay < 0 {
+		errors = append(errors, fmt.Errorf("--server.pre-stop-delay cannot be negative"))
+	}
+
 	return errors
 }
 
@@ -56,4 +98,21 @@ func (s *ServerRunOptions) AddFlags(fs *pflag.FlagSet) {
 
 	fs.StringSliceVar(&s.Middlewares, "server.middlewares", s.Middlewares, ""+
 		"List of allowed middlewares for server, comma separated. If this list is empty default middlewares will be used.")
+
+	fs.DurationVar(&s.RequestTimeout, "server.request-timeout", s.RequestTimeout, ""+
+		"The deadline of every request when the timeout middleware is installed, 0 means no deadline.")
+
+	fs.Int64Var(&s.MaxRequestBodySize, "server.max-request-body-size", s.MaxRequestBodySize, ""+
+		"The maximum size in bytes of the request body when the maxbody middleware is installed, "+
+		"the requests with a larger body are rejected with 413. 0 means no limit. The maxbody middleware "+
+		"is always installed first, or right after recovery, before the middlewares reading the body.")
+
+	fs.StringSliceVar(&s.RedisRequiredRoutes, "server.redis-required-routes", s.RedisRequiredRoutes, ""+
+		"The routes which require redis, they are rejected with 503 when redis is down. A route is \"METHOD /path\" "+
+		"or \"/path\" for all the methods, the path is the route pattern, e.g. /v1/policies/:name, and a path "+
+		"ending with * matches the routes with the prefix.")
+
+	fs.DurationVar(&s.PreStopDelay, "server.pre-stop-delay", s.PreStopDelay, ""+
+		"The time to wait between marking the server as not ready (/readyz) and shutting it down, "+
+		"so load balancers stop routing requests to it.")
 }
@@ -0,0 +1,40 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package options
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServerRunOptions_Validate(t *testing.T) {
+	tests := []struct {
+		mode    string
+		wantErr bool
+	}{
+		{mode: "debug"},
+		{mode: "test"},
+		{mode: "release"},
+		{mode: "production", wantErr: true},
+		{mode: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			s := NewServerRunOptions()
+			s.Mode = tt.mode
+
+			errs := s.Validate()
+			if tt.wantErr {
+				assert.Len(t, errs, 1)
+
+				return
+			}
+
+			assert.Empty(t, errs)
+		})
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package options
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+)
+
+// TracingOptions contains configuration items related to OpenTelemetry tracing.
+type TracingOptions struct {
+	Enabled       bool    `json:"enabled"        mapstructure:"enabled"`
+	ServiceName   string  `json:"service-name"   mapstructure:"service-name"`
+	SamplingRatio float64 `json:"sampling-ratio" mapstructure:"sampling-ratio"`
+}
+
+// NewTracingOptions creates a TracingOptions object with default parameters.
+func NewTracingOptions(serviceName string) *TracingOptions {
+	return &TracingOptions{
+		Enabled:       false,
+		ServiceName:   serviceName,
+		SamplingRatio: 1,
+	}
+}
+
+// Validate is used to parse and validate the parameters entered by the user at
+// the command line when the program starts.
+func (o *TracingOptions) Validate() []error {
+	var errs []error
+
+	if o.SamplingRatio < 0 || o.SamplingRatio > 1 {
+		errs = append(errs, fmt.Errorf("--tracing.sampling-ratio %v must be between 0 and 1, inclusive", o.SamplingRatio))
+	}
+
+	return errs
+}
+
+// AddFlags adds flags related to tracing for a specific server to the specified FlagSet.
+func (o *TracingOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.BoolVar(&o.Enabled, "tracing.enabled", o.Enabled, ""+
+		"Enable OpenTelemetry tracing, the trace context of the requests is propagated "+
+		"and the spans are written to the log.")
+
+	fs.StringVar(&o.ServiceName, "tracing.service-name", o.ServiceName,
+		"The service name the spans are reported with.")
+
+	fs.Float64Var(&o.SamplingRatio, "tracing.sampling-ratio", o.SamplingRatio, ""+
+		"The ratio of the traces to sample, between 0 and 1. The traces sampled by the "+
+		"caller are always sampled.")
+}
@@ -0,0 +1,33 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package pubsub
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/marmotedu/iam/internal/pkg/metrics"
+)
+
+var publishFailures = registerCounterVec(prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "iam_pubsub_publish_failures_total",
+		Help: "The number of the reload notifications failed to publish, by command.",
+	},
+	[]string{"command"},
+))
+
+// registerCounterVec registers c to the registry of the business metrics.
+func registerCounterVec(c *prometheus.CounterVec) *prometheus.CounterVec {
+	if existing, ok := metrics.Register(c).(*prometheus.CounterVec); ok {
+		return existing
+	}
+
+	return c
+}
+
+// RecordPublishFailure counts a notification of command failed to publish.
+func RecordPublishFailure(command NotificationCommand) {
+	publishFailures.WithLabelValues(string(command)).Inc()
+}
@@ -0,0 +1,75 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+// Package pubsub defines the notifications published by the apiserver on redis
+// when the secrets or the policies change, and subscribed to by the authzserver.
+package pubsub
+
+import (
+	"crypto"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// NotificationCommand defines a new notification type.
+type NotificationCommand string
+
+// Define Redis pub/sub events.
+const (
+	RedisPubSubChannel                      = "iam.cluster.notifications"
+	NoticePolicyChanged NotificationCommand = "PolicyChanged"
+	NoticeSecretChanged NotificationCommand = "SecretChanged"
+)
+
+// Define Redis pub/sub channels of every resource, they share the prefix of
+// RedisPubSubChannel, so RedisPubSubPattern matches them together with the
+// shared channel used by older publishers.
+const (
+	RedisPubSubPattern   = RedisPubSubChannel + "*"
+	PolicyChangedChannel = RedisPubSubChannel + ".policy"
+	SecretChangedChannel = RedisPubSubChannel + ".secret"
+)
+
+// NotificationChannel returns the channel the notification command is published to.
+func NotificationChannel(command NotificationCommand) string {
+	switch command {
+	case NoticePolicyChanged:
+		return PolicyChangedChannel
+	case NoticeSecretChanged:
+		return SecretChangedChannel
+	default:
+		return RedisPubSubChannel
+	}
+}
+
+// Notification is a type that encodes a message published to a pub sub channel (shared between implementations).
+type Notification struct {
+	Command       NotificationCommand `json:"command"`
+	Payload       string              `json:"payload"`
+	Signature     string              `json:"signature"`
+	SignatureAlgo crypto.Hash         `json:"algorithm"`
+	// Mirrored is set on the copy of a resource channel notification published on
+	// RedisPubSubChannel for the subscribers which predate the resource channels.
+	// The other subscribers receive the original and ignore the copy.
+	Mirrored bool `json:"mirrored,omitempty"`
+}
+
+// MirroredNotification returns the copy of the notification command published on
+// RedisPubSubChannel, or false if the command is already published there. The copies
+// are published until all the subscribers listen on the resource channels, at least
+// for one release.
+func MirroredNotification(command NotificationCommand) (Notification, bool) {
+	if NotificationChannel(command) == RedisPubSubChannel {
+		return Notification{}, false
+	}
+
+	return Notification{Command: command, Mirrored: true}, true
+}
+
+// Sign sign Notification with SHA256 algorithm.
+func (n *Notification) Sign() {
+	n.SignatureAlgo = crypto.SHA256
+	hash := sha256.Sum256([]byte(string(n.Command) + n.Payload))
+	n.Signature = hex.EncodeToString(hash[:])
+}
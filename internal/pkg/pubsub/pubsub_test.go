@@ -0,0 +1,35 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package pubsub
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotificationChannel(t *testing.T) {
+	assert.Equal(t, PolicyChangedChannel, NotificationChannel(NoticePolicyChanged))
+	assert.Equal(t, SecretChangedChannel, NotificationChannel(NoticeSecretChanged))
+	assert.Equal(t, RedisPubSubChannel, NotificationChannel("Unknown"))
+}
+
+func TestMirroredNotification(t *testing.T) {
+	_, ok := MirroredNotification("Unknown")
+	assert.False(t, ok)
+
+	notif, ok := MirroredNotification(NoticeSecretChanged)
+	assert.True(t, ok)
+	assert.Equal(t, Notification{Command: NoticeSecretChanged, Mirrored: true}, notif)
+}
+
+func TestRecordPublishFailure(t *testing.T) {
+	failures := publishFailures.WithLabelValues(string(NoticePolicyChanged))
+	before := testutil.ToFloat64(failures)
+
+	RecordPublishFailure(NoticePolicyChanged)
+	assert.Equal(t, before+1, testutil.ToFloat64(failures))
+}
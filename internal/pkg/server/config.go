@@ -15,6 +15,7 @@ import (
 	"github.com/marmotedu/component-base/pkg/util/homedir"
 	"github.com/spf13/viper"
 
+	"github.com/marmotedu/iam/internal/pkg/middleware"
 	"github.com/marmotedu/iam/pkg/log"
 )
 
@@ -29,14 +30,20 @@ const (
 // Config is a structure used to configure a GenericAPIServer.
 // Its members are sorted roughly in order of importance for composers.
 type Config struct {
-	SecureServing   *SecureServingInfo
-	InsecureServing *InsecureServingInfo
-	Jwt             *JwtInfo
-	Mode            string
-	Middlewares     []string
-	Healthz         bool
-	EnableProfiling bool
-	EnableMetrics   bool
+	SecureServing       *SecureServingInfo
+	InsecureServing     *InsecureServingInfo
+	Jwt                 *JwtInfo
+	Mode                string
+	Middlewares         []string
+	RequestTimeout      time.Duration
+	MaxRequestBodySize  int64
+	RedisRequiredRoutes []string
+	PreStopDelay        time.Duration
+	Healthz             bool
+	EnableProfiling     bool
+	EnableMetrics       bool
+	MetricsBuckets      []float64
+	EnableVersion       bool
 }
 
 // CertKey contains configuration items related to certificate.
@@ -45,6 +52,10 @@ type CertKey struct {
 	CertFile string
 	// KeyFile is a file containing a PEM-encoded private key for the certificate specified by CertFile
 	KeyFile string
+	// CertData is the PEM-encoded certificate, e.g. from a secret manager, it takes precedence over CertFile
+	CertData []byte
+	// KeyData is the PEM-encoded private key for the certificate specified by CertData
+	KeyData []byte
 }
 
 // SecureServingInfo holds configuration of the TLS server.
@@ -62,6 +73,8 @@ func (s *SecureServingInfo) Address() string {
 // InsecureServingInfo holds configuration of the insecure http server.
 type InsecureServingInfo struct {
 	Address string
+	// H2C enables HTTP/2 over cleartext (h2c) on the insecure server, HTTP/1.1 keeps working.
+	H2C bool
 }
 
 // JwtInfo defines jwt fields used to create jwt authentication middleware.
@@ -74,20 +87,34 @@ type JwtInfo struct {
 	Timeout time.Duration
 	// defaults to zero
 	MaxRefresh time.Duration
+	// defaults to "HS256"
+	SigningAlgorithm string
+	// required by RS algorithms, defaults to empty
+	PrivateKeyFile string
+	PublicKeyFile  string
+	// public keys published besides PublicKeyFile, defaults to empty
+	AdditionalPublicKeyFiles []string
+	// defaults to "iam-apiserver"
+	Issuer string
 }
 
 // NewConfig returns a Config struct with the default values.
 func NewConfig() *Config {
 	return &Config{
-		Healthz:         true,
-		Mode:            gin.ReleaseMode,
-		Middlewares:     []string{},
-		EnableProfiling: true,
-		EnableMetrics:   true,
+		Healthz:            true,
+		Mode:               gin.ReleaseMode,
+		Middlewares:        []string{},
+		RequestTimeout:     middleware.DefaultRequestTimeout,
+		MaxRequestBodySize: middleware.DefaultMaxRequestBodySize,
+		EnableProfiling:    true,
+		EnableMetrics:      true,
+		EnableVersion:      true,
 		Jwt: &JwtInfo{
-			Realm:      "iam jwt",
-			Timeout:    1 * time.Hour,
-			MaxRefresh: 1 * time.Hour,
+			Realm:            "iam jwt",
+			Timeout:          1 * time.Hour,
+			MaxRefresh:       1 * time.Hour,
+			SigningAlgorithm: "HS256",
+			Issuer:           "iam-apiserver",
 		},
 	}
 }
@@ -114,7 +141,13 @@ func (c CompletedConfig) New() (*GenericAPIServer, error) {
 		healthz:             c.Healthz,
 		enableMetrics:       c.EnableMetrics,
 		enableProfiling:     c.EnableProfiling,
+		enableVersion:       c.EnableVersion,
 		middlewares:         c.Middlewares,
+		requestTimeout:      c.RequestTimeout,
+		maxRequestBodySize:  c.MaxRequestBodySize,
+		redisRequiredRoutes: c.RedisRequiredRoutes,
+		metricsBuckets:      c.MetricsBuckets,
+		preStopDelay:        c.PreStopDelay,
 		Engine:              gin.New(),
 	}
 
@@ -127,6 +160,7 @@ func (c CompletedConfig) New() (*GenericAPIServer, error) {
 func LoadConfig(cfg string, defaultName string) {
 	if cfg != "" {
 		viper.SetConfigFile(cfg)
+		viper.SetConfigType(configType(cfg)) // detect the type of the configuration from the file extension.
 	} else {
 		viper.AddConfigPath(".")
 		viper.AddConfigPath(filepath.Join(homedir.HomeDir(), RecommendedHomeDir))
@@ -134,8 +168,6 @@ func LoadConfig(cfg string, defaultName string) {
 		viper.SetConfigName(defaultName)
 	}
 
-	// Use config file from the flag.
-	viper.SetConfigType("yaml")              // set the type of the configuration to yaml.
 	viper.AutomaticEnv()                     // read in environment variables that match.
 	viper.SetEnvPrefix(RecommendedEnvPrefix) // set ENVIRONMENT variables prefix to IAM.
 	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_", "-", "_"))
@@ -145,3 +177,16 @@ func LoadConfig(cfg string, defaultName string) {
 		log.Warnf("WARNING: viper failed to discover and load the configuration file: %s", err.Error())
 	}
 }
+
+// configType returns the configuration type according to the extension of the
+// config file, yaml is used when the file has no supported extension.
+func configType(cfg string) string {
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(cfg), "."))
+	for _, supported := range viper.SupportedExts {
+		if ext == supported {
+			return ext
+		}
+	}
+
+	return "yaml"
+}
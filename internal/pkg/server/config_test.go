@@ -0,0 +1,45 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{name: "iamctl.json", content: `{"server": {"address": "127.0.0.1:8080"}}`},
+		{name: "iamctl.toml", content: "[server]\naddress = \"127.0.0.1:8080\"\n"},
+		{name: "iamctl.yaml", content: "server:\n  address: 127.0.0.1:8080\n"},
+		{name: "iamctl", content: "server:\n  address: 127.0.0.1:8080\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer viper.Reset()
+
+			cfg := filepath.Join(t.TempDir(), tt.name)
+			assert.Nil(t, os.WriteFile(cfg, []byte(tt.content), 0o600))
+
+			LoadConfig(cfg, "iamctl")
+			assert.Equal(t, "127.0.0.1:8080", viper.GetString("server.address"))
+		})
+	}
+}
+
+func Test_configType(t *testing.T) {
+	assert.Equal(t, "json", configType("iam.json"))
+	assert.Equal(t, "toml", configType("/etc/iam/iam.TOML"))
+	assert.Equal(t, "yaml", configType("iam.yml.bak"))
+	assert.Equal(t, "yaml", configType("iam"))
+}
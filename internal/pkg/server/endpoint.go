@@ -0,0 +1,55 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"github.com/marmotedu/iam/pkg/log"
+)
+
+// Endpoint describes an address exposed by the server.
+type Endpoint struct {
+	Name    string
+	Address string
+	TLS     bool
+}
+
+// AddEndpoint adds an endpoint which is served outside of the GenericAPIServer,
+// e.g. a grpc server, to the startup summary.
+func (s *GenericAPIServer) AddEndpoint(name, address string, tls bool) {
+	s.extraEndpoints = append(s.extraEndpoints, Endpoint{Name: name, Address: address, TLS: tls})
+}
+
+// Endpoints returns all the endpoints exposed by the server.
+func (s *GenericAPIServer) Endpoints() []Endpoint {
+	base := "http://" + s.InsecureServingInfo.Address
+	endpoints := []Endpoint{{Name: "http", Address: base}}
+
+	if s.secureServingEnabled() {
+		endpoints = append(endpoints, Endpoint{Name: "https", Address: "https://" + s.SecureServingInfo.Address(), TLS: true})
+	}
+
+	if s.healthz {
+		endpoints = append(endpoints, Endpoint{Name: "healthz", Address: base + "/healthz"})
+	}
+
+	endpoints = append(endpoints, Endpoint{Name: "metrics", Address: base + "/metrics"})
+
+	if s.enableProfiling {
+		endpoints = append(endpoints, Endpoint{Name: "pprof", Address: base + "/debug/pprof"})
+	}
+
+	return append(endpoints, s.extraEndpoints...)
+}
+
+// logEndpoints logs a summary of all the endpoints exposed by the server.
+func (s *GenericAPIServer) logEndpoints() {
+	for _, endpoint := range s.Endpoints() {
+		log.Infow("Serving endpoint", "name", endpoint.Name, "address", endpoint.Address, "tls", endpoint.TLS)
+	}
+}
+
+func (s *GenericAPIServer) secureServingEnabled() bool {
+	return s.SecureServingInfo.CertKey.IsSet() && s.SecureServingInfo.BindPort != 0
+}
@@ -0,0 +1,53 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/marmotedu/iam/pkg/log"
+)
+
+func TestGenericAPIServer_logEndpoints(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "iam.log")
+	opts := log.NewOptions()
+	opts.Format = "json"
+	opts.OutputPaths = []string{output}
+	log.Init(opts)
+	defer log.Init(log.NewOptions())
+
+	s := &GenericAPIServer{
+		InsecureServingInfo: &InsecureServingInfo{Address: "127.0.0.1:8080"},
+		SecureServingInfo: &SecureServingInfo{
+			BindAddress: "0.0.0.0",
+			BindPort:    8443,
+			CertKey:     CertKey{CertFile: "iam.pem", KeyFile: "iam-key.pem"},
+		},
+		healthz:       true,
+		enableMetrics: true,
+	}
+	s.AddEndpoint("grpc", "0.0.0.0:8081", true)
+
+	assert.Equal(t, []Endpoint{
+		{Name: "http", Address: "http://127.0.0.1:8080"},
+		{Name: "https", Address: "https://0.0.0.0:8443", TLS: true},
+		{Name: "healthz", Address: "http://127.0.0.1:8080/healthz"},
+		{Name: "metrics", Address: "http://127.0.0.1:8080/metrics"},
+		{Name: "grpc", Address: "0.0.0.0:8081", TLS: true},
+	}, s.Endpoints())
+
+	s.logEndpoints()
+	log.Flush()
+
+	data, err := os.ReadFile(output)
+	assert.Nil(t, err)
+	assert.Contains(t, string(data), `"name":"https","address":"https://0.0.0.0:8443","tls":true`)
+	assert.Contains(t, string(data), `"name":"grpc","address":"0.0.0.0:8081","tls":true`)
+	assert.NotContains(t, string(data), `"name":"pprof"`)
+}
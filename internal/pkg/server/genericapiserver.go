@@ -10,15 +10,18 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-contrib/pprof"
 	"github.com/gin-gonic/gin"
 	"github.com/marmotedu/component-base/pkg/core"
-	"github.com/marmotedu/component-base/pkg/version"
 	ginprometheus "github.com/zsais/go-gin-prometheus"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 	"golang.org/x/sync/errgroup"
 
+	"github.com/marmotedu/iam/internal/pkg/metrics"
 	"github.com/marmotedu/iam/internal/pkg/middleware"
 	"github.com/marmotedu/iam/pkg/log"
 )
@@ -27,6 +30,14 @@ import (
 // type GenericAPIServer gin.Engine.
 type GenericAPIServer struct {
 	middlewares []string
+	// requestTimeout is the deadline set by the timeout middleware.
+	requestTimeout time.Duration
+	// maxRequestBodySize is the request body size limit of the maxbody middleware.
+	maxRequestBodySize int64
+	// redisRequiredRoutes are the routes rejected when redis is down.
+	redisRequiredRoutes []string
+	// metricsBuckets are the buckets of the request latency histogram.
+	metricsBuckets []float64
 	// SecureServingInfo holds configuration of the TLS server.
 	SecureServingInfo *SecureServingInfo
 
@@ -37,13 +48,25 @@ type GenericAPIServer struct {
 	// gracefully shutdown returns.
 	ShutdownTimeout time.Duration
 
+	// preStopDelay is the time to wait after the server is marked as not ready
+	// and before it's shutdown, so load balancers stop routing to it.
+	preStopDelay time.Duration
+	// notReady is set to 1 once the server starts draining.
+	notReady int32
+	// readyzChecks are the dependency checks of the readiness probe.
+	readyzChecks readyzChecks
+
 	*gin.Engine
 	healthz         bool
 	enableMetrics   bool
 	enableProfiling bool
+	enableVersion   bool
 	// wrapper for gin.Engine
 
 	insecureServer, secureServer *http.Server
+
+	// extraEndpoints are the endpoints served outside of the GenericAPIServer.
+	extraEndpoints []Endpoint
 }
 
 func initGenericAPIServer(s *GenericAPIServer) {
@@ -57,27 +80,33 @@ func initGenericAPIServer(s *GenericAPIServer) {
 
 // InstallAPIs install generic apis.
 func (s *GenericAPIServer) InstallAPIs() {
-	// install healthz handler
+	// install healthz and readyz handler
 	if s.healthz {
 		s.GET("/healthz", func(c *gin.Context) {
 			core.WriteResponse(c, nil, map[string]string{"status": "ok"})
 		})
+
+		s.GET("/readyz", s.readyz)
 	}
 
-	// install metric handler
+	// install metric handler, the business metrics are served even if the http request metrics are disabled
 	if s.enableMetrics {
 		prometheus := ginprometheus.NewPrometheus("gin")
-		prometheus.Use(s.Engine)
+		s.Use(prometheus.HandlerFunc(), observeLatency(newRequestLatency(s.metricsBuckets)))
 	}
+	s.GET("/metrics", gin.WrapH(metrics.Handler()))
 
 	// install pprof handler
 	if s.enableProfiling {
 		pprof.Register(s.Engine)
 	}
 
-	s.GET("/version", func(c *gin.Context) {
-		core.WriteResponse(c, nil, version.Get())
-	})
+	// install version handler
+	if s.enableVersion {
+		s.GET("/version", func(c *gin.Context) {
+			core.WriteResponse(c, nil, versionInfo())
+		})
+	}
 }
 
 // Setup do some setup work for gin engine.
@@ -94,7 +123,7 @@ func (s *GenericAPIServer) InstallMiddlewares() {
 	s.Use(middleware.Context())
 
 	// install custom middlewares
-	for _, m := range s.middlewares {
+	for _, m := range orderMiddlewares(s.middlewares) {
 		mw, ok := middleware.Middlewares[m]
 		if !ok {
 			log.Warnf("can not find middleware: %s", m)
@@ -102,9 +131,48 @@ func (s *GenericAPIServer) InstallMiddlewares() {
 			continue
 		}
 
+		switch m {
+		case "timeout":
+			mw = middleware.Timeout(s.requestTimeout)
+		case "maxbody":
+			mw = middleware.MaxBodySize(s.maxRequestBodySize)
+		}
+
 		log.Infof("install middleware: %s", m)
 		s.Use(mw)
 	}
+
+	if len(s.redisRequiredRoutes) > 0 {
+		log.Infof("install middleware: requireredis, routes: %v", s.redisRequiredRoutes)
+		s.Use(middleware.RequireRedis(s.redisRequiredRoutes))
+	}
+}
+
+// orderMiddlewares moves maxbody first, or right after recovery when it comes first,
+// so the body is limited before the middlewares which read it, e.g. dump.
+func orderMiddlewares(middlewares []string) []string {
+	ordered := make([]string, 0, len(middlewares))
+	maxBody := false
+	for _, m := range middlewares {
+		if m == "maxbody" {
+			maxBody = true
+
+			continue
+		}
+
+		ordered = append(ordered, m)
+	}
+
+	if !maxBody {
+		return ordered
+	}
+
+	at := 0
+	if len(ordered) > 0 && ordered[0] == "recovery" {
+		at = 1
+	}
+
+	return append(ordered[:at], append([]string{"maxbody"}, ordered[at:]...)...)
 }
 
 /*
@@ -118,12 +186,22 @@ func (s *GenericAPIServer) PrepareRun() preparedGenericAPIServer {
 }
 */
 
+// insecureHandler returns the handler of the insecure server, it accepts HTTP/2 over
+// cleartext (h2c) besides HTTP/1.1 when H2C is enabled.
+func (s *GenericAPIServer) insecureHandler() http.Handler {
+	if !s.InsecureServingInfo.H2C {
+		return s
+	}
+
+	return h2c.NewHandler(s, &http2.Server{})
+}
+
 // Run spawns the http server. It only returns when the port cannot be listened on initially.
 func (s *GenericAPIServer) Run() error {
 	// For scalability, use custom HTTP configuration mode here
 	s.insecureServer = &http.Server{
 		Addr:    s.InsecureServingInfo.Address,
-		Handler: s,
+		Handler: s.insecureHandler(),
 		// ReadTimeout:    10 * time.Second,
 		// WriteTimeout:   10 * time.Second,
 		// MaxHeaderBytes: 1 << 20,
@@ -158,14 +236,20 @@ func (s *GenericAPIServer) Run() error {
 	})
 
 	eg.Go(func() error {
-		key, cert := s.SecureServingInfo.CertKey.KeyFile, s.SecureServingInfo.CertKey.CertFile
-		if cert == "" || key == "" || s.SecureServingInfo.BindPort == 0 {
+		if !s.secureServingEnabled() {
 			return nil
 		}
+		tlsConfig, err := s.SecureServingInfo.TLSConfig()
+		if err != nil {
+			log.Fatal(err.Error())
+
+			return err
+		}
+		s.secureServer.TLSConfig = tlsConfig
 
 		log.Infof("Start to listening the incoming requests on https address: %s", s.SecureServingInfo.Address())
 
-		if err := s.secureServer.ListenAndServeTLS(cert, key); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		if err := s.secureServer.ListenAndServeTLS("", ""); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			log.Fatal(err.Error())
 
 			return err
@@ -185,6 +269,8 @@ func (s *GenericAPIServer) Run() error {
 		}
 	}
 
+	s.logEndpoints()
+
 	if err := eg.Wait(); err != nil {
 		log.Fatal(err.Error())
 	}
@@ -192,6 +278,25 @@ func (s *GenericAPIServer) Run() error {
 	return nil
 }
 
+// Ready returns whether the server is ready to serve requests, it's false
+// once the server starts draining.
+func (s *GenericAPIServer) Ready() bool {
+	return atomic.LoadInt32(&s.notReady) == 0
+}
+
+// Drain marks the server as not ready, then waits for the pre-stop delay, so
+// load balancers stop routing new requests to the server before it's closed.
+func (s *GenericAPIServer) Drain() {
+	atomic.StoreInt32(&s.notReady, 1)
+
+	if s.preStopDelay <= 0 {
+		return
+	}
+
+	log.Infof("Server is not ready, wait %s before shutdown", s.preStopDelay)
+	time.Sleep(s.preStopDelay)
+}
+
 // Close graceful shutdown the api server.
 func (s *GenericAPIServer) Close() {
 	// The context is used to inform the server it has 10 seconds to finish
@@ -0,0 +1,180 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http2"
+
+	"github.com/marmotedu/iam/internal/pkg/metrics"
+)
+
+func readyzStatus(s *GenericAPIServer) int {
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	return w.Code
+}
+
+func TestGenericAPIServer_Drain(t *testing.T) {
+	c := NewConfig()
+	c.PreStopDelay = 200 * time.Millisecond
+	s, err := c.Complete().New()
+	assert.Nil(t, err)
+
+	assert.True(t, s.Ready())
+	assert.Equal(t, http.StatusOK, readyzStatus(s))
+
+	drained := make(chan struct{})
+	start := time.Now()
+	go func() {
+		s.Drain()
+		close(drained)
+	}()
+
+	// readiness goes false before the delay elapses and the shutdown begins
+	assert.Eventually(t, func() bool { return !s.Ready() }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, http.StatusServiceUnavailable, readyzStatus(s))
+	select {
+	case <-drained:
+		t.Fatal("drain returned before the pre-stop delay")
+	default:
+	}
+
+	<-drained
+	assert.GreaterOrEqual(t, time.Since(start), c.PreStopDelay)
+	assert.Equal(t, http.StatusServiceUnavailable, readyzStatus(s))
+}
+
+func TestGenericAPIServer_ReadyzChecks(t *testing.T) {
+	s, err := NewConfig().Complete().New()
+	assert.Nil(t, err)
+
+	var depErr error
+	s.AddReadyzCheck("dependency", func() error { return depErr })
+
+	assert.Equal(t, http.StatusOK, readyzStatus(s))
+
+	depErr = errors.New("unavailable")
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Contains(t, w.Body.String(), "dependency: unavailable")
+
+	// the checks don't affect the liveness probe
+	w = httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestGenericAPIServer_Metrics(t *testing.T) {
+	requests := metrics.Register(prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "iam_test_business_requests_total",
+		Help: "The number of the business requests of the test.",
+	})).(prometheus.Counter)
+	requests.Inc()
+
+	// the business metrics are served without the http request metrics
+	c := NewConfig()
+	c.EnableMetrics = false
+	s, err := c.Complete().New()
+	assert.Nil(t, err)
+
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "iam_test_business_requests_total 1")
+}
+
+func TestGenericAPIServer_MetricsBuckets(t *testing.T) {
+	c := NewConfig()
+	c.MetricsBuckets = []float64{0.001, 0.005, 0.01}
+	s, err := c.Complete().New()
+	assert.Nil(t, err)
+
+	s.GET("/fast", func(c *gin.Context) {
+		c.String(http.StatusOK, "done")
+	})
+
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/fast", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := w.Body.String()
+	for _, le := range []string{"0.001", "0.005", "0.01", "+Inf"} {
+		assert.Contains(t, body, `gin_request_latency_seconds_bucket{code="200",method="GET",route="/fast",le="`+le+`"}`)
+	}
+	assert.NotContains(t, body, `gin_request_latency_seconds_bucket{code="200",method="GET",route="/fast",le="0.025"}`)
+}
+
+func TestGenericAPIServer_InsecureH2C(t *testing.T) {
+	c := NewConfig()
+	c.InsecureServing = &InsecureServingInfo{H2C: true}
+	s, err := c.Complete().New()
+	require.NoError(t, err)
+
+	srv := httptest.NewServer(s.insecureHandler())
+	defer srv.Close()
+
+	// prior knowledge h2c client, it dials plain tcp instead of tls
+	h2cClient := &http.Client{Transport: &http2.Transport{
+		AllowHTTP: true,
+		DialTLS: func(network, addr string, _ *tls.Config) (net.Conn, error) {
+			return net.Dial(network, addr)
+		},
+	}}
+
+	resp, err := h2cClient.Get(srv.URL + "/healthz")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 2, resp.ProtoMajor)
+
+	// HTTP/1.1 keeps working
+	resp, err = http.Get(srv.URL + "/healthz")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 1, resp.ProtoMajor)
+}
+
+func TestGenericAPIServer_InsecureH2CDisabled(t *testing.T) {
+	c := NewConfig()
+	c.InsecureServing = &InsecureServingInfo{}
+	s, err := c.Complete().New()
+	require.NoError(t, err)
+
+	assert.Equal(t, http.Handler(s), s.insecureHandler())
+}
+
+func TestOrderMiddlewares(t *testing.T) {
+	tests := []struct {
+		middlewares []string
+		want        []string
+	}{
+		{[]string{"recovery", "logger", "dump", "maxbody"}, []string{"recovery", "maxbody", "logger", "dump"}},
+		{[]string{"dump", "maxbody", "recovery"}, []string{"maxbody", "dump", "recovery"}},
+		{[]string{"dump", "maxbody"}, []string{"maxbody", "dump"}},
+		{[]string{"recovery", "dump"}, []string{"recovery", "dump"}},
+		{nil, []string{}},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, orderMiddlewares(tt.middlewares))
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// newRequestLatency creates the histogram of the http request latencies with buckets, the
+// latencies observed by ginprometheus are a summary which can't be aggregated by buckets.
+// It replaces the histogram registered by a previous server, so the buckets of the last
+// server are used.
+func newRequestLatency(buckets []float64) *prometheus.HistogramVec {
+	if len(buckets) == 0 {
+		buckets = prometheus.DefBuckets
+	}
+
+	latency := prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Subsystem: "gin",
+			Name:      "request_latency_seconds",
+			Help:      "The HTTP request latencies in seconds, partitioned by status code, HTTP method and route.",
+			Buckets:   buckets,
+		},
+		[]string{"code", "method", "route"},
+	)
+
+	if err := prometheus.Register(latency); err != nil {
+		var are prometheus.AlreadyRegisteredError
+		if errors.As(err, &are) {
+			prometheus.Unregister(are.ExistingCollector)
+			prometheus.MustRegister(latency)
+		}
+	}
+
+	return latency
+}
+
+// observeLatency is a middleware that observes the latency of the request in latency, the
+// route is the path pattern so the cardinality is bounded.
+func observeLatency(latency *prometheus.HistogramVec) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.URL.Path == "/metrics" {
+			c.Next()
+
+			return
+		}
+
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		latency.WithLabelValues(strconv.Itoa(c.Writer.Status()), c.Request.Method, route).
+			Observe(time.Since(start).Seconds())
+	}
+}
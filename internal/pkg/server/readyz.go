@@ -0,0 +1,63 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/marmotedu/component-base/pkg/core"
+)
+
+// ReadyzCheck checks a dependency the server needs to serve requests, it returns an
+// error when the dependency is unavailable.
+type ReadyzCheck func() error
+
+type namedReadyzCheck struct {
+	name  string
+	check ReadyzCheck
+}
+
+// readyzChecks holds the checks of the readiness probe.
+type readyzChecks struct {
+	lock   sync.RWMutex
+	checks []namedReadyzCheck
+}
+
+// AddReadyzCheck adds a check to the readiness probe /readyz, the server isn't ready
+// while any of the checks fails. The liveness probe /healthz doesn't run the checks, so
+// the server isn't restarted while its dependencies are unavailable.
+func (s *GenericAPIServer) AddReadyzCheck(name string, check ReadyzCheck) {
+	s.readyzChecks.lock.Lock()
+	defer s.readyzChecks.lock.Unlock()
+
+	s.readyzChecks.checks = append(s.readyzChecks.checks, namedReadyzCheck{name: name, check: check})
+}
+
+// readyz serves the readiness probe.
+func (s *GenericAPIServer) readyz(c *gin.Context) {
+	if !s.Ready() {
+		c.JSON(http.StatusServiceUnavailable, map[string]string{"status": "not ready"})
+
+		return
+	}
+
+	s.readyzChecks.lock.RLock()
+	defer s.readyzChecks.lock.RUnlock()
+
+	for _, rc := range s.readyzChecks.checks {
+		if err := rc.check(); err != nil {
+			c.JSON(http.StatusServiceUnavailable, map[string]string{
+				"status": "not ready",
+				"reason": rc.name + ": " + err.Error(),
+			})
+
+			return
+		}
+	}
+
+	core.WriteResponse(c, nil, map[string]string{"status": "ok"})
+}
@@ -0,0 +1,205 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/marmotedu/iam/pkg/log"
+)
+
+// IsSet returns whether the certificate and key are provided, either as PEM data or files.
+func (c CertKey) IsSet() bool {
+	return (len(c.CertData) != 0 && len(c.KeyData) != 0) || (c.CertFile != "" && c.KeyFile != "")
+}
+
+// Load loads the certificate and key, the PEM data is used if set, it falls back to the files.
+func (c CertKey) Load() (tls.Certificate, error) {
+	if len(c.CertData) != 0 || len(c.KeyData) != 0 {
+		cert, err := tls.X509KeyPair(c.CertData, c.KeyData)
+		if err != nil {
+			return tls.Certificate{}, fmt.Errorf("failed to load certificate from PEM data: %w", err)
+		}
+
+		return cert, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("failed to load certificate from %s and %s: %w", c.CertFile, c.KeyFile, err)
+	}
+
+	return cert, nil
+}
+
+// TLSConfig returns the TLS configuration serving the certificate. The certificate
+// files are checked for changes at most every certReloadInterval and reloaded when they
+// changed, so a rotated certificate is served without a restart.
+func (c CertKey) TLSConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if len(c.CertData) != 0 || len(c.KeyData) != 0 {
+		cert, err := c.Load()
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+
+		return tlsConfig, nil
+	}
+
+	reloader, err := newCertReloader(c, certReloadInterval)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig.GetCertificate = reloader.GetCertificate
+
+	return tlsConfig, nil
+}
+
+// TLSConfig returns the TLS configuration of the secure server.
+func (s *SecureServingInfo) TLSConfig() (*tls.Config, error) {
+	return s.CertKey.TLSConfig()
+}
+
+// certReloadInterval is how often the certificate files are checked for changes.
+const certReloadInterval = 10 * time.Second
+
+// certReloader reloads the certificate when its files change.
+type certReloader struct {
+	certKey  CertKey
+	interval time.Duration
+
+	lock      sync.Mutex
+	cert      *tls.Certificate
+	stamp     string
+	lastCheck time.Time
+}
+
+func newCertReloader(certKey CertKey, interval time.Duration) (*certReloader, error) {
+	r := &certReloader{certKey: certKey, interval: interval}
+
+	stamp, err := r.fileStamp()
+	if err != nil {
+		return nil, err
+	}
+
+	cert, err := certKey.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	r.cert, r.stamp, r.lastCheck = &cert, stamp, time.Now()
+
+	return r, nil
+}
+
+// GetCertificate returns the current certificate, it's used as tls.Config.GetCertificate.
+func (r *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if time.Since(r.lastCheck) < r.interval {
+		return r.cert, nil
+	}
+	r.lastCheck = time.Now()
+
+	stamp, err := r.fileStamp()
+	if err != nil || stamp == r.stamp {
+		return r.cert, nil
+	}
+
+	// the files may be partially written, keep the current certificate and retry later
+	cert, err := r.certKey.Load()
+	if err != nil {
+		log.Warnf("Failed to reload the certificate, keep serving the current one: %s", err.Error())
+
+		return r.cert, nil
+	}
+
+	log.Infof("Reloaded the certificate from %s", r.certKey.CertFile)
+	r.cert, r.stamp = &cert, stamp
+
+	return r.cert, nil
+}
+
+// fileStamp returns the modification time and size of the certificate and key files.
+func (r *certReloader) fileStamp() (string, error) {
+	var stamp string
+	for _, file := range []string{r.certKey.CertFile, r.certKey.KeyFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			return "", fmt.Errorf("failed to stat %s: %w", file, err)
+		}
+
+		stamp += fmt.Sprintf("%d-%d;", info.ModTime().UnixNano(), info.Size())
+	}
+
+	return stamp, nil
+}
+
+// selfSignedCertValidity is the validity of the generated self-signed certificates.
+const selfSignedCertValidity = 365 * 24 * time.Hour
+
+// GenerateSelfSignedCertKey generates a self-signed certificate and key in PEM for host,
+// the certificate is valid for localhost and the loopback addresses as well. It's meant
+// for local development only.
+func GenerateSelfSignedCertKey(host string) ([]byte, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate private key: %w", err)
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate serial number: %w", err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: fmt.Sprintf("%s@%d", host, time.Now().Unix())},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(selfSignedCertValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1"), net.IPv6loopback},
+	}
+
+	if ip := net.ParseIP(host); ip != nil {
+		if !ip.IsUnspecified() && !ip.IsLoopback() {
+			tmpl.IPAddresses = append(tmpl.IPAddresses, ip)
+		}
+	} else if host != "" && host != "localhost" {
+		tmpl.DNSNames = append(tmpl.DNSNames, host)
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create certificate: %w", err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal private key: %w", err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), nil
+}
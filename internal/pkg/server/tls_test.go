@@ -0,0 +1,166 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testCertFile = "../../../configs/cert/iam.pem"
+	testKeyFile  = "../../../configs/cert/iam-key.pem"
+)
+
+// serveTLS serves s with tlsConfig, it returns the certificate served.
+func serveTLS(t *testing.T, s *GenericAPIServer, tlsConfig *tls.Config) []byte {
+	t.Helper()
+
+	// httptest.Server adds its own certificate, which is preferred over GetCertificate
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := &http.Server{Handler: s, TLSConfig: tlsConfig}
+	go func() { _ = server.ServeTLS(ln, "", "") }()
+	defer server.Close()
+
+	client := &http.Client{Transport: &http.Transport{
+		TLSClientConfig:   &tls.Config{InsecureSkipVerify: true}, // nolint: gosec
+		DisableKeepAlives: true,
+	}}
+	resp, err := client.Get("https://" + ln.Addr().String() + "/healthz")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NotEmpty(t, resp.TLS.PeerCertificates)
+
+	return resp.TLS.PeerCertificates[0].Raw
+}
+
+func TestSecureServing_PEMData(t *testing.T) {
+	certPEM, err := os.ReadFile(testCertFile)
+	require.NoError(t, err)
+	keyPEM, err := os.ReadFile(testKeyFile)
+	require.NoError(t, err)
+
+	c := NewConfig()
+	c.SecureServing = &SecureServingInfo{
+		BindPort: 8443,
+		// the files don't exist, the PEM data takes precedence
+		CertKey: CertKey{CertFile: "absent.pem", KeyFile: "absent-key.pem", CertData: certPEM, KeyData: keyPEM},
+	}
+	s, err := c.Complete().New()
+	require.NoError(t, err)
+	assert.True(t, s.secureServingEnabled())
+
+	tlsConfig, err := s.SecureServingInfo.TLSConfig()
+	require.NoError(t, err)
+
+	want, err := tls.X509KeyPair(certPEM, keyPEM)
+	require.NoError(t, err)
+	assert.Equal(t, want.Certificate[0], serveTLS(t, s, tlsConfig))
+}
+
+func TestCertKey_Load(t *testing.T) {
+	cert, err := CertKey{CertFile: testCertFile, KeyFile: testKeyFile}.Load()
+	require.NoError(t, err)
+	assert.NotEmpty(t, cert.Certificate)
+
+	_, err = CertKey{CertData: []byte("invalid"), KeyData: []byte("invalid")}.Load()
+	assert.Error(t, err)
+
+	_, err = CertKey{CertFile: "absent.pem", KeyFile: "absent-key.pem"}.Load()
+	assert.Error(t, err)
+
+	assert.False(t, CertKey{CertData: []byte("cert")}.IsSet())
+	assert.True(t, CertKey{CertData: []byte("cert"), KeyData: []byte("key")}.IsSet())
+	assert.True(t, CertKey{CertFile: "cert.pem", KeyFile: "key.pem"}.IsSet())
+}
+
+// newTestCert returns a self-signed certificate and key in PEM.
+func newTestCert(t *testing.T, name string) ([]byte, []byte) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+// writeTestCert writes the certificate and key, the modification time is moved forward
+// so the change is detected on file systems with a coarse time resolution.
+func writeTestCert(t *testing.T, certFile, keyFile string, certPEM, keyPEM []byte, mtime time.Time) {
+	t.Helper()
+
+	require.NoError(t, os.WriteFile(certFile, certPEM, 0o600))
+	require.NoError(t, os.WriteFile(keyFile, keyPEM, 0o600))
+	require.NoError(t, os.Chtimes(certFile, mtime, mtime))
+	require.NoError(t, os.Chtimes(keyFile, mtime, mtime))
+}
+
+func TestCertReloader_Rotation(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "iam.pem"), filepath.Join(dir, "iam-key.pem")
+
+	oldCert, oldKey := newTestCert(t, "old")
+	writeTestCert(t, certFile, keyFile, oldCert, oldKey, time.Now().Add(-time.Minute))
+
+	c := NewConfig()
+	c.SecureServing = &SecureServingInfo{BindPort: 8443, CertKey: CertKey{CertFile: certFile, KeyFile: keyFile}}
+	s, err := c.Complete().New()
+	require.NoError(t, err)
+
+	tlsConfig, err := s.SecureServingInfo.TLSConfig()
+	require.NoError(t, err)
+	require.NotNil(t, tlsConfig.GetCertificate)
+
+	reloader, err := newCertReloader(s.SecureServingInfo.CertKey, 0)
+	require.NoError(t, err)
+	tlsConfig.GetCertificate = reloader.GetCertificate
+
+	want, _ := tls.X509KeyPair(oldCert, oldKey)
+	assert.Equal(t, want.Certificate[0], serveTLS(t, s, tlsConfig))
+
+	// a broken certificate is not served
+	writeTestCert(t, certFile, keyFile, []byte("broken"), oldKey, time.Now())
+	assert.Equal(t, want.Certificate[0], serveTLS(t, s, tlsConfig))
+
+	// the rotated certificate is served to the new connections
+	newCert, newKey := newTestCert(t, "new")
+	writeTestCert(t, certFile, keyFile, newCert, newKey, time.Now().Add(time.Minute))
+	want, _ = tls.X509KeyPair(newCert, newKey)
+	assert.Equal(t, want.Certificate[0], serveTLS(t, s, tlsConfig))
+}
@@ -0,0 +1,57 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"runtime/debug"
+	"strings"
+
+	"github.com/marmotedu/component-base/pkg/version"
+)
+
+// defaultBuildDate is the build date of binaries built without ldflags.
+const defaultBuildDate = "1970-01-01T00:00:00Z"
+
+// versionInfo returns the version information of the binary. The build metadata
+// is set by ldflags when building with make, otherwise it's filled with the vcs
+// information embedded by the go toolchain.
+func versionInfo() version.Info {
+	info := version.Get()
+
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		info = fillBuildInfo(info, bi)
+	}
+
+	return info
+}
+
+// fillBuildInfo fills the fields of info which are not set by ldflags with bi.
+func fillBuildInfo(info version.Info, bi *debug.BuildInfo) version.Info {
+	if strings.HasPrefix(info.GitVersion, "v0.0.0-master") && bi.Main.Version != "" && bi.Main.Version != "(devel)" {
+		info.GitVersion = bi.Main.Version
+	}
+
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			if strings.HasPrefix(info.GitCommit, "$Format") {
+				info.GitCommit = s.Value
+			}
+		case "vcs.time":
+			if info.BuildDate == defaultBuildDate {
+				info.BuildDate = s.Value
+			}
+		case "vcs.modified":
+			if info.GitTreeState == "" {
+				info.GitTreeState = "clean"
+				if s.Value == "true" {
+					info.GitTreeState = "dirty"
+				}
+			}
+		}
+	}
+
+	return info
+}
@@ -0,0 +1,77 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"runtime/debug"
+	"testing"
+
+	"github.com/marmotedu/component-base/pkg/json"
+	"github.com/marmotedu/component-base/pkg/version"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVersionRoute(t *testing.T) {
+	s, err := NewConfig().Complete().New()
+	assert.Nil(t, err)
+
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/version", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var fields map[string]string
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &fields))
+	for _, key := range []string{"gitVersion", "gitCommit", "buildDate", "goVersion", "compiler", "platform"} {
+		assert.NotEmpty(t, fields[key], key)
+	}
+}
+
+func TestFillBuildInfo(t *testing.T) {
+	bi := &debug.BuildInfo{
+		Main: debug.Module{Version: "v1.6.2"},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs.revision", Value: "4f8f04b"},
+			{Key: "vcs.time", Value: "2022-05-01T10:00:00Z"},
+			{Key: "vcs.modified", Value: "true"},
+		},
+	}
+
+	// binaries built without ldflags
+	info := fillBuildInfo(version.Info{
+		GitVersion: "v0.0.0-master+$Format:%h$",
+		GitCommit:  "$Format:%H$",
+		BuildDate:  defaultBuildDate,
+	}, bi)
+	assert.Equal(t, "v1.6.2", info.GitVersion)
+	assert.Equal(t, "4f8f04b", info.GitCommit)
+	assert.Equal(t, "2022-05-01T10:00:00Z", info.BuildDate)
+	assert.Equal(t, "dirty", info.GitTreeState)
+
+	// ldflags take precedence
+	ldflags := version.Info{
+		GitVersion:   "v1.7.0",
+		GitCommit:    "a85cadb",
+		GitTreeState: "clean",
+		BuildDate:    "2022-06-01T10:00:00Z",
+	}
+	assert.Equal(t, ldflags, fillBuildInfo(ldflags, bi))
+}
+
+func TestVersionRoute_Disabled(t *testing.T) {
+	c := NewConfig()
+	c.EnableVersion = false
+	s, err := c.Complete().New()
+	assert.Nil(t, err)
+
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/version", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	for _, route := range s.Routes() {
+		assert.NotEqual(t, "/version", route.Path)
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+// Package tracing instruments the iam services with OpenTelemetry. The spans are
+// created by the global tracer provider, which doesn't record anything until Init
+// is called, so the services not enabling tracing aren't affected.
+package tracing
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/marmotedu/iam/pkg/log"
+)
+
+// instrumentationName is the name of the tracer of the iam services.
+const instrumentationName = "github.com/marmotedu/iam"
+
+// Tracer returns the tracer the spans of the iam services are created by.
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Init installs a tracer provider which samples the traces by samplingRatio, unless the
+// parent span is sampled already, and W3C trace context propagation. The finished spans are
+// written to the log. The returned function flushes the pending spans and stops the provider.
+func Init(serviceName string, samplingRatio float64) func(context.Context) error {
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(samplingRatio))),
+		sdktrace.WithBatcher(&logExporter{}),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceNameKey.String(serviceName))),
+	)
+
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	return tp.Shutdown
+}
+
+// logExporter writes the finished spans to the log.
+type logExporter struct{}
+
+// ExportSpans writes spans to the log.
+func (e *logExporter) ExportSpans(_ context.Context, spans []sdktrace.ReadOnlySpan) error {
+	for _, span := range spans {
+		keysAndValues := []interface{}{
+			"traceID", span.SpanContext().TraceID().String(),
+			"spanID", span.SpanContext().SpanID().String(),
+			"parentSpanID", span.Parent().SpanID().String(),
+			"duration", span.EndTime().Sub(span.StartTime()).Round(time.Microsecond).String(),
+			"status", span.Status().Code.String(),
+		}
+
+		for _, attr := range span.Attributes() {
+			keysAndValues = append(keysAndValues, string(attr.Key), attr.Value.Emit())
+		}
+
+		log.Infow("Span "+span.Name(), keysAndValues...)
+	}
+
+	return nil
+}
+
+// Shutdown stops the exporter, nothing is buffered by it.
+func (e *logExporter) Shutdown(context.Context) error {
+	return nil
+}
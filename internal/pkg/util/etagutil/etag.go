@@ -0,0 +1,66 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+// Package etagutil writes resource responses with an ETag, and answers the
+// conditional requests made with If-None-Match.
+package etagutil
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/marmotedu/component-base/pkg/core"
+	"github.com/marmotedu/component-base/pkg/json"
+)
+
+// ETag returns the strong entity tag of the resource, which is the hash of its
+// json representation.
+func ETag(obj interface{}) (string, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(data)
+
+	return `"` + hex.EncodeToString(sum[:]) + `"`, nil
+}
+
+// Match reports whether the If-None-Match header value matches the etag. Weak
+// comparison is used as defined in RFC 7232, section 3.2.
+func Match(ifNoneMatch, etag string) bool {
+	for _, tag := range strings.Split(ifNoneMatch, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WriteResourceResponse writes the resource with its ETag, a 304 Not Modified
+// response without body is written instead if the client already has it.
+func WriteResourceResponse(c *gin.Context, obj interface{}) {
+	etag, err := ETag(obj)
+	if err != nil {
+		core.WriteResponse(c, nil, obj)
+
+		return
+	}
+
+	c.Header("ETag", etag)
+
+	if ifNoneMatch := c.GetHeader("If-None-Match"); ifNoneMatch != "" && Match(ifNoneMatch, etag) {
+		c.Status(http.StatusNotModified)
+		c.Writer.WriteHeaderNow()
+
+		return
+	}
+
+	core.WriteResponse(c, nil, obj)
+}
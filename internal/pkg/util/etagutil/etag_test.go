@@ -0,0 +1,71 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package etagutil
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		ifNoneMatch string
+		want        bool
+	}{
+		{`"abc"`, true},
+		{`W/"abc"`, true},
+		{`"foo", "abc"`, true},
+		{`*`, true},
+		{`"abd"`, false},
+		{`abc`, false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, Match(tt.ifNoneMatch, `"abc"`), tt.ifNoneMatch)
+	}
+}
+
+func TestWriteResourceResponse(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	resource := map[string]string{"name": "admin", "nickname": "admin"}
+	r := gin.New()
+	r.GET("/v1/users/admin", func(c *gin.Context) {
+		WriteResourceResponse(c, resource)
+	})
+
+	get := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/v1/users/admin", nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		return w
+	}
+
+	w := get("")
+	assert.Equal(t, http.StatusOK, w.Code)
+	etag := w.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+	assert.JSONEq(t, `{"name":"admin","nickname":"admin"}`, w.Body.String())
+
+	w = get(etag)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Equal(t, etag, w.Header().Get("ETag"))
+	assert.Empty(t, w.Body.String())
+
+	resource["nickname"] = "colin"
+	w = get(etag)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotEqual(t, etag, w.Header().Get("ETag"))
+	assert.JSONEq(t, `{"name":"admin","nickname":"colin"}`, w.Body.String())
+}
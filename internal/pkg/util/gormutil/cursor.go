@@ -0,0 +1,77 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gormutil
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// CursorField is the field selector key which carries the keyset pagination cursor.
+	CursorField = "after"
+
+	// NextCursorHeader is the response header which carries the cursor of the next page.
+	NextCursorHeader = "X-Next-Cursor"
+)
+
+// EncodeCursor encodes the id of the last record in a page into an opaque cursor.
+func EncodeCursor(id uint64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatUint(id, 10)))
+}
+
+// DecodeCursor decodes a cursor created by EncodeCursor. An empty cursor decodes to 0,
+// which means keyset pagination is not used.
+func DecodeCursor(cursor string) (uint64, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, fmt.Errorf("invalid cursor %q", cursor)
+	}
+
+	id, err := strconv.ParseUint(string(b), 10, 64)
+	if err != nil || id == 0 {
+		return 0, fmt.Errorf("invalid cursor %q", cursor)
+	}
+
+	return id, nil
+}
+
+// WithCursor appends the cursor to the field selector.
+func WithCursor(fieldSelector, cursor string) string {
+	if cursor == "" {
+		return fieldSelector
+	}
+
+	return WithField(fieldSelector, CursorField, cursor)
+}
+
+// NextCursor returns the cursor of the page after the one ending with lastID. Empty string
+// is returned when the page is not full, which means there is no more records.
+func NextCursor(count int, limit int, lastID uint64) string {
+	if count == 0 || count < limit {
+		return ""
+	}
+
+	return EncodeCursor(lastID)
+}
+
+// Paginate returns a gorm scope which applies keyset pagination when cursor is not 0, otherwise
+// offset pagination. Keyset pagination requires the records to be ordered by id desc.
+func Paginate(ol *LimitAndOffset, cursor uint64) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if cursor > 0 {
+			return db.Where("id < ?", cursor).Limit(ol.Limit)
+		}
+
+		return db.Offset(ol.Offset).Limit(ol.Limit)
+	}
+}
@@ -0,0 +1,39 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gormutil
+
+import (
+	"testing"
+
+	"github.com/marmotedu/component-base/pkg/fields"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCursor(t *testing.T) {
+	cursor := EncodeCursor(42)
+
+	id, err := DecodeCursor(cursor)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(42), id)
+
+	id, err = DecodeCursor("")
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(0), id)
+
+	_, err = DecodeCursor("not-a-cursor")
+	assert.NotNil(t, err)
+
+	selector, err := fields.ParseSelector(WithCursor("name=colin", cursor))
+	assert.Nil(t, err)
+	after, found := selector.RequiresExactMatch(CursorField)
+	assert.True(t, found)
+	assert.Equal(t, cursor, after)
+}
+
+func TestNextCursor(t *testing.T) {
+	assert.Equal(t, "", NextCursor(0, 10, 0))
+	assert.Equal(t, "", NextCursor(5, 10, 3))
+	assert.Equal(t, EncodeCursor(3), NextCursor(10, 10, 3))
+}
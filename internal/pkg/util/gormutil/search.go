@@ -0,0 +1,33 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gormutil
+
+import (
+	"strings"
+
+	"github.com/marmotedu/component-base/pkg/fields"
+)
+
+// SearchQuery is the query parameter which carries the search keyword of list requests.
+const SearchQuery = "q"
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// ContainsPattern returns the LIKE pattern matching the values which contain s.
+// The wildcards in s are escaped, so they are matched literally.
+func ContainsPattern(s string) string {
+	return "%" + likeEscaper.Replace(s) + "%"
+}
+
+// WithField appends the field requirement to the field selector, the value is
+// escaped so it may contain any character.
+func WithField(fieldSelector, field, value string) string {
+	requirement := field + "=" + fields.EscapeValue(value)
+	if fieldSelector == "" {
+		return requirement
+	}
+
+	return fieldSelector + "," + requirement
+}
@@ -0,0 +1,31 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gormutil
+
+import (
+	"testing"
+
+	"github.com/marmotedu/component-base/pkg/fields"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContainsPattern(t *testing.T) {
+	assert.Equal(t, "%%", ContainsPattern(""))
+	assert.Equal(t, "%col%", ContainsPattern("col"))
+	assert.Equal(t, `%100\%\_off\\%`, ContainsPattern(`100%_off\`))
+}
+
+func TestWithField(t *testing.T) {
+	selector, err := fields.ParseSelector(WithField("status=1", "name", "a,b=c"))
+	assert.Nil(t, err)
+
+	name, found := selector.RequiresExactMatch("name")
+	assert.True(t, found)
+	assert.Equal(t, "a,b=c", name)
+
+	status, found := selector.RequiresExactMatch("status")
+	assert.True(t, found)
+	assert.Equal(t, "1", status)
+}
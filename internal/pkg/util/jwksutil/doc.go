@@ -0,0 +1,5 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package jwksutil // import "github.com/marmotedu/iam/internal/pkg/util/jwksutil"
@@ -0,0 +1,111 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package jwksutil
+
+import (
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"os"
+
+	jwt "github.com/golang-jwt/jwt/v4"
+)
+
+// JWK is a RSA public key in JSON Web Key format, see RFC 7517.
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// Set is a JSON Web Key Set.
+type Set struct {
+	Keys []JWK `json:"keys"`
+}
+
+// IsAsymmetric returns true if the jwt signing algorithm signs with a RSA private key,
+// whose public key can be published.
+func IsAsymmetric(alg string) bool {
+	switch alg {
+	case "RS256", "RS384", "RS512":
+		return true
+	}
+
+	return false
+}
+
+// KeyID returns the id of the public key, which is its JWK thumbprint defined in RFC 7638.
+func KeyID(pub *rsa.PublicKey) string {
+	// members in lexicographic order without whitespace, as required by RFC 7638.
+	thumbprint := fmt.Sprintf(`{"e":"%s","kty":"RSA","n":"%s"}`, encodeExponent(pub.E), encodeModulus(pub.N))
+	sum := sha256.Sum256([]byte(thumbprint))
+
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// NewJWK returns the JWK of a public key which verifies the signatures of alg.
+func NewJWK(alg string, pub *rsa.PublicKey) JWK {
+	return JWK{
+		Kty: "RSA",
+		Use: "sig",
+		Alg: alg,
+		Kid: KeyID(pub),
+		N:   encodeModulus(pub.N),
+		E:   encodeExponent(pub.E),
+	}
+}
+
+// NewSet returns the key set of the public keys, keys with the same id are only
+// included once.
+func NewSet(alg string, pubs ...*rsa.PublicKey) Set {
+	set := Set{Keys: []JWK{}}
+	seen := make(map[string]bool, len(pubs))
+
+	for _, pub := range pubs {
+		key := NewJWK(alg, pub)
+		if seen[key.Kid] {
+			continue
+		}
+
+		seen[key.Kid] = true
+		set.Keys = append(set.Keys, key)
+	}
+
+	return set
+}
+
+// LoadPublicKeys reads the PEM encoded RSA public keys from files.
+func LoadPublicKeys(files ...string) ([]*rsa.PublicKey, error) {
+	pubs := make([]*rsa.PublicKey, 0, len(files))
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		pub, err := jwt.ParseRSAPublicKeyFromPEM(data)
+		if err != nil {
+			return nil, fmt.Errorf("parse public key %s: %w", file, err)
+		}
+
+		pubs = append(pubs, pub)
+	}
+
+	return pubs, nil
+}
+
+func encodeModulus(n *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(n.Bytes())
+}
+
+func encodeExponent(e int) string {
+	return base64.RawURLEncoding.EncodeToString(big.NewInt(int64(e)).Bytes())
+}
@@ -0,0 +1,42 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package jwksutil
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyID(t *testing.T) {
+	// the example key of RFC 7638, section 3.1.
+	n, err := base64.RawURLEncoding.DecodeString("0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAt" +
+		"VT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf" +
+		"0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt" +
+		"-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw")
+	assert.Nil(t, err)
+
+	pub := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: 65537}
+	assert.Equal(t, "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs", KeyID(pub))
+}
+
+func TestIsAsymmetric(t *testing.T) {
+	assert.True(t, IsAsymmetric("RS256"))
+	assert.False(t, IsAsymmetric("HS256"))
+}
+
+func TestNewSet(t *testing.T) {
+	pub := &rsa.PublicKey{N: big.NewInt(0xbeef), E: 65537}
+
+	set := NewSet("RS256", pub, pub)
+	if assert.Len(t, set.Keys, 1) {
+		assert.Equal(t, KeyID(pub), set.Keys[0].Kid)
+		assert.Equal(t, "AQAB", set.Keys[0].E)
+		assert.Equal(t, "sig", set.Keys[0].Use)
+	}
+}
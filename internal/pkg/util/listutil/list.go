@@ -0,0 +1,65 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+// Package listutil writes the responses of list requests with their pagination metadata.
+package listutil
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/marmotedu/component-base/pkg/core"
+	"github.com/marmotedu/component-base/pkg/fields"
+	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
+
+	"github.com/marmotedu/iam/internal/pkg/util/gormutil"
+)
+
+// ListResponse is the response of list requests. Besides the items, it carries
+// the total count of matched records, and the limit/offset applied to the query,
+// so clients are able to build pagers.
+type ListResponse[T metav1.Object] struct {
+	TotalCount int64  `json:"totalCount"`
+	Offset     int    `json:"offset"`
+	Limit      int    `json:"limit"`
+	NextCursor string `json:"nextCursor,omitempty"`
+	Items      []T    `json:"items"`
+}
+
+// NewListResponse returns the response of a list request made with opts. The
+// offset is 0 when the request paginates with a cursor.
+func NewListResponse[T metav1.Object](opts metav1.ListOptions, totalCount int64, items []T) *ListResponse[T] {
+	ol := gormutil.Unpointer(opts.Offset, opts.Limit)
+	if items == nil {
+		items = []T{}
+	}
+
+	resp := &ListResponse[T]{
+		TotalCount: totalCount,
+		Offset:     ol.Offset,
+		Limit:      ol.Limit,
+		Items:      items,
+	}
+
+	if selector, err := fields.ParseSelector(opts.FieldSelector); err == nil {
+		if _, found := selector.RequiresExactMatch(gormutil.CursorField); found {
+			resp.Offset = 0
+		}
+	}
+
+	if n := len(items); n > 0 {
+		resp.NextCursor = gormutil.NextCursor(n, ol.Limit, items[n-1].GetID())
+	}
+
+	return resp
+}
+
+// WriteListResponse writes the items with their pagination metadata, the cursor
+// of the next page is also set in the gormutil.NextCursorHeader header.
+func WriteListResponse[T metav1.Object](c *gin.Context, opts metav1.ListOptions, totalCount int64, items []T) {
+	resp := NewListResponse(opts, totalCount, items)
+	if resp.NextCursor != "" {
+		c.Header(gormutil.NextCursorHeader, resp.NextCursor)
+	}
+
+	core.WriteResponse(c, nil, resp)
+}
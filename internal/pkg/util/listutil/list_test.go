@@ -0,0 +1,44 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package listutil
+
+import (
+	"testing"
+
+	"github.com/AlekSi/pointer"
+	v1 "github.com/marmotedu/api/apiserver/v1"
+	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/marmotedu/iam/internal/pkg/util/gormutil"
+)
+
+func TestNewListResponse(t *testing.T) {
+	items := []*v1.Policy{
+		{ObjectMeta: metav1.ObjectMeta{ID: 9}},
+		{ObjectMeta: metav1.ObjectMeta{ID: 8}},
+	}
+
+	resp := NewListResponse(metav1.ListOptions{Offset: pointer.ToInt64(4), Limit: pointer.ToInt64(2)}, 10, items)
+	assert.Equal(t, int64(10), resp.TotalCount)
+	assert.Equal(t, 4, resp.Offset)
+	assert.Equal(t, 2, resp.Limit)
+	assert.Equal(t, gormutil.EncodeCursor(8), resp.NextCursor)
+
+	// the last page has no next cursor
+	resp = NewListResponse(metav1.ListOptions{Limit: pointer.ToInt64(5)}, 2, items)
+	assert.Equal(t, "", resp.NextCursor)
+
+	// offset is ignored when paginating with a cursor
+	opts := metav1.ListOptions{
+		FieldSelector: gormutil.WithCursor("", gormutil.EncodeCursor(10)),
+		Offset:        pointer.ToInt64(4),
+	}
+	assert.Equal(t, 0, NewListResponse(opts, 10, items).Offset)
+
+	resp = NewListResponse[*v1.Policy](metav1.ListOptions{}, 0, nil)
+	assert.Equal(t, gormutil.DefaultLimit, resp.Limit)
+	assert.NotNil(t, resp.Items)
+}
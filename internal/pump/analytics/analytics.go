@@ -9,19 +9,62 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/marmotedu/component-base/pkg/json"
 )
 
 // AnalyticsRecord encodes the details of a authorization request.
 type AnalyticsRecord struct {
 	TimeStamp  int64     `json:"timestamp"`
 	Username   string    `json:"username"`
+	RequestID  string    `json:"requestID"`
+	ClientIP   string    `json:"clientIP"`
 	Effect     string    `json:"effect"`
 	Conclusion string    `json:"conclusion"`
 	Request    string    `json:"request"`
 	Policies   string    `json:"policies"`
 	Deciders   string    `json:"deciders"`
 	ExpireAt   time.Time `json:"expireAt"   bson:"expireAt"`
+
+	// ClaimedClientIP is the ip of its client passed by the caller, which is not verified.
+	ClaimedClientIP string `json:"claimedClientIP,omitempty"`
+
+	// Country and City are resolved from ClientIP by iam-pump when geoip is enabled.
+	Country string `json:"country,omitempty"`
+	City    string `json:"city,omitempty"`
+
+	// request caches the parsed Request, it is shared by the copies of the record.
+	request *lazyRequest
+}
+
+// lazyRequest parses the json encoded ladon request once.
+type lazyRequest struct {
+	once     sync.Once
+	resource string
+	action   string
+}
+
+// RequestResourceAndAction returns the resource and action of the ladon request
+// stored in the record.
+func (a *AnalyticsRecord) RequestResourceAndAction() (string, string) {
+	if a.request == nil {
+		a.request = &lazyRequest{}
+	}
+
+	a.request.once.Do(func() {
+		var r struct {
+			Resource string `json:"resource"`
+			Action   string `json:"action"`
+		}
+		if err := json.Unmarshal([]byte(a.Request), &r); err != nil {
+			return
+		}
+		a.request.resource, a.request.action = r.Resource, r.Action
+	})
+
+	return a.request.resource, a.request.action
 }
 
 // GetFieldNames returns all the AnalyticsRecord field names.
@@ -31,6 +74,9 @@ func (a *AnalyticsRecord) GetFieldNames() []string {
 
 	for i := 0; i < val.NumField(); i++ {
 		typeField := val.Type().Field(i)
+		if typeField.PkgPath != "" {
+			continue
+		}
 		fields = append(fields, typeField.Name)
 	}
 
@@ -39,33 +85,47 @@ func (a *AnalyticsRecord) GetFieldNames() []string {
 
 // GetLineValues returns all the line values.
 func (a *AnalyticsRecord) GetLineValues() []string {
+	return a.GetFieldValues(a.GetFieldNames())
+}
+
+// GetFieldValues returns the values of the given fields in order, an unknown field has an empty value.
+func (a *AnalyticsRecord) GetFieldValues(names []string) []string {
 	val := reflect.ValueOf(a).Elem()
-	fields := []string{}
+	fields := make([]string, 0, len(names))
 
-	for i := 0; i < val.NumField(); i++ {
-		valueField := val.Field(i)
-		typeField := val.Type().Field(i)
-		var thisVal string
-		switch typeField.Type.String() {
-		case "int":
-			thisVal = strconv.Itoa(int(valueField.Int()))
-		case "int64":
-			thisVal = strconv.Itoa(int(valueField.Int()))
-		case "[]string":
-			tmpVal, _ := valueField.Interface().([]string)
-			thisVal = strings.Join(tmpVal, ";")
-		case "time.Time":
-			tmpVal, _ := valueField.Interface().(time.Time)
-			thisVal = tmpVal.String()
-		case "time.Month":
-			tmpVal, _ := valueField.Interface().(time.Month)
-			thisVal = tmpVal.String()
-		default:
-			thisVal = valueField.String()
+	for _, name := range names {
+		typeField, ok := val.Type().FieldByName(name)
+		if !ok || typeField.PkgPath != "" {
+			fields = append(fields, "")
+
+			continue
 		}
 
-		fields = append(fields, thisVal)
+		fields = append(fields, formatFieldValue(val.FieldByIndex(typeField.Index), typeField.Type))
 	}
 
 	return fields
 }
+
+func formatFieldValue(valueField reflect.Value, typ reflect.Type) string {
+	var thisVal string
+	switch typ.String() {
+	case "int":
+		thisVal = strconv.Itoa(int(valueField.Int()))
+	case "int64":
+		thisVal = strconv.Itoa(int(valueField.Int()))
+	case "[]string":
+		tmpVal, _ := valueField.Interface().([]string)
+		thisVal = strings.Join(tmpVal, ";")
+	case "time.Time":
+		tmpVal, _ := valueField.Interface().(time.Time)
+		thisVal = tmpVal.String()
+	case "time.Month":
+		tmpVal, _ := valueField.Interface().(time.Month)
+		thisVal = tmpVal.String()
+	default:
+		thisVal = valueField.String()
+	}
+
+	return thisVal
+}
@@ -4,10 +4,18 @@
 
 package analytics
 
+import "path"
+
 // AnalyticsFilters defines the analytics options.
 type AnalyticsFilters struct {
 	Usernames        []string `json:"usernames"`
 	SkippedUsernames []string `json:"skip_usernames"`
+	// Effects only keeps the records with the given effects, e.g. deny.
+	Effects []string `json:"effects"`
+	// Resources and Actions only keep the records whose request matches one of
+	// the glob patterns, e.g. resources:secrets:*.
+	Resources []string `json:"resources"`
+	Actions   []string `json:"actions"`
 }
 
 // ShouldFilter determine whether a record should to be filtered out.
@@ -17,6 +25,20 @@ func (filters AnalyticsFilters) ShouldFilter(record AnalyticsRecord) bool {
 		return true
 	case len(filters.Usernames) > 0 && !stringInSlice(record.Username, filters.Usernames):
 		return true
+	case len(filters.Effects) > 0 && !stringInSlice(record.Effect, filters.Effects):
+		return true
+	}
+
+	if len(filters.Resources) == 0 && len(filters.Actions) == 0 {
+		return false
+	}
+
+	resource, action := record.RequestResourceAndAction()
+	switch {
+	case len(filters.Resources) > 0 && !matchAny(resource, filters.Resources):
+		return true
+	case len(filters.Actions) > 0 && !matchAny(action, filters.Actions):
+		return true
 	}
 
 	return false
@@ -24,7 +46,8 @@ func (filters AnalyticsFilters) ShouldFilter(record AnalyticsRecord) bool {
 
 // HasFilter determine whether a record has a filter.
 func (filters AnalyticsFilters) HasFilter() bool {
-	if len(filters.SkippedUsernames) == 0 && len(filters.Usernames) == 0 {
+	if len(filters.SkippedUsernames) == 0 && len(filters.Usernames) == 0 && len(filters.Effects) == 0 &&
+		len(filters.Resources) == 0 && len(filters.Actions) == 0 {
 		return false
 	}
 
@@ -40,3 +63,14 @@ func stringInSlice(a string, list []string) bool {
 
 	return false
 }
+
+// matchAny reports whether s matches any of the glob patterns.
+func matchAny(s string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, s); matched {
+			return true
+		}
+	}
+
+	return false
+}
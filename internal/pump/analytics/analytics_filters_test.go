@@ -37,6 +37,97 @@ func TestShouldFilter(t *testing.T) {
 	}
 }
 
+func TestShouldFilter_Effects(t *testing.T) {
+	tests := []struct {
+		name   string
+		record AnalyticsRecord
+		filter AnalyticsFilters
+		want   bool
+	}{
+		{
+			name:   "deny record with deny effect filter",
+			record: AnalyticsRecord{Username: "colin", Effect: "deny"},
+			filter: AnalyticsFilters{Effects: []string{"deny"}},
+			want:   false,
+		},
+		{
+			name:   "allow record with deny effect filter",
+			record: AnalyticsRecord{Username: "colin", Effect: "allow"},
+			filter: AnalyticsFilters{Effects: []string{"deny"}},
+			want:   true,
+		},
+		{
+			name:   "matched username and effect",
+			record: AnalyticsRecord{Username: "colin", Effect: "deny"},
+			filter: AnalyticsFilters{Usernames: []string{"colin"}, Effects: []string{"deny"}},
+			want:   false,
+		},
+		{
+			name:   "matched effect but different username",
+			record: AnalyticsRecord{Username: "james", Effect: "deny"},
+			filter: AnalyticsFilters{Usernames: []string{"colin"}, Effects: []string{"deny"}},
+			want:   true,
+		},
+		{
+			name:   "matched effect but skipped username",
+			record: AnalyticsRecord{Username: "colin", Effect: "deny"},
+			filter: AnalyticsFilters{SkippedUsernames: []string{"colin"}, Effects: []string{"deny"}},
+			want:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.ShouldFilter(tt.record); got != tt.want {
+				t.Fatalf("ShouldFilter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShouldFilter_ResourcesAndActions(t *testing.T) {
+	record := AnalyticsRecord{
+		Username: "colin",
+		Effect:   "deny",
+		Request:  `{"resource":"resources:secrets:ladon-secret","action":"delete","subject":"users:colin"}`,
+	}
+
+	tests := []struct {
+		name   string
+		filter AnalyticsFilters
+		want   bool
+	}{
+		{name: "matched resource", filter: AnalyticsFilters{Resources: []string{"resources:secrets:*"}}, want: false},
+		{name: "unmatched resource", filter: AnalyticsFilters{Resources: []string{"resources:policies:*"}}, want: true},
+		{
+			name:   "one of the resources matched",
+			filter: AnalyticsFilters{Resources: []string{"resources:policies:*", "resources:secrets:ladon-*"}},
+			want:   false,
+		},
+		{name: "matched action", filter: AnalyticsFilters{Actions: []string{"delete", "update"}}, want: false},
+		{name: "unmatched action", filter: AnalyticsFilters{Actions: []string{"get"}}, want: true},
+		{
+			name:   "matched resource and unmatched effect",
+			filter: AnalyticsFilters{Resources: []string{"resources:secrets:*"}, Effects: []string{"allow"}},
+			want:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.ShouldFilter(record); got != tt.want {
+				t.Fatalf("ShouldFilter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// records with an invalid request never match the resource patterns
+	record.Request = "invalid"
+	if !(AnalyticsFilters{Resources: []string{"resources:*"}}).ShouldFilter(record) {
+		t.Fatal("filter should be filtering the record")
+	}
+}
+
 func TestHasFilter(t *testing.T) {
 	filter := AnalyticsFilters{}
 
@@ -52,4 +143,12 @@ func TestHasFilter(t *testing.T) {
 	if hasFilter == false {
 		t.Fatal("HasFilter should be true.")
 	}
+
+	filter = AnalyticsFilters{
+		Effects: []string{"deny"},
+	}
+	hasFilter = filter.HasFilter()
+	if hasFilter == false {
+		t.Fatal("HasFilter should be true.")
+	}
 }
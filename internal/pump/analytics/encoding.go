@@ -0,0 +1,34 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package analytics
+
+import (
+	"fmt"
+
+	"github.com/marmotedu/component-base/pkg/json"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Supported encodings of the analytics records stored in redis.
+// iam-authz-server must be configured with the same encoding.
+const (
+	EncodingMsgpack = "msgpack"
+	EncodingJSON    = "json"
+)
+
+// Decode decodes the analytics record with the given encoding.
+func (a *AnalyticsRecord) Decode(data []byte, encoding string) error {
+	// allocate the cache before the record is copied to each pump
+	a.request = &lazyRequest{}
+
+	switch encoding {
+	case EncodingMsgpack, "":
+		return msgpack.Unmarshal(data, a)
+	case EncodingJSON:
+		return json.Unmarshal(data, a)
+	default:
+		return fmt.Errorf("unsupported analytics encoding: %s", encoding)
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package analytics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	authzanalytics "github.com/marmotedu/iam/internal/authzserver/analytics"
+)
+
+func TestAnalyticsRecord_EncodeDecode(t *testing.T) {
+	record := &authzanalytics.AnalyticsRecord{
+		TimeStamp:  1609459200,
+		Username:   "colin",
+		RequestID:  "2d6c3a8b-3c1e-4b5e-9f0e-6b1d2a3c4d5e",
+		Effect:     "allow",
+		Conclusion: "policies policy1 allow access",
+		Request:    `{"resource":"resources:articles:ladon-introduction"}`,
+		ExpireAt:   time.Date(2121, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	for _, encoding := range []string{EncodingMsgpack, EncodingJSON} {
+		t.Run(encoding, func(t *testing.T) {
+			data, err := record.Encode(encoding)
+			assert.Nil(t, err)
+
+			decoded := AnalyticsRecord{}
+			assert.Nil(t, decoded.Decode(data, encoding))
+			assert.Equal(t, record.Username, decoded.Username)
+			assert.Equal(t, record.RequestID, decoded.RequestID)
+			assert.Equal(t, record.TimeStamp, decoded.TimeStamp)
+			assert.Equal(t, record.Conclusion, decoded.Conclusion)
+			assert.Equal(t, record.Request, decoded.Request)
+			assert.True(t, record.ExpireAt.Equal(decoded.ExpireAt))
+		})
+	}
+
+	_, err := record.Encode("xml")
+	assert.NotNil(t, err)
+	assert.NotNil(t, (&AnalyticsRecord{}).Decode([]byte("{}"), "xml"))
+}
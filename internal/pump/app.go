@@ -6,7 +6,6 @@
 package pump
 
 import (
-	genericapiserver "github.com/marmotedu/iam/internal/pkg/server"
 	"github.com/marmotedu/iam/internal/pump/config"
 	"github.com/marmotedu/iam/internal/pump/options"
 	"github.com/marmotedu/iam/pkg/app"
@@ -26,6 +25,7 @@ func NewApp(basename string) *app.App {
 		app.WithOptions(opts),
 		app.WithDescription(commandDesc),
 		app.WithDefaultValidArgs(),
+		app.WithCompletion(),
 		app.WithRunFunc(run(opts)),
 	)
 
@@ -42,8 +42,6 @@ func run(opts *options.Options) app.RunFunc {
 			return err
 		}
 
-		stopCh := genericapiserver.SetupSignalHandler()
-
-		return Run(cfg, stopCh)
+		return Run(cfg)
 	}
 }
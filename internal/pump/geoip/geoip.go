@@ -0,0 +1,71 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+// Package geoip looks up the location of ip addresses in a MaxMind DB, e.g. GeoLite2-City.mmdb.
+package geoip
+
+import (
+	"net"
+	"os"
+
+	"github.com/marmotedu/errors"
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// Location is the location of an ip address.
+type Location struct {
+	// Country is the ISO 3166-1 code of the country, e.g. CN.
+	Country string
+	// City is the English name of the city.
+	City string
+}
+
+// record holds the fields of a GeoIP2/GeoLite2 City record used by Location.
+type record struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+}
+
+// Reader looks up the location of ip addresses in a MaxMind DB.
+type Reader struct {
+	db *maxminddb.Reader
+}
+
+// Open opens the MaxMind DB file.
+func Open(file string) (*Reader, error) {
+	buf, err := os.ReadFile(file)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read geoip database")
+	}
+
+	return NewReader(buf)
+}
+
+// NewReader creates a Reader from the content of a MaxMind DB.
+func NewReader(buf []byte) (*Reader, error) {
+	db, err := maxminddb.FromBytes(buf)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid geoip database")
+	}
+
+	return &Reader{db: db}, nil
+}
+
+// Lookup returns the location of ip, it returns nil if the location is unknown.
+func (r *Reader) Lookup(ip net.IP) (*Location, error) {
+	var rec record
+	_, ok, err := r.db.LookupNetwork(ip, &rec)
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	return &Location{
+		Country: rec.Country.ISOCode,
+		City:    rec.City.Names["en"],
+	}, nil
+}
@@ -0,0 +1,166 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package geoip
+
+import (
+	"bytes"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// the helpers below encode the few MaxMind DB types used by the tests,
+// see https://maxmind.github.io/MaxMind-DB/ for the format.
+
+var metadataStartMarker = []byte("\xAB\xCD\xEFMaxMind.com")
+
+const dataSectionSeparatorSize = 16
+
+const (
+	typePointer = 1
+	typeString  = 2
+	typeUint16  = 5
+	typeUint32  = 6
+	typeMap     = 7
+)
+
+func encString(s string) []byte {
+	return append([]byte{typeString<<5 | byte(len(s))}, s...)
+}
+
+func encUint16(v uint16) []byte {
+	return []byte{typeUint16<<5 | 2, byte(v >> 8), byte(v)}
+}
+
+func encUint32(v uint32) []byte {
+	return []byte{typeUint32<<5 | 4, byte(v >> 24), byte(v >> 16), byte(v >> 8), byte(v)}
+}
+
+func encPointer(offset uint16) []byte {
+	return []byte{typePointer<<5 | byte(offset>>8), byte(offset)}
+}
+
+func encMap(kvs ...[]byte) []byte {
+	buf := []byte{typeMap<<5 | byte(len(kvs)/2)}
+	for _, kv := range kvs {
+		buf = append(buf, kv...)
+	}
+
+	return buf
+}
+
+// buildDB builds a database with record size 24 which maps the network of the
+// prefix bits to the record at recordOffset of data.
+func buildDB(ipVersion uint16, prefix []uint, data []byte, recordOffset uint) []byte {
+	nodeCount := uint(len(prefix))
+	tree := make([]byte, 0, nodeCount*6)
+	for i, bit := range prefix {
+		next := uint(i) + 1
+		if next == nodeCount {
+			next = nodeCount + dataSectionSeparatorSize + recordOffset
+		}
+
+		records := [2]uint{nodeCount, nodeCount}
+		records[bit] = next
+		for _, r := range records {
+			tree = append(tree, byte(r>>16), byte(r>>8), byte(r))
+		}
+	}
+
+	metadata := encMap(
+		encString("node_count"), encUint32(uint32(nodeCount)),
+		encString("record_size"), encUint16(24),
+		encString("ip_version"), encUint16(ipVersion),
+		encString("database_type"), encString("Test-City"),
+	)
+
+	var buf bytes.Buffer
+	buf.Write(tree)
+	buf.Write(make([]byte, dataSectionSeparatorSize))
+	buf.Write(data)
+	buf.Write(metadataStartMarker)
+	buf.Write(metadata)
+
+	return buf.Bytes()
+}
+
+// sydneyData holds the record of Sydney, the city name is referenced by a pointer.
+func sydneyData() ([]byte, uint) {
+	data := encString("Sydney")
+	offset := uint(len(data))
+	data = append(data, encMap(
+		encString("country"), encMap(
+			encString("iso_code"), encString("AU"),
+			encString("names"), encMap(encString("en"), encString("Australia")),
+		),
+		encString("city"), encMap(
+			encString("names"), encMap(encString("en"), encPointer(0)),
+		),
+	)...)
+
+	return data, offset
+}
+
+// prefixBits returns the first n bits of ip.
+func prefixBits(ip net.IP, n int) []uint {
+	bits := make([]uint, n)
+	for i := range bits {
+		bits[i] = uint(ip[i/8]>>(7-uint(i%8))) & 1
+	}
+
+	return bits
+}
+
+func TestReader_LookupIPv4(t *testing.T) {
+	data, offset := sydneyData()
+	// 1.0.0.0/8
+	db := buildDB(4, prefixBits(net.IPv4(1, 0, 0, 0).To4(), 8), data, offset)
+
+	file := filepath.Join(t.TempDir(), "test.mmdb")
+	require.NoError(t, os.WriteFile(file, db, 0o600))
+
+	r, err := Open(file)
+	require.NoError(t, err)
+
+	loc, err := r.Lookup(net.ParseIP("1.2.3.4"))
+	require.NoError(t, err)
+	assert.Equal(t, &Location{Country: "AU", City: "Sydney"}, loc)
+
+	loc, err = r.Lookup(net.ParseIP("2.2.3.4"))
+	require.NoError(t, err)
+	assert.Nil(t, loc)
+
+	_, err = r.Lookup(net.ParseIP("2001:db8::1"))
+	assert.Error(t, err)
+}
+
+func TestReader_LookupIPv6(t *testing.T) {
+	data, offset := sydneyData()
+	// ::1.0.0.0/104, the IPv4 addresses of an IPv6 database
+	db := buildDB(6, prefixBits(net.ParseIP("::1.0.0.0"), 104), data, offset)
+
+	r, err := NewReader(db)
+	require.NoError(t, err)
+
+	loc, err := r.Lookup(net.ParseIP("1.2.3.4"))
+	require.NoError(t, err)
+	assert.Equal(t, &Location{Country: "AU", City: "Sydney"}, loc)
+
+	loc, err = r.Lookup(net.ParseIP("2001:db8::1"))
+	require.NoError(t, err)
+	assert.Nil(t, loc)
+}
+
+func TestNewReader_Invalid(t *testing.T) {
+	_, err := NewReader([]byte("not a maxmind database"))
+	assert.Error(t, err)
+
+	_, err = Open(filepath.Join(t.TempDir(), "absent.mmdb"))
+	assert.Error(t, err)
+}
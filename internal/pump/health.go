@@ -0,0 +1,106 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package pump
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/marmotedu/component-base/pkg/json"
+
+	"github.com/marmotedu/iam/pkg/log"
+)
+
+const statusOK = "ok"
+
+// pumpHealth records the result of the last operation of every configured pump.
+type pumpHealth struct {
+	mu   sync.RWMutex
+	errs map[string]string
+}
+
+func newPumpHealth() *pumpHealth {
+	return &pumpHealth{errs: map[string]string{}}
+}
+
+// set records the result of the pump, nil means the pump is healthy.
+func (h *pumpHealth) set(name string, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if err != nil {
+		h.errs[name] = err.Error()
+
+		return
+	}
+
+	h.errs[name] = ""
+}
+
+// report returns the status of every pump and whether they are all healthy.
+func (h *pumpHealth) report() (map[string]string, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	healthy := true
+	statuses := make(map[string]string, len(h.errs))
+	for name, err := range h.errs {
+		if err != "" {
+			healthy = false
+			statuses[name] = err
+
+			continue
+		}
+
+		statuses[name] = statusOK
+	}
+
+	return statuses, healthy
+}
+
+// healthReport is the response of the health check endpoint.
+type healthReport struct {
+	Status string            `json:"status"`
+	Redis  string            `json:"redis"`
+	Pumps  map[string]string `json:"pumps"`
+}
+
+// newHealthHandler returns a handler reporting the health of redis and the pumps. It
+// responds with 503 if redis is unreachable or any pump is down.
+func newHealthHandler(ping func() error, health *pumpHealth) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pumps, healthy := health.report()
+		report := healthReport{
+			Status: statusOK,
+			Redis:  statusOK,
+			Pumps:  pumps,
+		}
+
+		if err := ping(); err != nil {
+			report.Redis = err.Error()
+			healthy = false
+		}
+
+		code := http.StatusOK
+		if !healthy {
+			report.Status = "unhealthy"
+			code = http.StatusServiceUnavailable
+		}
+
+		w.Header().Set("Content-type", "application/json")
+		w.WriteHeader(code)
+		_ = json.NewEncoder(w).Encode(report)
+	})
+}
+
+// serveHealthCheck runs a http server used to provide a api to check the health of redis and the pumps.
+func serveHealthCheck(healthPath string, healthAddress string, handler http.Handler) {
+	mux := http.NewServeMux()
+	mux.Handle("/"+healthPath, handler)
+
+	if err := http.ListenAndServe(healthAddress, mux); err != nil {
+		log.Fatalf("Error serving health check endpoint: %s", err.Error())
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package pump
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/marmotedu/component-base/pkg/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/marmotedu/iam/internal/pump/options"
+)
+
+func checkHealth(t *testing.T, ping func() error) (int, healthReport) {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.Handle("/healthz", newHealthHandler(ping, health))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/healthz")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var report healthReport
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&report))
+
+	return resp.StatusCode, report
+}
+
+func TestHealthHandler(t *testing.T) {
+	defer func(old *pumpHealth) { health = old }(health)
+	health = newPumpHealth()
+
+	s := &pumpServer{pumps: map[string]options.PumpConfig{
+		"dummy": {},
+		"down":  {Type: "xyz"},
+	}}
+	s.initialize()
+	defer stopPumps()
+
+	ping := func() error { return nil }
+
+	code, report := checkHealth(t, ping)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "unhealthy", report.Status)
+	assert.Equal(t, statusOK, report.Redis)
+	assert.Equal(t, statusOK, report.Pumps["dummy"])
+	assert.NotEqual(t, statusOK, report.Pumps["down"])
+
+	health.set("down", nil)
+	code, report = checkHealth(t, ping)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, statusOK, report.Status)
+
+	code, report = checkHealth(t, func() error { return errors.New("connection refused") })
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "connection refused", report.Redis)
+}
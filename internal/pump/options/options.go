@@ -17,19 +17,31 @@ import (
 // PumpConfig defines options for pump back-end.
 type PumpConfig struct {
 	Type                  string                     `json:"type"                    mapstructure:"type"`
+	Enabled               *bool                      `json:"enabled"                 mapstructure:"enabled"`
 	Filters               analytics.AnalyticsFilters `json:"filters"                 mapstructure:"filters"`
 	Timeout               int                        `json:"timeout"                 mapstructure:"timeout"`
+	BatchSize             int                        `json:"batch-size"              mapstructure:"batch-size"`
+	MaxRetries            int                        `json:"max-retries"             mapstructure:"max-retries"`
 	OmitDetailedRecording bool                       `json:"omit-detailed-recording" mapstructure:"omit-detailed-recording"`
 	Meta                  map[string]interface{}     `json:"meta"                    mapstructure:"meta"`
 }
 
+// IsEnabled returns whether the pump is enabled, a pump without the enabled option is enabled.
+func (c PumpConfig) IsEnabled() bool {
+	return c.Enabled == nil || *c.Enabled
+}
+
 // Options runs a pumpserver.
 type Options struct {
 	PurgeDelay            int                          `json:"purge-delay"             mapstructure:"purge-delay"`
+	MaxRecordsPerPurge    int64                        `json:"max-records-per-purge"   mapstructure:"max-records-per-purge"`
+	ShutdownTimeout       int                          `json:"shutdown-timeout"        mapstructure:"shutdown-timeout"`
 	Pumps                 map[string]PumpConfig        `json:"pumps"                   mapstructure:"pumps"`
 	HealthCheckPath       string                       `json:"health-check-path"       mapstructure:"health-check-path"`
 	HealthCheckAddress    string                       `json:"health-check-address"    mapstructure:"health-check-address"`
 	OmitDetailedRecording bool                         `json:"omit-detailed-recording" mapstructure:"omit-detailed-recording"`
+	AnalyticsEncoding     string                       `json:"analytics-encoding"      mapstructure:"analytics-encoding"`
+	GeoIPDatabase         string                       `json:"geoip-database"          mapstructure:"geoip-database"`
 	RedisOptions          *genericoptions.RedisOptions `json:"redis"                   mapstructure:"redis"`
 	Log                   *log.Options                 `json:"log"                     mapstructure:"log"`
 }
@@ -37,7 +49,8 @@ type Options struct {
 // NewOptions creates a new Options object with default parameters.
 func NewOptions() *Options {
 	s := Options{
-		PurgeDelay: 10,
+		PurgeDelay:      10,
+		ShutdownTimeout: 30,
 		Pumps: map[string]PumpConfig{
 			"csv": {
 				Type: "csv",
@@ -48,6 +61,7 @@ func NewOptions() *Options {
 		},
 		HealthCheckPath:    "healthz",
 		HealthCheckAddress: "0.0.0.0:7070",
+		AnalyticsEncoding:  analytics.EncodingMsgpack,
 		RedisOptions:       genericoptions.NewRedisOptions(),
 		Log:                log.NewOptions(),
 	}
@@ -65,12 +79,22 @@ func (o *Options) Flags() (fss cliflag.NamedFlagSets) {
 	fs := fss.FlagSet("misc")
 	fs.IntVar(&o.PurgeDelay, "purge-delay", o.PurgeDelay, ""+
 		"This setting the purge delay (in seconds) when purge the data from Redis to MongoDB or other data stores.")
+	fs.Int64Var(&o.MaxRecordsPerPurge, "max-records-per-purge", o.MaxRecordsPerPurge, ""+
+		"The maximum number of records purged from Redis in a purge cycle, 0 means all the records.")
+	fs.IntVar(&o.ShutdownTimeout, "shutdown-timeout", o.ShutdownTimeout, ""+
+		"The timeout (in seconds) of purging the remaining records to the pumps when shutting down.")
 	fs.StringVar(&o.HealthCheckPath, "health-check-path", o.HealthCheckPath, ""+
 		"Specifies liveness health check request path.")
 	fs.StringVar(&o.HealthCheckAddress, "health-check-address", o.HealthCheckAddress, ""+
 		"Specifies liveness health check bind address.")
 	fs.BoolVar(&o.OmitDetailedRecording, "omit-detailed-recording", o.OmitDetailedRecording, ""+
 		"Setting this to true will avoid writing policy fields for each authorization request in pumps.")
+	fs.StringVar(&o.AnalyticsEncoding, "analytics-encoding", o.AnalyticsEncoding, ""+
+		"The encoding of the analytics records stored in Redis, msgpack or json. "+
+		"Must be the same as the analytics.encoding of iam-authz-server.")
+	fs.StringVar(&o.GeoIPDatabase, "geoip-database", o.GeoIPDatabase, ""+
+		"The path of a MaxMind DB (e.g. GeoLite2-City.mmdb) used to add the country and city of the client ip "+
+		"to the analytics records. Leave empty to disable the geoip enrichment.")
 
 	return fss
 }
@@ -4,6 +4,12 @@
 
 package options
 
+import (
+	"fmt"
+
+	"github.com/marmotedu/iam/internal/pump/analytics"
+)
+
 // Validate checks Options and return a slice of found errs.
 func (o *Options) Validate() []error {
 	var errs []error
@@ -11,5 +17,27 @@ func (o *Options) Validate() []error {
 	errs = append(errs, o.RedisOptions.Validate()...)
 	errs = append(errs, o.Log.Validate()...)
 
+	if o.AnalyticsEncoding != analytics.EncodingMsgpack && o.AnalyticsEncoding != analytics.EncodingJSON {
+		errs = append(errs, fmt.Errorf("--analytics-encoding %q must be %s or %s",
+			o.AnalyticsEncoding, analytics.EncodingMsgpack, analytics.EncodingJSON))
+	}
+
+	if o.MaxRecordsPerPurge < 0 {
+		errs = append(errs, fmt.Errorf("--max-records-per-purge %d must not be negative", o.MaxRecordsPerPurge))
+	}
+
+	if o.ShutdownTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("--shutdown-timeout %d must be greater than 0", o.ShutdownTimeout))
+	}
+
+	for name, pmp := range o.Pumps {
+		if pmp.BatchSize < 0 {
+			errs = append(errs, fmt.Errorf("pumps.%s.batch-size %d must not be negative", name, pmp.BatchSize))
+		}
+		if pmp.MaxRetries < 0 {
+			errs = append(errs, fmt.Errorf("pumps.%s.max-retries %d must not be negative", name, pmp.MaxRetries))
+		}
+	}
+
 	return errs
 }
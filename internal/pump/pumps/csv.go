@@ -12,6 +12,7 @@ import (
 	"path"
 	"time"
 
+	"github.com/marmotedu/component-base/pkg/util/stringutil"
 	"github.com/marmotedu/errors"
 	"github.com/mitchellh/mapstructure"
 
@@ -29,6 +30,8 @@ type CSVPump struct {
 type CSVConf struct {
 	// Specify the directory used to store automatically generated csv file which contains analyzed data.
 	CSVDir string `mapstructure:"csv_dir"`
+	// Specify the AnalyticsRecord fields written to the csv file and their order, all the fields by default.
+	Fields []string `mapstructure:"fields"`
 }
 
 // New create a csv pump instance.
@@ -51,6 +54,17 @@ func (c *CSVPump) Init(conf interface{}) error {
 		log.Fatalf("Failed to decode configuration: %s", err.Error())
 	}
 
+	fieldNames := (&analytics.AnalyticsRecord{}).GetFieldNames()
+	if len(c.csvConf.Fields) == 0 {
+		c.csvConf.Fields = fieldNames
+	}
+
+	for _, field := range c.csvConf.Fields {
+		if !stringutil.StringIn(field, fieldNames) {
+			return errors.Errorf("unknown csv field %q, must be one of %v", field, fieldNames)
+		}
+	}
+
 	ferr := os.MkdirAll(c.csvConf.CSVDir, 0o777)
 	if ferr != nil {
 		log.Error(ferr.Error())
@@ -89,10 +103,7 @@ func (c *CSVPump) WriteData(ctx context.Context, data []interface{}) error {
 	writer := csv.NewWriter(outfile)
 
 	if appendHeader {
-		startRecord := analytics.AnalyticsRecord{}
-		headers := startRecord.GetFieldNames()
-
-		err := writer.Write(headers)
+		err := writer.Write(c.csvConf.Fields)
 		if err != nil {
 			log.Errorf("Failed to write file headers: %s", err.Error())
 
@@ -103,7 +114,7 @@ func (c *CSVPump) WriteData(ctx context.Context, data []interface{}) error {
 	for _, v := range data {
 		decoded, _ := v.(analytics.AnalyticsRecord)
 
-		toWrite := decoded.GetLineValues()
+		toWrite := decoded.GetFieldValues(c.csvConf.Fields)
 		err := writer.Write(toWrite)
 		if err != nil {
 			log.Error("File write failed!")
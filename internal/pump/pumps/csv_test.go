@@ -0,0 +1,72 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package pumps
+
+import (
+	"context"
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/marmotedu/iam/internal/pump/analytics"
+)
+
+func readCSV(t *testing.T, dir string) [][]string {
+	t.Helper()
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.csv"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	f, err := os.Open(files[0])
+	require.NoError(t, err)
+	defer f.Close()
+
+	lines, err := csv.NewReader(f).ReadAll()
+	require.NoError(t, err)
+
+	return lines
+}
+
+func TestCSVPump_Fields(t *testing.T) {
+	dir := t.TempDir()
+	pmp := (&CSVPump{}).New()
+	require.NoError(t, pmp.Init(map[string]interface{}{
+		"csv_dir": dir,
+		"fields":  []string{"Username", "Effect", "TimeStamp"},
+	}))
+
+	record := analytics.AnalyticsRecord{TimeStamp: 1608197860, Username: "colin", Effect: "allow", Policies: "p"}
+	require.NoError(t, pmp.WriteData(context.Background(), []interface{}{record}))
+
+	assert.Equal(t, [][]string{
+		{"Username", "Effect", "TimeStamp"},
+		{"colin", "allow", "1608197860"},
+	}, readCSV(t, dir))
+}
+
+func TestCSVPump_DefaultFields(t *testing.T) {
+	dir := t.TempDir()
+	pmp := (&CSVPump{}).New()
+	require.NoError(t, pmp.Init(map[string]interface{}{"csv_dir": dir}))
+	require.NoError(t, pmp.WriteData(context.Background(), []interface{}{analytics.AnalyticsRecord{Username: "colin"}}))
+
+	lines := readCSV(t, dir)
+	require.Len(t, lines, 2)
+	assert.Equal(t, (&analytics.AnalyticsRecord{}).GetFieldNames(), lines[0])
+	assert.Equal(t, "colin", lines[1][1])
+}
+
+func TestCSVPump_UnknownField(t *testing.T) {
+	err := (&CSVPump{}).New().Init(map[string]interface{}{
+		"csv_dir": t.TempDir(),
+		"fields":  []string{"Username", "Password"},
+	})
+	assert.Error(t, err)
+}
@@ -19,4 +19,6 @@ func init() {
 	availablePumps["prometheus"] = &PrometheusPump{}
 	availablePumps["kafka"] = &KafkaPump{}
 	availablePumps["syslog"] = &SyslogPump{}
+	availablePumps["webhook"] = &WebhookPump{}
+	availablePumps["s3"] = &S3Pump{}
 }
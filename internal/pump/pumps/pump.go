@@ -25,6 +25,12 @@ type Pump interface {
 	GetOmitDetailedRecording() bool
 }
 
+// Flusher is implemented by the pumps buffering the data, Flush writes the buffered data
+// and is called when iam-pump shuts down.
+type Flusher interface {
+	Flush(ctx context.Context) error
+}
+
 // GetPumpByName returns the pump instance by given name.
 func GetPumpByName(name string) (Pump, error) {
 	if pump, ok := availablePumps[name]; ok && pump != nil {
@@ -0,0 +1,346 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package pumps
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/marmotedu/component-base/pkg/json"
+	"github.com/marmotedu/errors"
+	"github.com/mitchellh/mapstructure"
+
+	"github.com/marmotedu/iam/internal/pump/analytics"
+	"github.com/marmotedu/iam/pkg/log"
+)
+
+const (
+	s3FormatJSON = "json"
+	s3FormatCSV  = "csv"
+
+	s3DefaultRegion        = "us-east-1"
+	s3DefaultBatchSize     = 10000
+	s3DefaultFlushInterval = 300
+
+	s3TimeFormat = "20060102T150405Z"
+	s3DateFormat = "20060102"
+)
+
+// S3Pump defines a s3 pump with s3 specific options and common options. It archives the
+// records as gzip compressed objects into any S3 compatible object storage.
+type S3Pump struct {
+	s3Conf *S3Conf
+	client *http.Client
+
+	mu        sync.Mutex
+	buffer    []interface{}
+	lastFlush time.Time
+
+	CommonPumpConfig
+}
+
+// S3Conf defines s3 specific options.
+type S3Conf struct {
+	// The S3 compatible endpoint, e.g. https://s3.amazonaws.com or http://127.0.0.1:9000.
+	Endpoint        string `mapstructure:"endpoint"`
+	Region          string `mapstructure:"region"`
+	Bucket          string `mapstructure:"bucket"`
+	Prefix          string `mapstructure:"prefix"`
+	AccessKeyID     string `mapstructure:"access_key_id"`
+	SecretAccessKey string `mapstructure:"secret_access_key"`
+	// The format of the objects, json (one record per line) or csv.
+	Format string `mapstructure:"format"`
+	// The maximum number of records in an object.
+	BatchSize int `mapstructure:"batch_size"`
+	// The interval (in seconds) records are buffered for before being written even if the batch is not full.
+	FlushInterval int `mapstructure:"flush_interval"`
+}
+
+// New create a s3 pump instance.
+func (s *S3Pump) New() Pump {
+	newPump := S3Pump{}
+
+	return &newPump
+}
+
+// GetName returns the s3 pump name.
+func (s *S3Pump) GetName() string {
+	return "S3 Pump"
+}
+
+// Init initialize the s3 pump instance.
+func (s *S3Pump) Init(config interface{}) error {
+	s.s3Conf = &S3Conf{}
+	if err := mapstructure.Decode(config, &s.s3Conf); err != nil {
+		return errors.Wrap(err, "failed to decode s3 configuration")
+	}
+
+	if s.s3Conf.Endpoint == "" || s.s3Conf.Bucket == "" {
+		return errors.New("s3 endpoint and bucket must be specified")
+	}
+
+	if s.s3Conf.Region == "" {
+		s.s3Conf.Region = s3DefaultRegion
+	}
+
+	if s.s3Conf.Format == "" {
+		s.s3Conf.Format = s3FormatJSON
+	}
+
+	if s.s3Conf.Format != s3FormatJSON && s.s3Conf.Format != s3FormatCSV {
+		return errors.Errorf("s3 format %q must be %s or %s", s.s3Conf.Format, s3FormatJSON, s3FormatCSV)
+	}
+
+	if s.s3Conf.BatchSize <= 0 {
+		s.s3Conf.BatchSize = s3DefaultBatchSize
+	}
+
+	if s.s3Conf.FlushInterval <= 0 {
+		s.s3Conf.FlushInterval = s3DefaultFlushInterval
+	}
+
+	s.client = &http.Client{}
+	s.lastFlush = time.Now()
+
+	log.Debugf("S3 Initialized, bucket: %s", s.s3Conf.Bucket)
+
+	return nil
+}
+
+// WriteData buffers analyzed data and writes it to s3 once a batch is full or the flush
+// interval elapsed. When the writing fails, the unwritten records buffered by the previous calls are
+// kept and data is left to the retry of the caller, so the records are written at least once.
+func (s *S3Pump) WriteData(ctx context.Context, data []interface{}) error {
+	return s.write(ctx, data, false)
+}
+
+// Flush writes the buffered records to s3 regardless of the batch size and flush interval.
+func (s *S3Pump) Flush(ctx context.Context) error {
+	return s.write(ctx, nil, true)
+}
+
+func (s *S3Pump) write(ctx context.Context, data []interface{}, force bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records := make([]interface{}, 0, len(s.buffer)+len(data))
+	records = append(records, s.buffer...)
+	records = append(records, data...)
+
+	// write the partial batch only when the flush interval elapsed
+	flushInterval := time.Duration(s.s3Conf.FlushInterval) * time.Second
+	flushAll := force || time.Since(s.lastFlush) >= flushInterval
+
+	for len(records) >= s.s3Conf.BatchSize || (flushAll && len(records) > 0) {
+		batch := records
+		if len(batch) > s.s3Conf.BatchSize {
+			batch = records[:s.s3Conf.BatchSize]
+		}
+
+		if err := s.putObject(ctx, batch); err != nil {
+			// records holds the unwritten records, keep the ones of the previous calls
+			if len(records) > len(data) {
+				s.buffer = records[:len(records)-len(data)]
+			} else {
+				s.buffer = nil
+			}
+
+			return err
+		}
+
+		records = records[len(batch):]
+		s.lastFlush = time.Now()
+	}
+
+	s.buffer = records
+
+	return nil
+}
+
+func (s *S3Pump) putObject(ctx context.Context, records []interface{}) error {
+	body, err := s.encode(records)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	key := fmt.Sprintf("%s-%d.%s.gz", now.Format("2006/01/02/150405"), now.UnixNano(), s.s3Conf.Format)
+	if prefix := strings.Trim(s.s3Conf.Prefix, "/"); prefix != "" {
+		key = prefix + "/" + key
+	}
+
+	endpoint := strings.TrimRight(s.s3Conf.Endpoint, "/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, endpoint+"/"+s.s3Conf.Bucket+"/"+key, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "failed to create s3 request")
+	}
+
+	contentType := "application/x-ndjson"
+	if s.s3Conf.Format == s3FormatCSV {
+		contentType = "text/csv"
+	}
+
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Content-Encoding", "gzip")
+	signS3Request(req, body, s.s3Conf.AccessKeyID, s.s3Conf.SecretAccessKey, s.s3Conf.Region, now)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to put s3 object")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+
+		return errors.Errorf("failed to put s3 object %s, status code %d: %s", key, resp.StatusCode, msg)
+	}
+
+	log.Debugf("Wrote %d records to s3 object %s", len(records), key)
+
+	return nil
+}
+
+// encode encodes records in the configured format and compresses them with gzip.
+func (s *S3Pump) encode(records []interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+
+	var err error
+	if s.s3Conf.Format == s3FormatCSV {
+		err = encodeCSV(zw, records)
+	} else {
+		err = encodeJSONLines(zw, records)
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode records")
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, errors.Wrap(err, "failed to compress records")
+	}
+
+	return buf.Bytes(), nil
+}
+
+func encodeJSONLines(w io.Writer, records []interface{}) error {
+	enc := json.NewEncoder(w)
+	for _, v := range records {
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func encodeCSV(w io.Writer, records []interface{}) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write((&analytics.AnalyticsRecord{}).GetFieldNames()); err != nil {
+		return err
+	}
+
+	for _, v := range records {
+		decoded, _ := v.(analytics.AnalyticsRecord)
+		if err := writer.Write(decoded.GetLineValues()); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
+
+// signS3Request signs req with AWS Signature Version 4, all the headers of req are signed.
+func signS3Request(req *http.Request, body []byte, accessKeyID, secretAccessKey, region string, now time.Time) {
+	payloadHash := sha256Hex(body)
+	amzDate := now.UTC().Format(s3TimeFormat)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for k, v := range req.Header {
+		headers[strings.ToLower(k)] = strings.TrimSpace(strings.Join(v, ","))
+	}
+
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, k := range names {
+		canonicalHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		s3EscapePath(req.URL.Path),
+		req.URL.Query().Encode(),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	date := now.UTC().Format(s3DateFormat)
+	scope := date + "/" + region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+secretAccessKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKeyID, scope, signedHeaders, signature))
+}
+
+// s3EscapePath escapes every byte of the path except the unreserved characters and '/',
+// as required by the canonical request.
+func s3EscapePath(path string) string {
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '_' || c == '.' || c == '~' || c == '/' {
+			b.WriteByte(c)
+
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+
+	return b.String()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+
+	return h.Sum(nil)
+}
@@ -0,0 +1,137 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package pumps
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/marmotedu/component-base/pkg/json"
+	"github.com/marmotedu/errors"
+	"github.com/mitchellh/mapstructure"
+
+	"github.com/marmotedu/iam/pkg/log"
+)
+
+// WebhookPump defines a webhook pump with webhook specific options and common options.
+// Each batch of records is posted to the webhook as a json array.
+type WebhookPump struct {
+	client      *http.Client
+	webhookConf *WebhookConf
+	CommonPumpConfig
+}
+
+// WebhookConf defines webhook specific options.
+type WebhookConf struct {
+	// The url the records are posted to.
+	URL string `mapstructure:"url"`
+	// Extra headers sent with each request, e.g. Authorization.
+	Headers map[string]string `mapstructure:"headers"`
+	// The number of retries when the webhook fails or responds with a 5xx status code.
+	MaxRetries int `mapstructure:"max_retries"`
+	// The interval (in seconds) between two retries.
+	RetryInterval int `mapstructure:"retry_interval"`
+}
+
+// New create a webhook pump instance.
+func (w *WebhookPump) New() Pump {
+	newPump := WebhookPump{}
+
+	return &newPump
+}
+
+// GetName returns the webhook pump name.
+func (w *WebhookPump) GetName() string {
+	return "Webhook Pump"
+}
+
+// Init initialize the webhook pump instance.
+func (w *WebhookPump) Init(conf interface{}) error {
+	w.webhookConf = &WebhookConf{}
+	if err := mapstructure.Decode(conf, &w.webhookConf); err != nil {
+		return errors.Wrap(err, "failed to decode webhook configuration")
+	}
+
+	if w.webhookConf.URL == "" {
+		return errors.New("webhook url must be specified")
+	}
+
+	w.client = &http.Client{}
+
+	log.Debugf("Webhook Initialized, url: %s", w.webhookConf.URL)
+
+	return nil
+}
+
+// SetTimeout set the timeout of the requests posted to the webhook.
+func (w *WebhookPump) SetTimeout(timeout int) {
+	w.CommonPumpConfig.SetTimeout(timeout)
+	if w.client != nil {
+		w.client.Timeout = time.Duration(timeout) * time.Second
+	}
+}
+
+// WriteData posts analyzed data to the webhook.
+func (w *WebhookPump) WriteData(ctx context.Context, data []interface{}) error {
+	if len(data) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(data)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode records")
+	}
+
+	for attempt := 0; ; attempt++ {
+		retry, err := w.post(ctx, body)
+		if err == nil {
+			log.Debugf("Posted %d records to webhook", len(data))
+
+			return nil
+		}
+
+		if !retry || attempt >= w.webhookConf.MaxRetries {
+			return err
+		}
+
+		log.Warnf("Failed to post records to webhook, retrying: %s", err.Error())
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Duration(w.webhookConf.RetryInterval) * time.Second):
+		}
+	}
+}
+
+// post posts body to the webhook and reports whether a failure is worth retrying.
+func (w *WebhookPump) post(ctx context.Context, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.webhookConf.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, errors.Wrap(err, "failed to create webhook request")
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range w.webhookConf.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, errors.Wrap(err, "failed to post records to webhook")
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return resp.StatusCode >= http.StatusInternalServerError,
+			fmt.Errorf("webhook responded with status code %d", resp.StatusCode)
+	}
+
+	return false, nil
+}
@@ -0,0 +1,78 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package pumps
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/marmotedu/component-base/pkg/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/marmotedu/iam/internal/pump/analytics"
+)
+
+func TestWebhookPump_WriteData(t *testing.T) {
+	var (
+		requests int
+		records  []analytics.AnalyticsRecord
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+
+			return
+		}
+
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&records))
+	}))
+	defer srv.Close()
+
+	pmp := (&WebhookPump{}).New()
+	require.NoError(t, pmp.Init(map[string]interface{}{
+		"url":         srv.URL,
+		"headers":     map[string]interface{}{"Authorization": "Bearer token"},
+		"max_retries": 1,
+	}))
+	pmp.SetTimeout(5)
+
+	data := []interface{}{
+		analytics.AnalyticsRecord{Username: "colin", Effect: "allow"},
+		analytics.AnalyticsRecord{Username: "admin", Effect: "deny"},
+	}
+	require.NoError(t, pmp.WriteData(context.Background(), data))
+
+	assert.Equal(t, 2, requests)
+	require.Len(t, records, 2)
+	assert.Equal(t, "colin", records[0].Username)
+	assert.Equal(t, "deny", records[1].Effect)
+}
+
+func TestWebhookPump_ClientError(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	pmp := (&WebhookPump{}).New()
+	require.NoError(t, pmp.Init(map[string]interface{}{"url": srv.URL, "max_retries": 3}))
+
+	err := pmp.WriteData(context.Background(), []interface{}{analytics.AnalyticsRecord{Username: "colin"}})
+	assert.Error(t, err)
+	assert.Equal(t, 1, requests, "client errors must not be retried")
+}
+
+func TestWebhookPump_Init(t *testing.T) {
+	assert.Error(t, (&WebhookPump{}).New().Init(map[string]interface{}{}))
+}
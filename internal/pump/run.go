@@ -5,18 +5,18 @@
 package pump
 
 import (
-	genericapiserver "github.com/marmotedu/iam/internal/pkg/server"
 	"github.com/marmotedu/iam/internal/pump/config"
 )
 
 // Run runs the specified pump server. This should never exit.
-func Run(cfg *config.Config, stopCh <-chan struct{}) error {
-	go genericapiserver.ServeHealthCheck(cfg.HealthCheckPath, cfg.HealthCheckAddress)
-
+func Run(cfg *config.Config) error {
 	server, err := createPumpServer(cfg)
 	if err != nil {
 		return err
 	}
 
-	return server.PrepareRun().Run(stopCh)
+	go serveHealthCheck(cfg.HealthCheckPath, cfg.HealthCheckAddress,
+		newHealthHandler(server.analyticsStore.Ping, health))
+
+	return server.PrepareRun().Run()
 }
@@ -7,31 +7,170 @@ package pump
 import (
 	"context"
 	"fmt"
+	"net"
 	"sync"
 	"time"
 
 	goredislib "github.com/go-redis/redis/v8"
 	"github.com/go-redsync/redsync/v4"
 	"github.com/go-redsync/redsync/v4/redis/goredis/v8"
-	"github.com/vmihailenco/msgpack/v5"
 
 	"github.com/marmotedu/iam/internal/pump/analytics"
 	"github.com/marmotedu/iam/internal/pump/config"
+	"github.com/marmotedu/iam/internal/pump/geoip"
 	"github.com/marmotedu/iam/internal/pump/options"
 	"github.com/marmotedu/iam/internal/pump/pumps"
 	"github.com/marmotedu/iam/internal/pump/storage"
 	"github.com/marmotedu/iam/internal/pump/storage/redis"
 	"github.com/marmotedu/iam/pkg/log"
+	"github.com/marmotedu/iam/pkg/shutdown"
+	"github.com/marmotedu/iam/pkg/shutdown/shutdownmanagers/posixsignal"
 )
 
-var pmps []pumps.Pump
+var (
+	pmps    []loadedPump
+	workers sync.WaitGroup
+	health  = newPumpHealth()
+)
+
+// pumpQueueSize is the number of purged batches buffered for a pump which is still writing.
+const pumpQueueSize = 10
+
+// retryInterval is the interval between two retries of writing a batch to a pump.
+var retryInterval = time.Second
+
+// loadedPump is a pump successfully initialized from the configuration entry key. Every loaded
+// pump writes the purged batches in its own goroutine, so a failing pump does not stall the others.
+type loadedPump struct {
+	key        string
+	batchSize  int
+	maxRetries int
+	queue      chan []interface{}
+	pumps.Pump
+}
+
+func newLoadedPump(key string, cfg options.PumpConfig, pmp pumps.Pump) loadedPump {
+	return loadedPump{
+		key:        key,
+		batchSize:  cfg.BatchSize,
+		maxRetries: cfg.MaxRetries,
+		queue:      make(chan []interface{}, pumpQueueSize),
+		Pump:       pmp,
+	}
+}
+
+// start starts the goroutine writing the queued batches to the pump.
+func (p loadedPump) start(purgeDelay int) {
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		p.run(purgeDelay)
+	}()
+}
+
+// enqueue hands a copy of keys to the pump. Redis is only purged when every queue has room,
+// see fallingBehind, so it doesn't block.
+func (p loadedPump) enqueue(keys []interface{}) {
+	batch := make([]interface{}, len(keys))
+	copy(batch, keys)
+
+	p.queue <- batch
+}
+
+// fallingBehind returns the first pump whose queue is full.
+func fallingBehind() (loadedPump, bool) {
+	for _, pmp := range pmps {
+		if len(pmp.queue) == cap(pmp.queue) {
+			return pmp, true
+		}
+	}
+
+	return loadedPump{}, false
+}
+
+// send hands a copy of keys to the pump, waiting for the pump to catch up until ctx is done.
+func (p loadedPump) send(ctx context.Context, keys []interface{}) error {
+	batch := make([]interface{}, len(keys))
+	copy(batch, keys)
+
+	select {
+	case p.queue <- batch:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run writes the queued batches to the pump until the queue is closed, then flushes the
+// data buffered by the pump.
+func (p loadedPump) run(purgeDelay int) {
+	for keys := range p.queue {
+		p.write(keys, purgeDelay)
+	}
+
+	if flusher, ok := p.Pump.(pumps.Flusher); ok {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(purgeDelay)*time.Second)
+		defer cancel()
+
+		if err := flusher.Flush(ctx); err != nil {
+			log.Errorf("Failed to flush %s: %s", p.GetName(), err.Error())
+		}
+	}
+}
+
+// write writes keys to the pump in chunks of batchSize records.
+func (p loadedPump) write(keys []interface{}, purgeDelay int) {
+	var err error
+	for len(keys) > 0 {
+		chunk := keys
+		if p.batchSize > 0 && len(chunk) > p.batchSize {
+			chunk = keys[:p.batchSize]
+		}
+		keys = keys[len(chunk):]
+
+		if err = p.writeChunk(chunk, purgeDelay); err != nil {
+			break
+		}
+	}
+
+	health.set(p.key, err)
+}
+
+// writeChunk writes keys to the pump, retrying up to maxRetries times on failure.
+func (p loadedPump) writeChunk(keys []interface{}, purgeDelay int) error {
+	var err error
+	for attempt := 0; attempt <= p.maxRetries; attempt++ {
+		if attempt > 0 {
+			log.Infof("Retrying writing to %s (%d/%d)", p.GetName(), attempt, p.maxRetries)
+			time.Sleep(retryInterval)
+		}
+
+		if err = execPumpWriting(p.Pump, keys, purgeDelay); err == nil {
+			break
+		}
+	}
+
+	return err
+}
+
+// mutex guarantees that only one iam-pump instance purges redis at a time.
+type mutex interface {
+	Lock() error
+	LockContext(ctx context.Context) error
+	Unlock() (bool, error)
+}
 
 type pumpServer struct {
-	secInterval    int
-	omitDetails    bool
-	mutex          *redsync.Mutex
-	analyticsStore storage.AnalyticsStorage
-	pumps          map[string]options.PumpConfig
+	gs              *shutdown.GracefulShutdown
+	secInterval     int
+	shutdownTimeout int
+	maxRecords      int64
+	omitDetails     bool
+	encoding        string
+	mutex           mutex
+	analyticsStore  storage.AnalyticsStorage
+	geoip           *geoip.Reader
+	pumps           map[string]options.PumpConfig
 }
 
 // preparedGenericAPIServer is a private wrapper that enforces a call of PrepareRun() before Run can be invoked.
@@ -49,18 +188,34 @@ func createPumpServer(cfg *config.Config) (*pumpServer, error) {
 
 	rs := redsync.New(goredis.NewPool(client))
 
+	gs := shutdown.New()
+	gs.AddShutdownManager(posixsignal.NewPosixSignalManager())
+
 	server := &pumpServer{
-		secInterval:    cfg.PurgeDelay,
-		omitDetails:    cfg.OmitDetailedRecording,
-		mutex:          rs.NewMutex("iam-pump", redsync.WithExpiry(10*time.Minute)),
-		analyticsStore: &redis.RedisClusterStorageManager{},
-		pumps:          cfg.Pumps,
+		gs:              gs,
+		secInterval:     cfg.PurgeDelay,
+		shutdownTimeout: cfg.ShutdownTimeout,
+		maxRecords:      cfg.MaxRecordsPerPurge,
+		omitDetails:     cfg.OmitDetailedRecording,
+		encoding:        cfg.AnalyticsEncoding,
+		mutex:           rs.NewMutex("iam-pump", redsync.WithExpiry(10*time.Minute)),
+		analyticsStore:  &redis.RedisClusterStorageManager{},
+		pumps:           cfg.Pumps,
 	}
 
 	if err := server.analyticsStore.Init(cfg.RedisOptions); err != nil {
 		return nil, err
 	}
 
+	if cfg.GeoIPDatabase != "" {
+		reader, err := geoip.Open(cfg.GeoIPDatabase)
+		if err != nil {
+			log.Warnf("GeoIP enrichment is disabled: %s", err.Error())
+		} else {
+			server.geoip = reader
+		}
+	}
+
 	return server, nil
 }
 
@@ -70,7 +225,33 @@ func (s *pumpServer) PrepareRun() preparedPumpServer {
 	return preparedPumpServer{s}
 }
 
-func (s preparedPumpServer) Run(stopCh <-chan struct{}) error {
+func (s preparedPumpServer) Run() error {
+	stopCh := make(chan struct{})
+	doneCh := make(chan struct{})
+
+	// in order to ensure that the analytics data is not lost, stop the purge loop, then
+	// purge the remaining records and wait for the pumps to write them before exit.
+	s.gs.AddShutdownCallback(shutdown.ShutdownFunc(func(string) error {
+		close(stopCh)
+		<-doneCh
+
+		return nil
+	}))
+
+	// start shutdown managers
+	if err := s.gs.Start(); err != nil {
+		log.Fatalf("start shutdown manager failed: %s", err.Error())
+	}
+
+	s.loop(stopCh)
+	s.drain(time.Duration(s.shutdownTimeout) * time.Second)
+	close(doneCh)
+
+	return nil
+}
+
+// loop purges redis every secInterval seconds until stopCh is closed.
+func (s *pumpServer) loop(stopCh <-chan struct{}) {
 	ticker := time.NewTicker(time.Duration(s.secInterval) * time.Second)
 	defer ticker.Stop()
 
@@ -83,13 +264,70 @@ func (s preparedPumpServer) Run(stopCh <-chan struct{}) error {
 		case <-stopCh:
 			log.Info("stop purge loop")
 
-			return nil
+			return
+		}
+	}
+}
+
+// drain purges the remaining records from redis, then stops the pumps and waits for them to
+// write the pending data. It gives up when timeout elapses.
+func (s *pumpServer) drain(timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	log.Info("Purge the remaining records before exit")
+	if err := s.mutex.LockContext(ctx); err != nil {
+		log.Info("there is already an iam-pump instance running, skip purging the remaining records.")
+	} else {
+		s.purgeRemaining(ctx)
+		if _, err := s.mutex.Unlock(); err != nil {
+			log.Errorf("could not release iam-pump lock. err: %v", err)
+		}
+	}
+
+	for _, pmp := range pmps {
+		close(pmp.queue)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		log.Info("All the pumps are stopped")
+	case <-ctx.Done():
+		log.Errorf("Pumps were not stopped after %s, some records may be lost", timeout)
+	}
+}
+
+func (s *pumpServer) purgeRemaining(ctx context.Context) {
+	for ctx.Err() == nil {
+		keys := s.purge()
+		if len(keys) == 0 {
+			return
+		}
+
+		for _, pmp := range pmps {
+			if err := pmp.send(ctx, keys); err != nil {
+				log.Errorf("Failed to send %d records to %s: %s", len(keys), pmp.GetName(), err.Error())
+			}
 		}
 	}
 }
 
 // pump get authorization log from redis and write to pumps.
 func (s *pumpServer) pump() {
+	// the purged records would be lost if a pump couldn't queue them, so they are left in redis
+	// until the pump catches up.
+	if pmp, ok := fallingBehind(); ok {
+		log.Warnf("Pump %s is falling behind, leave the records in redis", pmp.GetName())
+
+		return
+	}
+
 	if err := s.mutex.Lock(); err != nil {
 		log.Info("there is already an iam-pump instance running.")
 
@@ -101,17 +339,28 @@ func (s *pumpServer) pump() {
 		}
 	}()
 
-	analyticsValues := s.analyticsStore.GetAndDeleteSet(storage.AnalyticsKeyName)
-	if len(analyticsValues) == 0 {
+	keys := s.purge()
+	if len(keys) == 0 {
 		return
 	}
 
+	// Send to pumps
+	writeToPumps(keys)
+}
+
+// purge pops at most maxRecords analytics records from redis and decodes them.
+func (s *pumpServer) purge() []interface{} {
+	analyticsValues := s.analyticsStore.GetListRangeAndTrim(storage.AnalyticsKeyName, s.maxRecords)
+	if len(analyticsValues) == 0 {
+		return nil
+	}
+
 	// Convert to something clean
 	keys := make([]interface{}, len(analyticsValues))
 
 	for i, v := range analyticsValues {
 		decoded := analytics.AnalyticsRecord{}
-		err := msgpack.Unmarshal([]byte(v.(string)), &decoded)
+		err := decoded.Decode([]byte(v.(string)), s.encoding)
 		log.Debugf("Decoded Record: %v", decoded)
 		if err != nil {
 			log.Errorf("Couldn't unmarshal analytics data: %s", err.Error())
@@ -120,18 +369,46 @@ func (s *pumpServer) pump() {
 				decoded.Policies = ""
 				decoded.Deciders = ""
 			}
+			s.locate(&decoded)
 			keys[i] = interface{}(decoded)
 		}
 	}
 
-	// Send to pumps
-	writeToPumps(keys, s.secInterval)
+	return keys
+}
+
+// locate adds the country and city of the client ip to the record when geoip is enabled.
+func (s *pumpServer) locate(record *analytics.AnalyticsRecord) {
+	if s.geoip == nil || record.ClientIP == "" {
+		return
+	}
+
+	ip := net.ParseIP(record.ClientIP)
+	if ip == nil {
+		return
+	}
+
+	loc, err := s.geoip.Lookup(ip)
+	if err != nil {
+		log.Debugf("Failed to look up the location of %s: %s", record.ClientIP, err.Error())
+
+		return
+	}
+
+	if loc != nil {
+		record.Country, record.City = loc.Country, loc.City
+	}
 }
 
 func (s *pumpServer) initialize() {
-	pmps = make([]pumps.Pump, len(s.pumps))
-	i := 0
+	pmps = make([]loadedPump, 0, len(s.pumps))
 	for key, pmp := range s.pumps {
+		if !pmp.IsEnabled() {
+			log.Infof("Pump %s is disabled (skipping)", key)
+
+			continue
+		}
+
 		pumpTypeName := pmp.Type
 		if pumpTypeName == "" {
 			pumpTypeName = key
@@ -140,34 +417,41 @@ func (s *pumpServer) initialize() {
 		pmpType, err := pumps.GetPumpByName(pumpTypeName)
 		if err != nil {
 			log.Errorf("Pump load error (skipping): %s", err.Error())
-		} else {
-			pmpIns := pmpType.New()
-			initErr := pmpIns.Init(pmp.Meta)
-			if initErr != nil {
-				log.Errorf("Pump init error (skipping): %s", initErr.Error())
-			} else {
-				log.Infof("Init Pump: %s", pmpIns.GetName())
-				pmpIns.SetFilters(pmp.Filters)
-				pmpIns.SetTimeout(pmp.Timeout)
-				pmpIns.SetOmitDetailedRecording(pmp.OmitDetailedRecording)
-				pmps[i] = pmpIns
-			}
+			health.set(key, err)
+
+			continue
 		}
-		i++
+
+		pmpIns := pmpType.New()
+		if initErr := pmpIns.Init(pmp.Meta); initErr != nil {
+			log.Errorf("Pump init error (skipping): %s", initErr.Error())
+			health.set(key, initErr)
+
+			continue
+		}
+
+		log.Infof("Init Pump: %s", pmpIns.GetName())
+		pmpIns.SetFilters(pmp.Filters)
+		pmpIns.SetTimeout(pmp.Timeout)
+		pmpIns.SetOmitDetailedRecording(pmp.OmitDetailedRecording)
+
+		loaded := newLoadedPump(key, pmp, pmpIns)
+		loaded.start(s.secInterval)
+		pmps = append(pmps, loaded)
+		health.set(key, nil)
 	}
 }
 
-func writeToPumps(keys []interface{}, purgeDelay int) {
-	// Send to pumps
-	if pmps != nil {
-		var wg sync.WaitGroup
-		wg.Add(len(pmps))
-		for _, pmp := range pmps {
-			go execPumpWriting(&wg, pmp, &keys, purgeDelay)
-		}
-		wg.Wait()
-	} else {
+// writeToPumps hands keys to every pump without waiting for the writing to finish.
+func writeToPumps(keys []interface{}) {
+	if len(pmps) == 0 {
 		log.Warn("No pumps defined!")
+
+		return
+	}
+
+	for _, pmp := range pmps {
+		pmp.enqueue(keys)
 	}
 }
 
@@ -196,7 +480,7 @@ func filterData(pump pumps.Pump, keys []interface{}) []interface{} {
 	return filteredKeys
 }
 
-func execPumpWriting(wg *sync.WaitGroup, pmp pumps.Pump, keys *[]interface{}, purgeDelay int) {
+func execPumpWriting(pmp pumps.Pump, keys []interface{}, purgeDelay int) (err error) {
 	timer := time.AfterFunc(time.Duration(purgeDelay)*time.Second, func() {
 		if pmp.GetTimeout() == 0 {
 			log.Warnf(
@@ -208,7 +492,6 @@ func execPumpWriting(wg *sync.WaitGroup, pmp pumps.Pump, keys *[]interface{}, pu
 		}
 	})
 	defer timer.Stop()
-	defer wg.Done()
 
 	log.Debugf("Writing to: %s", pmp.GetName())
 
@@ -224,24 +507,34 @@ func execPumpWriting(wg *sync.WaitGroup, pmp pumps.Pump, keys *[]interface{}, pu
 
 	defer cancel()
 
-	go func(ch chan error, ctx context.Context, pmp pumps.Pump, keys *[]interface{}) {
-		filteredKeys := filterData(pmp, *keys)
+	go func(ch chan error, ctx context.Context, pmp pumps.Pump, keys []interface{}) {
+		// a panicking pump must not crash the other pumps
+		defer func() {
+			if r := recover(); r != nil {
+				ch <- fmt.Errorf("pump panicked: %v", r)
+			}
+		}()
+
+		filteredKeys := filterData(pmp, keys)
 
 		ch <- pmp.WriteData(ctx, filteredKeys)
 	}(ch, ctx, pmp, keys)
 
 	select {
-	case err := <-ch:
+	case err = <-ch:
 		if err != nil {
 			log.Warnf("Error Writing to: %s - Error: %s", pmp.GetName(), err.Error())
 		}
 	case <-ctx.Done():
+		err = ctx.Err()
 		//nolint: errorlint
-		switch ctx.Err() {
+		switch err {
 		case context.Canceled:
 			log.Warnf("The writing to %s have got canceled.", pmp.GetName())
 		case context.DeadlineExceeded:
 			log.Warnf("Timeout Writing to: %s", pmp.GetName())
 		}
 	}
+
+	return err
 }
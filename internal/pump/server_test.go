@@ -0,0 +1,233 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package pump
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/marmotedu/iam/internal/pump/analytics"
+	"github.com/marmotedu/iam/internal/pump/options"
+	"github.com/marmotedu/iam/internal/pump/pumps"
+	"github.com/marmotedu/iam/internal/pump/storage"
+)
+
+type fakeAnalyticsStore struct {
+	storage.AnalyticsStorage
+	records []interface{}
+}
+
+func (s *fakeAnalyticsStore) GetListRangeAndTrim(keyName string, count int64) []interface{} {
+	if count <= 0 || count > int64(len(s.records)) {
+		count = int64(len(s.records))
+	}
+	values := s.records[:count]
+	s.records = s.records[count:]
+
+	return values
+}
+
+type fakePump struct {
+	pumps.CommonPumpConfig
+	err      error
+	attempts int32
+	written  chan []interface{}
+}
+
+func (p *fakePump) GetName() string             { return "Fake Pump" }
+func (p *fakePump) New() pumps.Pump             { return p }
+func (p *fakePump) Init(conf interface{}) error { return nil }
+
+func (p *fakePump) WriteData(ctx context.Context, data []interface{}) error {
+	atomic.AddInt32(&p.attempts, 1)
+	if p.err != nil {
+		return p.err
+	}
+
+	p.written <- data
+
+	return nil
+}
+
+// stopPumps stops the pumps started by initialize.
+func stopPumps() {
+	for _, pmp := range pmps {
+		close(pmp.queue)
+	}
+	workers.Wait()
+	pmps = nil
+}
+
+func TestWriteToPumps_IsolateFailures(t *testing.T) {
+	defer func(old *pumpHealth, interval time.Duration) {
+		health, retryInterval, pmps = old, interval, nil
+	}(health, retryInterval)
+	health = newPumpHealth()
+	retryInterval = time.Millisecond
+
+	failing := &fakePump{err: errors.New("elasticsearch is down")}
+	healthy := &fakePump{written: make(chan []interface{}, 1)}
+	pmps = []loadedPump{
+		newLoadedPump("failing", options.PumpConfig{MaxRetries: 2}, failing),
+		newLoadedPump("healthy", options.PumpConfig{MaxRetries: 2}, healthy),
+	}
+	for _, pmp := range pmps {
+		go pmp.run(10)
+		defer close(pmp.queue)
+	}
+
+	writeToPumps([]interface{}{analytics.AnalyticsRecord{Username: "colin"}})
+
+	select {
+	case data := <-healthy.written:
+		require.Len(t, data, 1)
+		assert.Equal(t, "colin", data[0].(analytics.AnalyticsRecord).Username)
+	case <-time.After(time.Second):
+		t.Fatal("the healthy pump did not receive the data")
+	}
+
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&failing.attempts) == 3
+	}, time.Second, time.Millisecond)
+	assert.Eventually(t, func() bool {
+		statuses, _ := health.report()
+
+		return statuses["failing"] == "elasticsearch is down" && statuses["healthy"] == statusOK
+	}, time.Second, time.Millisecond)
+}
+
+func TestInitialize_SkipDisabledPumps(t *testing.T) {
+	defer func(old *pumpHealth) { health = old }(health)
+	health = newPumpHealth()
+
+	disabled := false
+	s := &pumpServer{pumps: map[string]options.PumpConfig{
+		"dummy":    {},
+		"disabled": {Type: "dummy", Enabled: &disabled},
+	}}
+	s.initialize()
+	defer stopPumps()
+
+	require.Len(t, pmps, 1)
+	assert.Equal(t, "dummy", pmps[0].key)
+}
+
+func TestPurge_Chunked(t *testing.T) {
+	defer func(old *pumpHealth) { health = old }(health)
+	health = newPumpHealth()
+
+	store := &fakeAnalyticsStore{}
+	for i := 0; i < 2500; i++ {
+		store.records = append(store.records, fmt.Sprintf(`{"username":"user%d"}`, i))
+	}
+	s := &pumpServer{
+		secInterval:    10,
+		maxRecords:     1000,
+		encoding:       analytics.EncodingJSON,
+		analyticsStore: store,
+	}
+
+	keys := s.purge()
+	require.Len(t, keys, 1000)
+	assert.Len(t, store.records, 1500)
+	assert.Equal(t, "user0", keys[0].(analytics.AnalyticsRecord).Username)
+
+	pmp := &fakePump{written: make(chan []interface{}, 20)}
+	newLoadedPump("fake", options.PumpConfig{BatchSize: 300}, pmp).write(keys, s.secInterval)
+	close(pmp.written)
+
+	var sizes []int
+	for data := range pmp.written {
+		sizes = append(sizes, len(data))
+	}
+	assert.Equal(t, []int{300, 300, 300, 100}, sizes)
+}
+
+type fakeMutex struct{}
+
+func (m fakeMutex) Lock() error                           { return nil }
+func (m fakeMutex) LockContext(ctx context.Context) error { return nil }
+func (m fakeMutex) Unlock() (bool, error)                 { return true, nil }
+
+// bufferedPump buffers the written records until it is flushed.
+type bufferedPump struct {
+	fakePump
+	buffer  []interface{}
+	flushed []interface{}
+}
+
+func (p *bufferedPump) WriteData(ctx context.Context, data []interface{}) error {
+	p.buffer = append(p.buffer, data...)
+
+	return nil
+}
+
+func (p *bufferedPump) Flush(ctx context.Context) error {
+	p.flushed, p.buffer = append(p.flushed, p.buffer...), nil
+
+	return nil
+}
+
+func TestDrain(t *testing.T) {
+	defer func(old *pumpHealth) { health, pmps = old, nil }(health)
+	health = newPumpHealth()
+
+	store := &fakeAnalyticsStore{}
+	for i := 0; i < 25; i++ {
+		store.records = append(store.records, fmt.Sprintf(`{"username":"user%d"}`, i))
+	}
+	s := &pumpServer{
+		secInterval:    10,
+		maxRecords:     10,
+		encoding:       analytics.EncodingJSON,
+		mutex:          fakeMutex{},
+		analyticsStore: store,
+	}
+
+	pmp := &bufferedPump{}
+	pmps = []loadedPump{newLoadedPump("buffered", options.PumpConfig{}, pmp)}
+	pmps[0].start(s.secInterval)
+
+	s.drain(time.Second)
+
+	assert.Empty(t, store.records)
+	assert.Empty(t, pmp.buffer)
+	require.Len(t, pmp.flushed, 25)
+	assert.Equal(t, "user24", pmp.flushed[24].(analytics.AnalyticsRecord).Username)
+}
+
+func TestPump_Backpressure(t *testing.T) {
+	defer func() { pmps = nil }()
+
+	store := &fakeAnalyticsStore{records: []interface{}{`{"username":"colin"}`}}
+	s := &pumpServer{
+		maxRecords:     10,
+		encoding:       analytics.EncodingJSON,
+		mutex:          fakeMutex{},
+		analyticsStore: store,
+	}
+
+	// the pump isn't running, so its queue fills up
+	pmps = []loadedPump{newLoadedPump("stalled", options.PumpConfig{}, &fakePump{})}
+	for i := 0; i < pumpQueueSize; i++ {
+		pmps[0].enqueue(nil)
+	}
+
+	// the records are left in redis until the pump catches up
+	s.pump()
+	assert.Len(t, store.records, 1)
+
+	<-pmps[0].queue
+	s.pump()
+	assert.Empty(t, store.records)
+	assert.Len(t, pmps[0].queue, pumpQueueSize)
+}
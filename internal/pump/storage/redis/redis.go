@@ -251,6 +251,13 @@ func (r *RedisClusterStorageManager) Connect() bool {
 	return true
 }
 
+// Ping checks whether redis is reachable.
+func (r *RedisClusterStorageManager) Ping() error {
+	r.ensureConnection()
+
+	return r.db.Ping().Err()
+}
+
 func (r *RedisClusterStorageManager) hashKey(in string) string {
 	return in
 }
@@ -304,6 +311,57 @@ func (r *RedisClusterStorageManager) GetAndDeleteSet(keyName string) []interface
 	return result
 }
 
+// GetListRangeAndTrim atomically reads and removes up to count elements from the
+// head of the list, all the elements are read if count is not positive. Unlike
+// GetAndDeleteSet, the elements appended after the read are preserved.
+func (r *RedisClusterStorageManager) GetListRangeAndTrim(keyName string, count int64) []interface{} {
+	if r.db == nil {
+		log.Warn("Connection dropped, connecting..")
+		r.Connect()
+
+		return r.GetListRangeAndTrim(keyName, count)
+	}
+
+	fixedKey := r.fixKey(keyName)
+
+	if count <= 0 {
+		llen, err := r.db.LLen(fixedKey).Result()
+		if err != nil {
+			log.Errorf("Failed to get the length of list %s: %s", fixedKey, err)
+
+			return nil
+		}
+		count = llen
+	}
+	if count == 0 {
+		return nil
+	}
+
+	var lrange *redis.StringSliceCmd
+	_, err := r.db.TxPipelined(func(pipe redis.Pipeliner) error {
+		lrange = pipe.LRange(fixedKey, 0, count-1)
+		pipe.LTrim(fixedKey, count, -1)
+
+		return nil
+	})
+	if err != nil {
+		log.Errorf("Multi command failed: %s", err)
+		r.Connect()
+
+		return nil
+	}
+
+	vals := lrange.Val()
+	result := make([]interface{}, len(vals))
+	for i, v := range vals {
+		result[i] = v
+	}
+
+	log.Debugf("Unpacked vals: %d", len(result))
+
+	return result
+}
+
 // SetKey will create (or update) a key value in the store.
 func (r *RedisClusterStorageManager) SetKey(keyName, session string, timeout int64) error {
 	log.Debugf("[STORE] SET Raw key is: %s", keyName)
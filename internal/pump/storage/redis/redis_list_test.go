@@ -0,0 +1,61 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package redis
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	redis "github.com/go-redis/redis/v7"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedisClusterStorageManager_GetListRangeAndTrim(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	r := &RedisClusterStorageManager{db: client, KeyPrefix: RedisKeyPrefix}
+
+	const writers, perWriter = 4, 250
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWriter; i++ {
+				client.RPush(RedisKeyPrefix+"iam-system-analytics", fmt.Sprintf("record-%d-%d", w, i))
+			}
+		}(w)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	seen := map[string]bool{}
+	consume := func() {
+		for _, v := range r.GetListRangeAndTrim("iam-system-analytics", 0) {
+			assert.False(t, seen[v.(string)], "record %s read twice", v)
+			seen[v.(string)] = true
+		}
+	}
+
+	for {
+		select {
+		case <-done:
+			consume()
+			assert.Len(t, seen, writers*perWriter)
+
+			return
+		default:
+			consume()
+		}
+	}
+}
@@ -11,6 +11,8 @@ type AnalyticsStorage interface {
 	GetName() string
 	Connect() bool
 	GetAndDeleteSet(string) []interface{}
+	GetListRangeAndTrim(string, int64) []interface{}
+	Ping() error
 }
 
 const (
@@ -26,6 +26,7 @@ func NewApp(basename string) *app.App {
 		app.WithOptions(opts),
 		app.WithDescription(commandDesc),
 		app.WithDefaultValidArgs(),
+		app.WithCompletion(),
 		app.WithRunFunc(run(opts)),
 	)
 
@@ -7,6 +7,7 @@ package app
 import (
 	"fmt"
 	"os"
+	"sync"
 
 	"github.com/fatih/color"
 	cliflag "github.com/marmotedu/component-base/pkg/cli/flag"
@@ -65,17 +66,23 @@ Use "%s --help" for more information about a command.{{end}}
 // App is the main structure of a cli application.
 // It is recommended that an app be created with the app.NewApp() function.
 type App struct {
-	basename    string
-	name        string
-	description string
-	options     CliOptions
-	runFunc     RunFunc
-	silence     bool
-	noVersion   bool
-	noConfig    bool
-	commands    []*Command
-	args        cobra.PositionalArgs
-	cmd         *cobra.Command
+	basename      string
+	name          string
+	description   string
+	options       CliOptions
+	runFunc       RunFunc
+	newOptions    func() CliOptions
+	reloadFunc    ReloadFunc
+	reloadMu      sync.Mutex
+	shutdownFuncs []ShutdownFunc
+	silence       bool
+	noVersion     bool
+	noConfig      bool
+	completion    bool
+	dryRun        bool
+	commands      []*Command
+	args          cobra.PositionalArgs
+	cmd           *cobra.Command
 }
 
 // Option defines optional parameters for initializing the application
@@ -191,6 +198,9 @@ func (a *App) buildCommand() {
 		}
 		cmd.SetHelpCommand(helpCommand(FormatBaseName(a.basename)))
 	}
+	if a.completion {
+		cmd.AddCommand(completionCommand(FormatBaseName(a.basename)))
+	}
 	if a.runFunc != nil {
 		cmd.RunE = a.runCommand
 	}
@@ -209,10 +219,25 @@ func (a *App) buildCommand() {
 	}
 	if !a.noConfig {
 		addConfigFlag(a.basename, namedFlagSets.FlagSet("global"))
+		cmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+			// the completion script does not depend on the configuration file
+			if cmd.Name() == completionCommandName {
+				return nil
+			}
+
+			return loadConfigFile(a.basename)
+		}
+	}
+	if a.runFunc != nil {
+		namedFlagSets.FlagSet("global").BoolVar(&a.dryRun, "dry-run", a.dryRun, ""+
+			"Load and validate the configuration, then exit without running the application.")
 	}
 	globalflag.AddGlobalFlags(namedFlagSets.FlagSet("global"), cmd.Name())
 	// add new global flagset to cmd FlagSet
 	cmd.Flags().AddFlagSet(namedFlagSets.FlagSet("global"))
+	if !a.noConfig {
+		cmd.AddCommand(configCommand(FormatBaseName(a.basename), namedFlagSets))
+	}
 
 	addCmdTemplate(&cmd, namedFlagSets)
 	a.cmd = &cmd
@@ -263,12 +288,24 @@ func (a *App) runCommand(cmd *cobra.Command, args []string) error {
 			return err
 		}
 	}
+	if a.dryRun {
+		log.Infof("%v Dry run, the configuration is valid, exiting", progressMessage)
+
+		return nil
+	}
+	if a.reloadFunc != nil && !a.noConfig {
+		stop := a.watchConfig()
+		defer stop()
+	}
 	// run application
-	if a.runFunc != nil {
-		return a.runFunc(a.basename)
+	if a.runFunc == nil {
+		return nil
+	}
+	if len(a.shutdownFuncs) > 0 {
+		return a.runWithShutdown()
 	}
 
-	return nil
+	return a.runFunc(a.basename)
 }
 
 func (a *App) applyOptionRules() error {
@@ -0,0 +1,57 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+type dryRunOptions struct {
+	viewOptions
+}
+
+func (o *dryRunOptions) Validate() []error {
+	if o.Server.Mode != "debug" && o.Server.Mode != "release" {
+		return []error{fmt.Errorf("invalid server mode %q", o.Server.Mode)}
+	}
+
+	return nil
+}
+
+func TestApp_DryRun(t *testing.T) {
+	defer viper.Reset()
+
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr bool
+	}{
+		{name: "valid", args: []string{"--dry-run"}},
+		{name: "invalid", args: []string{"--dry-run", "--server.mode", "production"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Reset()
+
+			var ran bool
+			cmd := NewApp("test", "iam-test", WithOptions(&dryRunOptions{}), WithNoVersion(), WithSilence(),
+				WithRunFunc(func(basename string) error {
+					ran = true
+
+					return nil
+				})).Command()
+			cmd.SetArgs(tt.args)
+
+			err := cmd.Execute()
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.False(t, ran)
+		})
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+const completionCommandName = "completion"
+
+const completionDesc = `Output shell completion code for the specified shell (bash, zsh, fish, or powershell).
+The shell code must be evaluated to provide interactive completion of %[1]s commands.
+
+Examples:
+  # Load the %[1]s completion code for bash into the current shell
+  source <(%[1]s completion bash)
+
+  # Load the %[1]s completion code for zsh into the current shell
+  source <(%[1]s completion zsh)`
+
+// WithCompletion adds a completion sub command which outputs the shell
+// completion script of the application.
+func WithCompletion() Option {
+	return func(a *App) {
+		a.completion = true
+	}
+}
+
+func completionCommand(basename string) *cobra.Command {
+	return &cobra.Command{
+		Use:                   completionCommandName + " [bash|zsh|fish|powershell]",
+		Short:                 "Output shell completion code for the specified shell",
+		Long:                  fmt.Sprintf(completionDesc, basename),
+		DisableFlagsInUseLine: true,
+		ValidArgs:             []string{"bash", "zsh", "fish", "powershell"},
+		Args:                  cobra.ExactValidArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			root := cmd.Root()
+			out := cmd.OutOrStdout()
+
+			switch args[0] {
+			case "bash":
+				return root.GenBashCompletion(out)
+			case "zsh":
+				return root.GenZshCompletion(out)
+			case "fish":
+				return root.GenFishCompletion(out, true)
+			default:
+				return root.GenPowerShellCompletionWithDesc(out)
+			}
+		},
+	}
+}
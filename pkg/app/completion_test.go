@@ -0,0 +1,32 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApp_Completion(t *testing.T) {
+	a := NewApp("test", "iam-test", WithCompletion(), WithNoVersion())
+
+	for _, shell := range []string{"bash", "zsh", "fish", "powershell"} {
+		t.Run(shell, func(t *testing.T) {
+			out := new(bytes.Buffer)
+			cmd := a.Command()
+			cmd.SetOut(out)
+			cmd.SetArgs([]string{"completion", shell})
+
+			assert.Nil(t, cmd.Execute())
+			assert.Contains(t, out.String(), "iam-test")
+		})
+	}
+
+	cmd := a.Command()
+	cmd.SetArgs([]string{"completion", "tcsh"})
+	assert.NotNil(t, cmd.Execute())
+}
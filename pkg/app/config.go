@@ -5,16 +5,21 @@
 package app
 
 import (
+	"errors"
 	"fmt"
-	"os"
+	"io"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/gosuri/uitable"
+	cliflag "github.com/marmotedu/component-base/pkg/cli/flag"
 	"github.com/marmotedu/component-base/pkg/util/homedir"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
+
+	"github.com/marmotedu/iam/pkg/log"
 )
 
 const configFlagName = "config"
@@ -33,44 +38,136 @@ func addConfigFlag(basename string, fs *pflag.FlagSet) {
 	fs.AddFlag(pflag.Lookup(configFlagName))
 
 	viper.AutomaticEnv()
-	viper.SetEnvPrefix(strings.Replace(strings.ToUpper(basename), "-", "_", -1))
-	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_", "-", "_"))
+	viper.SetEnvPrefix(envPrefix(basename))
+	viper.SetEnvKeyReplacer(envKeyReplacer)
+}
 
-	cobra.OnInitialize(func() {
-		if cfgFile != "" {
-			viper.SetConfigFile(cfgFile)
-		} else {
-			viper.AddConfigPath(".")
+// envKeyReplacer maps the configuration keys to the names of the environment variables.
+var envKeyReplacer = strings.NewReplacer(".", "_", "-", "_")
 
-			if names := strings.Split(basename, "-"); len(names) > 1 {
-				viper.AddConfigPath(filepath.Join(homedir.HomeDir(), "."+names[0]))
-				viper.AddConfigPath(filepath.Join("/etc", names[0]))
-			}
+// envPrefix returns the prefix of the environment variables of the application.
+func envPrefix(basename string) string {
+	return strings.Replace(strings.ToUpper(basename), "-", "_", -1)
+}
 
-			viper.SetConfigName(basename)
+// loadConfigFile reads in the config file specified by the config flag or
+// searched by the basename.
+func loadConfigFile(basename string) error {
+	if cfgFile != "" {
+		viper.SetConfigFile(cfgFile)
+	} else {
+		viper.AddConfigPath(".")
+
+		if names := strings.Split(basename, "-"); len(names) > 1 {
+			viper.AddConfigPath(filepath.Join(homedir.HomeDir(), "."+names[0]))
+			viper.AddConfigPath(filepath.Join("/etc", names[0]))
 		}
 
-		if err := viper.ReadInConfig(); err != nil {
-			_, _ = fmt.Fprintf(os.Stderr, "Error: failed to read configuration file(%s): %v\n", cfgFile, err)
-			os.Exit(1)
+		viper.SetConfigName(basename)
+	}
+
+	if err := viper.ReadInConfig(); err != nil {
+		// the config file is optional when it is not specified explicitly, all the options can be
+		// configured by environment variables and flags.
+		var notFound viper.ConfigFileNotFoundError
+		if cfgFile == "" && errors.As(err, &notFound) {
+			log.Warnf("No configuration file found, using environment variables and flags only: %s", err.Error())
+
+			return nil
 		}
-	})
+
+		return fmt.Errorf("failed to read configuration file(%s): %w", cfgFile, err)
+	}
+
+	return nil
 }
 
-func printConfig() {
-	if keys := viper.AllKeys(); len(keys) > 0 {
-		fmt.Printf("%v Configuration items:\n", progressMessage)
+// redactedValue replaces the value of the secret-looking configuration items.
+const redactedValue = "******"
+
+func printConfig(w io.Writer, redact bool) {
+	if items := EffectiveConfig(redact); len(items) > 0 {
+		keys := make([]string, 0, len(items))
+		for k := range items {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		fmt.Fprintf(w, "%v Configuration items:\n", progressMessage)
 		table := uitable.New()
 		table.Separator = " "
 		table.MaxColWidth = 80
 		table.RightAlign(0)
 		for _, k := range keys {
-			table.AddRow(fmt.Sprintf("%s:", k), viper.Get(k))
+			table.AddRow(fmt.Sprintf("%s:", k), items[k])
 		}
-		fmt.Printf("%v", table)
+		fmt.Fprintf(w, "%v\n", table)
 	}
 }
 
+// EffectiveConfig returns the configuration items merged from flags, environment variables
+// and the config file, keyed by their dotted names. Secret-looking items are redacted if redact is true.
+func EffectiveConfig(redact bool) map[string]interface{} {
+	keys := viper.AllKeys()
+	items := make(map[string]interface{}, len(keys))
+	for _, k := range keys {
+		var value interface{} = viper.Get(k)
+		if redact && isSecretKey(k) && viper.GetString(k) != "" {
+			value = redactedValue
+		}
+		items[k] = value
+	}
+
+	return items
+}
+
+// isSecretKey reports whether the configuration item looks like a secret, such
+// as mysql.password or jwt.key.
+func isSecretKey(key string) bool {
+	name := strings.ToLower(key[strings.LastIndex(key, ".")+1:])
+	for _, word := range []string{"password", "secret", "token"} {
+		if strings.Contains(name, word) {
+			return true
+		}
+	}
+
+	return name == "key" || strings.HasSuffix(name, "-key") || strings.HasSuffix(name, "-key-data")
+}
+
+func configCommand(basename string, namedFlagSets cliflag.NamedFlagSets) *cobra.Command {
+	var showSecrets bool
+
+	viewCmd := &cobra.Command{
+		Use:   "view",
+		Short: "Print the effective configuration merged from flags, environment variables and the config file",
+		Long: fmt.Sprintf(`Print the effective configuration of %[1]s merged from flags, environment variables
+and the config file, which is what %[1]s actually loads. Secret-looking items are redacted
+unless --show-secrets is specified.`, basename),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := viper.BindPFlags(cmd.Flags()); err != nil {
+				return err
+			}
+
+			printConfig(cmd.OutOrStdout(), !showSecrets)
+
+			return nil
+		},
+	}
+	// share the flags of the application, so they can override the config file.
+	for _, f := range namedFlagSets.FlagSets {
+		viewCmd.Flags().AddFlagSet(f)
+	}
+	viewCmd.Flags().BoolVar(&showSecrets, "show-secrets", showSecrets, "Print secret-looking items without redaction.")
+
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect the configuration of " + basename,
+	}
+	cmd.AddCommand(viewCmd)
+
+	return cmd
+}
+
 /*
 // loadConfig reads in config file and ENV variables if set.
 func loadConfig(cfg string, defaultName string) {
@@ -0,0 +1,131 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	cliflag "github.com/marmotedu/component-base/pkg/cli/flag"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+type viewOptions struct {
+	Server struct {
+		Mode string `json:"mode" mapstructure:"mode"`
+	} `json:"server" mapstructure:"server"`
+	MySQL struct {
+		Password string `json:"password" mapstructure:"password"`
+	} `json:"mysql"  mapstructure:"mysql"`
+}
+
+func (o *viewOptions) Flags() (fss cliflag.NamedFlagSets) {
+	fs := fss.FlagSet("server")
+	fs.StringVar(&o.Server.Mode, "server.mode", "release", "Server mode.")
+	fs.StringVar(&o.MySQL.Password, "mysql.password", "", "MySQL password.")
+
+	return fss
+}
+
+func (o *viewOptions) Validate() []error { return nil }
+
+func TestApp_ConfigView(t *testing.T) {
+	defer func() {
+		viper.Reset()
+		cfgFile = ""
+	}()
+
+	cfg := filepath.Join(t.TempDir(), "iam-test.yaml")
+	content := "server:\n  mode: debug\nmysql:\n  host: 127.0.0.1:3306\n  password: iam59!z$\njwt:\n  key: dfVpOK8LZeJLZHYmHdb1VdyRrACKpqoo\n"
+	assert.Nil(t, os.WriteFile(cfg, []byte(content), 0o600))
+
+	tests := []struct {
+		name       string
+		args       []string
+		contains   []string
+		notContain []string
+	}{
+		{
+			name:       "redacted",
+			args:       []string{"config", "view", "-c", cfg},
+			contains:   []string{"server.mode:", "debug", "mysql.host:", "127.0.0.1:3306", "mysql.password:", redactedValue},
+			notContain: []string{"iam59!z$", "dfVpOK8LZeJLZHYmHdb1VdyRrACKpqoo"},
+		},
+		{
+			name:     "flag overrides config file",
+			args:     []string{"config", "view", "-c", cfg, "--server.mode", "test", "--show-secrets"},
+			contains: []string{"test", "iam59!z$", "dfVpOK8LZeJLZHYmHdb1VdyRrACKpqoo"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Reset()
+
+			out := new(bytes.Buffer)
+			cmd := NewApp("test", "iam-test", WithOptions(&viewOptions{}), WithNoVersion()).Command()
+			cmd.SetOut(out)
+			cmd.SetArgs(tt.args)
+
+			assert.Nil(t, cmd.Execute())
+			for _, s := range tt.contains {
+				assert.Contains(t, out.String(), s)
+			}
+			for _, s := range tt.notContain {
+				assert.NotContains(t, out.String(), s)
+			}
+		})
+	}
+}
+
+func Test_isSecretKey(t *testing.T) {
+	assert.True(t, isSecretKey("mysql.password"))
+	assert.True(t, isSecretKey("jwt.key"))
+	assert.True(t, isSecretKey("feature.api-token"))
+	assert.True(t, isSecretKey("secure.tls.cert-key.private-key-data"))
+	assert.False(t, isSecretKey("secure.tls.cert-key.private-key-file"))
+	assert.False(t, isSecretKey("secure.tls.cert-key.cert-data"))
+	assert.False(t, isSecretKey("mysql.host"))
+}
+
+func TestApp_EnvOnly(t *testing.T) {
+	defer viper.Reset()
+	viper.Reset()
+
+	t.Setenv("IAM_TEST_SERVER_MODE", "test")
+	t.Setenv("IAM_TEST_MYSQL_PASSWORD", "iam59!z$")
+
+	opts := &viewOptions{}
+	var ran bool
+	cmd := NewApp("test", "iam-test", WithOptions(opts), WithNoVersion(), WithSilence(),
+		WithRunFunc(func(basename string) error {
+			ran = true
+
+			return nil
+		})).Command()
+	cmd.SetArgs([]string{})
+
+	assert.Nil(t, cmd.Execute())
+	assert.True(t, ran)
+	assert.Equal(t, "test", opts.Server.Mode)
+	assert.Equal(t, "iam59!z$", opts.MySQL.Password)
+}
+
+func TestApp_MissingExplicitConfig(t *testing.T) {
+	defer func() {
+		viper.Reset()
+		cfgFile = ""
+	}()
+	viper.Reset()
+
+	cmd := NewApp("test", "iam-test", WithOptions(&viewOptions{}), WithNoVersion(),
+		WithRunFunc(func(basename string) error { return nil })).Command()
+	cmd.SetArgs([]string{"-c", filepath.Join(t.TempDir(), "iam-test.yaml")})
+
+	assert.NotNil(t, cmd.Execute())
+}
@@ -0,0 +1,128 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/marmotedu/errors"
+	"github.com/spf13/viper"
+
+	"github.com/marmotedu/iam/pkg/log"
+)
+
+// ReloadFunc defines the callback function invoked after the configuration
+// file is reloaded. opts is a new options instance holding the reloaded
+// configuration, it is nil if the application has no options.
+type ReloadFunc func(opts CliOptions)
+
+// WithConfigReload reloads the configuration file when the application receives
+// a SIGHUP signal. The configuration is unmarshalled into a new options instance
+// created by newOptions, which holds the default values like the instance given to
+// WithOptions, and validated before reload is called, so it can re-apply settings
+// such as the log level. The options given to WithOptions are never modified, an
+// invalid configuration is rejected and reload is not called.
+func WithConfigReload(newOptions func() CliOptions, reload ReloadFunc) Option {
+	return func(a *App) {
+		a.newOptions = newOptions
+		a.reloadFunc = reload
+	}
+}
+
+// watchConfig reloads the configuration on every SIGHUP until the returned stop
+// function is called.
+func (a *App) watchConfig() (stop func()) {
+	sigCh := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(sigCh, syscall.SIGHUP)
+
+	configFile := viper.ConfigFileUsed()
+
+	go func() {
+		for {
+			select {
+			case <-sigCh:
+				if err := a.reloadConfig(configFile); err != nil {
+					log.Errorf("Failed to reload configuration file `%s`: %s", configFile, err.Error())
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(sigCh)
+		close(done)
+	}
+}
+
+func (a *App) reloadConfig(configFile string) error {
+	a.reloadMu.Lock()
+	defer a.reloadMu.Unlock()
+
+	v, err := a.readConfig(configFile)
+	if err != nil {
+		return err
+	}
+
+	var opts CliOptions
+	if a.newOptions != nil {
+		if opts, err = newOptionsFromConfig(v, a.newOptions()); err != nil {
+			return err
+		}
+	}
+
+	log.Infof("%v Config file reloaded: `%s`", progressMessage, configFile)
+	a.reloadFunc(opts)
+
+	return nil
+}
+
+// readConfig reads the configuration file into a new viper instance, merged with the
+// environment variables and the flags like the global one. The global viper is left
+// untouched, the running application reads it concurrently and viper is not safe for
+// concurrent use.
+func (a *App) readConfig(configFile string) (*viper.Viper, error) {
+	v := viper.New()
+	v.SetConfigFile(configFile)
+	v.AutomaticEnv()
+	v.SetEnvPrefix(envPrefix(a.basename))
+	v.SetEnvKeyReplacer(envKeyReplacer)
+
+	if a.cmd != nil {
+		if err := v.BindPFlags(a.cmd.Flags()); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := v.ReadInConfig(); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// newOptionsFromConfig unmarshals the configuration into opts, which holds the default
+// values, and validates it.
+func newOptionsFromConfig(v *viper.Viper, opts CliOptions) (CliOptions, error) {
+	if err := v.Unmarshal(opts); err != nil {
+		return nil, err
+	}
+
+	if completeableOptions, ok := opts.(CompleteableOptions); ok {
+		if err := completeableOptions.Complete(); err != nil {
+			return nil, err
+		}
+	}
+
+	if errs := opts.Validate(); len(errs) != 0 {
+		return nil, errors.NewAggregate(errs)
+	}
+
+	return opts, nil
+}
@@ -0,0 +1,91 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	cliflag "github.com/marmotedu/component-base/pkg/cli/flag"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+type reloadOptions struct {
+	Level  string `json:"level"  mapstructure:"level"`
+	Format string `json:"format" mapstructure:"format"`
+}
+
+func newReloadOptions() CliOptions {
+	return &reloadOptions{Level: "info", Format: "console"}
+}
+
+func (o *reloadOptions) Flags() (fss cliflag.NamedFlagSets) { return fss }
+
+func (o *reloadOptions) Validate() []error {
+	if o.Level == "invalid" {
+		return []error{fmt.Errorf("invalid level")}
+	}
+
+	return nil
+}
+
+func TestApp_WatchConfig(t *testing.T) {
+	defer viper.Reset()
+
+	cfg := filepath.Join(t.TempDir(), "app.yaml")
+	assert.Nil(t, os.WriteFile(cfg, []byte("level: info\n"), 0o600))
+	viper.SetConfigFile(cfg)
+	assert.Nil(t, viper.ReadInConfig())
+
+	opts := &reloadOptions{}
+	assert.Nil(t, viper.Unmarshal(opts))
+	assert.Equal(t, "info", opts.Level)
+
+	reloaded := make(chan *reloadOptions, 1)
+	a := NewApp("test", "test", WithOptions(opts), WithConfigReload(newReloadOptions, func(o CliOptions) {
+		reloaded <- o.(*reloadOptions)
+	}))
+
+	stop := a.watchConfig()
+	defer stop()
+
+	assert.Nil(t, os.WriteFile(cfg, []byte("level: debug\n"), 0o600))
+	assert.Nil(t, syscall.Kill(os.Getpid(), syscall.SIGHUP))
+
+	select {
+	case o := <-reloaded:
+		assert.Equal(t, "debug", o.Level)
+		// the defaults of the options constructor are kept
+		assert.Equal(t, "console", o.Format)
+		assert.Equal(t, "info", opts.Level)
+		// the global viper read by the running application is left untouched
+		assert.Equal(t, "info", viper.GetString("level"))
+	case <-time.After(5 * time.Second):
+		t.Fatal("reload callback was not called")
+	}
+}
+
+func TestApp_ReloadConfigInvalid(t *testing.T) {
+	defer viper.Reset()
+
+	cfg := filepath.Join(t.TempDir(), "app.yaml")
+	assert.Nil(t, os.WriteFile(cfg, []byte("level: invalid\n"), 0o600))
+	viper.SetConfigFile(cfg)
+
+	opts := &reloadOptions{Level: "info"}
+	called := false
+	a := NewApp("test", "test", WithOptions(opts), WithConfigReload(newReloadOptions, func(CliOptions) {
+		called = true
+	}))
+
+	assert.NotNil(t, a.reloadConfig(cfg))
+	assert.False(t, called)
+	assert.Equal(t, "info", opts.Level)
+}
@@ -0,0 +1,99 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/marmotedu/errors"
+
+	"github.com/marmotedu/iam/pkg/log"
+)
+
+// shutdownWaitTimeout is how long the application waits for the run function
+// to return once the shutdown callbacks are called.
+var shutdownWaitTimeout = 30 * time.Second
+
+// ShutdownFunc defines the callback function invoked when the application
+// receives a SIGINT or SIGTERM signal.
+type ShutdownFunc func() error
+
+// WithShutdownFunc registers a callback which is called when the application
+// receives a SIGINT or SIGTERM signal. Callbacks are called in the order they
+// are registered, they should make the run function return, then the
+// application exits once the run function returned.
+func WithShutdownFunc(fn ShutdownFunc) Option {
+	return func(a *App) {
+		a.shutdownFuncs = append(a.shutdownFuncs, fn)
+	}
+}
+
+// runWithShutdown calls the run function and runs the shutdown callbacks on
+// SIGINT or SIGTERM. It returns once the run function returned, or once the wait
+// for it timed out after a shutdown, so that the deferred cleanups of the caller
+// still run.
+func (a *App) runWithShutdown() error {
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- a.runFunc(a.basename)
+	}()
+
+	shutdownErr, stop := a.watchShutdown()
+	defer stop()
+
+	select {
+	case err := <-runErr:
+		return err
+	case err := <-shutdownErr:
+		select {
+		case <-runErr:
+		case <-time.After(shutdownWaitTimeout):
+			log.Warnf("%s did not stop within %s after the shutdown", a.name, shutdownWaitTimeout)
+		}
+
+		return err
+	}
+}
+
+// watchShutdown runs the shutdown callbacks on SIGINT or SIGTERM and sends their
+// result on the returned channel, until the returned stop function is called.
+func (a *App) watchShutdown() (<-chan error, func()) {
+	sigCh := make(chan os.Signal, 1)
+	errCh := make(chan error, 1)
+	done := make(chan struct{})
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		select {
+		case sig := <-sigCh:
+			log.Infof("%v Received signal %s, shutting down %s ...", progressMessage, sig, a.name)
+			err := a.shutdown()
+			if err != nil {
+				log.Errorf("Failed to shutdown %s: %s", a.name, err.Error())
+			}
+			errCh <- err
+		case <-done:
+		}
+	}()
+
+	return errCh, func() {
+		signal.Stop(sigCh)
+		close(done)
+	}
+}
+
+func (a *App) shutdown() error {
+	var errs []error
+	for _, fn := range a.shutdownFuncs {
+		if err := fn(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.NewAggregate(errs)
+}
@@ -0,0 +1,79 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApp_WithShutdownFunc(t *testing.T) {
+	stopped := make(chan struct{})
+	returned := false
+
+	var called []int
+	a := NewApp("test", "test", WithNoConfig(), WithNoVersion(),
+		WithRunFunc(func(string) error {
+			<-stopped
+			returned = true
+
+			return nil
+		}),
+		WithShutdownFunc(func() error {
+			called = append(called, 1)
+
+			return nil
+		}),
+		WithShutdownFunc(func() error {
+			called = append(called, 2)
+			close(stopped)
+
+			return fmt.Errorf("close failed")
+		}),
+		WithShutdownFunc(func() error {
+			called = append(called, 3)
+
+			return nil
+		}),
+	)
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- a.runWithShutdown()
+	}()
+
+	// give the application time to watch the signals
+	time.Sleep(100 * time.Millisecond)
+	assert.Nil(t, syscall.Kill(os.Getpid(), syscall.SIGTERM))
+
+	select {
+	case err := <-errCh:
+		assert.NotNil(t, err)
+		assert.True(t, returned)
+		assert.Equal(t, []int{1, 2, 3}, called)
+	case <-time.After(5 * time.Second):
+		t.Fatal("shutdown callbacks were not called")
+	}
+}
+
+func TestApp_RunWithShutdown_RunReturns(t *testing.T) {
+	a := NewApp("test", "test", WithNoConfig(), WithNoVersion(),
+		WithRunFunc(func(string) error {
+			return fmt.Errorf("run failed")
+		}),
+		WithShutdownFunc(func() error {
+			t.Error("shutdown callback should not be called")
+
+			return nil
+		}),
+	)
+
+	assert.EqualError(t, a.runWithShutdown(), "run failed")
+}
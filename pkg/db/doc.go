@@ -2,5 +2,5 @@
 // Use of this source code is governed by a MIT style
 // license that can be found in the LICENSE file.
 
-// Package db provide useful functions to create mysql instance.
+// Package db provide useful functions to create mysql or postgres instance.
 package db // import "github.com/marmotedu/iam/pkg/db"
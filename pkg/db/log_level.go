@@ -0,0 +1,39 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package db
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm/logger"
+)
+
+// Supported gorm log levels.
+const (
+	LogLevelSilent = "silent"
+	LogLevelError  = "error"
+	LogLevelWarn   = "warn"
+	LogLevelInfo   = "info"
+)
+
+// ParseLogLevel maps the string log level to gorm's log level.
+// The numeric levels 1-4 of the former log-mode option are accepted as aliases of
+// silent, error, warn and info.
+func ParseLogLevel(level string) (logger.LogLevel, error) {
+	switch strings.ToLower(level) {
+	case LogLevelSilent, "1":
+		return logger.Silent, nil
+	case LogLevelError, "2":
+		return logger.Error, nil
+	case LogLevelWarn, "3":
+		return logger.Warn, nil
+	case LogLevelInfo, "4":
+		return logger.Info, nil
+	default:
+		return logger.Silent, fmt.Errorf("unrecognized gorm log level: %q, must be one of: %s, %s, %s, %s",
+			level, LogLevelSilent, LogLevelError, LogLevelWarn, LogLevelInfo)
+	}
+}
@@ -0,0 +1,40 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm/logger"
+)
+
+func TestParseLogLevel(t *testing.T) {
+	tests := []struct {
+		level   string
+		want    logger.LogLevel
+		wantErr bool
+	}{
+		{level: "silent", want: logger.Silent},
+		{level: "error", want: logger.Error},
+		{level: "warn", want: logger.Warn},
+		{level: "info", want: logger.Info},
+		{level: "INFO", want: logger.Info},
+		{level: "1", want: logger.Silent},
+		{level: "2", want: logger.Error},
+		{level: "3", want: logger.Warn},
+		{level: "4", want: logger.Info},
+		{level: "5", want: logger.Silent, wantErr: true},
+		{level: "debug", want: logger.Silent, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.level, func(t *testing.T) {
+			got, err := ParseLogLevel(tt.level)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
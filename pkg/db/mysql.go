@@ -8,37 +8,60 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/avast/retry-go"
 	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
+
+	"github.com/marmotedu/iam/pkg/log"
+)
+
+// openDB is used to open the gorm db instance, can be replaced in unit test.
+var openDB = gorm.Open
+
+// Supported database drivers.
+const (
+	DriverMySQL    = "mysql"
+	DriverPostgres = "postgres"
 )
 
 // Options defines optsions for mysql database.
 type Options struct {
+	Driver                string
 	Host                  string
 	Username              string
 	Password              string
 	Database              string
+	SSLMode               string
 	MaxIdleConnections    int
 	MaxOpenConnections    int
 	MaxConnectionLifeTime time.Duration
-	LogLevel              int
+	MaxConnectRetries     int
+	ConnectRetryInterval  time.Duration
+	LogLevel              string
 	Logger                logger.Interface
 }
 
 // New create a new gorm db instance with the given options.
 func New(opts *Options) (*gorm.DB, error) {
-	dsn := fmt.Sprintf(`%s:%s@tcp(%s)/%s?charset=utf8&parseTime=%t&loc=%s`,
-		opts.Username,
-		opts.Password,
-		opts.Host,
-		opts.Database,
-		true,
-		"Local")
-
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{
-		Logger: opts.Logger,
-	})
+	dialector, err := newDialector(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	gormLogger := opts.Logger
+	if gormLogger == nil && opts.LogLevel != "" {
+		level, err := ParseLogLevel(opts.LogLevel)
+		if err != nil {
+			return nil, err
+		}
+		gormLogger = logger.Default.LogMode(level)
+	}
+
+	db, err := open(dialector, &gorm.Config{
+		Logger: gormLogger,
+	}, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -59,3 +82,68 @@ func New(opts *Options) (*gorm.DB, error) {
 
 	return db, nil
 }
+
+// newDialector returns the gorm dialector of the configured driver, mysql is used by default.
+func newDialector(opts *Options) (gorm.Dialector, error) {
+	switch opts.Driver {
+	case "", DriverMySQL:
+		dsn := fmt.Sprintf(`%s:%s@tcp(%s)/%s?charset=utf8&parseTime=%t&loc=%s`,
+			opts.Username,
+			opts.Password,
+			opts.Host,
+			opts.Database,
+			true,
+			"Local")
+
+		return mysql.Open(dsn), nil
+	case DriverPostgres:
+		dsn, err := postgresDSN(opts)
+		if err != nil {
+			return nil, err
+		}
+
+		return postgres.Open(dsn), nil
+	default:
+		return nil, fmt.Errorf("unsupported database driver: %s", opts.Driver)
+	}
+}
+
+// open opens the database with exponential backoff, so the server can survive
+// the database not being ready yet at startup.
+func open(dialector gorm.Dialector, config *gorm.Config, opts *Options) (*gorm.DB, error) {
+	attempts := 1
+	if opts.MaxConnectRetries > 0 {
+		attempts += opts.MaxConnectRetries
+	}
+
+	var db *gorm.DB
+	err := retry.Do(
+		func() error {
+			var openErr error
+			db, openErr = openDB(dialector, config)
+			if openErr != nil {
+				return openErr
+			}
+
+			// some drivers(e.g. postgres) connect lazily, ping to make sure the database is reachable.
+			sqlDB, openErr := db.DB()
+			if openErr != nil {
+				return openErr
+			}
+
+			return sqlDB.Ping()
+		},
+		retry.Attempts(uint(attempts)),
+		retry.Delay(opts.ConnectRetryInterval),
+		retry.DelayType(retry.BackOffDelay),
+		retry.LastErrorOnly(true),
+		retry.OnRetry(func(n uint, err error) {
+			log.Warnf("Failed to connect to database(attempt %d/%d): %s", n+1, attempts, err.Error())
+		}),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return db, nil
+}
@@ -0,0 +1,115 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package db
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jackc/pgconn"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func TestNew_RetryUntilConnected(t *testing.T) {
+	sqlDB, _, err := sqlmock.New()
+	assert.Nil(t, err)
+	defer sqlDB.Close()
+
+	failures := 3
+	attempts := 0
+	openDB = func(_ gorm.Dialector, config ...gorm.Option) (*gorm.DB, error) {
+		attempts++
+		if attempts <= failures {
+			return nil, errors.New("dial tcp 127.0.0.1:3306: connect: connection refused")
+		}
+
+		return gorm.Open(mysql.New(mysql.Config{Conn: sqlDB, SkipInitializeWithVersion: true}), config...)
+	}
+	defer func() { openDB = gorm.Open }()
+
+	db, err := New(&Options{MaxConnectRetries: failures})
+	assert.Nil(t, err)
+	assert.NotNil(t, db)
+	assert.Equal(t, failures+1, attempts)
+}
+
+func TestNew_RetryExhausted(t *testing.T) {
+	attempts := 0
+	openDB = func(_ gorm.Dialector, _ ...gorm.Option) (*gorm.DB, error) {
+		attempts++
+
+		return nil, errors.New("dial tcp 127.0.0.1:3306: connect: connection refused")
+	}
+	defer func() { openDB = gorm.Open }()
+
+	_, err := New(&Options{MaxConnectRetries: 2})
+	assert.NotNil(t, err)
+	assert.Equal(t, 3, attempts)
+}
+
+func TestNew_Postgres(t *testing.T) {
+	sqlDB, _, err := sqlmock.New()
+	assert.Nil(t, err)
+	defer sqlDB.Close()
+
+	var dsn string
+	openDB = func(dialector gorm.Dialector, config ...gorm.Option) (*gorm.DB, error) {
+		pg, ok := dialector.(*postgres.Dialector)
+		if !ok {
+			return nil, errors.New("expected postgres dialector")
+		}
+		dsn = pg.DSN
+
+		return gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), config...)
+	}
+	defer func() { openDB = gorm.Open }()
+
+	db, err := New(&Options{
+		Driver:   DriverPostgres,
+		Host:     "127.0.0.1:15432",
+		Username: "iam",
+		Password: "iam59!z$",
+		Database: "iam",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "postgres", db.Dialector.Name())
+	assert.Equal(t, "host='127.0.0.1' port='15432' user='iam' password='iam59!z$' dbname='iam' sslmode='disable'", dsn)
+}
+
+func TestNew_UnsupportedDriver(t *testing.T) {
+	_, err := New(&Options{Driver: "sqlite"})
+	assert.NotNil(t, err)
+}
+
+func Test_postgresDSN(t *testing.T) {
+	dsn, err := postgresDSN(&Options{Host: "db.local", Username: "iam", Database: "iam"})
+	assert.Nil(t, err)
+	assert.Equal(t, "host='db.local' port='5432' user='iam' password='' dbname='iam' sslmode='disable'", dsn)
+
+	// the values with spaces, quotes and backslashes are passed as is
+	dsn, err = postgresDSN(&Options{
+		Host:     "db.local:15432",
+		Username: "iam",
+		Password: `p@ss word' sslmode=disable \`,
+		Database: "iam",
+		SSLMode:  "verify-full",
+	})
+	assert.Nil(t, err)
+
+	config, err := pgconn.ParseConfig(dsn)
+	assert.Nil(t, err)
+	assert.Equal(t, "db.local", config.Host)
+	assert.Equal(t, uint16(15432), config.Port)
+	assert.Equal(t, `p@ss word' sslmode=disable \`, config.Password)
+	assert.Equal(t, "iam", config.Database)
+	// verify-full requires a TLS config verifying the server name
+	if assert.NotNil(t, config.TLSConfig) {
+		assert.Equal(t, "db.local", config.TLSConfig.ServerName)
+	}
+}
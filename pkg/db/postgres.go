@@ -0,0 +1,53 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package db
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+const defaultPostgresPort = "5432"
+
+// DefaultPostgresSSLMode is the sslmode used when none is configured.
+const DefaultPostgresSSLMode = "disable"
+
+// PostgresSSLModes are the sslmode values supported by postgres.
+var PostgresSSLModes = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+
+// dsnValueEscaper escapes the quoted values of the postgres dsn.
+var dsnValueEscaper = strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+
+// postgresDSN builds postgres dsn from the given options, Host is in `host[:port]` format
+// which is the same as mysql. The values are quoted, so a password with spaces or quotes
+// is passed as is.
+func postgresDSN(opts *Options) (string, error) {
+	host, port := opts.Host, defaultPostgresPort
+	if strings.Contains(opts.Host, ":") {
+		var err error
+		if host, port, err = net.SplitHostPort(opts.Host); err != nil {
+			return "", fmt.Errorf("invalid postgres host %q: %w", opts.Host, err)
+		}
+	}
+
+	sslMode := opts.SSLMode
+	if sslMode == "" {
+		sslMode = DefaultPostgresSSLMode
+	}
+
+	return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
+		quoteDSNValue(host),
+		quoteDSNValue(port),
+		quoteDSNValue(opts.Username),
+		quoteDSNValue(opts.Password),
+		quoteDSNValue(opts.Database),
+		quoteDSNValue(sslMode),
+	), nil
+}
+
+func quoteDSNValue(value string) string {
+	return "'" + dsnValueEscaper.Replace(value) + "'"
+}
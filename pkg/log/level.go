@@ -0,0 +1,82 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package log
+
+import (
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// namedLevelCore filters the entries by the level of the logger name, the levels
+// override the global level for the loggers named after them and their children,
+// e.g. the level of storage applies to storage and storage.redis as well.
+type namedLevelCore struct {
+	zapcore.Core
+
+	// root is the name of the root logger, it's not part of the names of the levels.
+	root   string
+	global zapcore.Level
+	levels map[string]zapcore.Level
+}
+
+func newNamedLevelCore(core zapcore.Core, root string, global zapcore.Level,
+	levels map[string]zapcore.Level,
+) zapcore.Core {
+	return &namedLevelCore{Core: core, root: root, global: global, levels: levels}
+}
+
+// With adds structured context to the Core.
+func (c *namedLevelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &namedLevelCore{Core: c.Core.With(fields), root: c.root, global: c.global, levels: c.levels}
+}
+
+// Check determines whether the supplied Entry should be logged.
+func (c *namedLevelCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.levelOf(ent.LoggerName).Enabled(ent.Level) {
+		return ce
+	}
+
+	return c.Core.Check(ent, ce)
+}
+
+// levelOf returns the level of the longest name matching the logger name.
+func (c *namedLevelCore) levelOf(name string) zapcore.Level {
+	if c.root != "" {
+		if name == c.root {
+			name = ""
+		} else {
+			name = strings.TrimPrefix(name, c.root+".")
+		}
+	}
+
+	for name != "" {
+		if level, ok := c.levels[name]; ok {
+			return level
+		}
+
+		i := strings.LastIndex(name, ".")
+		if i < 0 {
+			break
+		}
+		name = name[:i]
+	}
+
+	return c.global
+}
+
+// parseLevels parses the level of each logger name.
+func parseLevels(levels map[string]string) (map[string]zapcore.Level, error) {
+	parsed := make(map[string]zapcore.Level, len(levels))
+	for name, text := range levels {
+		var level zapcore.Level
+		if err := level.UnmarshalText([]byte(text)); err != nil {
+			return nil, err
+		}
+		parsed[name] = level
+	}
+
+	return parsed, nil
+}
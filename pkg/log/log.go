@@ -24,6 +24,7 @@ import (
 	"fmt"
 	"log"
 	"sync"
+	"time"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -208,6 +209,8 @@ var (
 func Init(opts *Options) {
 	mu.Lock()
 	defer mu.Unlock()
+	// the entries buffered by the previous logger would be lost otherwise
+	std.Flush()
 	std = New(opts)
 }
 
@@ -221,6 +224,18 @@ func New(opts *Options) *zapLogger {
 	if err := zapLevel.UnmarshalText([]byte(opts.Level)); err != nil {
 		zapLevel = zapcore.InfoLevel
 	}
+
+	// the invalid levels are reported by Validate
+	levels, _ := parseLevels(opts.Levels)
+
+	// the core accepts the lowest level, the levels of the names are checked by namedLevelCore
+	coreLevel := zapLevel
+	for _, level := range levels {
+		if level < coreLevel {
+			coreLevel = level
+		}
+	}
+
 	encodeLevel := zapcore.CapitalLevelEncoder
 	// when output to local path, with color is forbidden
 	if opts.Format == consoleFormat && opts.EnableColor {
@@ -242,7 +257,7 @@ func New(opts *Options) *zapLogger {
 	}
 
 	loggerConfig := &zap.Config{
-		Level:             zap.NewAtomicLevelAt(zapLevel),
+		Level:             zap.NewAtomicLevelAt(coreLevel),
 		Development:       opts.Development,
 		DisableCaller:     opts.DisableCaller,
 		DisableStacktrace: opts.DisableStacktrace,
@@ -256,8 +271,28 @@ func New(opts *Options) *zapLogger {
 		ErrorOutputPaths: opts.ErrorOutputPaths,
 	}
 
-	var err error
-	l, err := loggerConfig.Build(zap.AddStacktrace(zapcore.PanicLevel), zap.AddCallerSkip(1))
+	if err := createOutputDirs(opts.OutputPaths, opts.ErrorOutputPaths); err != nil {
+		panic(err)
+	}
+
+	zapOpts := []zap.Option{zap.AddStacktrace(zapcore.PanicLevel), zap.AddCallerSkip(1)}
+	if opts.BufferSize > 0 {
+		core, err := newBufferedCore(opts, loggerConfig)
+		if err != nil {
+			panic(err)
+		}
+		// the core built from the output paths is replaced by the buffered one
+		loggerConfig.OutputPaths = nil
+		zapOpts = append(zapOpts, zap.WrapCore(func(zapcore.Core) zapcore.Core { return core }))
+	}
+
+	if len(levels) > 0 {
+		zapOpts = append(zapOpts, zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return newNamedLevelCore(core, opts.Name, zapLevel, levels)
+		}))
+	}
+
+	l, err := loggerConfig.Build(zapOpts...)
 	if err != nil {
 		panic(err)
 	}
@@ -274,6 +309,32 @@ func New(opts *Options) *zapLogger {
 	return logger
 }
 
+// newBufferedCore creates a core which writes the log entries to a buffer, the buffer is
+// written to the output paths when it's full, every FlushInterval and on Flush.
+func newBufferedCore(opts *Options, config *zap.Config) (zapcore.Core, error) {
+	sink, _, err := zap.Open(config.OutputPaths...)
+	if err != nil {
+		return nil, err
+	}
+
+	ws := &zapcore.BufferedWriteSyncer{
+		WS:            sink,
+		Size:          opts.BufferSize,
+		FlushInterval: opts.FlushInterval,
+	}
+
+	var encoder zapcore.Encoder
+	if config.Encoding == jsonFormat {
+		encoder = zapcore.NewJSONEncoder(config.EncoderConfig)
+	} else {
+		encoder = zapcore.NewConsoleEncoder(config.EncoderConfig)
+	}
+
+	core := zapcore.NewCore(encoder, ws, config.Level)
+
+	return zapcore.NewSamplerWithOptions(core, time.Second, config.Sampling.Initial, config.Sampling.Thereafter), nil
+}
+
 // SugaredLogger returns global sugared logger.
 func SugaredLogger() *zap.SugaredLogger {
 	return std.zapLogger.Sugar()
@@ -325,7 +386,10 @@ func (l *zapLogger) Write(p []byte) (n int, err error) {
 	return len(p), nil
 }
 
-// WithValues creates a child logger and adds adds Zap fields to it.
+// WithValues creates a child logger and adds adds Zap fields to it. The fields are
+// kept by the loggers derived from the child logger, e.g.
+//
+//	logger := log.WithName("storage").WithValues("backend", "redis")
 func WithValues(keysAndValues ...interface{}) Logger { return std.WithValues(keysAndValues...) }
 
 func (l *zapLogger) WithValues(keysAndValues ...interface{}) Logger {
@@ -335,7 +399,8 @@ func (l *zapLogger) WithValues(keysAndValues ...interface{}) Logger {
 }
 
 // WithName adds a new path segment to the logger's name. Segments are joined by
-// periods. By default, Loggers are unnamed.
+// periods. By default, Loggers are unnamed. Packages use it to tag their logs with
+// the subsystem name, e.g. log.WithName("authz").
 func WithName(s string) Logger { return std.WithName(s) }
 
 func (l *zapLogger) WithName(name string) Logger {
@@ -19,10 +19,17 @@
 package log_test
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/spf13/pflag"
 	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
 
 	"github.com/marmotedu/iam/pkg/log"
 )
@@ -42,6 +49,100 @@ func Test_WithValues(t *testing.T) {
 	logger.Info("Hello world!")
 }
 
+func Test_ScopedLogger(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := log.NewLogger(zap.New(core))
+
+	storage := logger.WithName("storage").WithValues("backend", "redis")
+	storage.Infow("connected", "addr", "127.0.0.1:6379")
+	storage.WithName("pubsub").WithValues("channel", "iam.cluster.notifications").Warn("reconnecting")
+	logger.Info("unscoped")
+
+	entries := logs.AllUntimed()
+	assert.Len(t, entries, 3)
+
+	assert.Equal(t, "storage", entries[0].LoggerName)
+	assert.Equal(t, map[string]interface{}{"backend": "redis", "addr": "127.0.0.1:6379"}, entries[0].ContextMap())
+
+	assert.Equal(t, "storage.pubsub", entries[1].LoggerName)
+	assert.Equal(t, map[string]interface{}{
+		"backend": "redis",
+		"channel": "iam.cluster.notifications",
+	}, entries[1].ContextMap())
+
+	// the parent logger is not affected by its children
+	assert.Empty(t, entries[2].LoggerName)
+	assert.Empty(t, entries[2].ContextMap())
+}
+
+func Test_NamedLevels(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "iam.log")
+
+	opts := log.NewOptions()
+	opts.Name = "apiserver"
+	opts.OutputPaths = []string{file}
+	opts.Levels = map[string]string{"storage": "debug", "authz": "error"}
+	assert.Empty(t, opts.Validate())
+	logger := log.New(opts)
+
+	logger.Debug("root debug")
+	logger.Info("root info")
+	logger.WithName("storage").Debug("storage debug")
+	logger.WithName("storage").WithName("redis").Debug("storage redis debug")
+	logger.WithName("storagex").Debug("storagex debug")
+	logger.WithName("authz").Warn("authz warn")
+	logger.WithName("authz").Error("authz error")
+	logger.Flush()
+
+	data, err := os.ReadFile(file)
+	assert.Nil(t, err)
+
+	output := string(data)
+	assert.NotContains(t, output, "root debug")
+	assert.Contains(t, output, "root info")
+	assert.Contains(t, output, "storage debug")
+	assert.Contains(t, output, "storage redis debug")
+	assert.NotContains(t, output, "storagex debug")
+	assert.NotContains(t, output, "authz warn")
+	assert.Contains(t, output, "authz error")
+
+	opts.Levels = map[string]string{"storage": "verbose"}
+	assert.NotEmpty(t, opts.Validate())
+}
+
+// recorder records the lines of the test logger.
+type recorder struct {
+	lines []string
+}
+
+func (r *recorder) Logf(format string, args ...interface{}) {
+	r.lines = append(r.lines, fmt.Sprintf(format, args...))
+}
+
+func Test_NewTestLogger(t *testing.T) {
+	r := &recorder{}
+	logger := log.NewTestLogger(r)
+
+	logger.WithName("storage").Debugw("connected", "addr", "127.0.0.1:6379")
+	logger.Errorf("failed to load %d policies", 2)
+
+	assert.Equal(t, []string{
+		`DEBUG	storage	connected	{"addr": "127.0.0.1:6379"}`,
+		"ERROR	failed to load 2 policies",
+	}, r.lines)
+
+	// it's a testing.TB as well
+	log.NewTestLogger(t).Info("logged by the test")
+}
+
+func Test_NewNop(t *testing.T) {
+	logger := log.NewNop()
+	logger.WithName("storage").Info("discarded")
+	logger.Flush()
+
+	assert.False(t, logger.V(log.InfoLevel).Enabled())
+}
+
 func Test_V(t *testing.T) {
 	defer log.Flush() // used for record logger printer
 
@@ -59,4 +160,48 @@ func Test_Option(t *testing.T) {
 	assert.Nil(t, err)
 
 	assert.Equal(t, "debug", opt.Level)
+
+	err = fs.Parse([]string{"--log.levels=storage=debug,authz=warn"})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"storage": "debug", "authz": "warn"}, opt.Levels)
 }
+
+func Test_BufferedOutput(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "iam.log")
+
+	opts := log.NewOptions()
+	opts.OutputPaths = []string{file}
+	opts.BufferSize = 1 << 20
+	opts.FlushInterval = time.Hour
+	logger := log.New(opts)
+
+	logger.Info("buffered message")
+
+	data, err := os.ReadFile(file)
+	assert.Nil(t, err)
+	assert.NotContains(t, string(data), "buffered message")
+
+	logger.Flush()
+
+	data, err = os.ReadFile(file)
+	assert.Nil(t, err)
+	assert.Contains(t, string(data), "buffered message")
+}
+
+func benchmarkOutput(b *testing.B, bufferSize int) {
+	opts := log.NewOptions()
+	opts.OutputPaths = []string{filepath.Join(b.TempDir(), "iam.log")}
+	opts.BufferSize = bufferSize
+	logger := log.New(opts)
+	defer logger.Flush()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		// distinct messages, the sampling drops the repeated ones
+		logger.Infof("Hello world %d!", i)
+	}
+}
+
+func Benchmark_UnbufferedOutput(b *testing.B) { benchmarkOutput(b, 0) }
+
+func Benchmark_BufferedOutput(b *testing.B) { benchmarkOutput(b, 256*1024) }
@@ -20,7 +20,11 @@ package log
 
 import (
 	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/marmotedu/component-base/pkg/json"
 	"github.com/spf13/pflag"
@@ -38,6 +42,9 @@ const (
 	flagErrorOutputPaths  = "log.error-output-paths"
 	flagDevelopment       = "log.development"
 	flagName              = "log.name"
+	flagBufferSize        = "log.buffer-size"
+	flagFlushInterval     = "log.flush-interval"
+	flagLevels            = "log.levels"
 
 	consoleFormat = "console"
 	jsonFormat    = "json"
@@ -54,6 +61,13 @@ type Options struct {
 	EnableColor       bool     `json:"enable-color"       mapstructure:"enable-color"`
 	Development       bool     `json:"development"        mapstructure:"development"`
 	Name              string   `json:"name"               mapstructure:"name"`
+	// BufferSize is the size in bytes of the buffer the log entries are written to before
+	// they reach the output paths, 0 disables the buffering.
+	BufferSize int `json:"buffer-size" mapstructure:"buffer-size"`
+	// FlushInterval is how often the buffered log entries are flushed.
+	FlushInterval time.Duration `json:"flush-interval" mapstructure:"flush-interval"`
+	// Levels overrides Level for the loggers with the names, and their children, e.g. {"storage": "debug"}.
+	Levels map[string]string `json:"levels" mapstructure:"levels"`
 }
 
 // NewOptions creates an Options object with default parameters.
@@ -67,6 +81,8 @@ func NewOptions() *Options {
 		Development:       false,
 		OutputPaths:       []string{"stdout"},
 		ErrorOutputPaths:  []string{"stderr"},
+		BufferSize:        0,
+		FlushInterval:     30 * time.Second,
 	}
 }
 
@@ -79,14 +95,112 @@ func (o *Options) Validate() []error {
 		errs = append(errs, err)
 	}
 
+	if _, err := parseLevels(o.Levels); err != nil {
+		errs = append(errs, fmt.Errorf("--%s: %w", flagLevels, err))
+	}
+
 	format := strings.ToLower(o.Format)
 	if format != consoleFormat && format != jsonFormat {
 		errs = append(errs, fmt.Errorf("not a valid log format: %q", o.Format))
 	}
 
+	if o.BufferSize < 0 {
+		errs = append(errs, fmt.Errorf("--%s %d must not be negative", flagBufferSize, o.BufferSize))
+	}
+
+	if o.BufferSize > 0 && o.FlushInterval <= 0 {
+		errs = append(errs, fmt.Errorf("--%s must be positive when --%s is set", flagFlushInterval, flagBufferSize))
+	}
+
+	for _, path := range append(append([]string{}, o.OutputPaths...), o.ErrorOutputPaths...) {
+		if err := validateOutputPath(path); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
 	return errs
 }
 
+// localOutputPath returns the local file of a log output path, false is returned for
+// the standard streams and the sinks registered for other schemes.
+func localOutputPath(path string) (string, bool, error) {
+	if path == "stdout" || path == "stderr" {
+		return "", false, nil
+	}
+
+	if strings.Contains(path, "://") {
+		u, err := url.Parse(path)
+		if err != nil {
+			return "", false, fmt.Errorf("not a valid log output path %q: %w", path, err)
+		}
+		// only local files can be checked, other schemes are registered sinks.
+		if u.Scheme != "file" {
+			return "", false, nil
+		}
+		path = u.Path
+	}
+
+	return path, true, nil
+}
+
+// validateOutputPath makes sure the nearest existing parent of a log file is a writable
+// directory, so a mistyped path is reported before zap fails to open it. Nothing is
+// created here, the missing directories are created when the logger is built.
+func validateOutputPath(path string) error {
+	file, ok, err := localOutputPath(path)
+	if err != nil || !ok {
+		return err
+	}
+
+	dir := filepath.Dir(file)
+	for {
+		info, err := os.Stat(dir)
+		if err == nil {
+			if !info.IsDir() {
+				return fmt.Errorf("log output path %q is not writable: %s is not a directory", path, dir)
+			}
+
+			break
+		}
+		if !os.IsNotExist(err) {
+			return fmt.Errorf("log output path %q is not writable: %w", path, err)
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	if err := writable(dir); err != nil {
+		return fmt.Errorf("log output path %q is not writable: %w", path, err)
+	}
+
+	return nil
+}
+
+// createOutputDirs creates the missing parent directories of the local log files.
+func createOutputDirs(paths ...[]string) error {
+	for _, list := range paths {
+		for _, path := range list {
+			file, ok, err := localOutputPath(path)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue
+			}
+
+			if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+				return fmt.Errorf("create the directory of log output path %q failed: %w", path, err)
+			}
+		}
+	}
+
+	return nil
+}
+
 // AddFlags adds flags for log to the specified FlagSet object.
 func (o *Options) AddFlags(fs *pflag.FlagSet) {
 	fs.StringVar(&o.Level, flagLevel, o.Level, "Minimum log output `LEVEL`.")
@@ -105,6 +219,14 @@ func (o *Options) AddFlags(fs *pflag.FlagSet) {
 			"the behavior of DPanicLevel and takes stacktraces more liberally.",
 	)
 	fs.StringVar(&o.Name, flagName, o.Name, "The name of the logger.")
+	fs.StringToStringVar(&o.Levels, flagLevels, o.Levels, ""+
+		"Minimum log output `LEVEL` of the loggers with the names and their children, "+
+		"overriding --log.level, e.g. storage=debug,authz=warn.")
+	fs.IntVar(&o.BufferSize, flagBufferSize, o.BufferSize, ""+
+		"Size in bytes of the buffer log entries are written to before reaching the output paths, "+
+		"it improves the throughput of heavy logging. 0 disables the buffering.")
+	fs.DurationVar(&o.FlushInterval, flagFlushInterval, o.FlushInterval, ""+
+		"How often the buffered log entries are flushed, only used when --log.buffer-size is set.")
 }
 
 func (o *Options) String() string {
@@ -151,6 +273,9 @@ func (o *Options) Build() error {
 		OutputPaths:      o.OutputPaths,
 		ErrorOutputPaths: o.ErrorOutputPaths,
 	}
+	if err := createOutputDirs(o.OutputPaths, o.ErrorOutputPaths); err != nil {
+		return err
+	}
 	logger, err := zc.Build(zap.AddStacktrace(zapcore.PanicLevel))
 	if err != nil {
 		return err
@@ -20,6 +20,8 @@ package log_test
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -41,3 +43,30 @@ func Test_Options_Validate(t *testing.T) {
 	expected := `[unrecognized level: "test" not a valid log format: "test"]`
 	assert.Equal(t, expected, fmt.Sprintf("%s", errs))
 }
+
+func Test_Options_ValidateOutputPaths(t *testing.T) {
+	dir := t.TempDir()
+
+	opts := log.NewOptions()
+	opts.OutputPaths = []string{"stdout", filepath.Join(dir, "logs", "iam.log")}
+	opts.ErrorOutputPaths = []string{"stderr", "file://" + filepath.Join(dir, "logs", "iam.error.log")}
+	assert.Empty(t, opts.Validate())
+
+	// Validate has no side effects, the directory is created by the logger.
+	_, err := os.Stat(filepath.Join(dir, "logs"))
+	assert.True(t, os.IsNotExist(err))
+
+	log.New(opts).Flush()
+	info, err := os.Stat(filepath.Join(dir, "logs"))
+	assert.Nil(t, err)
+	assert.True(t, info.IsDir())
+
+	// a regular file can not be used as the parent directory.
+	file := filepath.Join(dir, "file")
+	assert.Nil(t, os.WriteFile(file, nil, 0o600))
+
+	opts.OutputPaths = []string{filepath.Join(file, "iam.log")}
+	errs := opts.Validate()
+	assert.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "is not writable")
+}
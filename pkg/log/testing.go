@@ -0,0 +1,50 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package log
+
+import (
+	"bytes"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// NewNop returns a logger which discards all the log entries.
+func NewNop() Logger {
+	return NewLogger(zap.NewNop())
+}
+
+// TestingT is the subset of testing.TB used by the test logger.
+type TestingT interface {
+	Logf(format string, args ...interface{})
+}
+
+// NewTestLogger returns a logger which writes the log entries of all the levels to t.Logf,
+// so they are only shown for the failed or verbose tests.
+func NewTestLogger(t TestingT) Logger {
+	encoder := zapcore.NewConsoleEncoder(zapcore.EncoderConfig{
+		MessageKey:     "message",
+		LevelKey:       "level",
+		NameKey:        "logger",
+		LineEnding:     zapcore.DefaultLineEnding,
+		EncodeLevel:    zapcore.CapitalLevelEncoder,
+		EncodeDuration: milliSecondsDurationEncoder,
+		EncodeName:     zapcore.FullNameEncoder,
+	})
+	core := zapcore.NewCore(encoder, zapcore.AddSync(testWriter{t: t}), zapcore.DebugLevel)
+
+	return NewLogger(zap.New(core))
+}
+
+// testWriter writes every log entry as a line of the test log.
+type testWriter struct {
+	t TestingT
+}
+
+func (w testWriter) Write(p []byte) (int, error) {
+	w.t.Logf("%s", bytes.TrimRight(p, "\n"))
+
+	return len(p), nil
+}
@@ -0,0 +1,15 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+//go:build !windows
+// +build !windows
+
+package log
+
+import "golang.org/x/sys/unix"
+
+// writable checks whether the current user may create files in the directory.
+func writable(dir string) error {
+	return unix.Access(dir, unix.W_OK)
+}
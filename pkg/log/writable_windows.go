@@ -0,0 +1,10 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package log
+
+// writable is not checked on windows, the permissions are kept in ACLs there.
+func writable(dir string) error {
+	return nil
+}
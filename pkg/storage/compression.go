@@ -0,0 +1,80 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strings"
+	"sync/atomic"
+
+	"github.com/marmotedu/iam/pkg/log"
+)
+
+// compressedValueHeader precedes the gzip compressed values, so they are told apart from the
+// values stored uncompressed, e.g. before the compression is enabled.
+const compressedValueHeader = "\x00gz:"
+
+// compressionMinSize is the size from which the values are compressed, the smaller values don't
+// benefit from compression.
+const compressionMinSize = 1024
+
+// compressValues reports whether SetKey compresses the values, it is set by ConnectToRedis.
+var compressValues atomic.Value
+
+func compressionEnabled() bool {
+	if v := compressValues.Load(); v != nil {
+		return v.(bool)
+	}
+
+	return false
+}
+
+// compressValue compresses the value if it's large enough, it returns the value as is if the
+// compressed one isn't smaller.
+func compressValue(value string) string {
+	if len(value) < compressionMinSize {
+		return value
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(compressedValueHeader)
+
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write([]byte(value)); err != nil {
+		return value
+	}
+
+	if err := zw.Close(); err != nil || buf.Len() >= len(value) {
+		return value
+	}
+
+	return buf.String()
+}
+
+// decompressValue decompresses the value written by compressValue, the values without the header
+// are returned as is.
+func decompressValue(value string) string {
+	if !strings.HasPrefix(value, compressedValueHeader) {
+		return value
+	}
+
+	zr, err := gzip.NewReader(strings.NewReader(value[len(compressedValueHeader):]))
+	if err != nil {
+		log.Warnf("Failed to decompress value, it's returned as is: %s", err.Error())
+
+		return value
+	}
+
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		log.Warnf("Failed to decompress value, it's returned as is: %s", err.Error())
+
+		return value
+	}
+
+	return string(data)
+}
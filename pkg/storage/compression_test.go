@@ -0,0 +1,57 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedisCluster_Compression(t *testing.T) {
+	mr, _ := newTestRedis(t)
+	defer compressValues.Store(false)
+
+	r := &RedisCluster{KeyPrefix: "policy-"}
+	large := strings.Repeat(`{"effect":"allow","resources":["resources:articles:*"]}`, 100)
+
+	for _, compression := range []bool{false, true} {
+		compressValues.Store(compression)
+
+		assert.Nil(t, r.SetKey("large", large, time.Minute))
+		assert.Nil(t, r.SetKey("small", "small", time.Minute))
+
+		stored, _ := mr.Get("policy-large")
+		assert.Equal(t, compression, strings.HasPrefix(stored, compressedValueHeader))
+		assert.Equal(t, compression, len(stored) < len(large))
+
+		// the small values are never compressed
+		stored, _ = mr.Get("policy-small")
+		assert.Equal(t, "small", stored)
+
+		value, err := r.GetKey("large")
+		assert.Nil(t, err)
+		assert.Equal(t, large, value)
+
+		values, err := r.GetMultiKey([]string{"large", "small", "absent"})
+		assert.Nil(t, err)
+		assert.Equal(t, []string{large, "small", ""}, values)
+	}
+
+	// the values written before the compression is disabled are still read
+	compressValues.Store(false)
+	value, err := r.GetKey("large")
+	assert.Nil(t, err)
+	assert.Equal(t, large, value)
+}
+
+func TestDecompressValue_Invalid(t *testing.T) {
+	// a value with the header but not compressed is returned as is
+	value := compressedValueHeader + "not gzip"
+	assert.Equal(t, value, decompressValue(value))
+	assert.Equal(t, "plain", decompressValue("plain"))
+}
@@ -0,0 +1,97 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"context"
+	"io"
+	"net"
+	"strings"
+	"syscall"
+
+	redis "github.com/go-redis/redis/v7"
+	"github.com/marmotedu/errors"
+)
+
+// The errors the failed redis operations are classified as, the returned errors wrap the original
+// errors of redis, so they can be checked with errors.Is, e.g. errors.Is(err, storage.ErrTimeout).
+var (
+	// ErrTimeout is returned when a redis operation times out, or no connection is available in time.
+	ErrTimeout = errors.New("storage: redis operation timed out")
+	// ErrConnection is returned when the connection to redis fails or is closed.
+	ErrConnection = errors.New("storage: redis connection failed")
+	// ErrServer is returned when redis replies with an error, e.g. WRONGTYPE or READONLY.
+	ErrServer = errors.New("storage: redis server error")
+)
+
+// redisError is a redis error classified as one of ErrTimeout, ErrConnection and ErrServer.
+type redisError struct {
+	kind error
+	err  error
+}
+
+func (e *redisError) Error() string {
+	return e.kind.Error() + ": " + e.err.Error()
+}
+
+func (e *redisError) Is(target error) bool {
+	return target == e.kind
+}
+
+func (e *redisError) Unwrap() error {
+	return e.err
+}
+
+// wrapRedisError classifies the error returned by redis, redis.Nil and the errors not coming
+// from redis are returned as they are.
+func wrapRedisError(err error) error {
+	if err == nil || errors.Is(err, redis.Nil) {
+		return err
+	}
+
+	var classified *redisError
+	if errors.As(err, &classified) {
+		return err
+	}
+
+	if kind := classifyRedisError(err); kind != nil {
+		return &redisError{kind: kind, err: err}
+	}
+
+	return err
+}
+
+func classifyRedisError(err error) error {
+	var netErr net.Error
+	isNetErr := errors.As(err, &netErr)
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded),
+		isNetErr && netErr.Timeout(),
+		err.Error() == "redis: connection pool timeout":
+		return ErrTimeout
+	case isNetErr,
+		errors.Is(err, redis.ErrClosed),
+		errors.Is(err, io.EOF),
+		errors.Is(err, io.ErrUnexpectedEOF),
+		errors.Is(err, syscall.ECONNREFUSED),
+		errors.Is(err, syscall.ECONNRESET),
+		errors.Is(err, syscall.EPIPE):
+		return ErrConnection
+	}
+
+	var serverErr redis.Error
+	if errors.As(err, &serverErr) || strings.HasPrefix(err.Error(), "CLUSTERDOWN ") {
+		return ErrServer
+	}
+
+	return nil
+}
+
+// IsUnavailable reports whether err is caused by redis being unavailable, i.e. redis is down, the
+// connection to it failed or the operation timed out, the operation may succeed once redis recovers.
+func IsUnavailable(err error) bool {
+	return errors.Is(err, ErrRedisIsDown) || errors.Is(err, ErrConnection) || errors.Is(err, ErrTimeout)
+}
@@ -0,0 +1,83 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	redis "github.com/go-redis/redis/v7"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrapRedisError(t *testing.T) {
+	_, client := newTestRedis(t)
+	_ = client.Set("string", "value", 0).Err()
+	wrongType := client.LRange("string", 0, -1).Err()
+
+	plain := errors.New("plain")
+	tests := []struct {
+		name string
+		err  error
+		kind error
+	}{
+		{"deadline", context.DeadlineExceeded, ErrTimeout},
+		{"net timeout", &net.OpError{Op: "read", Net: "tcp", Err: os.ErrDeadlineExceeded}, ErrTimeout},
+		{"pool timeout", errors.New("redis: connection pool timeout"), ErrTimeout},
+		{"refused", &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}, ErrConnection},
+		{"reset", fmt.Errorf("write: %w", syscall.ECONNRESET), ErrConnection},
+		{"eof", io.EOF, ErrConnection},
+		{"closed", redis.ErrClosed, ErrConnection},
+		{"wrong type", wrongType, ErrServer},
+		{"nil", redis.Nil, redis.Nil},
+		{"down", ErrRedisIsDown, ErrRedisIsDown},
+		{"not redis", plain, plain},
+	}
+
+	kinds := []error{ErrTimeout, ErrConnection, ErrServer}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := wrapRedisError(tt.err)
+			assert.ErrorIs(t, err, tt.err)
+			assert.ErrorIs(t, err, tt.kind)
+			for _, kind := range kinds {
+				if kind != tt.kind {
+					assert.NotErrorIs(t, err, kind)
+				}
+			}
+
+			// the classified errors are not wrapped twice
+			assert.Equal(t, err, wrapRedisError(err))
+		})
+	}
+
+	assert.Nil(t, wrapRedisError(nil))
+}
+
+func TestRedisCluster_ClassifiedErrors(t *testing.T) {
+	mr, _ := newTestRedis(t)
+	r := &RedisCluster{KeyPrefix: "test-"}
+
+	assert.Nil(t, r.SetKey("string", "value", 0))
+	_, err := r.GetListRange("string", 0, -1)
+	assert.ErrorIs(t, err, ErrServer)
+	assert.False(t, IsUnavailable(err))
+
+	mr.Close()
+	err = r.SetKey("key", "value", time.Minute)
+	assert.ErrorIs(t, err, ErrConnection)
+	assert.True(t, IsUnavailable(err))
+
+	redisUp.Store(false)
+	defer redisUp.Store(true)
+	assert.True(t, IsUnavailable(r.SetKey("key", "value", time.Minute)))
+}
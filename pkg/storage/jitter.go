@@ -0,0 +1,30 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"math/rand"
+	"sync/atomic"
+	"time"
+)
+
+// ttlJitter is the fraction the timeouts of SetKey are randomly changed by, it is set by ConnectToRedis.
+var ttlJitter atomic.Value
+
+// jitterTTL changes timeout randomly within ±ttlJitter, so the keys set at the same time with the
+// same timeout don't expire all at once.
+func jitterTTL(timeout time.Duration) time.Duration {
+	jitter, _ := ttlJitter.Load().(float64)
+	if jitter <= 0 || timeout <= 0 {
+		return timeout
+	}
+
+	delta := time.Duration((rand.Float64()*2 - 1) * jitter * float64(timeout)) // nolint: gosec
+	if timeout+delta < time.Second {
+		return timeout
+	}
+
+	return timeout + delta
+}
@@ -0,0 +1,43 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedisCluster_TTLJitter(t *testing.T) {
+	mr, _ := newTestRedis(t)
+	defer ttlJitter.Store(float64(0))
+
+	r := &RedisCluster{KeyPrefix: "secret-"}
+	timeout := time.Hour
+
+	ttlJitter.Store(0.1)
+	ttls := map[time.Duration]bool{}
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		assert.Nil(t, r.SetKey(key, "value", timeout))
+
+		ttl := mr.TTL("secret-" + key)
+		assert.GreaterOrEqual(t, ttl, 54*time.Minute)
+		assert.LessOrEqual(t, ttl, 66*time.Minute)
+		ttls[ttl] = true
+	}
+	assert.Greater(t, len(ttls), 1)
+
+	// the keys without expiration are not changed
+	assert.Nil(t, r.SetKey("persistent", "value", 0))
+	assert.Equal(t, time.Duration(0), mr.TTL("secret-persistent"))
+
+	// the timeouts are kept as they are when the jitter is disabled
+	ttlJitter.Store(float64(0))
+	assert.Nil(t, r.SetKey("exact", "value", timeout))
+	assert.Equal(t, timeout, mr.TTL("secret-exact"))
+}
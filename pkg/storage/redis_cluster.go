@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -36,6 +37,12 @@ type Config struct {
 	EnableCluster         bool
 	UseSSL                bool
 	SSLInsecureSkipVerify bool
+	// Compression enables the gzip compression of the large values written by SetKey,
+	// the compressed values are always decompressed by GetKey and GetMultiKey.
+	Compression bool
+	// TTLJitter randomly changes the timeouts of SetKey within ±TTLJitter of them, e.g. 0.1 for ±10%,
+	// so the keys set with the same timeout don't expire all at once. It's disabled if not set.
+	TTLJitter float64
 }
 
 // ErrRedisIsDown is returned when we can't communicate with redis.
@@ -49,6 +56,10 @@ var (
 
 var disableRedis atomic.Value
 
+// resubscribeInterval is the time to wait before a lost subscription is
+// re-established.
+var resubscribeInterval = 10 * time.Second
+
 // DisableRedis very handy when testsing it allows to dynamically enable/disable talking with redisW.
 func DisableRedis(ok bool) {
 	if ok {
@@ -113,13 +124,28 @@ func connectSingleton(cache bool, config *Config) bool {
 
 // RedisCluster is a storage manager that uses the redis database.
 type RedisCluster struct {
+	// Name is the name of the redis instance connected by ConnectToNamedRedis
+	// or RegisterRedisInstance, the default connection is used if empty.
+	Name      string
 	KeyPrefix string
 	HashKeys  bool
 	IsCache   bool
+	// MaxPipelineSize limits the number of commands sent in one pipeline,
+	// DefaultMaxPipelineSize is used if not set.
+	MaxPipelineSize int
+	// RollingWindowMaxElements limits the number of the elements kept in a rolling window by
+	// SetRollingWindow, the oldest elements are removed. It's unlimited if not set.
+	RollingWindowMaxElements int64
 }
 
+// DefaultMaxPipelineSize is the default number of commands sent in one pipeline.
+const DefaultMaxPipelineSize = 500
+
 func clusterConnectionIsOpen(cluster RedisCluster) bool {
-	c := singleton(cluster.IsCache)
+	return connectionIsOpen(singleton(cluster.IsCache))
+}
+
+func connectionIsOpen(c redis.UniversalClient) bool {
 	testKey := "redis-test-" + uuid.Must(uuid.NewV4()).String()
 	if err := c.Set(testKey, "test", time.Second).Err(); err != nil {
 		log.Warnf("Error trying to set test key: %s", err.Error())
@@ -137,6 +163,9 @@ func clusterConnectionIsOpen(cluster RedisCluster) bool {
 
 // ConnectToRedis starts a go routine that periodically tries to connect to redis.
 func ConnectToRedis(ctx context.Context, config *Config) {
+	compressValues.Store(config.Compression)
+	ttlJitter.Store(config.TTLJitter)
+
 	tick := time.NewTicker(time.Second)
 	defer tick.Stop()
 	c := []RedisCluster{
@@ -358,7 +387,17 @@ func (r *RedisCluster) Connect() bool {
 	return true
 }
 
+// singleton returns the client of the redis instance, it returns nil for an unknown instance.
 func (r *RedisCluster) singleton() redis.UniversalClient {
+	if r.Name != "" {
+		instance, ok := lookupRedisInstance(r.Name)
+		if !ok {
+			return nil
+		}
+
+		return instance.getClient()
+	}
+
 	return singleton(r.IsCache)
 }
 
@@ -379,8 +418,17 @@ func (r *RedisCluster) cleanKey(keyName string) string {
 	return strings.Replace(keyName, r.KeyPrefix, "", 1)
 }
 
+// up returns ErrRedisIsDown if the redis instance is unknown or not connected.
 func (r *RedisCluster) up() error {
-	if !Connected() {
+	if r.Name != "" {
+		if !ConnectedInstance(r.Name) {
+			return ErrRedisIsDown
+		}
+	} else if !Connected() {
+		return ErrRedisIsDown
+	}
+
+	if r.singleton() == nil {
 		return ErrRedisIsDown
 	}
 
@@ -402,7 +450,7 @@ func (r *RedisCluster) GetKey(keyName string) (string, error) {
 		return "", ErrKeyNotFound
 	}
 
-	return value, nil
+	return decompressValue(value), nil
 }
 
 // GetMultiKey gets multiple keys from the database.
@@ -455,6 +503,10 @@ func (r *RedisCluster) GetMultiKey(keys []string) ([]string, error) {
 		}
 	}
 
+	for i, val := range result {
+		result[i] = decompressValue(val)
+	}
+
 	for _, val := range result {
 		if val != "" {
 			return result, nil
@@ -471,7 +523,7 @@ func (r *RedisCluster) GetKeyTTL(keyName string) (ttl int64, err error) {
 	}
 	duration, err := r.singleton().TTL(r.fixKey(keyName)).Result()
 
-	return int64(duration.Seconds()), err
+	return int64(duration.Seconds()), wrapRedisError(err)
 }
 
 // GetRawKey return the value of the given key.
@@ -516,7 +568,7 @@ func (r *RedisCluster) SetExp(keyName string, timeout time.Duration) error {
 		log.Errorf("Could not EXPIRE key: %s", err.Error())
 	}
 
-	return err
+	return wrapRedisError(err)
 }
 
 // SetKey will create (or update) a key value in the store.
@@ -527,11 +579,15 @@ func (r *RedisCluster) SetKey(keyName, session string, timeout time.Duration) er
 	if err := r.up(); err != nil {
 		return err
 	}
-	err := r.singleton().Set(r.fixKey(keyName), session, timeout).Err()
+	if compressionEnabled() {
+		session = compressValue(session)
+	}
+
+	err := r.singleton().Set(r.fixKey(keyName), session, jitterTTL(timeout)).Err()
 	if err != nil {
 		log.Errorf("Error trying to set value: %s", err.Error())
 
-		return err
+		return wrapRedisError(err)
 	}
 
 	return nil
@@ -546,23 +602,43 @@ func (r *RedisCluster) SetRawKey(keyName, session string, timeout time.Duration)
 	if err != nil {
 		log.Errorf("Error trying to set value: %s", err.Error())
 
-		return err
+		return wrapRedisError(err)
 	}
 
 	return nil
 }
 
+// SetRawKeyIfNotExists set the value of the given key only if the key doesn't exist,
+// it reports whether the key is set.
+func (r *RedisCluster) SetRawKeyIfNotExists(keyName, value string, timeout time.Duration) (bool, error) {
+	if err := r.up(); err != nil {
+		return false, err
+	}
+	ok, err := r.singleton().SetNX(keyName, value, timeout).Result()
+	if err != nil {
+		log.Errorf("Error trying to set value: %s", err.Error())
+
+		return false, wrapRedisError(err)
+	}
+
+	return ok, nil
+}
+
 // Decrement will decrement a key in redis.
-func (r *RedisCluster) Decrement(keyName string) {
+func (r *RedisCluster) Decrement(keyName string) error {
 	keyName = r.fixKey(keyName)
 	log.Debugf("Decrementing key: %s", keyName)
 	if err := r.up(); err != nil {
-		return
+		return err
 	}
 	err := r.singleton().Decr(keyName).Err()
 	if err != nil {
 		log.Errorf("Error trying to decrement value: %s", err.Error())
+
+		return wrapRedisError(err)
 	}
+
+	return nil
 }
 
 // IncrememntWithExpire will increment a key in redis.
@@ -729,6 +805,101 @@ func (r *RedisCluster) GetKeysAndValuesWithFilter(filter string) map[string]stri
 	return m
 }
 
+// ForEachKeyValue calls fn with every key matching the filter and its value, like
+// GetKeysAndValuesWithFilter, but the keys are scanned and their values fetched in batches of
+// MaxPipelineSize, so the keyspace is never held in memory. The keys which are removed during
+// the iteration or don't hold a string are skipped. fn is never called concurrently, the
+// iteration stops at the first error returned by fn, which is returned.
+func (r *RedisCluster) ForEachKeyValue(filter string, fn func(key, value string) error) error {
+	if err := r.up(); err != nil {
+		return err
+	}
+
+	filterHash := ""
+	if filter != "" {
+		filterHash = r.hashKey(filter)
+	}
+	searchStr := r.KeyPrefix + filterHash + "*"
+
+	var mu sync.Mutex
+	forEach := func(client *redis.Client) error {
+		var cursor uint64
+		for {
+			keys, next, err := client.Scan(cursor, searchStr, int64(r.maxPipelineSize())).Result()
+			if err != nil {
+				return wrapRedisError(err)
+			}
+
+			// SCAN may return more keys than its COUNT
+			size := r.maxPipelineSize()
+			for start := 0; start < len(keys); start += size {
+				end := start + size
+				if end > len(keys) {
+					end = len(keys)
+				}
+
+				if err := r.forEachValue(client, keys[start:end], func(key, value string) error {
+					mu.Lock()
+					defer mu.Unlock()
+
+					return fn(key, value)
+				}); err != nil {
+					return err
+				}
+			}
+
+			if next == 0 {
+				return nil
+			}
+			cursor = next
+		}
+	}
+
+	var err error
+	switch v := r.singleton().(type) {
+	case *redis.ClusterClient:
+		err = v.ForEachMaster(forEach)
+	case *redis.Client:
+		err = forEach(v)
+	}
+
+	return err
+}
+
+// forEachValue fetches the values of the keys in a pipeline and calls fn with them, the
+// error of fn is returned unchanged.
+func (r *RedisCluster) forEachValue(client *redis.Client, keys []string, fn func(key, value string) error) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	pipe := client.Pipeline()
+	cmds := make([]*redis.StringCmd, 0, len(keys))
+	for _, key := range keys {
+		cmds = append(cmds, pipe.Get(key))
+	}
+
+	// the errors of the keys are checked one by one
+	_, _ = pipe.Exec()
+
+	for i, cmd := range cmds {
+		value, err := cmd.Result()
+		if err != nil {
+			if errors.Is(err, redis.Nil) || strings.HasPrefix(err.Error(), "WRONGTYPE") {
+				continue
+			}
+
+			return wrapRedisError(err)
+		}
+
+		if err := fn(r.cleanKey(keys[i]), decompressValue(value)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // GetKeysAndValues will return all keys and their values - not to be used lightly.
 func (r *RedisCluster) GetKeysAndValues() map[string]string {
 	return r.GetKeysAndValuesWithFilter("")
@@ -895,28 +1066,118 @@ func (r *RedisCluster) DeleteKeys(keys []string) bool {
 // StartPubSubHandler will listen for a signal and run the callback for
 // every subscription and message event.
 func (r *RedisCluster) StartPubSubHandler(channel string, callback func(interface{})) error {
-	if err := r.up(); err != nil {
+	pubsub, err := r.subscribe(func(client redis.UniversalClient) *redis.PubSub {
+		return client.Subscribe(channel)
+	})
+	if err != nil {
 		return err
 	}
+	defer pubsub.Close()
+
+	for msg := range pubsub.Channel() {
+		callback(msg)
+	}
+
+	return nil
+}
+
+// SubscribeChannel subscribes to the channel and delivers its messages on the
+// returned go channel. The subscription is re-established when it's lost, until
+// ctx is cancelled, then the returned channel is closed.
+func (r *RedisCluster) SubscribeChannel(ctx context.Context, channel string) (<-chan *redis.Message, error) {
+	return r.subscribeChannel(ctx, channel, func(client redis.UniversalClient) *redis.PubSub {
+		return client.Subscribe(channel)
+	})
+}
+
+// PSubscribeChannel works like SubscribeChannel, but subscribes to all the
+// channels matching the glob-style pattern, e.g. iam.cluster.*. The originating
+// channel of a message is set in its Channel field.
+func (r *RedisCluster) PSubscribeChannel(ctx context.Context, pattern string) (<-chan *redis.Message, error) {
+	return r.subscribeChannel(ctx, pattern, func(client redis.UniversalClient) *redis.PubSub {
+		return client.PSubscribe(pattern)
+	})
+}
+
+func (r *RedisCluster) subscribeChannel(
+	ctx context.Context,
+	name string,
+	subscribeFn func(redis.UniversalClient) *redis.PubSub,
+) (<-chan *redis.Message, error) {
+	pubsub, err := r.subscribe(subscribeFn)
+	if err != nil {
+		return nil, err
+	}
+
+	msgs := make(chan *redis.Message)
+	go func() {
+		defer close(msgs)
+
+		for {
+			forwardMessages(ctx, pubsub, msgs)
+
+			for pubsub = nil; pubsub == nil; {
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(resubscribeInterval):
+				}
+
+				if pubsub, err = r.subscribe(subscribeFn); err != nil {
+					log.Warnf("Resubscribing to %s failed: %s", name, err.Error())
+				}
+			}
+		}
+	}()
+
+	return msgs, nil
+}
+
+// subscribe subscribes with subscribeFn and waits for the confirmation of redis.
+func (r *RedisCluster) subscribe(subscribeFn func(redis.UniversalClient) *redis.PubSub) (*redis.PubSub, error) {
+	if err := r.up(); err != nil {
+		return nil, err
+	}
 	client := r.singleton()
 	if client == nil {
-		return errors.New("redis connection failed")
+		return nil, ErrConnection
 	}
 
-	pubsub := client.Subscribe(channel)
-	defer pubsub.Close()
-
+	pubsub := subscribeFn(client)
 	if _, err := pubsub.Receive(); err != nil {
 		log.Errorf("Error while receiving pubsub message: %s", err.Error())
+		_ = pubsub.Close()
 
-		return err
+		return nil, wrapRedisError(err)
 	}
 
-	for msg := range pubsub.Channel() {
-		callback(msg)
-	}
+	return pubsub, nil
+}
 
-	return nil
+// forwardMessages sends the messages of pubsub to msgs until ctx is cancelled
+// or the subscription is closed, then the subscription is released.
+func forwardMessages(ctx context.Context, pubsub *redis.PubSub, msgs chan<- *redis.Message) {
+	defer pubsub.Close()
+
+	ch := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				log.Warn("Subscription to redis is closed, resubscribing")
+
+				return
+			}
+
+			select {
+			case msgs <- msg:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
 }
 
 // Publish publish a message to the specify channel.
@@ -928,7 +1189,7 @@ func (r *RedisCluster) Publish(channel, message string) error {
 	if err != nil {
 		log.Errorf("Error trying to set value: %s", err.Error())
 
-		return err
+		return wrapRedisError(err)
 	}
 
 	return nil
@@ -974,21 +1235,100 @@ func (r *RedisCluster) GetAndDeleteSet(keyName string) []interface{} {
 	return result
 }
 
+// GetListRangeAndTrim atomically reads and removes up to count elements from the
+// head of the list, all the elements are read if count is not positive. Elements
+// appended after the read are preserved.
+func (r *RedisCluster) GetListRangeAndTrim(keyName string, count int64) []interface{} {
+	if err := r.up(); err != nil {
+		return nil
+	}
+	fixedKey := r.fixKey(keyName)
+	client := r.singleton()
+
+	if count <= 0 {
+		llen, err := client.LLen(fixedKey).Result()
+		if err != nil {
+			log.Errorf("Failed to get the length of list %s: %s", fixedKey, err.Error())
+
+			return nil
+		}
+		count = llen
+	}
+	if count == 0 {
+		return nil
+	}
+
+	var lrange *redis.StringSliceCmd
+	_, err := client.TxPipelined(func(pipe redis.Pipeliner) error {
+		lrange = pipe.LRange(fixedKey, 0, count-1)
+		pipe.LTrim(fixedKey, count, -1)
+
+		return nil
+	})
+	if err != nil {
+		log.Errorf("Multi command failed: %s", err.Error())
+
+		return nil
+	}
+
+	vals := lrange.Val()
+	result := make([]interface{}, len(vals))
+	for i, v := range vals {
+		result[i] = v
+	}
+
+	return result
+}
+
+// TrimList caps the length of the list to maxLength by removing the oldest
+// elements, and returns the number of removed elements.
+func (r *RedisCluster) TrimList(keyName string, maxLength int64) (int64, error) {
+	if err := r.up(); err != nil {
+		return 0, err
+	}
+	fixedKey := r.fixKey(keyName)
+
+	var llen *redis.IntCmd
+	_, err := r.singleton().TxPipelined(func(pipe redis.Pipeliner) error {
+		llen = pipe.LLen(fixedKey)
+		pipe.LTrim(fixedKey, -maxLength, -1)
+
+		return nil
+	})
+	if err != nil {
+		return 0, wrapRedisError(err)
+	}
+
+	if trimmed := llen.Val() - maxLength; trimmed > 0 {
+		return trimmed, nil
+	}
+
+	return 0, nil
+}
+
 // AppendToSet append a value to the key set.
-func (r *RedisCluster) AppendToSet(keyName, value string) {
+func (r *RedisCluster) AppendToSet(keyName, value string) error {
 	fixedKey := r.fixKey(keyName)
 	log.Debug("Pushing to raw key list", log.String("keyName", keyName))
 	log.Debug("Appending to fixed key list", log.String("fixedKey", fixedKey))
 	if err := r.up(); err != nil {
-		return
+		return err
 	}
 	if err := r.singleton().RPush(fixedKey, value).Err(); err != nil {
 		log.Errorf("Error trying to append to set keys: %s", err.Error())
+
+		return wrapRedisError(err)
 	}
+
+	return nil
 }
 
 // Exists check if keyName exists.
 func (r *RedisCluster) Exists(keyName string) (bool, error) {
+	if err := r.up(); err != nil {
+		return false, err
+	}
+
 	fixedKey := r.fixKey(keyName)
 	log.Debug("Checking if exists", log.String("keyName", fixedKey))
 
@@ -996,7 +1336,7 @@ func (r *RedisCluster) Exists(keyName string) (bool, error) {
 	if err != nil {
 		log.Errorf("Error trying to check if key exists: %s", err.Error())
 
-		return false, err
+		return false, wrapRedisError(err)
 	}
 	if exists == 1 {
 		return true, nil
@@ -1005,8 +1345,52 @@ func (r *RedisCluster) Exists(keyName string) (bool, error) {
 	return false, nil
 }
 
+// ExistsMulti reports whether each of the keys exists, the EXISTS commands are pipelined
+// so that they work with a redis cluster where the keys live in different slots.
+func (r *RedisCluster) ExistsMulti(keys []string) (map[string]bool, error) {
+	result := make(map[string]bool, len(keys))
+	if len(keys) == 0 {
+		return result, nil
+	}
+
+	if err := r.up(); err != nil {
+		return nil, err
+	}
+	client := r.singleton()
+
+	size := r.maxPipelineSize()
+	for start := 0; start < len(keys); start += size {
+		end := start + size
+		if end > len(keys) {
+			end = len(keys)
+		}
+
+		pipe := client.Pipeline()
+		cmds := make([]*redis.IntCmd, 0, end-start)
+		for _, key := range keys[start:end] {
+			cmds = append(cmds, pipe.Exists(r.fixKey(key)))
+		}
+
+		if _, err := pipe.Exec(); err != nil {
+			log.Errorf("Error trying to check if keys exist: %s", err.Error())
+
+			return nil, wrapRedisError(err)
+		}
+
+		for i, cmd := range cmds {
+			result[keys[start+i]] = cmd.Val() == 1
+		}
+	}
+
+	return result, nil
+}
+
 // RemoveFromList delete an value from a list idetinfied with the keyName.
 func (r *RedisCluster) RemoveFromList(keyName, value string) error {
+	if err := r.up(); err != nil {
+		return err
+	}
+
 	fixedKey := r.fixKey(keyName)
 
 	log.Debug(
@@ -1025,7 +1409,7 @@ func (r *RedisCluster) RemoveFromList(keyName, value string) error {
 			log.String("error", err.Error()),
 		)
 
-		return err
+		return wrapRedisError(err)
 	}
 
 	return nil
@@ -1033,6 +1417,10 @@ func (r *RedisCluster) RemoveFromList(keyName, value string) error {
 
 // GetListRange gets range of elements of list identified by keyName.
 func (r *RedisCluster) GetListRange(keyName string, from, to int64) ([]string, error) {
+	if err := r.up(); err != nil {
+		return nil, err
+	}
+
 	fixedKey := r.fixKey(keyName)
 
 	elements, err := r.singleton().LRange(fixedKey, from, to).Result()
@@ -1049,33 +1437,46 @@ func (r *RedisCluster) GetListRange(keyName string, from, to int64) ([]string, e
 			log.String("error", err.Error()),
 		)
 
-		return nil, err
+		return nil, wrapRedisError(err)
 	}
 
 	return elements, nil
 }
 
 // AppendToSetPipelined append values to redis pipeline.
-func (r *RedisCluster) AppendToSetPipelined(key string, values [][]byte) {
+// The first failed batch aborts the append and its error is returned, batches
+// sent before it stay in redis.
+func (r *RedisCluster) AppendToSetPipelined(key string, values [][]byte) error {
 	if len(values) == 0 {
-		return
+		return nil
 	}
 
 	fixedKey := r.fixKey(key)
 	if err := r.up(); err != nil {
 		log.Debug(err.Error())
 
-		return
+		return err
 	}
 	client := r.singleton()
 
-	pipe := client.Pipeline()
-	for _, val := range values {
-		pipe.RPush(fixedKey, val)
-	}
+	// split the values into batches to avoid sending an enormous pipeline to redis
+	size := r.maxPipelineSize()
+	for start := 0; start < len(values); start += size {
+		end := start + size
+		if end > len(values) {
+			end = len(values)
+		}
 
-	if _, err := pipe.Exec(); err != nil {
-		log.Errorf("Error trying to append to set keys: %s", err.Error())
+		pipe := client.Pipeline()
+		for _, val := range values[start:end] {
+			pipe.RPush(fixedKey, val)
+		}
+
+		if _, err := pipe.Exec(); err != nil {
+			log.Errorf("Error trying to append to set keys: %s", err.Error())
+
+			return wrapRedisError(err)
+		}
 	}
 
 	// if we need to set an expiration time
@@ -1086,6 +1487,16 @@ func (r *RedisCluster) AppendToSetPipelined(key string, values [][]byte) {
 			_ = r.SetExp(key, time.Duration(storageExpTime)*time.Second)
 		}
 	}
+
+	return nil
+}
+
+func (r *RedisCluster) maxPipelineSize() int {
+	if r.MaxPipelineSize > 0 {
+		return r.MaxPipelineSize
+	}
+
+	return DefaultMaxPipelineSize
 }
 
 // GetSet return key set value.
@@ -1099,7 +1510,7 @@ func (r *RedisCluster) GetSet(keyName string) (map[string]string, error) {
 	if err != nil {
 		log.Errorf("Error trying to get key set: %s", err.Error())
 
-		return nil, err
+		return nil, wrapRedisError(err)
 	}
 
 	result := make(map[string]string)
@@ -1111,31 +1522,39 @@ func (r *RedisCluster) GetSet(keyName string) (map[string]string, error) {
 }
 
 // AddToSet add value to key set.
-func (r *RedisCluster) AddToSet(keyName, value string) {
+func (r *RedisCluster) AddToSet(keyName, value string) error {
 	log.Debugf("Pushing to raw key set: %s", keyName)
 	log.Debugf("Pushing to fixed key set: %s", r.fixKey(keyName))
 	if err := r.up(); err != nil {
-		return
+		return err
 	}
 	err := r.singleton().SAdd(r.fixKey(keyName), value).Err()
 	if err != nil {
 		log.Errorf("Error trying to append keys: %s", err.Error())
+
+		return wrapRedisError(err)
 	}
+
+	return nil
 }
 
 // RemoveFromSet remove a value from key set.
-func (r *RedisCluster) RemoveFromSet(keyName, value string) {
+func (r *RedisCluster) RemoveFromSet(keyName, value string) error {
 	log.Debugf("Removing from raw key set: %s", keyName)
 	log.Debugf("Removing from fixed key set: %s", r.fixKey(keyName))
 	if err := r.up(); err != nil {
 		log.Debug(err.Error())
 
-		return
+		return err
 	}
 	err := r.singleton().SRem(r.fixKey(keyName), value).Err()
 	if err != nil {
 		log.Errorf("Error trying to remove keys: %s", err.Error())
+
+		return wrapRedisError(err)
 	}
+
+	return nil
 }
 
 // IsMemberOfSet return whether the given value belong to key set.
@@ -1158,12 +1577,15 @@ func (r *RedisCluster) IsMemberOfSet(keyName, value string) bool {
 }
 
 // SetRollingWindow will append to a sorted set in redis and extract a timed window of values.
+// At most RollingWindowMaxElements elements are kept in the window if it's set. The key is prefixed
+// (and hashed) like the other keys.
 func (r *RedisCluster) SetRollingWindow(
 	keyName string,
 	per int64,
 	valueOverride string,
 	pipeline bool,
 ) (int, []interface{}) {
+	keyName = r.fixKey(keyName)
 	log.Debugf("Incrementing raw key: %s", keyName)
 	if err := r.up(); err != nil {
 		log.Debug(err.Error())
@@ -1194,6 +1616,9 @@ func (r *RedisCluster) SetRollingWindow(
 		}
 
 		pipe.ZAdd(keyName, &element)
+		if r.RollingWindowMaxElements > 0 {
+			pipe.ZRemRangeByRank(keyName, 0, -r.RollingWindowMaxElements-1)
+		}
 		pipe.Expire(keyName, time.Duration(per)*time.Second)
 
 		return nil
@@ -1231,8 +1656,9 @@ func (r *RedisCluster) SetRollingWindow(
 	return intVal, result
 }
 
-// GetRollingWindow return rolling window.
+// GetRollingWindow return rolling window, the key is prefixed (and hashed) like the other keys.
 func (r RedisCluster) GetRollingWindow(keyName string, per int64, pipeline bool) (int, []interface{}) {
+	keyName = r.fixKey(keyName)
 	if err := r.up(); err != nil {
 		log.Debug(err.Error())
 
@@ -1310,6 +1736,10 @@ func (r *RedisCluster) AddToSortedSet(keyName, value string, score float64) {
 
 // GetSortedSetRange gets range of elements of sorted set identified by keyName.
 func (r *RedisCluster) GetSortedSetRange(keyName, scoreFrom, scoreTo string) ([]string, []float64, error) {
+	if err := r.up(); err != nil {
+		return nil, nil, err
+	}
+
 	fixedKey := r.fixKey(keyName)
 	log.Debug(
 		"Getting sorted set range",
@@ -1337,7 +1767,7 @@ func (r *RedisCluster) GetSortedSetRange(keyName, scoreFrom, scoreTo string) ([]
 			log.String("error", err.Error()),
 		)
 
-		return nil, nil, err
+		return nil, nil, wrapRedisError(err)
 	}
 
 	if len(values) == 0 {
@@ -1357,6 +1787,10 @@ func (r *RedisCluster) GetSortedSetRange(keyName, scoreFrom, scoreTo string) ([]
 
 // RemoveSortedSetRange removes range of elements from sorted set identified by keyName.
 func (r *RedisCluster) RemoveSortedSetRange(keyName, scoreFrom, scoreTo string) error {
+	if err := r.up(); err != nil {
+		return err
+	}
+
 	fixedKey := r.fixKey(keyName)
 
 	log.Debug(
@@ -1380,7 +1814,7 @@ func (r *RedisCluster) RemoveSortedSetRange(keyName, scoreFrom, scoreTo string)
 			log.String("error", err.Error()),
 		)
 
-		return err
+		return wrapRedisError(err)
 	}
 
 	return nil
@@ -0,0 +1,405 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v7"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestRedis starts an in-memory redis server and uses it as the redis
+// connection of the storage package.
+func newTestRedis(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	singlePool.Store(redis.UniversalClient(client))
+	redisUp.Store(true)
+
+	return mr, client
+}
+
+type pipelineCounter struct {
+	pipelines int
+}
+
+func (h *pipelineCounter) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	return ctx, nil
+}
+
+func (h *pipelineCounter) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	return nil
+}
+
+func (h *pipelineCounter) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	h.pipelines++
+
+	return ctx, nil
+}
+
+func (h *pipelineCounter) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	return nil
+}
+
+func TestRedisCluster_AppendToSetPipelined(t *testing.T) {
+	mr, client := newTestRedis(t)
+	counter := &pipelineCounter{}
+	client.AddHook(counter)
+
+	values := make([][]byte, 1001)
+	for i := range values {
+		values[i] = []byte(fmt.Sprintf("record-%d", i))
+	}
+
+	r := &RedisCluster{KeyPrefix: "analytics-", MaxPipelineSize: 100}
+	assert.Nil(t, r.AppendToSetPipelined("iam-system-analytics", values))
+
+	assert.Equal(t, 11, counter.pipelines)
+
+	list, err := mr.List("analytics-iam-system-analytics")
+	assert.Nil(t, err)
+	assert.Len(t, list, len(values))
+	assert.Equal(t, "record-0", list[0])
+	assert.Equal(t, "record-1000", list[1000])
+}
+
+func TestRedisCluster_MutatorErrors(t *testing.T) {
+	mr, _ := newTestRedis(t)
+	r := &RedisCluster{KeyPrefix: "test-"}
+
+	mutators := map[string]func() error{
+		"AddToSet":      func() error { return r.AddToSet("set", "value") },
+		"RemoveFromSet": func() error { return r.RemoveFromSet("set", "value") },
+		"AppendToSet":   func() error { return r.AppendToSet("list", "value") },
+		"Decrement":     func() error { return r.Decrement("counter") },
+		"AppendToSetPipelined": func() error {
+			return r.AppendToSetPipelined("list", [][]byte{[]byte("value")})
+		},
+	}
+
+	for name, mutate := range mutators {
+		assert.Nil(t, mutate(), name)
+	}
+
+	// the connection is marked as down
+	redisUp.Store(false)
+	for name, mutate := range mutators {
+		assert.Equal(t, ErrRedisIsDown, mutate(), name)
+	}
+
+	// the connection is believed to be up, but the server is gone
+	redisUp.Store(true)
+	mr.Close()
+	for name, mutate := range mutators {
+		assert.NotNil(t, mutate(), name)
+	}
+}
+
+func TestRedisCluster_SubscribeChannel(t *testing.T) {
+	mr, _ := newTestRedis(t)
+	r := &RedisCluster{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	msgs, err := r.SubscribeChannel(ctx, "iam.cluster.notifications")
+	assert.Nil(t, err)
+
+	for _, payload := range []string{"first", "second"} {
+		assert.Nil(t, r.Publish("iam.cluster.notifications", payload))
+
+		select {
+		case msg := <-msgs:
+			assert.Equal(t, "iam.cluster.notifications", msg.Channel)
+			assert.Equal(t, payload, msg.Payload)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("message %q was not received", payload)
+		}
+	}
+
+	assert.Equal(t, 1, mr.PubSubNumSub("iam.cluster.notifications")["iam.cluster.notifications"])
+
+	cancel()
+	select {
+	case _, ok := <-msgs:
+		assert.False(t, ok)
+	case <-time.After(5 * time.Second):
+		t.Fatal("channel was not closed after the context was cancelled")
+	}
+}
+
+func TestRedisCluster_PSubscribeChannel(t *testing.T) {
+	_, _ = newTestRedis(t)
+	r := &RedisCluster{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	msgs, err := r.PSubscribeChannel(ctx, "iam.cluster.*")
+	assert.Nil(t, err)
+
+	for _, channel := range []string{"iam.cluster.policy", "iam.cluster.secret"} {
+		assert.Nil(t, r.Publish(channel, "changed"))
+
+		select {
+		case msg := <-msgs:
+			assert.Equal(t, "iam.cluster.*", msg.Pattern)
+			assert.Equal(t, channel, msg.Channel)
+			assert.Equal(t, "changed", msg.Payload)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("message on %s was not received", channel)
+		}
+	}
+
+	// channels which don't match the pattern are not delivered
+	assert.Nil(t, r.Publish("iam.other", "changed"))
+	select {
+	case msg := <-msgs:
+		t.Fatalf("unexpected message on %s", msg.Channel)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestRedisCluster_SubscribeChannel_Down(t *testing.T) {
+	_, _ = newTestRedis(t)
+	redisUp.Store(false)
+	defer redisUp.Store(true)
+
+	msgs, err := (&RedisCluster{}).SubscribeChannel(context.Background(), "iam.cluster.notifications")
+	assert.Equal(t, ErrRedisIsDown, err)
+	assert.Nil(t, msgs)
+}
+
+func TestRedisCluster_TrimList(t *testing.T) {
+	mr, _ := newTestRedis(t)
+
+	r := &RedisCluster{KeyPrefix: "analytics-"}
+	for i := 0; i < 20; i++ {
+		_, err := mr.Push("analytics-iam-system-analytics", fmt.Sprintf("record-%d", i))
+		assert.Nil(t, err)
+	}
+
+	trimmed, err := r.TrimList("iam-system-analytics", 5)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(15), trimmed)
+
+	list, _ := mr.List("analytics-iam-system-analytics")
+	assert.Equal(t, []string{"record-15", "record-16", "record-17", "record-18", "record-19"}, list)
+
+	// a list under the cap is untouched
+	trimmed, err = r.TrimList("iam-system-analytics", 10)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), trimmed)
+
+	list, _ = mr.List("analytics-iam-system-analytics")
+	assert.Len(t, list, 5)
+}
+
+func TestRedisCluster_GetListRangeAndTrim(t *testing.T) {
+	_, client := newTestRedis(t)
+
+	r := &RedisCluster{KeyPrefix: "analytics-"}
+	const total = 1000
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < total; i++ {
+			client.RPush("analytics-iam-system-analytics", fmt.Sprintf("record-%d", i))
+		}
+	}()
+
+	var got []string
+	consume := func(count int64) {
+		for _, v := range r.GetListRangeAndTrim("iam-system-analytics", count) {
+			got = append(got, v.(string))
+		}
+	}
+
+	for {
+		select {
+		case <-done:
+			consume(0)
+
+			assert.Len(t, got, total)
+			for i, v := range got {
+				assert.Equal(t, fmt.Sprintf("record-%d", i), v)
+			}
+
+			return
+		default:
+			consume(7)
+		}
+	}
+}
+
+func TestRedisCluster_SetRawKeyIfNotExists(t *testing.T) {
+	mr, _ := newTestRedis(t)
+	r := &RedisCluster{}
+
+	ok, err := r.SetRawKeyIfNotExists("key", "first", time.Minute)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	ok, err = r.SetRawKeyIfNotExists("key", "second", time.Minute)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	value, _ := mr.Get("key")
+	assert.Equal(t, "first", value)
+	assert.Equal(t, time.Minute, mr.TTL("key"))
+}
+
+func TestRedisCluster_SetRollingWindowMaxElements(t *testing.T) {
+	mr, _ := newTestRedis(t)
+
+	r := &RedisCluster{RollingWindowMaxElements: 3}
+	for i := 0; i < 10; i++ {
+		count, _ := r.SetRollingWindow("window", 60, fmt.Sprintf("hit-%d", i), false)
+		// the count is the number of the elements before the hit
+		if i < 3 {
+			assert.Equal(t, i, count)
+		} else {
+			assert.Equal(t, 3, count)
+		}
+	}
+
+	// the newest elements are kept
+	members, err := mr.ZMembers("window")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"hit-7", "hit-8", "hit-9"}, members)
+
+	// the window is unlimited by default
+	r = &RedisCluster{}
+	for i := 0; i < 10; i++ {
+		r.SetRollingWindow("unlimited", 60, fmt.Sprintf("hit-%d", i), true)
+	}
+
+	members, _ = mr.ZMembers("unlimited")
+	assert.Len(t, members, 10)
+}
+
+func TestRedisCluster_RollingWindowKeyPrefix(t *testing.T) {
+	mr, _ := newTestRedis(t)
+
+	tenantA := &RedisCluster{KeyPrefix: "a-"}
+	tenantB := &RedisCluster{KeyPrefix: "b-"}
+
+	tenantA.SetRollingWindow("window", 60, "hit-1", false)
+	tenantA.SetRollingWindow("window", 60, "hit-2", false)
+	count, _ := tenantB.SetRollingWindow("window", 60, "hit-1", false)
+	// the windows of the prefixes are isolated
+	assert.Equal(t, 0, count)
+
+	count, _ = tenantA.GetRollingWindow("window", 60, false)
+	assert.Equal(t, 2, count)
+	count, _ = tenantB.GetRollingWindow("window", 60, true)
+	assert.Equal(t, 1, count)
+
+	assert.True(t, mr.Exists("a-window"))
+	assert.True(t, mr.Exists("b-window"))
+	assert.False(t, mr.Exists("window"))
+
+	// the keys are hashed too
+	hashed := &RedisCluster{KeyPrefix: "h-", HashKeys: true}
+	hashed.SetRollingWindow("window", 60, "hit-1", false)
+	assert.True(t, mr.Exists("h-"+HashStr("window")))
+}
+
+func TestRedisCluster_ExistsMulti(t *testing.T) {
+	mr, client := newTestRedis(t)
+	counter := &pipelineCounter{}
+	client.AddHook(counter)
+
+	r := &RedisCluster{KeyPrefix: "session-", MaxPipelineSize: 2}
+	assert.Nil(t, mr.Set("session-a", "1"))
+	assert.Nil(t, mr.Set("session-c", "1"))
+	// the keys without the prefix don't count
+	assert.Nil(t, mr.Set("b", "1"))
+
+	exists, err := r.ExistsMulti([]string{"a", "b", "c", "d", "a"})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]bool{"a": true, "b": false, "c": true, "d": false}, exists)
+	assert.Equal(t, 3, counter.pipelines)
+
+	exists, err = r.ExistsMulti(nil)
+	assert.Nil(t, err)
+	assert.Empty(t, exists)
+
+	redisUp.Store(false)
+	_, err = r.ExistsMulti([]string{"a"})
+	assert.Equal(t, ErrRedisIsDown, err)
+}
+
+type pipelineSizeRecorder struct {
+	pipelineCounter
+	maxSize int
+}
+
+func (h *pipelineSizeRecorder) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	if len(cmds) > h.maxSize {
+		h.maxSize = len(cmds)
+	}
+
+	return h.pipelineCounter.BeforeProcessPipeline(ctx, cmds)
+}
+
+func TestRedisCluster_ForEachKeyValue(t *testing.T) {
+	mr, client := newTestRedis(t)
+	recorder := &pipelineSizeRecorder{}
+	client.AddHook(recorder)
+
+	const total = 2500
+	for i := 0; i < total; i++ {
+		assert.Nil(t, mr.Set(fmt.Sprintf("session-%d", i), fmt.Sprintf("value-%d", i)))
+	}
+	// the keys of other prefixes and the keys not holding a string are skipped
+	assert.Nil(t, mr.Set("other-1", "value"))
+	_, _ = mr.Push("session-list", "value")
+
+	r := &RedisCluster{KeyPrefix: "session-", MaxPipelineSize: 100}
+
+	seen := make(map[string]bool, total)
+	err := r.ForEachKeyValue("", func(key, value string) error {
+		assert.Equal(t, "value-"+key, value)
+		seen[key] = true
+
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Len(t, seen, total)
+	// the values are fetched in batches
+	assert.GreaterOrEqual(t, recorder.pipelines, total/100)
+	assert.LessOrEqual(t, recorder.maxSize, 100)
+
+	// the iteration stops at the first error of the callback
+	stop := fmt.Errorf("stop")
+	calls := 0
+	err = r.ForEachKeyValue("", func(key, value string) error {
+		calls++
+
+		return stop
+	})
+	assert.Equal(t, stop, err)
+	assert.Equal(t, 1, calls)
+
+	// the error of the callback is not classified as a redis error
+	err = r.ForEachKeyValue("", func(key, value string) error {
+		return context.DeadlineExceeded
+	})
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	redisUp.Store(false)
+	assert.Equal(t, ErrRedisIsDown, r.ForEachKeyValue("", func(key, value string) error { return nil }))
+}
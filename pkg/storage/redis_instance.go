@@ -0,0 +1,97 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+
+	"github.com/marmotedu/iam/pkg/log"
+)
+
+// redisInstance holds the client and the connection state of a named redis
+// deployment, e.g. a redis dedicated to analytics.
+type redisInstance struct {
+	client atomic.Value // redis.UniversalClient
+	up     atomic.Value // bool
+}
+
+var redisInstances sync.Map // name -> *redisInstance
+
+func getRedisInstance(name string) *redisInstance {
+	v, _ := redisInstances.LoadOrStore(name, &redisInstance{})
+
+	return v.(*redisInstance)
+}
+
+// lookupRedisInstance returns the named redis instance if it is registered or being connected.
+func lookupRedisInstance(name string) (*redisInstance, bool) {
+	v, ok := redisInstances.Load(name)
+	if !ok {
+		return nil, false
+	}
+
+	return v.(*redisInstance), true
+}
+
+func (i *redisInstance) getClient() redis.UniversalClient {
+	if v := i.client.Load(); v != nil {
+		return v.(redis.UniversalClient)
+	}
+
+	return nil
+}
+
+func (i *redisInstance) connected() bool {
+	if v := i.up.Load(); v != nil {
+		return v.(bool)
+	}
+
+	return false
+}
+
+// RegisterRedisInstance binds the client to the named redis instance, RedisCluster
+// with the same Name uses this client instead of the default connection.
+func RegisterRedisInstance(name string, client redis.UniversalClient) {
+	instance := getRedisInstance(name)
+	instance.client.Store(client)
+	instance.up.Store(connectionIsOpen(client))
+}
+
+// ConnectedInstance returns true if the named redis instance is connected.
+func ConnectedInstance(name string) bool {
+	instance, ok := lookupRedisInstance(name)
+
+	return ok && instance.connected()
+}
+
+// ConnectToNamedRedis starts a go routine that periodically tries to connect to
+// the named redis instance. It works like ConnectToRedis, but the connection is
+// only used by RedisCluster with the same Name.
+func ConnectToNamedRedis(ctx context.Context, name string, config *Config) {
+	tick := time.NewTicker(time.Second)
+	defer tick.Stop()
+
+	instance := getRedisInstance(name)
+	for {
+		client := instance.getClient()
+		if client == nil {
+			log.Debugf("Connecting to redis instance %s", name)
+			client = NewRedisClusterPool(false, config)
+			instance.client.Store(client)
+		}
+		instance.up.Store(connectionIsOpen(client))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick.C:
+		}
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright 2020 Lingfei Kong <colin404@foxmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedisCluster_NamedInstance(t *testing.T) {
+	defaultRedis, _ := newTestRedis(t)
+	analyticsRedis := miniredis.RunT(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	port, _ := strconv.Atoi(analyticsRedis.Port())
+	go ConnectToNamedRedis(ctx, "analytics", &Config{Host: analyticsRedis.Host(), Port: port})
+	assert.Eventually(t, func() bool { return ConnectedInstance("analytics") }, 5*time.Second, 10*time.Millisecond)
+
+	defaultStore := &RedisCluster{KeyPrefix: "iam-"}
+	analyticsStore := &RedisCluster{Name: "analytics", KeyPrefix: "iam-"}
+
+	assert.Nil(t, defaultStore.SetKey("policy", "default", 0))
+	assert.Nil(t, analyticsStore.SetKey("record", "analytics", 0))
+
+	// each store only talks to its own redis
+	assert.True(t, defaultRedis.Exists("iam-policy"))
+	assert.False(t, defaultRedis.Exists("iam-record"))
+	assert.True(t, analyticsRedis.Exists("iam-record"))
+	assert.False(t, analyticsRedis.Exists("iam-policy"))
+
+	value, err := analyticsStore.GetKey("record")
+	assert.Nil(t, err)
+	assert.Equal(t, "analytics", value)
+
+	_, err = analyticsStore.GetKey("policy")
+	assert.NotNil(t, err)
+
+	// an unknown instance is reported as down
+	unknownStore := &RedisCluster{Name: "unknown"}
+	_, err = unknownStore.GetKey("record")
+	assert.Equal(t, ErrRedisIsDown, err)
+	_, err = unknownStore.Exists("record")
+	assert.Equal(t, ErrRedisIsDown, err)
+	_, err = unknownStore.GetListRange("record", 0, -1)
+	assert.Equal(t, ErrRedisIsDown, err)
+	assert.Equal(t, ErrRedisIsDown, unknownStore.RemoveFromList("record", "analytics"))
+}
@@ -42,14 +42,14 @@ type Handler interface {
 	GetKeysAndValues() map[string]string
 	GetKeysAndValuesWithFilter(string) map[string]string
 	DeleteKeys([]string) bool
-	Decrement(string)
+	Decrement(string) error
 	IncrememntWithExpire(string, int64) int64
 	SetRollingWindow(key string, per int64, val string, pipeline bool) (int, []interface{})
 	GetRollingWindow(key string, per int64, pipeline bool) (int, []interface{})
 	GetSet(string) (map[string]string, error)
-	AddToSet(string, string)
+	AddToSet(string, string) error
 	GetAndDeleteSet(string) []interface{}
-	RemoveFromSet(string, string)
+	RemoveFromSet(string, string) error
 	DeleteScanMatch(string) bool
 	GetKeyPrefix() string
 	AddToSortedSet(string, string, float64)
@@ -57,17 +57,18 @@ type Handler interface {
 	RemoveSortedSetRange(string, string, string) error
 	GetListRange(string, int64, int64) ([]string, error)
 	RemoveFromList(string, string) error
-	AppendToSet(string, string)
+	AppendToSet(string, string) error
 	Exists(string) (bool, error)
 }
 
 // AnalyticsHandler defines the interface for analytics.
 type AnalyticsHandler interface {
 	Connect() bool
-	AppendToSetPipelined(string, [][]byte)
+	AppendToSetPipelined(string, [][]byte) error
 	GetAndDeleteSet(string) []interface{}
 	SetExp(string, time.Duration) error // Set key expiration
 	GetExp(string) (int64, error)       // Returns expiry of a key
+	TrimList(string, int64) (int64, error)
 }
 
 const defaultHashAlgorithm = "murmur64"